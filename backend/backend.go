@@ -16,6 +16,12 @@
 package backend
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/logger"
 	"github.com/rs/zerolog"
@@ -57,13 +63,17 @@ type ProverConfig struct {
 	Force         bool                      // defaults to false
 	HintFunctions map[hint.ID]hint.Function // defaults to all built-in hint functions
 	CircuitLogger zerolog.Logger            // defaults to gnark.Logger
+	MemoryBudget  uint64                    // defaults to 0, no budget
+	Ctx           context.Context           // defaults to context.Background()
+	Progress      ProgressFunc              // defaults to nil, no progress is reported
+	RandomSource  io.Reader                 // defaults to nil, crypto/rand is used
 }
 
 // NewProverConfig returns a default ProverConfig with given prover options opts
 // applied.
 func NewProverConfig(opts ...ProverOption) (ProverConfig, error) {
 	log := logger.Logger()
-	opt := ProverConfig{CircuitLogger: log, HintFunctions: make(map[hint.ID]hint.Function)}
+	opt := ProverConfig{CircuitLogger: log, HintFunctions: make(map[hint.ID]hint.Function), Ctx: context.Background()}
 	for _, v := range hint.GetRegistered() {
 		opt.HintFunctions[hint.UUID(v)] = v
 	}
@@ -114,3 +124,153 @@ func WithCircuitLogger(l zerolog.Logger) ProverOption {
 		return nil
 	}
 }
+
+// WithMemoryBudget is a prover option that bounds, in bytes, the memory used to hold the proving key
+// by the provers streaming it from disk, such as groth16.ProveStream. Other provers ignore it.
+// The budget doesn't account for the witness, the solver or the quotient computation.
+func WithMemoryBudget(bytes uint64) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.MemoryBudget = bytes
+		return nil
+	}
+}
+
+// WithContext is a prover option that stops the prover when ctx is done, the prover then returns ctx.Err().
+//
+// The context is checked between the levels of the solver and between the phases of the prover
+// (see ProgressFunc). Once it is done, the Groth16 provers stop their multi-exponentiations between
+// chunks of points, and the PLONK provers stop their goroutines between steps; an FFT which already
+// started isn't interrupted. Both wait for all their goroutines before returning, so no computation
+// outlives the call.
+func WithContext(ctx context.Context) ProverOption {
+	return func(opt *ProverConfig) error {
+		if ctx == nil {
+			return errors.New("nil context")
+		}
+		opt.Ctx = ctx
+		return nil
+	}
+}
+
+// WithProgress is a prover option that reports the end of each phase of the prover to f
+func WithProgress(f ProgressFunc) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.Progress = f
+		return nil
+	}
+}
+
+// WithRandomSource is a prover option that reads the blinding factors of the proof from r
+// instead of crypto/rand. With a deterministic r, the prover outputs the same proof for the
+// same proving key and witness, which allows tests to compare proofs byte for byte.
+//
+// /!\ warning /!\: this option is for tests only. The blinding factors are what make the proof
+// zero-knowledge: a proof built from a known or reused source leaks the witness.
+func WithRandomSource(r io.Reader) ProverOption {
+	return func(opt *ProverConfig) error {
+		opt.RandomSource = r
+		return nil
+	}
+}
+
+// SetupOption defines option for altering the behaviour of the setup in Setup, SetupWithDump and
+// SetupLazyWithDump. See the descriptions of functions returning instances of this type for
+// implemented options.
+type SetupOption func(*SetupConfig) error
+
+// SetupConfig is the configuration for the setup with the options applied.
+type SetupConfig struct {
+	Ctx          context.Context // defaults to context.Background()
+	Progress     ProgressFunc    // defaults to nil, no progress is reported
+	RandomSource io.Reader       // defaults to nil, crypto/rand is used
+}
+
+// NewSetupConfig returns a default SetupConfig with given setup options opts applied.
+func NewSetupConfig(opts ...SetupOption) (SetupConfig, error) {
+	opt := SetupConfig{Ctx: context.Background()}
+	for _, option := range opts {
+		if err := option(&opt); err != nil {
+			return SetupConfig{}, err
+		}
+	}
+	return opt, nil
+}
+
+// WithSetupContext is a setup option that stops the setup when ctx is done, the setup then returns ctx.Err().
+// As for WithContext, the context is checked between the phases of the setup.
+func WithSetupContext(ctx context.Context) SetupOption {
+	return func(opt *SetupConfig) error {
+		if ctx == nil {
+			return errors.New("nil context")
+		}
+		opt.Ctx = ctx
+		return nil
+	}
+}
+
+// WithSetupProgress is a setup option that reports the end of each phase of the setup to f
+func WithSetupProgress(f ProgressFunc) SetupOption {
+	return func(opt *SetupConfig) error {
+		opt.Progress = f
+		return nil
+	}
+}
+
+// WithSetupRandomSource is a setup option that reads the toxic waste of the setup from r instead
+// of crypto/rand. With a deterministic r, the setup outputs the same keys for the same circuit,
+// which allows tests to compare keys byte for byte.
+//
+// /!\ warning /!\: this option is for tests only. Anyone who knows the toxic waste can forge
+// proofs for the circuit.
+func WithSetupRandomSource(r io.Reader) SetupOption {
+	return func(opt *SetupConfig) error {
+		opt.RandomSource = r
+		return nil
+	}
+}
+
+// Progress describes a phase of a prover or a setup which just ended.
+//
+// The phases are named after what they compute:
+//   - "solve": the constraint system solver
+//   - "computeH": the quotient of the Groth16 prover
+//   - "msm.A", "msm.B1", "msm.B2", "msm.K", "msm.Z": the multi-exponentiations of the Groth16 prover
+//   - "commit.LRO", "commit.Z", "commit.H", "lookup", "quotient", "linearize": the KZG commitments and
+//     polynomials of the PLONK prover
+//   - "open.Z", "open.batch": the KZG openings of the PLONK prover
+//   - "qap", "pk.E", "pk.A", "pk.B1", "pk.B2", "pk.K", "pk.Z", "pk.G1", "pk.G2": the evaluation of the
+//     QAP and the points of the keys computed by the Groth16 setup
+//
+// Phases may run concurrently, so that their durations don't add up to the elapsed time.
+type Progress struct {
+	Phase   string        // name of the phase
+	Took    time.Duration // duration of the phase
+	Elapsed time.Duration // time elapsed since the start of the prover or setup
+	Done    int           // number of phases which ended, including this one
+	Total   int           // number of phases of the prover or setup
+}
+
+// Percent returns the percentage of the phases of the prover or setup which ended
+func (p Progress) Percent() float64 {
+	return 100 * float64(p.Done) / float64(p.Total)
+}
+
+// ProgressFunc is called at the end of each phase of a prover or a setup, see WithProgress
+// and WithSetupProgress. Calls are serialized, but may come from another goroutine than the
+// one of the prover. The prover or setup waits for f to return.
+type ProgressFunc func(Progress)
+
+// BatchVerifyError is returned by the batch verifiers, such as groth16.BatchVerify, when a proof
+// of the batch is invalid
+type BatchVerifyError struct {
+	Index int   // index of the first invalid proof in the batch
+	Err   error // error returned by the verification of this proof alone
+}
+
+func (err *BatchVerifyError) Error() string {
+	return fmt.Sprintf("proof %d of the batch is invalid: %v", err.Index, err.Err)
+}
+
+func (err *BatchVerifyError) Unwrap() error {
+	return err.Err
+}
@@ -0,0 +1,312 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package circom converts the files of circom and snarkjs to and from the objects of gnark, over BN254.
+//
+//	.r1cs  constraint system (ReadR1CS, WriteR1CS)
+//	.wtns  full witness, the values of all the wires (ReadWtns, WriteWtns)
+//	.zkey  Groth16 proving and verifying keys (ReadZkey, WriteZkey)
+//	proof.json, public.json  Groth16 proof and public inputs (ReadProof, WriteProof, ReadPublicSignals, WritePublicSignals)
+//
+// A circuit is proven by either stack once its keys are exchanged through a .zkey: the keys of snarkjs are
+// used by groth16.Prove on the constraint system returned by ReadR1CS, and the keys of groth16.Setup on this
+// constraint system are used by snarkjs.
+//
+// # See also
+//
+// https://github.com/iden3/r1csfile/blob/master/doc/r1cs_bin_format.md
+//
+// https://github.com/iden3/snarkjs
+package circom
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	bn254r1cs "github.com/consensys/gnark/internal/backend/bn254/cs"
+)
+
+// n8 is the size in bytes of the encoding of the elements of fp and fr
+const n8 = fr.Bytes
+
+// binFile holds the sections of a binary file of iden3 (.r1cs, .wtns and .zkey) by type.
+//
+// These files start with a magic of 4 bytes, a version and the number of sections (uint32), followed by
+// the sections. Each section has a type (uint32), a size (uint64) and its content. Integers are little-endian.
+type binFile map[uint32][]byte
+
+// readBinFile reads a binary file of iden3 with the given magic and version
+func readBinFile(r io.Reader, magic string, version uint32) (binFile, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != magic {
+		return nil, fmt.Errorf("invalid %s file", magic)
+	}
+	if v := binary.LittleEndian.Uint32(data[4:]); v != version {
+		return nil, fmt.Errorf("unsupported %s file version %d, expected %d", magic, v, version)
+	}
+	nbSections := binary.LittleEndian.Uint32(data[8:])
+	data = data[12:]
+
+	f := make(binFile)
+	for i := uint32(0); i < nbSections; i++ {
+		if len(data) < 12 {
+			return nil, io.ErrUnexpectedEOF
+		}
+		typ, size := binary.LittleEndian.Uint32(data), binary.LittleEndian.Uint64(data[4:])
+		data = data[12:]
+		if uint64(len(data)) < size {
+			return nil, io.ErrUnexpectedEOF
+		}
+		if _, ok := f[typ]; ok {
+			return nil, fmt.Errorf("invalid %s file: duplicate section %d", magic, typ)
+		}
+		f[typ] = data[:size]
+		data = data[size:]
+	}
+	return f, nil
+}
+
+// section returns a decoder of the section of type typ
+func (f binFile) section(typ uint32) (*decoder, error) {
+	content, ok := f[typ]
+	if !ok {
+		return nil, fmt.Errorf("missing section %d", typ)
+	}
+	return &decoder{data: content}, nil
+}
+
+// writeBinFile writes a binary file of iden3 with the given magic and version, the i-th section has type i+1
+func writeBinFile(w io.Writer, magic string, version uint32, sections ...*encoder) error {
+	var header encoder
+	header.WriteString(magic)
+	header.uint32(version)
+	header.uint32(uint32(len(sections)))
+	if _, err := w.Write(header.Bytes()); err != nil {
+		return err
+	}
+	for i, section := range sections {
+		var sectionHeader encoder
+		sectionHeader.uint32(uint32(i + 1))
+		sectionHeader.uint64(uint64(section.Len()))
+		if _, err := w.Write(sectionHeader.Bytes()); err != nil {
+			return err
+		}
+		if _, err := w.Write(section.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var errNonCanonical = errors.New("field element is not in canonical form")
+
+// decoder reads the content of a section, the first error is kept and the next reads return zero values
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) read(n int) []byte {
+	if d.err == nil && len(d.data) < n {
+		d.err = io.ErrUnexpectedEOF
+	}
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+
+func (d *decoder) uint32() uint32 {
+	return binary.LittleEndian.Uint32(d.read(4))
+}
+
+func (d *decoder) uint64() uint64 {
+	return binary.LittleEndian.Uint64(d.read(8))
+}
+
+// bigInt reads an integer of n bytes
+func (d *decoder) bigInt(n int) *big.Int {
+	b := d.read(n)
+	be := make([]byte, n)
+	for i := range b {
+		be[n-1-i] = b[i]
+	}
+	return new(big.Int).SetBytes(be)
+}
+
+// modulus reads the size in bytes of the elements of a field and its modulus, and checks that it's q
+func (d *decoder) modulus(q *big.Int) error {
+	size := d.uint32()
+	if d.err != nil {
+		return d.err
+	}
+	if size != n8 || d.bigInt(n8).Cmp(q) != 0 {
+		return errors.New("the file is not defined over BN254")
+	}
+	return d.err
+}
+
+// fr reads an element of fr in regular form
+func (d *decoder) fr() (res fr.Element) {
+	x := d.bigInt(n8)
+	if x.Cmp(fr.Modulus()) >= 0 && d.err == nil {
+		d.err = errNonCanonical
+	}
+	res.SetBigInt(x)
+	return
+}
+
+// fp reads an element of fp in Montgomery form, which is the internal representation of fp.Element
+func (d *decoder) fp() (res fp.Element) {
+	b := d.read(n8)
+	for i := range res {
+		res[i] = binary.LittleEndian.Uint64(b[8*i:])
+	}
+	if res.ToBigInt(new(big.Int)).Cmp(fp.Modulus()) >= 0 && d.err == nil {
+		d.err = errNonCanonical
+	}
+	return
+}
+
+// g1 reads the affine coordinates of a point of G1, (0, 0) being the point at infinity
+func (d *decoder) g1() (p curve.G1Affine) {
+	p.X, p.Y = d.fp(), d.fp()
+	return
+}
+
+// g2 reads the affine coordinates of a point of G2, the coordinates are encoded as (c₀, c₁)
+func (d *decoder) g2() (p curve.G2Affine) {
+	p.X.A0, p.X.A1, p.Y.A0, p.Y.A1 = d.fp(), d.fp(), d.fp(), d.fp()
+	return
+}
+
+// encoder builds the content of a section
+type encoder struct {
+	bytes.Buffer
+}
+
+func (e *encoder) uint32(v uint32) {
+	var b [4]byte
+	binary.LittleEndian.PutUint32(b[:], v)
+	e.Write(b[:])
+}
+
+func (e *encoder) uint64(v uint64) {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.Write(b[:])
+}
+
+// bigInt writes x on n8 bytes
+func (e *encoder) bigInt(x *big.Int) {
+	var b [n8]byte
+	x.FillBytes(b[:])
+	for i := n8 - 1; i >= 0; i-- {
+		e.WriteByte(b[i])
+	}
+}
+
+// modulus writes the size in bytes of the elements of a field and its modulus
+func (e *encoder) modulus(q *big.Int) {
+	e.uint32(n8)
+	e.bigInt(q)
+}
+
+// fr writes an element of fr in regular form
+func (e *encoder) fr(x *fr.Element) {
+	b := x.Bytes()
+	for i := len(b) - 1; i >= 0; i-- {
+		e.WriteByte(b[i])
+	}
+}
+
+// fp writes an element of fp in Montgomery form
+func (e *encoder) fp(x *fp.Element) {
+	for i := range x {
+		e.uint64(x[i])
+	}
+}
+
+func (e *encoder) g1(p *curve.G1Affine) {
+	e.fp(&p.X)
+	e.fp(&p.Y)
+}
+
+func (e *encoder) g2(p *curve.G2Affine) {
+	e.fp(&p.X.A0)
+	e.fp(&p.X.A1)
+	e.fp(&p.Y.A0)
+	e.fp(&p.Y.A1)
+}
+
+// term is a wire of a linear combination of circom and its coefficient
+type term struct {
+	wire  int
+	coeff fr.Element
+}
+
+// linearCombination returns the terms of l with distinct wires, in increasing order, and non zero coefficients
+func linearCombination(r1cs *bn254r1cs.R1CS, l compiled.LinearExpression) []term {
+	coeffs := make(map[int]fr.Element, len(l))
+	for _, t := range l {
+		c := coeffs[t.WireID()]
+		c.Add(&c, &r1cs.Coefficients[t.CoeffID()])
+		coeffs[t.WireID()] = c
+	}
+	res := make([]term, 0, len(coeffs))
+	for wire, c := range coeffs {
+		if !c.IsZero() {
+			res = append(res, term{wire: wire, coeff: c})
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].wire < res[j].wire })
+	return res
+}
+
+// toR1CS returns the BN254 R1CS of ccs, without lazy constraints
+func toR1CS(ccs frontend.CompiledConstraintSystem) (*bn254r1cs.R1CS, error) {
+	r1cs, ok := ccs.(*bn254r1cs.R1CS)
+	if !ok {
+		if ccs.CurveID() != ecc.BN254 {
+			return nil, notSupported(ccs.CurveID())
+		}
+		return nil, errors.New("the constraint system is not a R1CS")
+	}
+	if len(r1cs.LazyCons) != 0 {
+		return nil, errors.New("lazy constraints are not supported")
+	}
+	return r1cs, nil
+}
+
+func notSupported(curveID ecc.ID) error {
+	return fmt.Errorf("circom and snarkjs files are only supported on BN254, got %s", curveID)
+}
@@ -0,0 +1,361 @@
+package circom
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+
+	groth16_bn254 "github.com/consensys/gnark/internal/backend/bn254/groth16"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(api.Add(x3, circuit.X, 5), circuit.Y)
+	return nil
+}
+
+// multiplierR1CS returns the .r1cs file of circom of the circuit c <== a·b, with the public output c and the
+// private inputs a and b, the constraint is written as in circom: (-a)·b - (-c) = 0
+func multiplierR1CS() []byte {
+	var minusOne fr.Element
+	minusOne.SetOne().Neg(&minusOne)
+	one := fr.One()
+
+	var header, constraints, wire2Label encoder
+	header.modulus(fr.Modulus())
+	header.uint32(4) // wires
+	header.uint32(1) // outputs
+	header.uint32(0) // public inputs
+	header.uint32(2) // private inputs
+	header.uint64(4) // labels
+	header.uint32(1) // constraints
+	for _, t := range []term{{2, minusOne}, {3, one}, {1, minusOne}} {
+		constraints.uint32(1)
+		constraints.uint32(uint32(t.wire))
+		constraints.fr(&t.coeff)
+	}
+	for i := 0; i < 4; i++ {
+		wire2Label.uint64(uint64(i))
+	}
+
+	// the constraints come before the header
+	var buf bytes.Buffer
+	buf.WriteString("r1cs")
+	for _, v := range []uint32{1, 3} {
+		buf.Write([]byte{byte(v), 0, 0, 0})
+	}
+	writeSection(&buf, r1csConstraints, &constraints)
+	writeSection(&buf, r1csHeader, &header)
+	writeSection(&buf, r1csWire2Label, &wire2Label)
+	return buf.Bytes()
+}
+
+func writeSection(buf *bytes.Buffer, typ uint32, content *encoder) {
+	var header encoder
+	header.uint32(typ)
+	header.uint64(uint64(content.Len()))
+	buf.Write(header.Bytes())
+	buf.Write(content.Bytes())
+}
+
+// wtns returns the .wtns file of the given values
+func wtns(values ...uint64) []byte {
+	var header, content encoder
+	header.modulus(fr.Modulus())
+	header.uint32(uint32(len(values)))
+	for _, v := range values {
+		var x fr.Element
+		x.SetUint64(v)
+		content.fr(&x)
+	}
+	var buf bytes.Buffer
+	if err := writeBinFile(&buf, "wtns", 2, &header, &content); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+func TestMultiplier(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := ReadR1CS(bytes.NewReader(multiplierR1CS()))
+	assert.NoError(err)
+	internal, secret, public := ccs.GetNbVariables()
+	assert.Equal([]int{0, 2, 2}, []int{internal, secret, public})
+	assert.Equal(3, ccs.GetNbConstraints(), "the constraints of snarkjs on the public wires are appended")
+	assert.Equal(1, ccs.GetSchema().NbPublic)
+
+	fullWitness, err := ReadWtns(bytes.NewReader(wtns(1, 33, 3, 11)), ccs)
+	assert.NoError(err)
+	publicWitness, err := ReadPublicSignals(bytes.NewReader([]byte(`["33"]`)), ccs)
+	assert.NoError(err)
+	_publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	assert.Equal(publicWitness, _publicWitness)
+
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(WriteProof(&buf, proof))
+	_proof, err := ReadProof(&buf)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(_proof, vk, publicWitness))
+
+	buf.Reset()
+	assert.NoError(WritePublicSignals(&buf, publicWitness))
+	assert.Equal("[\"33\"]\n", buf.String())
+
+	// the witness doesn't satisfy the constraint
+	invalidWitness, err := ReadWtns(bytes.NewReader(wtns(1, 34, 3, 11)), ccs)
+	assert.NoError(err)
+	_, err = groth16.Prove(ccs, pk, invalidWitness)
+	assert.Error(err)
+
+	// invalid files
+	_, err = ReadWtns(bytes.NewReader(wtns(1, 33, 3)), ccs)
+	assert.Error(err)
+	_, err = ReadWtns(bytes.NewReader(wtns(2, 33, 3, 11)), ccs)
+	assert.Error(err)
+	r1csFile := multiplierR1CS()
+	_, err = ReadR1CS(bytes.NewReader(r1csFile[:len(r1csFile)-10]))
+	assert.Error(err)
+	_, err = ReadR1CS(bytes.NewReader(wtns(1)))
+	assert.Error(err)
+}
+
+func TestZkey(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := ReadR1CS(bytes.NewReader(multiplierR1CS()))
+	assert.NoError(err)
+	fullWitness, err := ReadWtns(bytes.NewReader(wtns(1, 33, 3, 11)), ccs)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	pk, vk, err := groth16.Setup(ccs)
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(WriteZkey(&buf, ccs, pk, vk))
+	zkey := append([]byte{}, buf.Bytes()...)
+	_pk, _vk, err := ReadZkey(&buf)
+	assert.NoError(err)
+	assert.Equal(pk, _pk)
+	assert.Equal(vk, _vk)
+
+	// proofs of a key are verified with the other one
+	proof, err := groth16.Prove(ccs, _pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+	proof, err = groth16.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, _vk, publicWitness))
+
+	// a point is not in G1
+	tampered := append([]byte{}, zkey...)
+	tampered[len(tampered)-1] ^= 1
+	_, _, err = ReadZkey(bytes.NewReader(tampered))
+	assert.Error(err)
+
+	_, _, err = ReadZkey(bytes.NewReader(zkey[:len(zkey)-1]))
+	assert.Error(err)
+}
+
+func TestGnarkCircuit(t *testing.T) {
+	assert := require.New(t)
+
+	// the R1CS of a gnark circuit is exported and imported back with the constraints of snarkjs
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &cubicCircuit{})
+	assert.NoError(err)
+	var buf bytes.Buffer
+	assert.NoError(WriteR1CS(&buf, ccs))
+	_ccs, err := ReadR1CS(&buf)
+	assert.NoError(err)
+	internal, secret, public := ccs.GetNbVariables()
+	_internal, _secret, _public := _ccs.GetNbVariables()
+	assert.Equal(public, _public)
+	assert.Equal(secret+internal, _secret+_internal)
+	assert.Equal(ccs.GetNbConstraints()+public, _ccs.GetNbConstraints())
+
+	buf.Reset()
+	assert.NoError(WriteR1CS(&buf, _ccs))
+	_ccs, err = ReadR1CS(&buf)
+	assert.NoError(err)
+	assert.Equal(ccs.GetNbConstraints()+public, _ccs.GetNbConstraints(), "the constraints of snarkjs are not duplicated")
+
+	// the witness is solved by gnark and exported with the values of all the wires
+	fullWitness, err := frontend.NewWitness(&cubicCircuit{X: 3, Y: 35}, ecc.BN254)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	buf.Reset()
+	assert.NoError(WriteWtns(&buf, ccs, fullWitness))
+	_fullWitness, err := ReadWtns(&buf, _ccs)
+	assert.NoError(err)
+
+	invalidWitness, err := frontend.NewWitness(&cubicCircuit{X: 3, Y: 36}, ecc.BN254)
+	assert.NoError(err)
+	assert.Error(WriteWtns(&bytes.Buffer{}, ccs, invalidWitness))
+
+	pk, vk, err := groth16.Setup(_ccs)
+	assert.NoError(err)
+	buf.Reset()
+	assert.NoError(WriteZkey(&buf, _ccs, pk, vk))
+	_pk, _vk, err := ReadZkey(&buf)
+	assert.NoError(err)
+	proof, err := groth16.Prove(_ccs, _pk, _fullWitness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, _vk, publicWitness))
+
+	// the keys of the gnark circuit are not defined on the QAP of snarkjs
+	pk, vk, err = groth16.Setup(ccs)
+	assert.NoError(err)
+	assert.Error(WriteZkey(&bytes.Buffer{}, ccs, pk, vk))
+
+	// only BN254 is supported
+	ccs, err = frontend.Compile(ecc.BLS12_381, r1cs.NewBuilder, &cubicCircuit{})
+	assert.NoError(err)
+	assert.Error(WriteR1CS(&bytes.Buffer{}, ccs))
+}
+
+func TestZFromH(t *testing.T) {
+	assert := require.New(t)
+
+	const n = 8
+	var tau fr.Element
+	tau.SetUint64(42)
+	_, _, g1, _ := curve.Generators()
+	domain := fft.NewDomain(2 * n)
+
+	// Hⱼ = [ℓ₂ⱼ₊₁(τ)]₁ with ℓₖ(τ) = xₖ·(τ²ⁿ-1) / 2n·(τ-xₖ)
+	var tau2n, one fr.Element
+	one.SetOne()
+	tau2n.Exp(tau, big.NewInt(2*n)).Sub(&tau2n, &one).Mul(&tau2n, &domain.CardinalityInv)
+	h := make([]curve.G1Affine, n)
+	for j := range h {
+		var x, l fr.Element
+		x.Exp(domain.Generator, big.NewInt(int64(2*j+1)))
+		l.Sub(&tau, &x).Inverse(&l).Mul(&l, &x).Mul(&l, &tau2n)
+		h[j].ScalarMultiplication(&g1, l.ToBigIntRegular(new(big.Int)))
+	}
+
+	// [τⁱ·(τⁿ-1)]₁ in bit reversed order
+	var z0 fr.Element
+	z0.Exp(tau, big.NewInt(n)).Sub(&z0, &one)
+	z := make([]curve.G1Affine, n)
+	for i := range z {
+		var s fr.Element
+		s.Exp(tau, big.NewInt(int64(i))).Mul(&s, &z0)
+		z[bitReverse(i, n)].ScalarMultiplication(&g1, s.ToBigIntRegular(new(big.Int)))
+	}
+
+	assert.Equal(z, zFromH(h))
+	assert.Equal(h, hFromZ(z))
+}
+
+// snarkjsVerifyingKey holds the points of a verification_key.json of snarkjs
+type snarkjsVerifyingKey struct {
+	NPublic int          `json:"nPublic"`
+	Alpha   [3]string    `json:"vk_alpha_1"`
+	Beta    [3][2]string `json:"vk_beta_2"`
+	Gamma   [3][2]string `json:"vk_gamma_2"`
+	Delta   [3][2]string `json:"vk_delta_2"`
+	IC      [][3]string  `json:"IC"`
+}
+
+func g1JSON(p *curve.G1Affine) [3]string {
+	return [3]string{p.X.String(), p.Y.String(), "1"}
+}
+
+func g2JSON(p *curve.G2Affine) [3][2]string {
+	return [3][2]string{{p.X.A0.String(), p.X.A1.String()}, {p.Y.A0.String(), p.Y.A1.String()}, {"1", "0"}}
+}
+
+// TestSnarkjsFixtures imports the files of circom and snarkjs of testdata/multiplier2, regenerated by gen.sh,
+// and checks that the proofs of snarkjs and of gnark are verified by the other stack
+func TestSnarkjsFixtures(t *testing.T) {
+	assert := require.New(t)
+	dir := filepath.Join("testdata", "multiplier2")
+	if _, err := os.Stat(filepath.Join(dir, "multiplier2.zkey")); os.IsNotExist(err) {
+		t.Skipf("the fixtures of %s are not generated, run gen.sh with circom and snarkjs installed", dir)
+	}
+	open := func(name string) *os.File {
+		f, err := os.Open(filepath.Join(dir, name))
+		assert.NoError(err)
+		t.Cleanup(func() { f.Close() })
+		return f
+	}
+
+	ccs, err := ReadR1CS(open("multiplier2.r1cs"))
+	assert.NoError(err)
+	fullWitness, err := ReadWtns(open("witness.wtns"), ccs)
+	assert.NoError(err)
+	publicWitness, err := ReadPublicSignals(open("public.json"), ccs)
+	assert.NoError(err)
+	_publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	assert.Equal(publicWitness, _publicWitness)
+	pk, vk, err := ReadZkey(open("multiplier2.zkey"))
+	assert.NoError(err)
+
+	// the verifying key of the .zkey is the one exported by snarkjs
+	var snarkjsVK snarkjsVerifyingKey
+	assert.NoError(json.NewDecoder(open("verification_key.json")).Decode(&snarkjsVK))
+	_vk := vk.(*groth16_bn254.VerifyingKey)
+	assert.Equal(ccs.GetSchema().NbPublic, snarkjsVK.NPublic)
+	assert.Equal(g1JSON(&_vk.G1.Alpha), snarkjsVK.Alpha)
+	assert.Equal(g2JSON(&_vk.G2.Beta), snarkjsVK.Beta)
+	assert.Equal(g2JSON(&_vk.G2.Gamma), snarkjsVK.Gamma)
+	assert.Equal(g2JSON(&_vk.G2.Delta), snarkjsVK.Delta)
+	assert.Equal(len(_vk.G1.K), len(snarkjsVK.IC))
+	for i := range _vk.G1.K {
+		assert.Equal(g1JSON(&_vk.G1.K[i]), snarkjsVK.IC[i])
+	}
+
+	// the proof of snarkjs is verified by gnark
+	snarkjsProof, err := ReadProof(open("proof.json"))
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(snarkjsProof, vk, publicWitness))
+	invalidWitness, err := ReadPublicSignals(bytes.NewReader([]byte(`["34"]`)), ccs)
+	assert.NoError(err)
+	assert.Error(groth16.Verify(snarkjsProof, vk, invalidWitness))
+
+	// the proof of gnark with the keys of snarkjs is verified by gnark, and by snarkjs when it is installed
+	proof, err := groth16.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(groth16.Verify(proof, vk, publicWitness))
+	snarkjs, err := exec.LookPath("snarkjs")
+	if err != nil {
+		return
+	}
+	proofPath := filepath.Join(t.TempDir(), "proof.json")
+	f, err := os.Create(proofPath)
+	assert.NoError(err)
+	assert.NoError(WriteProof(f, proof))
+	assert.NoError(f.Close())
+	out, err := exec.Command(snarkjs, "groth16", "verify",
+		filepath.Join(dir, "verification_key.json"), filepath.Join(dir, "public.json"), proofPath).CombinedOutput()
+	assert.NoError(err, string(out))
+}
@@ -0,0 +1,140 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circom
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	groth16_bn254 "github.com/consensys/gnark/internal/backend/bn254/groth16"
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+)
+
+// snarkjsProof is the proof.json of snarkjs, the points are in projective coordinates with Z = 1
+type snarkjsProof struct {
+	A        [3]string    `json:"pi_a"`
+	B        [3][2]string `json:"pi_b"`
+	C        [3]string    `json:"pi_c"`
+	Protocol string       `json:"protocol"`
+	Curve    string       `json:"curve"`
+}
+
+var errInvalidProof = errors.New("invalid snarkjs proof")
+
+// ReadProof reads a Groth16 proof.json of snarkjs
+func ReadProof(r io.Reader) (groth16.Proof, error) {
+	var p snarkjsProof
+	if err := json.NewDecoder(r).Decode(&p); err != nil {
+		return nil, err
+	}
+	if p.Protocol != "groth16" || p.Curve != "bn128" {
+		return nil, fmt.Errorf("%w: got a %s proof on %s", errInvalidProof, p.Protocol, p.Curve)
+	}
+	if p.A[2] != "1" || p.B[2] != [2]string{"1", "0"} || p.C[2] != "1" {
+		return nil, fmt.Errorf("%w: the points must be in affine coordinates", errInvalidProof)
+	}
+
+	var proof groth16_bn254.Proof
+	coordinates := []*fp.Element{
+		&proof.Ar.X, &proof.Ar.Y,
+		&proof.Bs.X.A0, &proof.Bs.X.A1, &proof.Bs.Y.A0, &proof.Bs.Y.A1,
+		&proof.Krs.X, &proof.Krs.Y,
+	}
+	values := []string{p.A[0], p.A[1], p.B[0][0], p.B[0][1], p.B[1][0], p.B[1][1], p.C[0], p.C[1]}
+	for i := range coordinates {
+		x, ok := new(big.Int).SetString(values[i], 10)
+		if !ok || x.Sign() < 0 || x.Cmp(fp.Modulus()) >= 0 {
+			return nil, fmt.Errorf("%w: invalid coordinate %q", errInvalidProof, values[i])
+		}
+		coordinates[i].SetBigInt(x)
+	}
+	if !proof.Ar.IsOnCurve() || !proof.Bs.IsOnCurve() || !proof.Krs.IsOnCurve() {
+		return nil, fmt.Errorf("%w: a point is not on the curve", errInvalidProof)
+	}
+	return &proof, nil
+}
+
+// WriteProof writes a BN254 Groth16 proof as a proof.json of snarkjs
+func WriteProof(w io.Writer, proof groth16.Proof) error {
+	_proof, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return notSupported(proof.CurveID())
+	}
+	p := snarkjsProof{
+		A: [3]string{_proof.Ar.X.String(), _proof.Ar.Y.String(), "1"},
+		B: [3][2]string{
+			{_proof.Bs.X.A0.String(), _proof.Bs.X.A1.String()},
+			{_proof.Bs.Y.A0.String(), _proof.Bs.Y.A1.String()},
+			{"1", "0"},
+		},
+		C:        [3]string{_proof.Krs.X.String(), _proof.Krs.Y.String(), "1"},
+		Protocol: "groth16",
+		Curve:    "bn128",
+	}
+	return json.NewEncoder(w).Encode(&p)
+}
+
+// ReadPublicSignals reads a public.json of snarkjs, the values of the public inputs, and returns the public
+// witness of ccs
+func ReadPublicSignals(r io.Reader, ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	r1cs, err := toR1CS(ccs)
+	if err != nil {
+		return nil, err
+	}
+	var signals []string
+	if err := json.NewDecoder(r).Decode(&signals); err != nil {
+		return nil, err
+	}
+	if len(signals) != r1cs.NbPublicVariables-1 {
+		return nil, fmt.Errorf("%w: got %d public signals, expected %d", witness.ErrInvalidWitness, len(signals), r1cs.NbPublicVariables-1)
+	}
+	values := make(bn254witness.Witness, len(signals))
+	for i := range signals {
+		x, ok := new(big.Int).SetString(signals[i], 10)
+		if !ok || x.Sign() < 0 || x.Cmp(fr.Modulus()) >= 0 {
+			return nil, fmt.Errorf("%w: invalid public signal %q", witness.ErrInvalidWitness, signals[i])
+		}
+		values[i].SetBigInt(x)
+	}
+	return &witness.Witness{
+		Vector:  &values,
+		Schema:  r1cs.Schema,
+		CurveID: ecc.BN254,
+	}, nil
+}
+
+// WritePublicSignals writes a BN254 public witness as a public.json of snarkjs
+func WritePublicSignals(w io.Writer, publicWitness *witness.Witness) error {
+	values, ok := publicWitness.Vector.(*bn254witness.Witness)
+	if !ok {
+		return witness.ErrInvalidWitness
+	}
+	signals := make([]string, len(*values))
+	for i := range signals {
+		signals[i] = (*values)[i].String()
+	}
+	return json.NewEncoder(w).Encode(signals)
+}
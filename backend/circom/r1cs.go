@@ -0,0 +1,251 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circom
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
+	"github.com/consensys/gnark/frontend/schema"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	bn254r1cs "github.com/consensys/gnark/internal/backend/bn254/cs"
+)
+
+// sections of a .r1cs file
+const (
+	r1csHeader      = 1
+	r1csConstraints = 2
+	r1csWire2Label  = 3
+)
+
+// ReadR1CS reads a .r1cs file of circom and returns the corresponding BN254 R1CS.
+//
+// The wires of circom are ordered as [1, public outputs, public inputs, private inputs, internal wires]: the
+// public outputs and inputs are the public variables of the R1CS, and all the other wires are its secret variables,
+// since the solver doesn't run the witness generator of circom (the full witness is read from a .wtns file,
+// see ReadWtns). The schema of the R1CS has two arrays of variables, Public and Secret.
+//
+// snarkjs appends a constraint wᵢ·0 = 0 for each public wire wᵢ (including 1) to make the polynomials of
+// the public inputs independent, ReadR1CS appends them too so that groth16.Setup and the keys of snarkjs
+// are defined on the same QAP.
+func ReadR1CS(r io.Reader) (frontend.CompiledConstraintSystem, error) {
+	f, err := readBinFile(r, "r1cs", 1)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := f.section(r1csHeader)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.modulus(fr.Modulus()); err != nil {
+		return nil, err
+	}
+	nbWires := int(d.uint32())
+	nbPublic := int(d.uint32()) + int(d.uint32()) // outputs and inputs
+	nbPrivate := int(d.uint32())
+	d.uint64() // number of labels
+	nbConstraints := int(d.uint32())
+	if d.err != nil {
+		return nil, d.err
+	}
+	if nbWires < 1+nbPublic+nbPrivate {
+		return nil, fmt.Errorf("invalid r1cs file: %d wires for %d public and %d private inputs", nbWires, nbPublic, nbPrivate)
+	}
+	nbSecret := nbWires - 1 - nbPublic
+
+	d, err = f.section(r1csConstraints)
+	if err != nil {
+		return nil, err
+	}
+	st := cs.NewCoeffTable()
+	readLinearExpression := func() compiled.LinearExpression {
+		n := int(d.uint32())
+		if d.err != nil || n > len(d.data)/(4+n8) {
+			d.err = io.ErrUnexpectedEOF
+			return nil
+		}
+		l := make(compiled.LinearExpression, n)
+		for i := range l {
+			wire := int(d.uint32())
+			coeff := d.bigInt(n8)
+			if d.err != nil {
+				return nil
+			}
+			if wire >= nbWires || coeff.Cmp(fr.Modulus()) >= 0 {
+				d.err = errors.New("invalid r1cs file: invalid term")
+				return nil
+			}
+			l[i] = compiled.Pack(wire, st.CoeffID(coeff), visibility(wire, nbPublic))
+		}
+		return l
+	}
+	constraints := make([]compiled.R1C, 0, nbConstraints+nbPublic+1)
+	for i := 0; i < nbConstraints; i++ {
+		var r1c compiled.R1C
+		r1c.L = readLinearExpression()
+		r1c.R = readLinearExpression()
+		r1c.O = readLinearExpression()
+		if d.err != nil {
+			return nil, d.err
+		}
+		constraints = append(constraints, r1c)
+	}
+
+	// the constraints added by snarkjs for the public wires
+	for i := 0; i <= nbPublic; i++ {
+		constraints = append(constraints, compiled.R1C{
+			L: compiled.LinearExpression{compiled.Pack(i, compiled.CoeffIdOne, schema.Public)},
+		})
+	}
+
+	// the solver checks all the constraints at once
+	level := make([]int, len(constraints))
+	for i := range level {
+		level[i] = i
+	}
+
+	res := compiled.R1CS{
+		ConstraintSystem: compiled.ConstraintSystem{
+			Schema:             newSchema(nbPublic, nbSecret),
+			NbPublicVariables:  nbPublic + 1,
+			NbSecretVariables:  nbSecret,
+			Public:             append([]string{"one"}, variableNames("Public", nbPublic)...),
+			Secret:             variableNames("Secret", nbSecret),
+			MDebug:             make(map[int]int),
+			MHints:             make(map[int]*compiled.Hint),
+			MHintsDependencies: make(map[hint.ID]string),
+			Levels:             [][]int{level},
+			CurveID:            ecc.BN254,
+		},
+		Constraints:            constraints,
+		LazyConsMap:            map[int]compiled.LazyIndexedInputs{},
+		LazyConsStaticR1CMap:   map[string][]compiled.R1C{},
+		LazyConsOriginInputMap: map[string]compiled.LazyInputs{},
+	}
+
+	return bn254r1cs.NewR1CS(res, st.Coeffs, st), nil
+}
+
+// WriteR1CS writes ccs as a .r1cs file of circom.
+//
+// The public variables are written as public inputs, the secret and internal variables as private inputs
+// and internal wires, and the constraints appended by ReadR1CS for the public wires are removed.
+func WriteR1CS(w io.Writer, ccs frontend.CompiledConstraintSystem) error {
+	r1cs, err := toR1CS(ccs)
+	if err != nil {
+		return err
+	}
+	nbWires := r1cs.NbPublicVariables + r1cs.NbSecretVariables + r1cs.NbInternalVariables
+	constraints := r1cs.Constraints
+	if hasPublicConstraints(r1cs) {
+		constraints = constraints[:len(constraints)-r1cs.NbPublicVariables]
+	}
+
+	var header encoder
+	header.modulus(fr.Modulus())
+	header.uint32(uint32(nbWires))
+	header.uint32(0) // outputs
+	header.uint32(uint32(r1cs.NbPublicVariables - 1))
+	header.uint32(uint32(r1cs.NbSecretVariables))
+	header.uint64(uint64(nbWires))
+	header.uint32(uint32(len(constraints)))
+
+	var content encoder
+	for _, r1c := range constraints {
+		for _, l := range []compiled.LinearExpression{r1c.L, r1c.R, r1c.O} {
+			terms := linearCombination(r1cs, l)
+			content.uint32(uint32(len(terms)))
+			for i := range terms {
+				content.uint32(uint32(terms[i].wire))
+				content.fr(&terms[i].coeff)
+			}
+		}
+	}
+
+	// the wires are their own labels
+	var wire2Label encoder
+	for i := 0; i < nbWires; i++ {
+		wire2Label.uint64(uint64(i))
+	}
+
+	return writeBinFile(w, "r1cs", 1, &header, &content, &wire2Label)
+}
+
+// hasPublicConstraints returns true if r1cs ends with the constraints wᵢ·0 = 0 of its public wires,
+// in the order of the wires
+func hasPublicConstraints(r1cs *bn254r1cs.R1CS) bool {
+	offset := len(r1cs.Constraints) - r1cs.NbPublicVariables
+	if offset < 0 {
+		return false
+	}
+	for i, r1c := range r1cs.Constraints[offset:] {
+		l := linearCombination(r1cs, r1c.L)
+		if len(l) != 1 || l[0].wire != i || !l[0].coeff.IsOne() ||
+			len(linearCombination(r1cs, r1c.R)) != 0 || len(linearCombination(r1cs, r1c.O)) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// visibility returns the visibility of a wire of circom in the R1CS
+func visibility(wire, nbPublic int) schema.Visibility {
+	if wire <= nbPublic {
+		return schema.Public
+	}
+	return schema.Secret
+}
+
+// newSchema returns the schema of an R1CS read from a .r1cs file, with arrays of nbPublic and nbSecret variables
+func newSchema(nbPublic, nbSecret int) *schema.Schema {
+	s := &schema.Schema{NbPublic: nbPublic, NbSecret: nbSecret}
+	if nbPublic != 0 {
+		s.Fields = append(s.Fields, schema.Field{
+			Name:       "Public",
+			NameTag:    "Public",
+			Visibility: schema.Public,
+			Type:       schema.Array,
+			ArraySize:  nbPublic,
+		})
+	}
+	if nbSecret != 0 {
+		s.Fields = append(s.Fields, schema.Field{
+			Name:       "Secret",
+			NameTag:    "Secret",
+			Visibility: schema.Secret,
+			Type:       schema.Array,
+			ArraySize:  nbSecret,
+		})
+	}
+	return s
+}
+
+// variableNames returns the names of the n variables of an array of the schema
+func variableNames(array string, n int) []string {
+	res := make([]string, n)
+	for i := range res {
+		res[i] = fmt.Sprintf("%s_%d", array, i)
+	}
+	return res
+}
@@ -0,0 +1,18 @@
+#!/bin/sh
+# Regenerates the fixtures of TestSnarkjsFixtures with circom and snarkjs:
+# multiplier2.r1cs, witness.wtns, multiplier2.zkey, verification_key.json, proof.json and public.json.
+set -e
+cd "$(dirname "$0")"
+
+circom multiplier2.circom --r1cs --wasm -o .
+node multiplier2_js/generate_witness.js multiplier2_js/multiplier2.wasm input.json witness.wtns
+
+snarkjs powersoftau new bn128 4 pot_0000.ptau
+snarkjs powersoftau contribute pot_0000.ptau pot_0001.ptau --name=fixture -e=fixture
+snarkjs powersoftau prepare phase2 pot_0001.ptau pot_final.ptau
+snarkjs groth16 setup multiplier2.r1cs pot_final.ptau multiplier2_0000.zkey
+snarkjs zkey contribute multiplier2_0000.zkey multiplier2.zkey --name=fixture -e=fixture
+snarkjs zkey export verificationkey multiplier2.zkey verification_key.json
+snarkjs groth16 prove multiplier2.zkey witness.wtns proof.json public.json
+
+rm -rf multiplier2_js pot_*.ptau multiplier2_0000.zkey
@@ -0,0 +1,122 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circom
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+)
+
+// sections of a .wtns file
+const (
+	wtnsHeader = 1
+	wtnsValues = 2
+)
+
+// ReadWtns reads a .wtns file, the values of all the wires computed by the witness generator of circom,
+// and returns the full witness of ccs, which is usually read from the .r1cs file of the same circuit.
+func ReadWtns(r io.Reader, ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	r1cs, err := toR1CS(ccs)
+	if err != nil {
+		return nil, err
+	}
+	f, err := readBinFile(r, "wtns", 2)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := f.section(wtnsHeader)
+	if err != nil {
+		return nil, err
+	}
+	if err := d.modulus(fr.Modulus()); err != nil {
+		return nil, err
+	}
+	nbValues := int(d.uint32())
+	if d.err != nil {
+		return nil, d.err
+	}
+	nbWires := r1cs.NbPublicVariables + r1cs.NbSecretVariables + r1cs.NbInternalVariables
+	if nbValues != nbWires {
+		return nil, fmt.Errorf("%w: got %d values for %d wires", witness.ErrInvalidWitness, nbValues, nbWires)
+	}
+
+	d, err = f.section(wtnsValues)
+	if err != nil {
+		return nil, err
+	}
+	if one := d.fr(); !one.IsOne() && d.err == nil {
+		return nil, fmt.Errorf("%w: the first wire must be 1", witness.ErrInvalidWitness)
+	}
+
+	// the internal wires of ccs, if any, are solved by the prover
+	values := make(bn254witness.Witness, r1cs.NbPublicVariables-1+r1cs.NbSecretVariables)
+	for i := range values {
+		values[i] = d.fr()
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+
+	return &witness.Witness{
+		Vector:  &values,
+		Schema:  r1cs.Schema,
+		CurveID: ecc.BN254,
+	}, nil
+}
+
+// WriteWtns solves ccs for fullWitness and writes the values of all its wires as a .wtns file
+func WriteWtns(w io.Writer, ccs frontend.CompiledConstraintSystem, fullWitness *witness.Witness, opts ...backend.ProverOption) error {
+	r1cs, err := toR1CS(ccs)
+	if err != nil {
+		return err
+	}
+	values, ok := fullWitness.Vector.(*bn254witness.Witness)
+	if !ok {
+		return witness.ErrInvalidWitness
+	}
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return err
+	}
+
+	a := make([]fr.Element, len(r1cs.Constraints))
+	b := make([]fr.Element, len(r1cs.Constraints))
+	c := make([]fr.Element, len(r1cs.Constraints))
+	wires, err := r1cs.Solve(*values, a, b, c, opt)
+	if err != nil {
+		return fmt.Errorf("the witness doesn't satisfy the constraint system: %w", err)
+	}
+
+	var header encoder
+	header.modulus(fr.Modulus())
+	header.uint32(uint32(len(wires)))
+
+	var content encoder
+	for i := range wires {
+		content.fr(&wires[i])
+	}
+
+	return writeBinFile(w, "wtns", 2, &header, &content)
+}
@@ -0,0 +1,443 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package circom
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/internal/utils"
+
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+
+	groth16_bn254 "github.com/consensys/gnark/internal/backend/bn254/groth16"
+)
+
+// sections of a Groth16 .zkey file
+const (
+	zkeyHeader        = 1
+	zkeyGroth16Header = 2
+	zkeyIC            = 3
+	zkeyCoeffs        = 4
+	zkeyA             = 5
+	zkeyB1            = 6
+	zkeyB2            = 7
+	zkeyC             = 8
+	zkeyH             = 9
+)
+
+const zkeyGroth16 = 1
+
+var errInvalidPoint = errors.New("invalid zkey file: a point is not in the correct subgroup")
+
+// ReadZkey reads a Groth16 .zkey file of snarkjs and returns the corresponding BN254 proving and verifying keys.
+//
+// The keys are used with the R1CS read from the .r1cs file of the circuit (see ReadR1CS). The proving key of
+// snarkjs holds the [ℓ₂ᵢ₊₁(τ)/δ]₁ instead of the [τⁱ·(τⁿ-1)/δ]₁ of gnark, where ℓⱼ are the Lagrange polynomials
+// on the domain of size 2n, they are converted with a FFT in G1 (see zFromH).
+func ReadZkey(r io.Reader) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	f, err := readBinFile(r, "zkey", 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d, err := f.section(zkeyHeader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if protocol := d.uint32(); d.err != nil || protocol != zkeyGroth16 {
+		return nil, nil, errors.New("only Groth16 zkey files are supported")
+	}
+
+	var pk groth16_bn254.ProvingKey
+	var vk groth16_bn254.VerifyingKey
+
+	d, err = f.section(zkeyGroth16Header)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := d.modulus(fp.Modulus()); err != nil {
+		return nil, nil, err
+	}
+	if err := d.modulus(fr.Modulus()); err != nil {
+		return nil, nil, err
+	}
+	nbWires := int(d.uint32())
+	nbPublic := int(d.uint32())
+	domainSize := int(d.uint32())
+	pk.G1.Alpha = d.g1()
+	pk.G1.Beta = d.g1()
+	pk.G2.Beta = d.g2()
+	vk.G2.Gamma = d.g2()
+	pk.G1.Delta = d.g1()
+	pk.G2.Delta = d.g2()
+	if d.err != nil {
+		return nil, nil, d.err
+	}
+	if nbWires < nbPublic+1 || domainSize == 0 || domainSize&(domainSize-1) != 0 || domainSize > 1<<27 {
+		return nil, nil, fmt.Errorf("invalid zkey file: %d wires, %d public inputs and a domain of size %d", nbWires, nbPublic, domainSize)
+	}
+
+	ic, err := f.g1Points(zkeyIC, nbPublic+1)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := f.g1Points(zkeyA, nbWires)
+	if err != nil {
+		return nil, nil, err
+	}
+	b1, err := f.g1Points(zkeyB1, nbWires)
+	if err != nil {
+		return nil, nil, err
+	}
+	b2, err := f.g2Points(zkeyB2, nbWires)
+	if err != nil {
+		return nil, nil, err
+	}
+	c, err := f.g1Points(zkeyC, nbWires-nbPublic-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	h, err := f.g1Points(zkeyH, domainSize)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !checkG1([]curve.G1Affine{pk.G1.Alpha, pk.G1.Beta, pk.G1.Delta}, ic, a, b1, c, h) ||
+		!checkG2([]curve.G2Affine{pk.G2.Beta, pk.G2.Delta, vk.G2.Gamma}, b2) {
+		return nil, nil, errInvalidPoint
+	}
+
+	// points at infinity are filtered out of [A(τ)]₁ and [B(τ)]₁,₂
+	pk.InfinityA = make([]bool, nbWires)
+	pk.InfinityB = make([]bool, nbWires)
+	for i := range a {
+		if a[i].IsInfinity() {
+			pk.InfinityA[i] = true
+			pk.NbInfinityA++
+			continue
+		}
+		pk.G1.A = append(pk.G1.A, a[i])
+	}
+	for i := range b1 {
+		if b1[i].IsInfinity() != b2[i].IsInfinity() {
+			return nil, nil, errors.New("invalid zkey file: the points of B in G1 and G2 are not consistent")
+		}
+		if b1[i].IsInfinity() {
+			pk.InfinityB[i] = true
+			pk.NbInfinityB++
+			continue
+		}
+		pk.G1.B = append(pk.G1.B, b1[i])
+		pk.G2.B = append(pk.G2.B, b2[i])
+	}
+	pk.G1.K = c
+	pk.G1.Z = zFromH(h)
+	pk.Domain = *fft.NewDomain(uint64(domainSize))
+
+	vk.G1.Alpha = pk.G1.Alpha
+	vk.G1.Beta = pk.G1.Beta
+	vk.G1.Delta = pk.G1.Delta
+	vk.G1.K = ic
+	vk.G2.Beta = pk.G2.Beta
+	vk.G2.Delta = pk.G2.Delta
+	if err := vk.Precompute(); err != nil {
+		return nil, nil, err
+	}
+
+	return &pk, &vk, nil
+}
+
+// WriteZkey writes pk and vk as a Groth16 .zkey file of snarkjs.
+//
+// The keys must be the output of groth16.Setup on ccs, and ccs must end with the constraints of snarkjs on
+// the public wires, which is the case of the R1CS returned by ReadR1CS: the R1CS of a gnark circuit is written
+// with WriteR1CS and read back with ReadR1CS before the setup. The section of the contributions to the phase 2
+// is not written, the file is used to prove and verify but not to contribute.
+func WriteZkey(w io.Writer, ccs frontend.CompiledConstraintSystem, pk groth16.ProvingKey, vk groth16.VerifyingKey) error {
+	r1cs, err := toR1CS(ccs)
+	if err != nil {
+		return err
+	}
+	_pk, ok := pk.(*groth16_bn254.ProvingKey)
+	if !ok {
+		return notSupported(pk.CurveID())
+	}
+	_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return notSupported(vk.CurveID())
+	}
+	if !hasPublicConstraints(r1cs) {
+		return errors.New("the R1CS doesn't end with the constraints of snarkjs on the public wires, see ReadR1CS")
+	}
+	nbWires := r1cs.NbPublicVariables + r1cs.NbSecretVariables + r1cs.NbInternalVariables
+	domainSize := _pk.Domain.Cardinality
+	if domainSize != ecc.NextPowerOfTwo(uint64(len(r1cs.Constraints))) || len(_pk.InfinityA) != nbWires ||
+		len(_vk.G1.K) != r1cs.NbPublicVariables {
+		return errors.New("the keys don't match the R1CS")
+	}
+
+	var header encoder
+	header.uint32(zkeyGroth16)
+
+	var groth16Header encoder
+	groth16Header.modulus(fp.Modulus())
+	groth16Header.modulus(fr.Modulus())
+	groth16Header.uint32(uint32(nbWires))
+	groth16Header.uint32(uint32(r1cs.NbPublicVariables - 1))
+	groth16Header.uint32(uint32(domainSize))
+	groth16Header.g1(&_vk.G1.Alpha)
+	groth16Header.g1(&_pk.G1.Beta)
+	groth16Header.g2(&_vk.G2.Beta)
+	groth16Header.g2(&_vk.G2.Gamma)
+	groth16Header.g1(&_pk.G1.Delta)
+	groth16Header.g2(&_pk.G2.Delta)
+
+	var ic encoder
+	for i := range _vk.G1.K {
+		ic.g1(&_vk.G1.K[i])
+	}
+
+	// the coefficients of A and B, multiplied by R = 2²⁵⁶ and in Montgomery form
+	var rMod fr.Element
+	rMod.SetBigInt(new(big.Int).Lsh(big.NewInt(1), 8*n8))
+	var coeffs encoder
+	nbCoeffs := 0
+	for i, r1c := range r1cs.Constraints {
+		for matrix, l := range []compiled.LinearExpression{r1c.L, r1c.R} {
+			for _, t := range linearCombination(r1cs, l) {
+				var coeff fr.Element
+				coeff.Mul(&t.coeff, &rMod)
+				coeffs.uint32(uint32(matrix))
+				coeffs.uint32(uint32(i))
+				coeffs.uint32(uint32(t.wire))
+				for j := range coeff {
+					coeffs.uint64(coeff[j])
+				}
+				nbCoeffs++
+			}
+		}
+	}
+	var coeffsSection encoder
+	coeffsSection.uint32(uint32(nbCoeffs))
+	coeffsSection.Write(coeffs.Bytes())
+
+	// the points at infinity of A and B are restored
+	var a, b1, b2 encoder
+	var infinity1 curve.G1Affine
+	var infinity2 curve.G2Affine
+	for i, j := 0, 0; i < nbWires; i++ {
+		if _pk.InfinityA[i] {
+			a.g1(&infinity1)
+			continue
+		}
+		a.g1(&_pk.G1.A[j])
+		j++
+	}
+	for i, j := 0, 0; i < nbWires; i++ {
+		if _pk.InfinityB[i] {
+			b1.g1(&infinity1)
+			b2.g2(&infinity2)
+			continue
+		}
+		b1.g1(&_pk.G1.B[j])
+		b2.g2(&_pk.G2.B[j])
+		j++
+	}
+
+	var c encoder
+	for i := range _pk.G1.K {
+		c.g1(&_pk.G1.K[i])
+	}
+
+	var h encoder
+	for _, p := range hFromZ(_pk.G1.Z) {
+		h.g1(&p)
+	}
+
+	return writeBinFile(w, "zkey", 1, &header, &groth16Header, &ic, &coeffsSection, &a, &b1, &b2, &c, &h)
+}
+
+// g1Points reads the n points of G1 of the section of type typ
+func (f binFile) g1Points(typ uint32, n int) ([]curve.G1Affine, error) {
+	d, err := f.section(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.data) != n*2*n8 {
+		return nil, fmt.Errorf("invalid zkey file: section %d has %d bytes for %d points", typ, len(d.data), n)
+	}
+	points := make([]curve.G1Affine, n)
+	for i := range points {
+		points[i] = d.g1()
+	}
+	return points, d.err
+}
+
+// g2Points reads the n points of G2 of the section of type typ
+func (f binFile) g2Points(typ uint32, n int) ([]curve.G2Affine, error) {
+	d, err := f.section(typ)
+	if err != nil {
+		return nil, err
+	}
+	if len(d.data) != n*4*n8 {
+		return nil, fmt.Errorf("invalid zkey file: section %d has %d bytes for %d points", typ, len(d.data), n)
+	}
+	points := make([]curve.G2Affine, n)
+	for i := range points {
+		points[i] = d.g2()
+	}
+	return points, d.err
+}
+
+// checkG1 returns true if the points are at infinity or in the subgroup of G1
+func checkG1(points ...[]curve.G1Affine) bool {
+	var nbInvalid uint64
+	for _, p := range points {
+		utils.Parallelize(len(p), func(start, end int) {
+			for i := start; i < end; i++ {
+				if !p[i].IsInfinity() && (!p[i].IsOnCurve() || !p[i].IsInSubGroup()) {
+					atomic.AddUint64(&nbInvalid, 1)
+					return
+				}
+			}
+		})
+	}
+	return nbInvalid == 0
+}
+
+// checkG2 returns true if the points are at infinity or in the subgroup of G2
+func checkG2(points ...[]curve.G2Affine) bool {
+	var nbInvalid uint64
+	for _, p := range points {
+		utils.Parallelize(len(p), func(start, end int) {
+			for i := start; i < end; i++ {
+				if !p[i].IsInfinity() && (!p[i].IsOnCurve() || !p[i].IsInSubGroup()) {
+					atomic.AddUint64(&nbInvalid, 1)
+					return
+				}
+			}
+		})
+	}
+	return nbInvalid == 0
+}
+
+// zFromH returns the [τⁱ·(τⁿ-1)/δ]₁ of a proving key of gnark, in bit reversed order, from the Hⱼ = [ℓ₂ⱼ₊₁(τ)/δ]₁
+// of snarkjs, where ℓₖ is the k-th Lagrange polynomial on the domain of size 2n generated by ω₂ₙ.
+//
+// For x = ω₂ₙ²ʲ⁺¹, xⁿ = -1 and
+//
+//	ℓ₂ⱼ₊₁(τ) = 1/2n · Σ_{i<2n} x⁻ⁱ·τⁱ = -1/2n · Σ_{i<n} x⁻ⁱ·τⁱ·(τⁿ-1)
+//
+// hence [τⁱ·(τⁿ-1)/δ]₁ = -2·ω₂ₙⁱ · Σⱼ ωⁱʲ·Hⱼ with ω = ω₂ₙ², a FFT in G1.
+func zFromH(h []curve.G1Affine) []curve.G1Affine {
+	n := len(h)
+	domain := fft.NewDomain(uint64(2 * n))
+	var omega fr.Element
+	omega.Square(&domain.Generator)
+
+	a := make([]curve.G1Jac, n)
+	for i := range h {
+		a[i].FromAffine(&h[i])
+	}
+	fftG1(a, omega)
+
+	// a is in bit reversed order, as the points of gnark
+	res := make([]curve.G1Affine, n)
+	utils.Parallelize(n, func(start, end int) {
+		var s fr.Element
+		var sBig big.Int
+		for i := start; i < end; i++ {
+			s.Exp(domain.Generator, big.NewInt(int64(bitReverse(i, n))))
+			s.Double(&s).Neg(&s)
+			a[i].ScalarMultiplication(&a[i], s.ToBigIntRegular(&sBig))
+			res[i].FromJacobian(&a[i])
+		}
+	})
+	return res
+}
+
+// hFromZ is the inverse of zFromH, Hⱼ = -1/2n · Σᵢ ω⁻ⁱʲ·ω₂ₙ⁻ⁱ·[τⁱ·(τⁿ-1)/δ]₁
+func hFromZ(z []curve.G1Affine) []curve.G1Affine {
+	n := len(z)
+	domain := fft.NewDomain(uint64(2 * n))
+	var omegaInv fr.Element
+	omegaInv.Square(&domain.GeneratorInv)
+
+	a := make([]curve.G1Jac, n)
+	utils.Parallelize(n, func(start, end int) {
+		var s fr.Element
+		var sBig big.Int
+		for i := start; i < end; i++ {
+			a[i].FromAffine(&z[bitReverse(i, n)])
+			s.Exp(domain.GeneratorInv, big.NewInt(int64(i)))
+			s.Mul(&s, &domain.CardinalityInv).Neg(&s)
+			a[i].ScalarMultiplication(&a[i], s.ToBigIntRegular(&sBig))
+		}
+	})
+	fftG1(a, omegaInv)
+
+	res := make([]curve.G1Affine, n)
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			res[bitReverse(i, n)].FromJacobian(&a[i])
+		}
+	})
+	return res
+}
+
+// fftG1 sets a to Σⱼ ωⁱʲ·aⱼ in bit reversed order, where ω is a root of unity of order len(a)
+func fftG1(a []curve.G1Jac, omega fr.Element) {
+	n := len(a)
+	twiddles := make([]big.Int, n/2)
+	w := fr.One()
+	for i := range twiddles {
+		w.ToBigIntRegular(&twiddles[i])
+		w.Mul(&w, &omega)
+	}
+
+	// decimation in frequency
+	for m := n / 2; m >= 1; m /= 2 {
+		stride := n / (2 * m)
+		utils.Parallelize(n/2, func(start, end int) {
+			for b := start; b < end; b++ {
+				k, j := (b/m)*2*m, b%m
+				u, v := a[k+j], a[k+j+m]
+				a[k+j].AddAssign(&v)
+				u.SubAssign(&v)
+				if j != 0 {
+					u.ScalarMultiplication(&u, &twiddles[j*stride])
+				}
+				a[k+j+m] = u
+			}
+		})
+	}
+}
+
+// bitReverse returns i with its log₂(n) bits reversed
+func bitReverse(i, n int) int {
+	return int(bits.Reverse64(uint64(i)) >> (64 - bits.TrailingZeros64(uint64(n))))
+}
@@ -0,0 +1,252 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package aggregate implements the aggregation of Groth16 proofs (SnarkPack).
+//
+// N proofs for the same groth16.VerifyingKey are aggregated in a proof of size O(log N), which is verified
+// in O(log N) pairings and GT exponentiations instead of the 3N pairings of N calls to groth16.Verify.
+// The commitment keys of the inner pairing product arguments are powers of the secrets of two independent
+// powers of tau ceremonies (see mpc.Phase1), DeriveSRS extracts them from the last accumulators.
+//
+// Only BN254 and BLS12-381 are supported.
+//
+// There is no solidity verifier of aggregated proofs: their verification needs arithmetic in the target group
+// of the pairing, which the EVM precompiles don't provide.
+//
+// # See also
+//
+// https://eprint.iacr.org/2021/529.pdf
+package aggregate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/mpc"
+	"github.com/consensys/gnark/backend/witness"
+
+	witness_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/witness"
+	witness_bn254 "github.com/consensys/gnark/internal/backend/bn254/witness"
+
+	groth16_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/groth16"
+	groth16_bn254 "github.com/consensys/gnark/internal/backend/bn254/groth16"
+)
+
+var errCurveMismatch = errors.New("aggregation objects are not defined over the same curve")
+
+type aggregateObject interface {
+	io.WriterTo
+	io.ReaderFrom
+	CurveID() ecc.ID
+}
+
+// SRS holds the commitment keys of the aggregation
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/internal/backend)
+type SRS interface {
+	aggregateObject
+}
+
+// VerifyingKey holds the part of the SRS needed to verify an aggregated Proof
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/internal/backend)
+type VerifyingKey interface {
+	aggregateObject
+}
+
+// Proof is the aggregation of a batch of groth16.Proof for the same groth16.VerifyingKey
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/internal/backend)
+type Proof interface {
+	aggregateObject
+}
+
+// DeriveSRS returns the SRS derived from the last accumulators of the phase 1 of two independent ceremonies,
+// it supports the aggregation of up to 2^power proofs where power is the one of the smallest accumulator
+func DeriveSRS(srsA, srsB mpc.Phase1) (SRS, error) {
+	switch _srsA := srsA.(type) {
+	case *groth16_bn254.Phase1:
+		_srsB, ok := srsB.(*groth16_bn254.Phase1)
+		if !ok {
+			return nil, errCurveMismatch
+		}
+		return groth16_bn254.NewAggregationSRS(_srsA, _srsB)
+	case *groth16_bls12381.Phase1:
+		_srsB, ok := srsB.(*groth16_bls12381.Phase1)
+		if !ok {
+			return nil, errCurveMismatch
+		}
+		return groth16_bls12381.NewAggregationSRS(_srsA, _srsB)
+	default:
+		return nil, fmt.Errorf("aggregation is not supported on %s", srsA.CurveID())
+	}
+}
+
+// ExtractVerifyingKey returns the VerifyingKey of srs
+func ExtractVerifyingKey(srs SRS) VerifyingKey {
+	switch _srs := srs.(type) {
+	case *groth16_bn254.AggregationSRS:
+		return _srs.VerifyingKey()
+	case *groth16_bls12381.AggregationSRS:
+		return _srs.VerifyingKey()
+	default:
+		panic("unrecognized aggregation SRS curve type")
+	}
+}
+
+// Aggregate returns the aggregation of proofs, which are valid for vk and publicWitnesses.
+// The proofs are not checked, an invalid proof makes the aggregated proof invalid.
+func Aggregate(srs SRS, vk groth16.VerifyingKey, proofs []groth16.Proof, publicWitnesses []*witness.Witness) (Proof, error) {
+	if len(proofs) != len(publicWitnesses) {
+		return nil, fmt.Errorf("got %d proofs for %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+	switch _srs := srs.(type) {
+	case *groth16_bn254.AggregationSRS:
+		_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+		if !ok {
+			return nil, errCurveMismatch
+		}
+		_proofs := make([]*groth16_bn254.Proof, len(proofs))
+		for i := range proofs {
+			if _proofs[i], ok = proofs[i].(*groth16_bn254.Proof); !ok {
+				return nil, errCurveMismatch
+			}
+		}
+		_publicWitnesses, err := witnessesBN254(publicWitnesses)
+		if err != nil {
+			return nil, err
+		}
+		return groth16_bn254.Aggregate(_srs, _vk, _proofs, _publicWitnesses)
+	case *groth16_bls12381.AggregationSRS:
+		_vk, ok := vk.(*groth16_bls12381.VerifyingKey)
+		if !ok {
+			return nil, errCurveMismatch
+		}
+		_proofs := make([]*groth16_bls12381.Proof, len(proofs))
+		for i := range proofs {
+			if _proofs[i], ok = proofs[i].(*groth16_bls12381.Proof); !ok {
+				return nil, errCurveMismatch
+			}
+		}
+		_publicWitnesses, err := witnessesBLS12381(publicWitnesses)
+		if err != nil {
+			return nil, err
+		}
+		return groth16_bls12381.Aggregate(_srs, _vk, _proofs, _publicWitnesses)
+	default:
+		panic("unrecognized aggregation SRS curve type")
+	}
+}
+
+// Verify checks that proof is the aggregation of proofs valid for vk and publicWitnesses
+func Verify(proof Proof, avk VerifyingKey, vk groth16.VerifyingKey, publicWitnesses []*witness.Witness) error {
+	switch _proof := proof.(type) {
+	case *groth16_bn254.AggregatedProof:
+		_avk, ok := avk.(*groth16_bn254.AggregationVerifyingKey)
+		if !ok {
+			return errCurveMismatch
+		}
+		_vk, ok := vk.(*groth16_bn254.VerifyingKey)
+		if !ok {
+			return errCurveMismatch
+		}
+		_publicWitnesses, err := witnessesBN254(publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bn254.VerifyAggregate(_proof, _avk, _vk, _publicWitnesses)
+	case *groth16_bls12381.AggregatedProof:
+		_avk, ok := avk.(*groth16_bls12381.AggregationVerifyingKey)
+		if !ok {
+			return errCurveMismatch
+		}
+		_vk, ok := vk.(*groth16_bls12381.VerifyingKey)
+		if !ok {
+			return errCurveMismatch
+		}
+		_publicWitnesses, err := witnessesBLS12381(publicWitnesses)
+		if err != nil {
+			return err
+		}
+		return groth16_bls12381.VerifyAggregate(_proof, _avk, _vk, _publicWitnesses)
+	default:
+		panic("unrecognized aggregated proof curve type")
+	}
+}
+
+// NewSRS instantiates a curve-typed SRS and returns an interface object
+// This function exists for serialization purposes
+func NewSRS(curveID ecc.ID) SRS {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.AggregationSRS{}
+	case ecc.BLS12_381:
+		return &groth16_bls12381.AggregationSRS{}
+	default:
+		panic("not implemented")
+	}
+}
+
+// NewVerifyingKey instantiates a curve-typed VerifyingKey and returns an interface object
+// This function exists for serialization purposes
+func NewVerifyingKey(curveID ecc.ID) VerifyingKey {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.AggregationVerifyingKey{}
+	case ecc.BLS12_381:
+		return &groth16_bls12381.AggregationVerifyingKey{}
+	default:
+		panic("not implemented")
+	}
+}
+
+// NewProof instantiates a curve-typed Proof and returns an interface object
+// This function exists for serialization purposes
+func NewProof(curveID ecc.ID) Proof {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.AggregatedProof{}
+	case ecc.BLS12_381:
+		return &groth16_bls12381.AggregatedProof{}
+	default:
+		panic("not implemented")
+	}
+}
+
+func witnessesBN254(publicWitnesses []*witness.Witness) ([]witness_bn254.Witness, error) {
+	res := make([]witness_bn254.Witness, len(publicWitnesses))
+	for i := range publicWitnesses {
+		w, ok := publicWitnesses[i].Vector.(*witness_bn254.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		res[i] = *w
+	}
+	return res, nil
+}
+
+func witnessesBLS12381(publicWitnesses []*witness.Witness) ([]witness_bls12381.Witness, error) {
+	res := make([]witness_bls12381.Witness, len(publicWitnesses))
+	for i := range publicWitnesses {
+		w, ok := publicWitnesses[i].Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		res[i] = *w
+	}
+	return res, nil
+}
@@ -0,0 +1,140 @@
+package aggregate
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/groth16/mpc"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	groth16_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/groth16"
+	groth16_bn254 "github.com/consensys/gnark/internal/backend/bn254/groth16"
+	"github.com/stretchr/testify/require"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(api.Add(x3, circuit.X, 5), circuit.Y)
+	return nil
+}
+
+// roundTrip serializes src and deserializes it in dst
+func roundTrip(assert *require.Assertions, src, dst aggregateObject) {
+	var buf bytes.Buffer
+	n, err := src.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Equal(int64(buf.Len()), n)
+	m, err := dst.ReadFrom(&buf)
+	assert.NoError(err)
+	assert.Equal(n, m)
+}
+
+// ceremony returns the last accumulator of a phase 1 with one contribution
+func ceremony(assert *require.Assertions, curve ecc.ID, power int) mpc.Phase1 {
+	srs1 := mpc.InitPhase1(curve, power)
+	assert.NoError(srs1.Contribute())
+	return srs1
+}
+
+func TestAggregate(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_381} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &cubicCircuit{})
+			assert.NoError(err)
+			pk, vk, err := groth16.Setup(ccs)
+			assert.NoError(err)
+
+			srs, err := DeriveSRS(ceremony(assert, curve, 3), ceremony(assert, curve, 3))
+			assert.NoError(err)
+			_srs := NewSRS(curve)
+			roundTrip(assert, srs, _srs)
+			avk := NewVerifyingKey(curve)
+			roundTrip(assert, ExtractVerifyingKey(_srs), avk)
+
+			var proofs []groth16.Proof
+			var publicWitnesses []*witness.Witness
+			for x := 1; x <= 5; x++ {
+				fullWitness, err := frontend.NewWitness(&cubicCircuit{X: x, Y: x*x*x + x + 5}, curve)
+				assert.NoError(err)
+				proof, err := groth16.Prove(ccs, pk, fullWitness)
+				assert.NoError(err)
+				publicWitness, err := fullWitness.Public()
+				assert.NoError(err)
+				proofs = append(proofs, proof)
+				publicWitnesses = append(publicWitnesses, publicWitness)
+			}
+
+			// 5 proofs are padded to 8, 9 are more than the SRS supports
+			aggregated, err := Aggregate(_srs, vk, proofs, publicWitnesses)
+			assert.NoError(err)
+			_aggregated := NewProof(curve)
+			roundTrip(assert, aggregated, _aggregated)
+			assert.NoError(Verify(_aggregated, avk, vk, publicWitnesses))
+
+			_, err = Aggregate(_srs, vk, append(proofs, proofs[:4]...), append(publicWitnesses, publicWitnesses[:4]...))
+			assert.Error(err)
+
+			// wrong public inputs
+			assert.Error(Verify(aggregated, avk, vk, append(publicWitnesses[1:], publicWitnesses[0])))
+			assert.Error(Verify(aggregated, avk, vk, publicWitnesses[:4]))
+
+			// AggC and IP are changed consistently with the Groth16 relation
+			assert.Error(Verify(tamperAggC(assert, aggregated, vk), avk, vk, publicWitnesses))
+
+			// one invalid proof
+			proofs[2] = proofs[3]
+			aggregated, err = Aggregate(_srs, vk, proofs, publicWitnesses)
+			assert.NoError(err)
+			assert.Error(Verify(aggregated, avk, vk, publicWitnesses))
+		})
+	}
+}
+
+// tamperAggC returns a copy of proof where AggC is shifted by the generator G of G1 and IP by e(G, δ), so
+// that IP still equals the right side of the aggregated Groth16 relation
+func tamperAggC(assert *require.Assertions, proof Proof, vk groth16.VerifyingKey) Proof {
+	switch _proof := proof.(type) {
+	case *groth16_bn254.AggregatedProof:
+		tampered := *_proof
+		_, _, g1, _ := bn254.Generators()
+		tampered.AggC.Add(&tampered.AggC, &g1)
+		e, err := bn254.Pair([]bn254.G1Affine{g1}, []bn254.G2Affine{vk.(*groth16_bn254.VerifyingKey).G2.Delta})
+		assert.NoError(err)
+		tampered.IP.Mul(&tampered.IP, &e)
+		return &tampered
+	case *groth16_bls12381.AggregatedProof:
+		tampered := *_proof
+		_, _, g1, _ := bls12381.Generators()
+		tampered.AggC.Add(&tampered.AggC, &g1)
+		e, err := bls12381.Pair([]bls12381.G1Affine{g1}, []bls12381.G2Affine{vk.(*groth16_bls12381.VerifyingKey).G2.Delta})
+		assert.NoError(err)
+		tampered.IP.Mul(&tampered.IP, &e)
+		return &tampered
+	default:
+		panic("unrecognized aggregated proof curve type")
+	}
+}
+
+func TestDeriveSRS(t *testing.T) {
+	assert := require.New(t)
+
+	srs1 := ceremony(assert, ecc.BN254, 2)
+	_, err := DeriveSRS(srs1, srs1)
+	assert.Error(err, "the ceremonies must be independent")
+	_, err = DeriveSRS(srs1, ceremony(assert, ecc.BLS12_381, 2))
+	assert.Error(err)
+	_, err = DeriveSRS(ceremony(assert, ecc.BLS12_377, 2), ceremony(assert, ecc.BLS12_377, 2))
+	assert.Error(err)
+}
@@ -0,0 +1,49 @@
+package groth16
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchVerify(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &dumpCircuit{})
+			assert.NoError(err)
+			pk, vk, err := Setup(ccs)
+			assert.NoError(err)
+
+			var proofs []Proof
+			var publicWitnesses []*witness.Witness
+			for x := 1; x <= 4; x++ {
+				fullWitness, err := frontend.NewWitness(&dumpCircuit{X: x, Y: x * x * x}, curve)
+				assert.NoError(err)
+				proof, err := Prove(ccs, pk, fullWitness)
+				assert.NoError(err)
+				publicWitness, err := fullWitness.Public()
+				assert.NoError(err)
+				proofs = append(proofs, proof)
+				publicWitnesses = append(publicWitnesses, publicWitness)
+			}
+			assert.NoError(BatchVerify(proofs, vk, publicWitnesses))
+
+			// the proof at index 2 doesn't match its public witness
+			proofs[2] = proofs[1]
+			err = BatchVerify(proofs, vk, publicWitnesses)
+			var batchErr *backend.BatchVerifyError
+			assert.True(errors.As(err, &batchErr))
+			assert.Equal(2, batchErr.Index)
+
+			assert.Error(BatchVerify(proofs, vk, publicWitnesses[:3]))
+		})
+	}
+}
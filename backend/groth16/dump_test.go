@@ -0,0 +1,170 @@
+package groth16
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/internal/backend/dump"
+	"github.com/stretchr/testify/require"
+)
+
+type dumpCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *dumpCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+func TestSetupWithDump(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &dumpCircuit{})
+	assert.NoError(err)
+
+	session := filepath.Join(t.TempDir(), "dump")
+	assert.NoError(SetupWithDump(ccs, session))
+	assert.NoError(VerifyDump(ecc.BN254, session))
+	assert.Error(VerifyDump(ecc.BLS12_381, session), "dump was written for BN254")
+
+	loaded, err := LoadR1CSFromFile(ecc.BN254, session)
+	assert.NoError(err)
+	assert.Equal(ccs.GetNbConstraints(), loaded.GetNbConstraints())
+
+	pks, err := ReadSegmentProveKey(ecc.BN254, session)
+	assert.NoError(err)
+
+	fullWitness, err := frontend.NewWitness(&dumpCircuit{X: 3, Y: 27}, ecc.BN254)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	proof, err := ProveRoll(loaded, pks[0], pks[1], fullWitness, session)
+	assert.NoError(err)
+
+	vk := NewVerifyingKey(ecc.BN254)
+	f, err := os.Open(session + ".vk.save")
+	assert.NoError(err)
+	_, err = vk.ReadFrom(f)
+	assert.NoError(err)
+	assert.NoError(f.Close())
+	assert.NoError(Verify(proof, vk, publicWitness))
+
+	// corrupted segment
+	segment := session + ".pk.A.save"
+	data, err := os.ReadFile(segment)
+	assert.NoError(err)
+	data[len(data)-1] ^= 1
+	assert.NoError(os.WriteFile(segment, data, 0o644))
+	assert.ErrorContains(VerifyDump(ecc.BN254, session), "checksum mismatch")
+	_, err = ProveRoll(loaded, pks[0], pks[1], fullWitness, session)
+	assert.ErrorContains(err, "checksum mismatch")
+
+	// truncated segment
+	segment = session + ".pk.B2.save"
+	data, err = os.ReadFile(segment)
+	assert.NoError(err)
+	assert.NoError(os.WriteFile(segment, data[:len(data)/2], 0o644))
+	_, err = ReadSegmentProveKey(ecc.BN254, session)
+	assert.ErrorContains(err, "truncated")
+
+	// missing manifest
+	assert.NoError(os.Remove(session + ".manifest.json"))
+	_, err = ReadSegmentProveKey(ecc.BN254, session)
+	assert.Error(err)
+}
+
+// offsetDumpCircuit has the shape of dumpCircuit, with another constant
+type offsetDumpCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *offsetDumpCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(api.Mul(circuit.X, circuit.X, circuit.X), 1), circuit.Y)
+	return nil
+}
+
+func TestProveWrongDump(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &dumpCircuit{})
+	assert.NoError(err)
+	other, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &offsetDumpCircuit{})
+	assert.NoError(err)
+
+	session := filepath.Join(t.TempDir(), "dump")
+	assert.NoError(SetupWithDump(ccs, session))
+	pks, err := ReadSegmentProveKey(ecc.BN254, session)
+	assert.NoError(err)
+
+	// a valid witness of the other circuit, proven with the dump of dumpCircuit
+	fullWitness, err := frontend.NewWitness(&offsetDumpCircuit{X: 3, Y: 28}, ecc.BN254)
+	assert.NoError(err)
+	_, err = ProveRoll(other, pks[0], pks[1], fullWitness, session)
+	assert.ErrorContains(err, "written for another constraint system")
+	pkE, err := ReadProveKeyE(ecc.BN254, session)
+	assert.NoError(err)
+	_, err = ProveStream(other, pkE, fullWitness, session)
+	assert.ErrorContains(err, "written for another constraint system")
+
+	// the dump of the other circuit, proven with dumpCircuit
+	otherSession := filepath.Join(t.TempDir(), "other")
+	assert.NoError(SetupWithDump(other, otherSession))
+	pks, err = ReadSegmentProveKey(ecc.BN254, otherSession)
+	assert.NoError(err)
+	fullWitness, err = frontend.NewWitness(&dumpCircuit{X: 3, Y: 27}, ecc.BN254)
+	assert.NoError(err)
+	_, err = ProveRoll(ccs, pks[0], pks[1], fullWitness, otherSession)
+	assert.ErrorContains(err, "written for another constraint system")
+}
+
+func TestProveStream(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &lazyPoseidonCircuit{}, frontend.IgnoreUnconstrainedInputs())
+			assert.NoError(err)
+
+			session := filepath.Join(t.TempDir(), "stream")
+			assert.NoError(SetupLazyWithDump(ccs, session))
+
+			// the B2 segment is streamed, not decoded with the E one: it isn't even opened
+			b2 := session + "." + dump.ProvingKeyB2 + ".save"
+			assert.NoError(os.Rename(b2, b2+".moved"))
+			pkE, err := ReadProveKeyE(curve, session)
+			assert.NoError(err)
+			assert.NoError(os.Rename(b2+".moved", b2))
+
+			vk := NewVerifyingKey(curve)
+			f, err := os.Open(session + ".vk.save")
+			assert.NoError(err)
+			_, err = vk.ReadFrom(f)
+			assert.NoError(err)
+			assert.NoError(f.Close())
+
+			data := [4]frontend.Variable{1, 2, 3, 4}
+			fullWitness, err := frontend.NewWitness(&lazyPoseidonCircuit{Data: data, Hash: poseidonHash(assert, curve, data)}, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+
+			// without budget, each segment is read at once
+			proof, err := ProveStream(ccs, pkE, fullWitness, session)
+			assert.NoError(err)
+			assert.NoError(Verify(proof, vk, publicWitness))
+
+			// a few points per chunk
+			proof, err = ProveStream(ccs, pkE, fullWitness, session, backend.WithMemoryBudget(4096))
+			assert.NoError(err)
+			assert.NoError(Verify(proof, vk, publicWitness))
+		})
+	}
+}
@@ -20,9 +20,9 @@
 package groth16
 
 import (
+	"errors"
 	"fmt"
 	"io"
-	"os"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
@@ -42,6 +42,7 @@ import (
 	witness_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/witness"
 	witness_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/witness"
 
+	"github.com/consensys/gnark/internal/backend/dump"
 	gnarkio "github.com/consensys/gnark/io"
 
 	groth16_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/groth16"
@@ -152,6 +153,112 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness *witness.Witness) error
 	}
 }
 
+var errCurveMismatch = errors.New("proof and verifying key are not defined over the same curve")
+
+// BatchVerify verifies proofs with given VerifyingKey and public witnesses in a single multi-pairing,
+// combining the verification equations with random coefficients. If a proof is invalid, the returned
+// error is a *backend.BatchVerifyError holding its index.
+func BatchVerify(proofs []Proof, vk VerifyingKey, publicWitnesses []*witness.Witness) error {
+	if len(proofs) != len(publicWitnesses) {
+		return fmt.Errorf("got %d proofs for %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+
+	switch _vk := vk.(type) {
+	case *groth16_bn254.VerifyingKey:
+		_proofs := make([]*groth16_bn254.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bn254.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*groth16_bn254.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bn254.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return groth16_bn254.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *groth16_bls12381.VerifyingKey:
+		_proofs := make([]*groth16_bls12381.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bls12381.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*groth16_bls12381.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bls12381.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return groth16_bls12381.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *groth16_bls12377.VerifyingKey:
+		_proofs := make([]*groth16_bls12377.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bls12377.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*groth16_bls12377.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bls12377.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return groth16_bls12377.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *groth16_bw6761.VerifyingKey:
+		_proofs := make([]*groth16_bw6761.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bw6761.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*groth16_bw6761.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bw6761.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return groth16_bw6761.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *groth16_bw6633.VerifyingKey:
+		_proofs := make([]*groth16_bw6633.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bw6633.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*groth16_bw6633.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bw6633.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return groth16_bw6633.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *groth16_bls24315.VerifyingKey:
+		_proofs := make([]*groth16_bls24315.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bls24315.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*groth16_bls24315.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bls24315.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return groth16_bls24315.BatchVerify(_proofs, _vk, _publicWitnesses)
+	default:
+		panic("unrecognized verifying key curve type")
+	}
+}
+
 // Prove runs the groth16.Prove algorithm.
 //
 // if the force flag is set:
@@ -209,75 +316,189 @@ func Prove(r1cs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *w
 	}
 }
 
+// LazifyR1cs removes the constraints recorded by lazy gadgets (see frontend.API.AddLazyPoseidon and frontend.RegisterLazyGadget)
+// from the R1CS; they are materialized on demand by the solver and the setup instead.
 func LazifyR1cs(r1cs frontend.CompiledConstraintSystem) {
 	switch _r1cs := r1cs.(type) {
+	case *backend_bls12377.R1CS:
+		_r1cs.Lazify()
+	case *backend_bls12381.R1CS:
+		_r1cs.Lazify()
 	case *backend_bn254.R1CS:
 		_r1cs.Lazify()
+	case *backend_bw6761.R1CS:
+		_r1cs.Lazify()
+	case *backend_bls24315.R1CS:
+		_r1cs.Lazify()
+	case *backend_bw6633.R1CS:
+		_r1cs.Lazify()
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// CheckLazify checks that LazifyR1cs preserves the semantic of r1cs, which is left unmodified:
+// the lazy constraints materialized by the solver and the setup must be identical to the constraints
+// they replace, and solving fullWitness or computing the setup polynomials must give the same results
+// with both forms.
+func CheckLazify(r1cs frontend.CompiledConstraintSystem, fullWitness *witness.Witness, opts ...backend.ProverOption) error {
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return err
+	}
+
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls12377.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return groth16_bls12377.CheckLazify(_r1cs, *w, opt)
+	case *backend_bls12381.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return groth16_bls12381.CheckLazify(_r1cs, *w, opt)
+	case *backend_bn254.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return groth16_bn254.CheckLazify(_r1cs, *w, opt)
+	case *backend_bw6761.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return groth16_bw6761.CheckLazify(_r1cs, *w, opt)
+	case *backend_bls24315.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return groth16_bls24315.CheckLazify(_r1cs, *w, opt)
+	case *backend_bw6633.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return witness.ErrInvalidWitness
+		}
+		return groth16_bw6633.CheckLazify(_r1cs, *w, opt)
 	default:
 		panic("unrecognized R1CS curve type")
 	}
 }
 
-func ReadSegmentProveKey(filepath string) (pks []ProvingKey, err error) {
+// segmentReader is implemented by the curve-typed ProvingKey to read the segments
+// written by SetupWithDump and SetupLazyWithDump
+type segmentReader interface {
+	UnsafeReadEFrom(r io.Reader) (int64, error)
+	UnsafeReadB2From(r io.Reader) (int64, error)
+}
+
+// ReadSegmentProveKey reads the E and B2 segments of a ProvingKey dumped by SetupWithDump
+// or SetupLazyWithDump under the given session prefix. The result is meant to be used with ProveRoll.
+//
+// The segments are checked against the manifest of the dump, an error is returned if they
+// were written for another curve, are truncated or don't match their checksum.
+func ReadSegmentProveKey(curveID ecc.ID, session string) (pks []ProvingKey, err error) {
+	m, err := dump.ReadManifest(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.CheckCurve(curveID); err != nil {
+		return nil, err
+	}
+
 	pks = make([]ProvingKey, 2)
 	// pkE
-	pks[0] = NewProvingKey(ecc.BN254)
+	pks[0] = NewProvingKey(curveID)
 	// pkB2
-	pks[1] = NewProvingKey(ecc.BN254)
+	pks[1] = NewProvingKey(curveID)
 
-	f0, _ := os.Open(filepath + ".pk.E.save")
-	_, err = pks[0].(*groth16_bn254.ProvingKey).UnsafeReadEFrom(f0)
-	if err != nil {
-		return pks, fmt.Errorf("read file error")
+	if err := m.ReadSegment(dump.ProvingKeyE, pks[0].(segmentReader).UnsafeReadEFrom); err != nil {
+		return nil, err
 	}
-	err = f0.Close()
-	if err != nil {
-		return pks, err
+	if err := m.ReadSegment(dump.ProvingKeyB2, pks[1].(segmentReader).UnsafeReadB2From); err != nil {
+		return nil, err
 	}
 
-	f1, _ := os.Open(filepath + ".pk.B2.save")
-	_, err = pks[1].(*groth16_bn254.ProvingKey).UnsafeReadB2From(f1)
+	return pks, nil
+}
+
+// ReadProveKeyE reads the E segment of a ProvingKey dumped by SetupWithDump or SetupLazyWithDump
+// under the given session prefix. The result is meant to be used with ProveStream, which streams
+// the other segments, B2 included, from the dump.
+//
+// The segment is checked against the manifest of the dump, an error is returned if it was
+// written for another curve, is truncated or doesn't match its checksum.
+func ReadProveKeyE(curveID ecc.ID, session string) (ProvingKey, error) {
+	m, err := dump.ReadManifest(session)
 	if err != nil {
-		return pks, fmt.Errorf("read file error")
+		return nil, err
 	}
-	err = f1.Close()
-	if err != nil {
-		return pks, err
+	if err := m.CheckCurve(curveID); err != nil {
+		return nil, err
 	}
 
-	return pks, nil
+	pkE := NewProvingKey(curveID)
+	if err := m.ReadSegment(dump.ProvingKeyE, pkE.(segmentReader).UnsafeReadEFrom); err != nil {
+		return nil, err
+	}
+	return pkE, nil
 }
 
-func LoadR1CSFromFile(filepath string) (r1cs frontend.CompiledConstraintSystem, err error) {
-	cccs := &backend_bn254.R1CS{}
-	ccsFile, err := os.Open(fmt.Sprintf("%s.ccs.save", filepath))
+// LoadR1CSFromFile reads a R1CS and its coefficient table dumped by SetupWithDump
+// or SetupLazyWithDump under the given session prefix.
+//
+// An error is returned if the decoded R1CS doesn't hash to the circuit hash of the manifest.
+func LoadR1CSFromFile(curveID ecc.ID, session string) (frontend.CompiledConstraintSystem, error) {
+	m, err := dump.ReadManifest(session)
 	if err != nil {
-		return r1cs, err
+		return nil, err
 	}
-	_, err = cccs.ReadFrom(ccsFile)
-	if err != nil {
-		return r1cs, err
+	if err := m.CheckCurve(curveID); err != nil {
+		return nil, err
 	}
-	err = ccsFile.Close()
-	if err != nil {
-		return r1cs, err
+
+	cccs := NewCS(curveID)
+	dumped := cccs.(interface {
+		ReadCTFrom(r io.Reader) (int64, error)
+		WriteCTTo(w io.Writer) (int64, error)
+		WriteCircuitTo(w io.Writer) (int64, error)
+	})
+	if err := m.ReadSegment(dump.ConstraintSystem, cccs.ReadFrom); err != nil {
+		return nil, err
+	}
+	if err := m.ReadSegment(dump.CoeffTable, dumped.ReadCTFrom); err != nil {
+		return nil, err
 	}
 
-	ctFile, err := os.Open(fmt.Sprintf("%s.ccs.ct.save", filepath))
-	if err != nil {
-		return r1cs, err
+	// the segments match their checksum, check that nothing was lost decoding them
+	if err := m.CheckCircuit(dumped.WriteCircuitTo, dumped.WriteCTTo); err != nil {
+		return nil, err
 	}
-	_, err = cccs.ReadCTFrom(ctFile)
+	return cccs, nil
+}
+
+// VerifyDump checks that the files dumped by SetupWithDump or SetupLazyWithDump under the given
+// session prefix were written for curveID and match the size and checksum recorded in their manifest
+func VerifyDump(curveID ecc.ID, session string) error {
+	m, err := dump.ReadManifest(session)
 	if err != nil {
-		return r1cs, err
+		return err
 	}
-	err = ctFile.Close()
-	if err != nil {
-		return r1cs, err
+	if err := m.CheckCurve(curveID); err != nil {
+		return err
 	}
-	return cccs, nil
+	return m.Verify()
 }
 
+// ProveRoll runs the groth16.Prove algorithm, loading the A, B1, Z and K segments of the
+// ProvingKey from the files dumped under session one at a time to bound memory usage.
+//
+// pkE and pkB2 are the E and B2 segments returned by ReadSegmentProveKey, an error is returned
+// if r1cs isn't the constraint system the dump was written for.
 func ProveRoll(r1cs frontend.CompiledConstraintSystem, pkE, pkB2 ProvingKey, fullWitness *witness.Witness, session string, opts ...backend.ProverOption) (Proof, error) {
 
 	// apply options
@@ -287,12 +508,98 @@ func ProveRoll(r1cs frontend.CompiledConstraintSystem, pkE, pkB2 ProvingKey, ful
 	}
 
 	switch _r1cs := r1cs.(type) {
+	case *backend_bls12377.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bls12377.ProveRoll(_r1cs, pkE.(*groth16_bls12377.ProvingKey), pkB2.(*groth16_bls12377.ProvingKey), *w, opt, session)
+	case *backend_bls12381.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bls12381.ProveRoll(_r1cs, pkE.(*groth16_bls12381.ProvingKey), pkB2.(*groth16_bls12381.ProvingKey), *w, opt, session)
 	case *backend_bn254.R1CS:
 		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
 		if !ok {
 			return nil, witness.ErrInvalidWitness
 		}
 		return groth16_bn254.ProveRoll(_r1cs, pkE.(*groth16_bn254.ProvingKey), pkB2.(*groth16_bn254.ProvingKey), *w, opt, session)
+	case *backend_bw6761.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bw6761.ProveRoll(_r1cs, pkE.(*groth16_bw6761.ProvingKey), pkB2.(*groth16_bw6761.ProvingKey), *w, opt, session)
+	case *backend_bls24315.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bls24315.ProveRoll(_r1cs, pkE.(*groth16_bls24315.ProvingKey), pkB2.(*groth16_bls24315.ProvingKey), *w, opt, session)
+	case *backend_bw6633.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bw6633.ProveRoll(_r1cs, pkE.(*groth16_bw6633.ProvingKey), pkB2.(*groth16_bw6633.ProvingKey), *w, opt, session)
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// ProveStream runs the groth16.Prove algorithm, streaming the bases of each multi-exponentiation
+// from the ProvingKey segments dumped under session instead of loading them, so that at most
+// backend.WithMemoryBudget bytes of the ProvingKey are held in memory at once.
+//
+// pkE is the E segment returned by ReadProveKeyE, an error is returned if r1cs isn't the
+// constraint system the dump was written for.
+func ProveStream(r1cs frontend.CompiledConstraintSystem, pkE ProvingKey, fullWitness *witness.Witness, session string, opts ...backend.ProverOption) (Proof, error) {
+
+	// apply options
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch _r1cs := r1cs.(type) {
+	case *backend_bls12377.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bls12377.ProveStream(_r1cs, pkE.(*groth16_bls12377.ProvingKey), *w, opt, session)
+	case *backend_bls12381.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bls12381.ProveStream(_r1cs, pkE.(*groth16_bls12381.ProvingKey), *w, opt, session)
+	case *backend_bn254.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bn254.ProveStream(_r1cs, pkE.(*groth16_bn254.ProvingKey), *w, opt, session)
+	case *backend_bw6761.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bw6761.ProveStream(_r1cs, pkE.(*groth16_bw6761.ProvingKey), *w, opt, session)
+	case *backend_bls24315.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bls24315.ProveStream(_r1cs, pkE.(*groth16_bls24315.ProvingKey), *w, opt, session)
+	case *backend_bw6633.R1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return groth16_bw6633.ProveStream(_r1cs, pkE.(*groth16_bw6633.ProvingKey), *w, opt, session)
 	default:
 		panic("unrecognized R1CS curve type")
 	}
@@ -302,52 +609,61 @@ func ProveRoll(r1cs frontend.CompiledConstraintSystem, pkE, pkB2 ProvingKey, ful
 //
 // Note that careful consideration must be given to this step in production environment.
 // groth16.Setup uses some randomness to precompute the Proving and Verifying keys. If the process
-// or machine leaks this randomness, an attacker could break the ZKP protocol.
+// or machine leaks this randomness, an attacker could break the ZKP protocol. The randomness
+// is read from crypto/rand, backend.WithSetupRandomSource replaces it for tests only.
 //
 // Two main solutions to this deployment issues are: running the Setup through a MPC (multi party computation)
 // or using a ZKP backend like PLONK where the per-circuit Setup is deterministic.
-func Setup(r1cs frontend.CompiledConstraintSystem) (ProvingKey, VerifyingKey, error) {
+//
+// The setup can be cancelled and observed with backend.WithSetupContext and backend.WithSetupProgress.
+func Setup(r1cs frontend.CompiledConstraintSystem, opts ...backend.SetupOption) (ProvingKey, VerifyingKey, error) {
+
+	// apply options
+	opt, err := backend.NewSetupConfig(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	switch _r1cs := r1cs.(type) {
 	case *backend_bls12377.R1CS:
 		var pk groth16_bls12377.ProvingKey
 		var vk groth16_bls12377.VerifyingKey
-		if err := groth16_bls12377.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls12377.Setup(_r1cs, &pk, &vk, opt); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bls12381.R1CS:
 		var pk groth16_bls12381.ProvingKey
 		var vk groth16_bls12381.VerifyingKey
-		if err := groth16_bls12381.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls12381.Setup(_r1cs, &pk, &vk, opt); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bn254.R1CS:
 		var pk groth16_bn254.ProvingKey
 		var vk groth16_bn254.VerifyingKey
-		if err := groth16_bn254.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bn254.Setup(_r1cs, &pk, &vk, opt); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bw6761.R1CS:
 		var pk groth16_bw6761.ProvingKey
 		var vk groth16_bw6761.VerifyingKey
-		if err := groth16_bw6761.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bw6761.Setup(_r1cs, &pk, &vk, opt); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bls24315.R1CS:
 		var pk groth16_bls24315.ProvingKey
 		var vk groth16_bls24315.VerifyingKey
-		if err := groth16_bls24315.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bls24315.Setup(_r1cs, &pk, &vk, opt); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
 	case *backend_bw6633.R1CS:
 		var pk groth16_bw6633.ProvingKey
 		var vk groth16_bw6633.VerifyingKey
-		if err := groth16_bw6633.Setup(_r1cs, &pk, &vk); err != nil {
+		if err := groth16_bw6633.Setup(_r1cs, &pk, &vk, opt); err != nil {
 			return nil, nil, err
 		}
 		return &pk, &vk, nil
@@ -356,28 +672,66 @@ func Setup(r1cs frontend.CompiledConstraintSystem) (ProvingKey, VerifyingKey, er
 	}
 }
 
-func SetupWithDump(r1cs frontend.CompiledConstraintSystem, session string) error {
+// SetupWithDump runs groth16.Setup and writes the R1CS, the VerifyingKey and each segment of
+// the ProvingKey to files prefixed by session instead of returning them, along with a manifest
+// recording the curve and the size and checksum of each file (see VerifyDump).
+//
+// The manifest is written last: if the setup is cancelled (see backend.WithSetupContext), the
+// segments already written are left without a manifest and VerifyDump rejects the session.
+func SetupWithDump(r1cs frontend.CompiledConstraintSystem, session string, opts ...backend.SetupOption) error {
+
+	// apply options
+	opt, err := backend.NewSetupConfig(opts...)
+	if err != nil {
+		return err
+	}
 
 	switch _r1cs := r1cs.(type) {
+	case *backend_bls12377.R1CS:
+		return groth16_bls12377.SetupWithDump(_r1cs, session, opt)
+	case *backend_bls12381.R1CS:
+		return groth16_bls12381.SetupWithDump(_r1cs, session, opt)
 	case *backend_bn254.R1CS:
-		if err := groth16_bn254.SetupWithDump(_r1cs, session); err != nil {
-			return err
-		}
-		return nil
+		return groth16_bn254.SetupWithDump(_r1cs, session, opt)
+	case *backend_bw6761.R1CS:
+		return groth16_bw6761.SetupWithDump(_r1cs, session, opt)
+	case *backend_bls24315.R1CS:
+		return groth16_bls24315.SetupWithDump(_r1cs, session, opt)
+	case *backend_bw6633.R1CS:
+		return groth16_bw6633.SetupWithDump(_r1cs, session, opt)
 	default:
 		panic("unrecognized R1CS curve type")
 	}
 }
 
-func SetupLazyWithDump(r1cs frontend.CompiledConstraintSystem, session string) error {
+// SetupLazyWithDump lazifies the R1CS (see LazifyR1cs) and behaves as SetupWithDump.
+func SetupLazyWithDump(r1cs frontend.CompiledConstraintSystem, session string, opts ...backend.SetupOption) error {
+
+	// apply options
+	opt, err := backend.NewSetupConfig(opts...)
+	if err != nil {
+		return err
+	}
 
 	switch _r1cs := r1cs.(type) {
+	case *backend_bls12377.R1CS:
+		_r1cs.Lazify()
+		return groth16_bls12377.SetupLazyWithDump(_r1cs, session, opt)
+	case *backend_bls12381.R1CS:
+		_r1cs.Lazify()
+		return groth16_bls12381.SetupLazyWithDump(_r1cs, session, opt)
 	case *backend_bn254.R1CS:
 		_r1cs.Lazify()
-		if err := groth16_bn254.SetupLazyWithDump(_r1cs, session); err != nil {
-			return err
-		}
-		return nil
+		return groth16_bn254.SetupLazyWithDump(_r1cs, session, opt)
+	case *backend_bw6761.R1CS:
+		_r1cs.Lazify()
+		return groth16_bw6761.SetupLazyWithDump(_r1cs, session, opt)
+	case *backend_bls24315.R1CS:
+		_r1cs.Lazify()
+		return groth16_bls24315.SetupLazyWithDump(_r1cs, session, opt)
+	case *backend_bw6633.R1CS:
+		_r1cs.Lazify()
+		return groth16_bw6633.SetupLazyWithDump(_r1cs, session, opt)
 	default:
 		panic("unrecognized R1CS curve type")
 	}
@@ -0,0 +1,307 @@
+package groth16
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/hash"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	backend_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/hash/poseidon"
+	"github.com/stretchr/testify/require"
+)
+
+type lazyPoseidonCircuit struct {
+	Data [4]frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+}
+
+func (circuit *lazyPoseidonCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(hashPoseidon(api, circuit.Data), circuit.Hash)
+	return nil
+}
+
+// hashPoseidon chains two Poseidon hashes of data, each recorded as lazy constraints
+func hashPoseidon(api frontend.API, data [4]frontend.Variable) frontend.Variable {
+	h := poseidon.Poseidon(api, data[:2]...)
+	return poseidon.Poseidon(api, h, data[2], data[3])
+}
+
+// eagerPoseidonCircuit computes the hash checked by lazyPoseidonCircuit, and hands it to the hint captureHash
+type eagerPoseidonCircuit struct {
+	Data [4]frontend.Variable
+}
+
+func (circuit *eagerPoseidonCircuit) Define(api frontend.API) error {
+	h := hashPoseidon(api, circuit.Data)
+	res, err := api.Compiler().NewHint(captureHash, 1, h)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(res[0], h)
+	return nil
+}
+
+// capturedHash is the last input of captureHash
+var capturedHash big.Int
+
+func captureHash(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	capturedHash.Set(inputs[0])
+	outputs[0].Set(inputs[0])
+	return nil
+}
+
+// poseidonHash returns the hash of data checked by lazyPoseidonCircuit on curve, as computed by the
+// solver of the constraints before they are lazified
+func poseidonHash(assert *require.Assertions, curve ecc.ID, data [4]frontend.Variable) *big.Int {
+	ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &eagerPoseidonCircuit{})
+	assert.NoError(err)
+	fullWitness, err := frontend.NewWitness(&eagerPoseidonCircuit{Data: data}, curve)
+	assert.NoError(err)
+	assert.NoError(ccs.IsSolved(fullWitness, backend.WithHints(captureHash)))
+	return new(big.Int).Set(&capturedHash)
+}
+
+func TestSetupLazyWithDump(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &lazyPoseidonCircuit{}, frontend.IgnoreUnconstrainedInputs())
+			assert.NoError(err)
+			nbConstraints := ccs.GetNbConstraints()
+
+			session := filepath.Join(t.TempDir(), "lazy")
+			assert.NoError(SetupLazyWithDump(ccs, session))
+			assert.Less(ccs.GetNbConstraints(), nbConstraints, "lazy constraints should be removed")
+
+			data := [4]frontend.Variable{1, 2, 3, 4}
+			assignment := lazyPoseidonCircuit{
+				Data: data,
+				Hash: poseidonHash(assert, curve, data),
+			}
+			fullWitness, err := frontend.NewWitness(&assignment, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+
+			pks, err := ReadSegmentProveKey(curve, session)
+			assert.NoError(err)
+
+			proof, err := ProveRoll(ccs, pks[0], pks[1], fullWitness, session)
+			assert.NoError(err)
+
+			vk := NewVerifyingKey(curve)
+			f, err := os.Open(session + ".vk.save")
+			assert.NoError(err)
+			_, err = vk.ReadFrom(f)
+			assert.NoError(err)
+			assert.NoError(f.Close())
+
+			assert.NoError(Verify(proof, vk, publicWitness))
+
+			assignment.Hash = 42
+			fullWitness, err = frontend.NewWitness(&assignment, curve)
+			assert.NoError(err)
+			_, err = ProveRoll(ccs, pks[0], pks[1], fullWitness, session)
+			assert.Error(err)
+		})
+	}
+}
+
+type lazyMimcCircuit struct {
+	Data [3]frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+}
+
+func (circuit *lazyMimcCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewLazyMiMC(api)
+	if err != nil {
+		return err
+	}
+	h.Write(circuit.Data[:]...)
+	api.AssertIsEqual(h.Sum(), circuit.Hash)
+	return nil
+}
+
+// TestLazyMimc proves MiMC hashes with lazified encryptions, whose round function depends on the curve
+func TestLazyMimc(t *testing.T) {
+	for curve, hashFunc := range map[ecc.ID]hash.Hash{ecc.BN254: hash.MIMC_BN254, ecc.BLS12_377: hash.MIMC_BLS12_377} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			goMimc := hashFunc.New()
+			var data [3]big.Int
+			for i := range data {
+				data[i].SetInt64(int64(i + 1))
+				goMimc.Write(data[i].FillBytes(make([]byte, goMimc.BlockSize())))
+			}
+			assignment := lazyMimcCircuit{Data: [3]frontend.Variable{1, 2, 3}, Hash: goMimc.Sum(nil)}
+			fullWitness, err := frontend.NewWitness(&assignment, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &lazyMimcCircuit{})
+			assert.NoError(err)
+			assert.NoError(CheckLazify(ccs, fullWitness))
+			nbConstraints := ccs.GetNbConstraints()
+
+			session := filepath.Join(t.TempDir(), "mimc")
+			assert.NoError(SetupLazyWithDump(ccs, session))
+			assert.Less(ccs.GetNbConstraints(), nbConstraints, "lazy constraints should be removed")
+
+			pks, err := ReadSegmentProveKey(curve, session)
+			assert.NoError(err)
+			vk := NewVerifyingKey(curve)
+			f, err := os.Open(session + ".vk.save")
+			assert.NoError(err)
+			_, err = vk.ReadFrom(f)
+			assert.NoError(err)
+			assert.NoError(f.Close())
+
+			proof, err := ProveRoll(ccs, pks[0], pks[1], fullWitness, session)
+			assert.NoError(err)
+			assert.NoError(Verify(proof, vk, publicWitness))
+
+			assignment.Hash = 42
+			fullWitness, err = frontend.NewWitness(&assignment, curve)
+			assert.NoError(err)
+			_, err = ProveRoll(ccs, pks[0], pks[1], fullWitness, session)
+			assert.Error(err)
+		})
+	}
+}
+
+func init() {
+	// cubic returns x*y + x³ and whether x == y
+	frontend.RegisterLazyGadget("test-cubic", func(api frontend.API, inputs ...frontend.Variable) []frontend.Variable {
+		x, y := inputs[0], inputs[1]
+		res := api.Mul(api.Add(y, api.Mul(x, x)), x)
+		return []frontend.Variable{res, api.IsZero(api.Sub(x, y))}
+	})
+}
+
+type lazyGadgetCircuit struct {
+	X, Y frontend.Variable
+	Out  frontend.Variable `gnark:",public"`
+}
+
+func (circuit *lazyGadgetCircuit) Define(api frontend.API) error {
+	a := api.AddLazyGadget("test-cubic", circuit.X, circuit.Y)
+	b := api.AddLazyGadget("test-cubic", a[0], circuit.Y)
+	c := api.AddLazyGadget("test-cubic", b[0], 3)
+	api.AssertIsEqual(api.Add(a[1], b[1], c[1]), 0)
+	api.AssertIsEqual(c[0], circuit.Out)
+	return nil
+}
+
+func TestLazyGadget(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &lazyGadgetCircuit{})
+	assert.NoError(err)
+	nbConstraints := ccs.GetNbConstraints()
+
+	session := filepath.Join(t.TempDir(), "gadget")
+	assert.NoError(SetupLazyWithDump(ccs, session))
+	assert.Less(ccs.GetNbConstraints(), nbConstraints, "lazy constraints should be removed")
+
+	// the last call has a constant input and gets its own template
+	_r1cs := ccs.(*backend_bn254.R1CS)
+	assert.Equal(3, len(_r1cs.LazyCons))
+	assert.Equal(2, len(_r1cs.LazyConsStaticR1CMap))
+
+	pks, err := ReadSegmentProveKey(ecc.BN254, session)
+	assert.NoError(err)
+
+	vk := NewVerifyingKey(ecc.BN254)
+	f, err := os.Open(session + ".vk.save")
+	assert.NoError(err)
+	_, err = vk.ReadFrom(f)
+	assert.NoError(err)
+	assert.NoError(f.Close())
+
+	// x*y + x³ chained three times from (2, 3)
+	good := lazyGadgetCircuit{X: 2, Y: 3, Out: 21624372014}
+	fullWitness, err := frontend.NewWitness(&good, ecc.BN254)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	proof, err := ProveRoll(ccs, pks[0], pks[1], fullWitness, session)
+	assert.NoError(err)
+	assert.NoError(Verify(proof, vk, publicWitness))
+
+	bad := lazyGadgetCircuit{X: 2, Y: 3, Out: 42}
+	fullWitness, err = frontend.NewWitness(&bad, ecc.BN254)
+	assert.NoError(err)
+	_, err = ProveRoll(ccs, pks[0], pks[1], fullWitness, session)
+	assert.Error(err)
+}
+
+// TestLazyGadgetInstantiate checks that the constraints of each call, instantiated from the template of
+// the gadget, are the ones it emitted: the second call has an input created after the other one, which
+// comes first in the template, so the instantiated linear expressions must be sorted again
+func TestLazyGadgetInstantiate(t *testing.T) {
+	assert := require.New(t)
+
+	eager, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &lazyGadgetCircuit{})
+	assert.NoError(err)
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &lazyGadgetCircuit{})
+	assert.NoError(err)
+	LazifyR1cs(ccs)
+
+	constraints := eager.(*backend_bn254.R1CS).Constraints
+	lazy := ccs.(*backend_bn254.R1CS)
+	for _, li := range lazy.LazyCons {
+		for j := 0; j < li.GetConstraintsNum(); j++ {
+			assert.Equal(constraints[li.GetLoc()+j], li.FetchLazy(j, &lazy.R1CS, &lazy.CoefT), "call %s, constraint %d", li.GetType(&lazy.CoefT), j)
+		}
+	}
+}
+
+func TestCheckLazify(t *testing.T) {
+	assert := require.New(t)
+
+	data := [4]frontend.Variable{1, 2, 3, 4}
+	assignment := lazyPoseidonCircuit{Data: data, Hash: poseidonHash(assert, ecc.BN254, data)}
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &lazyPoseidonCircuit{}, frontend.IgnoreUnconstrainedInputs())
+	assert.NoError(err)
+	fullWitness, err := frontend.NewWitness(&assignment, ecc.BN254)
+	assert.NoError(err)
+	nbConstraints := ccs.GetNbConstraints()
+	assert.NoError(CheckLazify(ccs, fullWitness))
+	assert.Equal(nbConstraints, ccs.GetNbConstraints(), "the R1CS should not be lazified")
+
+	gadget := lazyGadgetCircuit{X: 2, Y: 3, Out: 21624372014}
+	fullWitness, err = frontend.NewWitness(&gadget, ecc.BN254)
+	assert.NoError(err)
+	newCCS := func() *backend_bn254.R1CS {
+		ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &lazyGadgetCircuit{})
+		assert.NoError(err)
+		return ccs.(*backend_bn254.R1CS)
+	}
+	assert.NoError(CheckLazify(newCCS(), fullWitness))
+
+	// a coefficient of the second call which doesn't match the template captured from the first one
+	wrongCoeff := newCCS()
+	r := &wrongCoeff.Constraints[wrongCoeff.LazyCons[1].GetLoc()+2].R
+	(*r)[0].SetCoeffID(compiled.CoeffIdTwo)
+	assert.Error(CheckLazify(wrongCoeff, fullWitness))
+
+	// a wrong shift of the wires created by the second poseidon call
+	ccs, err = frontend.Compile(ecc.BN254, r1cs.NewBuilder, &lazyPoseidonCircuit{}, frontend.IgnoreUnconstrainedInputs())
+	assert.NoError(err)
+	fullWitness, err = frontend.NewWitness(&assignment, ecc.BN254)
+	assert.NoError(err)
+	v := ccs.(*backend_bn254.R1CS).LazyCons[1].(*compiled.LazyPoseidonInputs).V
+	v[0].SetWireID(v[0].WireID() + 1)
+	assert.Error(CheckLazify(ccs, fullWitness))
+}
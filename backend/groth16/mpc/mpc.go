@@ -0,0 +1,424 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mpc implements a multi-party computation of the Groth16 setup.
+//
+// The phase 1 is a powers of tau accumulator which can be shared by all the circuits of up to a given size.
+// The phase 2 is specific to a circuit: InitPhase2 derives it deterministically from the phase 1 and the R1CS,
+// then each participant contributes a secret δ. Every contribution carries a proof of knowledge of its secrets
+// chained to the hash of the previous contribution, so that the transcript can be checked by anyone with
+// VerifyPhase1 and VerifyPhase2. The setup is secure as long as one participant of each phase discarded
+// their secrets.
+//
+// ExtractKeys returns a groth16.ProvingKey and groth16.VerifyingKey which can be used with groth16.Prove and
+// groth16.Verify.
+//
+// # See also
+//
+// https://eprint.iacr.org/2017/1050.pdf
+package mpc
+
+import (
+	"errors"
+	"io"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	backend_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
+	backend_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/cs"
+	backend_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/cs"
+	backend_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	backend_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/cs"
+	backend_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/cs"
+
+	groth16_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/groth16"
+	groth16_bls12381 "github.com/consensys/gnark/internal/backend/bls12-381/groth16"
+	groth16_bls24315 "github.com/consensys/gnark/internal/backend/bls24-315/groth16"
+	groth16_bn254 "github.com/consensys/gnark/internal/backend/bn254/groth16"
+	groth16_bw6633 "github.com/consensys/gnark/internal/backend/bw6-633/groth16"
+	groth16_bw6761 "github.com/consensys/gnark/internal/backend/bw6-761/groth16"
+)
+
+var errCurveMismatch = errors.New("mpc objects are not defined over the same curve")
+
+type mpcObject interface {
+	io.WriterTo
+	io.ReaderFrom
+	CurveID() ecc.ID
+}
+
+// Phase1 is a powers of tau accumulator
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/internal/backend)
+type Phase1 interface {
+	mpcObject
+
+	// Contribute updates the accumulator with random secrets and proves the knowledge of them,
+	// the secrets are not kept
+	Contribute() error
+}
+
+// Phase2 holds the parameters of a Groth16 setup which depend on the secret δ
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/internal/backend)
+type Phase2 interface {
+	mpcObject
+
+	// Contribute updates the parameters with a random δ and proves the knowledge of it,
+	// δ is not kept
+	Contribute() error
+}
+
+// Phase2Evaluations holds the parameters of a Groth16 setup which don't depend on δ
+//
+// it's underlying implementation is strongly typed with the curve (see gnark/internal/backend)
+type Phase2Evaluations interface {
+	mpcObject
+}
+
+// InitPhase1 returns the first accumulator of a phase 1 for circuits of up to 2^power constraints
+func InitPhase1(curveID ecc.ID, power int) Phase1 {
+	if power < 1 {
+		panic("power must be at least 1")
+	}
+	switch curveID {
+	case ecc.BN254:
+		return groth16_bn254.InitPhase1(power)
+	case ecc.BLS12_377:
+		return groth16_bls12377.InitPhase1(power)
+	case ecc.BLS12_381:
+		return groth16_bls12381.InitPhase1(power)
+	case ecc.BW6_761:
+		return groth16_bw6761.InitPhase1(power)
+	case ecc.BLS24_315:
+		return groth16_bls24315.InitPhase1(power)
+	case ecc.BW6_633:
+		return groth16_bw6633.InitPhase1(power)
+	default:
+		panic("not implemented")
+	}
+}
+
+// VerifyPhase1 checks that each accumulator of the transcript c0, c1, c... is a valid contribution
+// to the previous one
+func VerifyPhase1(c0, c1 Phase1, c ...Phase1) error {
+	c = append([]Phase1{c1}, c...)
+	switch _c0 := c0.(type) {
+	case *groth16_bn254.Phase1:
+		_c := make([]*groth16_bn254.Phase1, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bn254.Phase1); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bn254.VerifyPhase1(_c0, _c[0], _c[1:]...)
+	case *groth16_bls12377.Phase1:
+		_c := make([]*groth16_bls12377.Phase1, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bls12377.Phase1); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bls12377.VerifyPhase1(_c0, _c[0], _c[1:]...)
+	case *groth16_bls12381.Phase1:
+		_c := make([]*groth16_bls12381.Phase1, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bls12381.Phase1); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bls12381.VerifyPhase1(_c0, _c[0], _c[1:]...)
+	case *groth16_bw6761.Phase1:
+		_c := make([]*groth16_bw6761.Phase1, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bw6761.Phase1); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bw6761.VerifyPhase1(_c0, _c[0], _c[1:]...)
+	case *groth16_bls24315.Phase1:
+		_c := make([]*groth16_bls24315.Phase1, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bls24315.Phase1); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bls24315.VerifyPhase1(_c0, _c[0], _c[1:]...)
+	case *groth16_bw6633.Phase1:
+		_c := make([]*groth16_bw6633.Phase1, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bw6633.Phase1); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bw6633.VerifyPhase1(_c0, _c[0], _c[1:]...)
+	default:
+		panic("unrecognized phase 1 curve type")
+	}
+}
+
+// InitPhase2 returns the first parameters of the phase 2 of r1cs and the parameters which don't depend on δ.
+// Both are derived deterministically from srs1, the last accumulator of the phase 1.
+func InitPhase2(r1cs frontend.CompiledConstraintSystem, srs1 Phase1) (Phase2, Phase2Evaluations, error) {
+	switch _r1cs := r1cs.(type) {
+	case *backend_bn254.R1CS:
+		_srs1, ok := srs1.(*groth16_bn254.Phase1)
+		if !ok {
+			return nil, nil, errCurveMismatch
+		}
+		return groth16_bn254.InitPhase2(_r1cs, _srs1)
+	case *backend_bls12377.R1CS:
+		_srs1, ok := srs1.(*groth16_bls12377.Phase1)
+		if !ok {
+			return nil, nil, errCurveMismatch
+		}
+		return groth16_bls12377.InitPhase2(_r1cs, _srs1)
+	case *backend_bls12381.R1CS:
+		_srs1, ok := srs1.(*groth16_bls12381.Phase1)
+		if !ok {
+			return nil, nil, errCurveMismatch
+		}
+		return groth16_bls12381.InitPhase2(_r1cs, _srs1)
+	case *backend_bw6761.R1CS:
+		_srs1, ok := srs1.(*groth16_bw6761.Phase1)
+		if !ok {
+			return nil, nil, errCurveMismatch
+		}
+		return groth16_bw6761.InitPhase2(_r1cs, _srs1)
+	case *backend_bls24315.R1CS:
+		_srs1, ok := srs1.(*groth16_bls24315.Phase1)
+		if !ok {
+			return nil, nil, errCurveMismatch
+		}
+		return groth16_bls24315.InitPhase2(_r1cs, _srs1)
+	case *backend_bw6633.R1CS:
+		_srs1, ok := srs1.(*groth16_bw6633.Phase1)
+		if !ok {
+			return nil, nil, errCurveMismatch
+		}
+		return groth16_bw6633.InitPhase2(_r1cs, _srs1)
+	default:
+		panic("unrecognized R1CS curve type")
+	}
+}
+
+// VerifyPhase2 checks that each parameters of the transcript c0, c1, c... is a valid contribution
+// to the previous one. c0 should be the output of InitPhase2, which can be recomputed from the phase 1
+// and the R1CS.
+func VerifyPhase2(c0, c1 Phase2, c ...Phase2) error {
+	c = append([]Phase2{c1}, c...)
+	switch _c0 := c0.(type) {
+	case *groth16_bn254.Phase2:
+		_c := make([]*groth16_bn254.Phase2, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bn254.Phase2); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bn254.VerifyPhase2(_c0, _c[0], _c[1:]...)
+	case *groth16_bls12377.Phase2:
+		_c := make([]*groth16_bls12377.Phase2, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bls12377.Phase2); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bls12377.VerifyPhase2(_c0, _c[0], _c[1:]...)
+	case *groth16_bls12381.Phase2:
+		_c := make([]*groth16_bls12381.Phase2, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bls12381.Phase2); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bls12381.VerifyPhase2(_c0, _c[0], _c[1:]...)
+	case *groth16_bw6761.Phase2:
+		_c := make([]*groth16_bw6761.Phase2, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bw6761.Phase2); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bw6761.VerifyPhase2(_c0, _c[0], _c[1:]...)
+	case *groth16_bls24315.Phase2:
+		_c := make([]*groth16_bls24315.Phase2, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bls24315.Phase2); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bls24315.VerifyPhase2(_c0, _c[0], _c[1:]...)
+	case *groth16_bw6633.Phase2:
+		_c := make([]*groth16_bw6633.Phase2, len(c))
+		for i := range c {
+			var ok bool
+			if _c[i], ok = c[i].(*groth16_bw6633.Phase2); !ok {
+				return errCurveMismatch
+			}
+		}
+		return groth16_bw6633.VerifyPhase2(_c0, _c[0], _c[1:]...)
+	default:
+		panic("unrecognized phase 2 curve type")
+	}
+}
+
+// ExtractKeys returns the keys of the setup given the last accumulator of the phase 1, the last contribution
+// to the phase 2 and the evaluations returned by InitPhase2
+func ExtractKeys(srs1 Phase1, srs2 Phase2, evals Phase2Evaluations) (groth16.ProvingKey, groth16.VerifyingKey, error) {
+	switch _srs1 := srs1.(type) {
+	case *groth16_bn254.Phase1:
+		_srs2, ok2 := srs2.(*groth16_bn254.Phase2)
+		_evals, ok3 := evals.(*groth16_bn254.Phase2Evaluations)
+		if !ok2 || !ok3 {
+			return nil, nil, errCurveMismatch
+		}
+		pk, vk, err := groth16_bn254.ExtractKeys(_srs1, _srs2, _evals)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pk, &vk, nil
+	case *groth16_bls12377.Phase1:
+		_srs2, ok2 := srs2.(*groth16_bls12377.Phase2)
+		_evals, ok3 := evals.(*groth16_bls12377.Phase2Evaluations)
+		if !ok2 || !ok3 {
+			return nil, nil, errCurveMismatch
+		}
+		pk, vk, err := groth16_bls12377.ExtractKeys(_srs1, _srs2, _evals)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pk, &vk, nil
+	case *groth16_bls12381.Phase1:
+		_srs2, ok2 := srs2.(*groth16_bls12381.Phase2)
+		_evals, ok3 := evals.(*groth16_bls12381.Phase2Evaluations)
+		if !ok2 || !ok3 {
+			return nil, nil, errCurveMismatch
+		}
+		pk, vk, err := groth16_bls12381.ExtractKeys(_srs1, _srs2, _evals)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pk, &vk, nil
+	case *groth16_bw6761.Phase1:
+		_srs2, ok2 := srs2.(*groth16_bw6761.Phase2)
+		_evals, ok3 := evals.(*groth16_bw6761.Phase2Evaluations)
+		if !ok2 || !ok3 {
+			return nil, nil, errCurveMismatch
+		}
+		pk, vk, err := groth16_bw6761.ExtractKeys(_srs1, _srs2, _evals)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pk, &vk, nil
+	case *groth16_bls24315.Phase1:
+		_srs2, ok2 := srs2.(*groth16_bls24315.Phase2)
+		_evals, ok3 := evals.(*groth16_bls24315.Phase2Evaluations)
+		if !ok2 || !ok3 {
+			return nil, nil, errCurveMismatch
+		}
+		pk, vk, err := groth16_bls24315.ExtractKeys(_srs1, _srs2, _evals)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pk, &vk, nil
+	case *groth16_bw6633.Phase1:
+		_srs2, ok2 := srs2.(*groth16_bw6633.Phase2)
+		_evals, ok3 := evals.(*groth16_bw6633.Phase2Evaluations)
+		if !ok2 || !ok3 {
+			return nil, nil, errCurveMismatch
+		}
+		pk, vk, err := groth16_bw6633.ExtractKeys(_srs1, _srs2, _evals)
+		if err != nil {
+			return nil, nil, err
+		}
+		return &pk, &vk, nil
+	default:
+		panic("unrecognized phase 1 curve type")
+	}
+}
+
+// NewPhase1 instantiates a curve-typed Phase1 and returns an interface object
+// This function exists for serialization purposes
+func NewPhase1(curveID ecc.ID) Phase1 {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.Phase1{}
+	case ecc.BLS12_377:
+		return &groth16_bls12377.Phase1{}
+	case ecc.BLS12_381:
+		return &groth16_bls12381.Phase1{}
+	case ecc.BW6_761:
+		return &groth16_bw6761.Phase1{}
+	case ecc.BLS24_315:
+		return &groth16_bls24315.Phase1{}
+	case ecc.BW6_633:
+		return &groth16_bw6633.Phase1{}
+	default:
+		panic("not implemented")
+	}
+}
+
+// NewPhase2 instantiates a curve-typed Phase2 and returns an interface object
+// This function exists for serialization purposes
+func NewPhase2(curveID ecc.ID) Phase2 {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.Phase2{}
+	case ecc.BLS12_377:
+		return &groth16_bls12377.Phase2{}
+	case ecc.BLS12_381:
+		return &groth16_bls12381.Phase2{}
+	case ecc.BW6_761:
+		return &groth16_bw6761.Phase2{}
+	case ecc.BLS24_315:
+		return &groth16_bls24315.Phase2{}
+	case ecc.BW6_633:
+		return &groth16_bw6633.Phase2{}
+	default:
+		panic("not implemented")
+	}
+}
+
+// NewPhase2Evaluations instantiates a curve-typed Phase2Evaluations and returns an interface object
+// This function exists for serialization purposes
+func NewPhase2Evaluations(curveID ecc.ID) Phase2Evaluations {
+	switch curveID {
+	case ecc.BN254:
+		return &groth16_bn254.Phase2Evaluations{}
+	case ecc.BLS12_377:
+		return &groth16_bls12377.Phase2Evaluations{}
+	case ecc.BLS12_381:
+		return &groth16_bls12381.Phase2Evaluations{}
+	case ecc.BW6_761:
+		return &groth16_bw6761.Phase2Evaluations{}
+	case ecc.BLS24_315:
+		return &groth16_bls24315.Phase2Evaluations{}
+	case ecc.BW6_633:
+		return &groth16_bw6633.Phase2Evaluations{}
+	default:
+		panic("not implemented")
+	}
+}
@@ -0,0 +1,110 @@
+package mpc
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+type mpcCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *mpcCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(api.Add(x3, circuit.X, 5), circuit.Y)
+	return nil
+}
+
+// roundTrip serializes src and deserializes it in dst
+func roundTrip(assert *require.Assertions, src, dst mpcObject) {
+	var buf bytes.Buffer
+	n, err := src.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Equal(int64(buf.Len()), n)
+	m, err := dst.ReadFrom(&buf)
+	assert.NoError(err)
+	assert.Equal(n, m)
+}
+
+func TestSetup(t *testing.T) {
+	curves := []ecc.ID{ecc.BN254, ecc.BLS12_381}
+	if !testing.Short() {
+		curves = append(curves, ecc.BLS12_377, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761)
+	}
+	for _, curve := range curves {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &mpcCircuit{})
+			assert.NoError(err)
+
+			// phase 1, each contribution goes through the wire
+			srs1 := []Phase1{InitPhase1(curve, 4)}
+			for i := 0; i < 3; i++ {
+				next := NewPhase1(curve)
+				roundTrip(assert, srs1[i], next)
+				assert.NoError(next.Contribute())
+				srs1 = append(srs1, next)
+			}
+			assert.NoError(VerifyPhase1(srs1[0], srs1[1], srs1[2:]...))
+
+			// phase 2
+			c0, evals, err := InitPhase2(ccs, srs1[len(srs1)-1])
+			assert.NoError(err)
+			srs2 := []Phase2{c0}
+			for i := 0; i < 3; i++ {
+				next := NewPhase2(curve)
+				roundTrip(assert, srs2[i], next)
+				assert.NoError(next.Contribute())
+				srs2 = append(srs2, next)
+			}
+			assert.NoError(VerifyPhase2(srs2[0], srs2[1], srs2[2:]...))
+
+			_evals := NewPhase2Evaluations(curve)
+			roundTrip(assert, evals, _evals)
+
+			pk, vk, err := ExtractKeys(srs1[len(srs1)-1], srs2[len(srs2)-1], _evals)
+			assert.NoError(err)
+
+			fullWitness, err := frontend.NewWitness(&mpcCircuit{X: 3, Y: 35}, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+			proof, err := groth16.Prove(ccs, pk, fullWitness)
+			assert.NoError(err)
+			assert.NoError(groth16.Verify(proof, vk, publicWitness))
+
+			wrongWitness, err := frontend.NewWitness(&mpcCircuit{X: 3, Y: 36}, curve, frontend.PublicOnly())
+			assert.NoError(err)
+			assert.Error(groth16.Verify(proof, vk, wrongWitness))
+
+			// a contribution which doesn't build on the previous one
+			assert.Error(VerifyPhase1(srs1[0], srs1[2]))
+			assert.Error(VerifyPhase2(srs2[0], srs2[2]))
+
+			// a fork of the transcript is valid on its own, a replayed contribution isn't
+			fork := NewPhase2(curve)
+			roundTrip(assert, srs2[1], fork)
+			assert.NoError(fork.Contribute())
+			assert.NoError(VerifyPhase2(srs2[1], fork))
+			roundTrip(assert, srs2[2], fork)
+			assert.Error(VerifyPhase2(srs2[1], srs2[2], fork))
+		})
+	}
+}
+
+func TestPhase2TooLarge(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &mpcCircuit{})
+	assert.NoError(err)
+	_, _, err = InitPhase2(ccs, InitPhase1(ecc.BN254, 1))
+	assert.Error(err)
+}
@@ -0,0 +1,193 @@
+package groth16
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/internal/backend/dump"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder records the phases reported to a backend.ProgressFunc
+type recorder struct {
+	phases []string
+	last   backend.Progress
+}
+
+func (r *recorder) report(p backend.Progress) {
+	r.phases = append(r.phases, p.Phase)
+	r.last = p
+}
+
+func (r *recorder) sorted() []string {
+	res := append([]string{}, r.phases...)
+	sort.Strings(res)
+	return res
+}
+
+func TestProgress(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &dumpCircuit{})
+	assert.NoError(err)
+	fullWitness, err := frontend.NewWitness(&dumpCircuit{X: 3, Y: 27}, ecc.BN254)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+
+	var setup, prove recorder
+	pk, vk, err := Setup(ccs, backend.WithSetupProgress(setup.report))
+	assert.NoError(err)
+	assert.Equal([]string{"qap", "pk.G1", "pk.G2"}, setup.phases)
+	assert.Equal(100.0, setup.last.Percent())
+
+	proof, err := Prove(ccs, pk, fullWitness, backend.WithProgress(prove.report))
+	assert.NoError(err)
+	assert.NoError(Verify(proof, vk, publicWitness))
+	assert.Equal([]string{"computeH", "msm.A", "msm.B1", "msm.B2", "msm.K", "msm.Z", "solve"}, prove.sorted())
+	assert.Equal("solve", prove.phases[0])
+	assert.Equal(7, prove.last.Done)
+	assert.Equal(7, prove.last.Total)
+
+	// the dump and the provers reading it report their phases
+	session := filepath.Join(t.TempDir(), "dump")
+	setup = recorder{}
+	assert.NoError(SetupWithDump(ccs, session, backend.WithSetupProgress(setup.report)))
+	assert.Equal([]string{"qap", "pk.E", "pk.A", "pk.B1", "pk.K", "pk.Z", "pk.B2"}, setup.phases)
+
+	_ccs, err := LoadR1CSFromFile(ecc.BN254, session)
+	assert.NoError(err)
+	pks, err := ReadSegmentProveKey(ecc.BN254, session)
+	assert.NoError(err)
+	prove = recorder{}
+	_, err = ProveRoll(_ccs, pks[0], pks[1], fullWitness, session, backend.WithProgress(prove.report))
+	assert.NoError(err)
+	assert.Equal([]string{"solve", "computeH", "msm.B2", "msm.A", "msm.B1", "msm.Z", "msm.K"}, prove.phases)
+	pkE, err := ReadProveKeyE(ecc.BN254, session)
+	assert.NoError(err)
+	prove = recorder{}
+	_, err = ProveStream(_ccs, pkE, fullWitness, session, backend.WithProgress(prove.report))
+	assert.NoError(err)
+	assert.Equal([]string{"solve", "computeH", "msm.B2", "msm.A", "msm.B1", "msm.Z", "msm.K"}, prove.phases)
+}
+
+func TestCancel(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &dumpCircuit{})
+	assert.NoError(err)
+	fullWitness, err := frontend.NewWitness(&dumpCircuit{X: 3, Y: 27}, ecc.BN254)
+	assert.NoError(err)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, _, err = Setup(ccs, backend.WithSetupContext(cancelled))
+	assert.ErrorIs(err, context.Canceled)
+	pk, _, err := Setup(ccs)
+	assert.NoError(err)
+	_, err = Prove(ccs, pk, fullWitness, backend.WithContext(cancelled))
+	assert.ErrorIs(err, context.Canceled)
+	_, err = Prove(ccs, pk, fullWitness, backend.WithContext(cancelled), backend.IgnoreSolverError())
+	assert.ErrorIs(err, context.Canceled, "the prover doesn't continue after a cancelled solver")
+
+	// the setup stops after the phase which cancelled it, and doesn't write the manifest
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var setup recorder
+	session := filepath.Join(t.TempDir(), "dump")
+	err = SetupWithDump(ccs, session, backend.WithSetupContext(ctx), backend.WithSetupProgress(func(p backend.Progress) {
+		setup.report(p)
+		if p.Phase == "pk.A" {
+			cancel()
+		}
+	}))
+	assert.ErrorIs(err, context.Canceled)
+	assert.Equal([]string{"qap", "pk.E", "pk.A"}, setup.phases)
+	_, err = os.Stat(session + ".manifest.json")
+	assert.True(os.IsNotExist(err))
+	assert.Error(VerifyDump(ecc.BN254, session))
+
+	// the prover stops after the phase which cancelled it
+	assert.NoError(SetupWithDump(ccs, session))
+	pks, err := ReadSegmentProveKey(ecc.BN254, session)
+	assert.NoError(err)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	var prove recorder
+	_, err = ProveRoll(ccs, pks[0], pks[1], fullWitness, session, backend.WithContext(ctx), backend.WithProgress(func(p backend.Progress) {
+		prove.report(p)
+		if p.Phase == "computeH" {
+			cancel()
+		}
+	}))
+	assert.ErrorIs(err, context.Canceled)
+	assert.Equal([]string{"solve", "computeH"}, prove.phases)
+
+	pkE, err := ReadProveKeyE(ecc.BN254, session)
+	assert.NoError(err)
+	_, err = ProveStream(ccs, pkE, fullWitness, session, backend.WithContext(cancelled))
+	assert.ErrorIs(err, context.Canceled)
+
+	// the streaming prover stops between chunks and doesn't read the rest of the segment to check it,
+	// so that a corrupted end of the segment isn't reported
+	b2 := session + "." + dump.ProvingKeyB2 + ".save"
+	data, err := os.ReadFile(b2)
+	assert.NoError(err)
+	data[len(data)-1] ^= 1
+	assert.NoError(os.WriteFile(b2, data, 0600))
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	_, err = ProveStream(ccs, pkE, fullWitness, session, backend.WithMemoryBudget(512), backend.WithContext(ctx), backend.WithProgress(func(p backend.Progress) {
+		if p.Phase == "computeH" {
+			cancel()
+		}
+	}))
+	assert.ErrorIs(err, context.Canceled)
+	_, err = ProveStream(ccs, pkE, fullWitness, session)
+	assert.Error(err)
+	assert.NotErrorIs(err, context.Canceled)
+}
+
+func TestCancelJoinsProver(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, r1cs.NewBuilder, &dumpCircuit{})
+	assert.NoError(err)
+	fullWitness, err := frontend.NewWitness(&dumpCircuit{X: 3, Y: 27}, ecc.BN254)
+	assert.NoError(err)
+	pk, _, err := Setup(ccs)
+	assert.NoError(err)
+
+	// the phase cancelling the prover holds the progress report while the other goroutines
+	// of the prover are still running, none of them may report once Prove returned
+	for _, cancelAt := range []string{"computeH", "msm."} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var returned, late int32
+		_, err = Prove(ccs, pk, fullWitness, backend.WithContext(ctx), backend.WithProgress(func(p backend.Progress) {
+			if atomic.LoadInt32(&returned) == 1 {
+				atomic.StoreInt32(&late, 1)
+			}
+			if strings.HasPrefix(p.Phase, cancelAt) && ctx.Err() == nil {
+				cancel()
+				time.Sleep(50 * time.Millisecond)
+			}
+		}))
+		atomic.StoreInt32(&returned, 1)
+		cancel()
+		assert.ErrorIs(err, context.Canceled, cancelAt)
+
+		time.Sleep(100 * time.Millisecond)
+		assert.Equal(int32(0), atomic.LoadInt32(&late), "progress reported after Prove returned when cancelled at %s", cancelAt)
+	}
+}
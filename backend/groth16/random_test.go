@@ -0,0 +1,60 @@
+package groth16
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/stretchr/testify/require"
+)
+
+func serialize(t *testing.T, o io.WriterTo) []byte {
+	var buf bytes.Buffer
+	_, err := o.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestRandomSource(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, r1cs.NewBuilder, &dumpCircuit{})
+			assert.NoError(err)
+			fullWitness, err := frontend.NewWitness(&dumpCircuit{X: 3, Y: 27}, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+
+			// the same source gives the same keys and the same proof
+			pk, vk, err := Setup(ccs, backend.WithSetupRandomSource(rand.New(rand.NewSource(1))))
+			assert.NoError(err)
+			_pk, _vk, err := Setup(ccs, backend.WithSetupRandomSource(rand.New(rand.NewSource(1))))
+			assert.NoError(err)
+			assert.Equal(serialize(t, pk), serialize(t, _pk))
+			assert.Equal(serialize(t, vk), serialize(t, _vk))
+
+			proof, err := Prove(ccs, pk, fullWitness, backend.WithRandomSource(rand.New(rand.NewSource(2))))
+			assert.NoError(err)
+			_proof, err := Prove(ccs, pk, fullWitness, backend.WithRandomSource(rand.New(rand.NewSource(2))))
+			assert.NoError(err)
+			assert.NoError(Verify(proof, vk, publicWitness))
+			assert.Equal(serialize(t, proof), serialize(t, _proof))
+
+			// crypto/rand is used by default
+			_pk, _vk, err = Setup(ccs)
+			assert.NoError(err)
+			assert.NotEqual(serialize(t, vk), serialize(t, _vk))
+			_proof, err = Prove(ccs, pk, fullWitness)
+			assert.NoError(err)
+			assert.NoError(Verify(_proof, vk, publicWitness))
+			assert.NotEqual(serialize(t, proof), serialize(t, _proof))
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package plonk_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	cs_bn254 "github.com/consensys/gnark/internal/backend/bn254/cs"
+	"github.com/consensys/gnark/std/hash/poseidon"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+type lazyPoseidonCircuit struct {
+	Data [4]frontend.Variable
+	Hash frontend.Variable `gnark:",public"`
+}
+
+func (circuit *lazyPoseidonCircuit) Define(api frontend.API) error {
+	h1 := poseidon.Poseidon(api, circuit.Data[0], circuit.Data[1])
+	h2 := poseidon.Poseidon(api, circuit.Data[2], circuit.Data[3])
+	api.AssertIsEqual(h1, h2)
+	api.AssertIsDifferent(h1, circuit.Hash)
+	return nil
+}
+
+func TestLazifySparseR1cs(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, scs.NewBuilder, &lazyPoseidonCircuit{})
+			assert.NoError(err)
+			nbConstraints := ccs.GetNbConstraints()
+
+			assert.NoError(plonk.LazifySparseR1cs(ccs))
+			assert.Equal(nbConstraints, ccs.GetNbConstraints(), "lazy constraints should be counted")
+			if spr, ok := ccs.(*cs_bn254.SparseR1CS); ok {
+				assert.Less(len(spr.Constraints), nbConstraints, "lazy constraints should be removed")
+				// both permutations share the same template
+				assert.Equal(2, len(spr.LazyCons))
+				assert.Equal(1, len(spr.LazyConsStaticR1CMap))
+			}
+
+			// lazy constraints survive serialization
+			var buf bytes.Buffer
+			_, err = ccs.WriteTo(&buf)
+			assert.NoError(err)
+			_ccs := plonk.NewCS(curve)
+			_, err = _ccs.ReadFrom(&buf)
+			assert.NoError(err)
+			assert.Equal(nbConstraints, _ccs.GetNbConstraints())
+
+			srs, err := test.NewKZGSRS(_ccs)
+			assert.NoError(err)
+
+			pk, vk, err := plonk.Setup(_ccs, srs)
+			assert.NoError(err)
+
+			good := lazyPoseidonCircuit{Data: [4]frontend.Variable{1, 2, 1, 2}, Hash: 42}
+			fullWitness, err := frontend.NewWitness(&good, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+			proof, err := plonk.Prove(_ccs, pk, fullWitness)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, publicWitness))
+
+			bad := lazyPoseidonCircuit{Data: [4]frontend.Variable{1, 2, 3, 4}, Hash: 42}
+			fullWitness, err = frontend.NewWitness(&bad, curve)
+			assert.NoError(err)
+			_, err = plonk.Prove(_ccs, pk, fullWitness)
+			assert.Error(err)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package plonk_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+// builtinLookupCircuit uses the gadgets of the scs builder relying on lookup tables
+type builtinLookupCircuit struct {
+	X, A, B frontend.Variable
+	Y       frontend.Variable `gnark:",public"`
+}
+
+func (circuit *builtinLookupCircuit) Define(api frontend.API) error {
+	api.AssertIsLessOrEqual(circuit.X, 1000)
+	bits := api.ToBinary(circuit.X, 10)
+	api.AssertIsEqual(api.Xor(bits[0], circuit.A), api.Xor(circuit.A, 1))
+	api.AssertIsEqual(api.Add(api.Xor(circuit.A, circuit.B), api.Mul(2, api.And(circuit.A, circuit.B))), circuit.Y)
+	return nil
+}
+
+// squareCircuit looks up the square of X in a table defined by the circuit
+type squareCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squareCircuit) Define(api frontend.API) error {
+	lookuper := api.(frontend.Lookuper)
+	var rows [][3]frontend.Variable
+	for i := 0; i < 16; i++ {
+		rows = append(rows, [3]frontend.Variable{i, 0, i * i})
+	}
+	table := lookuper.DefineTable(rows...)
+	y := lookuper.Lookup(table, circuit.X, 0)
+	api.AssertIsEqual(y, circuit.Y)
+	lookuper.AssertInTable(table, circuit.X, 0, y)
+	return nil
+}
+
+func TestLookupBuiltin(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&builtinLookupCircuit{}, &builtinLookupCircuit{X: 999, A: 1, B: 1, Y: 2},
+		test.WithBackends(backend.PLONK), test.WithCompileOpts(frontend.WithLookupTables()))
+	assert.ProverFailed(&builtinLookupCircuit{}, &builtinLookupCircuit{X: 1001, A: 1, B: 1, Y: 2},
+		test.WithBackends(backend.PLONK), test.WithCompileOpts(frontend.WithLookupTables()))
+	assert.ProverFailed(&builtinLookupCircuit{}, &builtinLookupCircuit{X: 998, A: 1, B: 0, Y: 1},
+		test.WithBackends(backend.PLONK), test.WithCompileOpts(frontend.WithLookupTables()))
+}
+
+func TestLookupTable(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	assert.ProverSucceeded(&squareCircuit{}, &squareCircuit{X: 7, Y: 49}, test.WithBackends(backend.PLONK))
+	assert.ProverFailed(&squareCircuit{}, &squareCircuit{X: 7, Y: 48}, test.WithBackends(backend.PLONK))
+	assert.ProverFailed(&squareCircuit{}, &squareCircuit{X: 16, Y: 256}, test.WithBackends(backend.PLONK))
+}
+
+func TestLookupNbConstraints(t *testing.T) {
+	assert := require.New(t)
+
+	eager, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &builtinLookupCircuit{})
+	assert.NoError(err)
+	lookup, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &builtinLookupCircuit{}, frontend.WithLookupTables())
+	assert.NoError(err)
+	assert.Less(lookup.GetNbConstraints(), eager.GetNbConstraints())
+}
+
+func TestLookupMarshal(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &squareCircuit{})
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	pk, vk, err := plonk.Setup(ccs, srs)
+	assert.NoError(err)
+
+	witness, err := frontend.NewWitness(&squareCircuit{X: 3, Y: 9}, ecc.BN254)
+	assert.NoError(err)
+	publicWitness, err := witness.Public()
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, witness)
+	assert.NoError(err)
+
+	// the lookup parts of the verifying key and the proof survive a round trip
+	var buf bytes.Buffer
+	_, err = vk.WriteTo(&buf)
+	assert.NoError(err)
+	expected := append([]byte{}, buf.Bytes()...)
+	_vk := plonk.NewVerifyingKey(ecc.BN254)
+	_, err = _vk.ReadFrom(&buf)
+	assert.NoError(err)
+	_, err = _vk.WriteTo(&buf)
+	assert.NoError(err)
+	assert.Equal(expected, buf.Bytes())
+
+	buf.Reset()
+	_, err = proof.WriteTo(&buf)
+	assert.NoError(err)
+	_proof := plonk.NewProof(ecc.BN254)
+	_, err = _proof.ReadFrom(&buf)
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(_proof, vk, publicWitness))
+
+	// lookups aren't supported by the disk-backed setup
+	assert.Error(plonk.SetupWithDump(ccs, srs, t.TempDir()))
+}
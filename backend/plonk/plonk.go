@@ -20,12 +20,15 @@
 package plonk
 
 import (
+	"errors"
+	"fmt"
 	"io"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/backend/dump"
 
 	"github.com/consensys/gnark/backend/witness"
 	cs_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
@@ -83,9 +86,13 @@ type VerifyingKey interface {
 	io.ReaderFrom
 	InitKZG(srs kzg.SRS) error
 	NbPublicWitness() int // number of elements expected in the public witness
+	ExportSolidity(w io.Writer) error
 }
 
 // Setup prepares the public data associated to a circuit + public inputs.
+//
+// The setup is deterministic: the keys only depend on the circuit and on kzgSRS. Proofs are
+// blinded with crypto/rand, see backend.WithRandomSource to make them reproducible in tests.
 func Setup(ccs frontend.CompiledConstraintSystem, kzgSRS kzg.SRS) (ProvingKey, VerifyingKey, error) {
 
 	switch tccs := ccs.(type) {
@@ -107,6 +114,182 @@ func Setup(ccs frontend.CompiledConstraintSystem, kzgSRS kzg.SRS) (ProvingKey, V
 
 }
 
+// LazifySparseR1cs removes the constraints recorded by lazy gadgets (see frontend.RegisterLazyGadget)
+// from the SparseR1CS; they are materialized on demand by the solver and the setup instead.
+func LazifySparseR1cs(ccs frontend.CompiledConstraintSystem) error {
+	var err error
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		_, err = tccs.Lazify()
+	case *cs_bls12381.SparseR1CS:
+		_, err = tccs.Lazify()
+	case *cs_bls12377.SparseR1CS:
+		_, err = tccs.Lazify()
+	case *cs_bw6761.SparseR1CS:
+		_, err = tccs.Lazify()
+	case *cs_bls24315.SparseR1CS:
+		_, err = tccs.Lazify()
+	case *cs_bw6633.SparseR1CS:
+		_, err = tccs.Lazify()
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+	return err
+}
+
+// SetupWithDump runs plonk.Setup and writes the SparseR1CS, the VerifyingKey and each polynomial
+// of the ProvingKey to files prefixed by session instead of returning them, along with a manifest
+// recording the curve and the size and checksum of each file (see VerifyDump).
+//
+// The polynomials are computed and written one at a time, the result is meant to be used
+// with ReadSegmentProveKey and ProveRoll.
+func SetupWithDump(ccs frontend.CompiledConstraintSystem, kzgSRS kzg.SRS, session string) error {
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return plonk_bn254.SetupWithDump(tccs, kzgSRS.(*kzg_bn254.SRS), session)
+	case *cs_bls12381.SparseR1CS:
+		return plonk_bls12381.SetupWithDump(tccs, kzgSRS.(*kzg_bls12381.SRS), session)
+	case *cs_bls12377.SparseR1CS:
+		return plonk_bls12377.SetupWithDump(tccs, kzgSRS.(*kzg_bls12377.SRS), session)
+	case *cs_bw6761.SparseR1CS:
+		return plonk_bw6761.SetupWithDump(tccs, kzgSRS.(*kzg_bw6761.SRS), session)
+	case *cs_bw6633.SparseR1CS:
+		return plonk_bw6633.SetupWithDump(tccs, kzgSRS.(*kzg_bw6633.SRS), session)
+	case *cs_bls24315.SparseR1CS:
+		return plonk_bls24315.SetupWithDump(tccs, kzgSRS.(*kzg_bls24315.SRS), session)
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+}
+
+// ReadSegmentProveKey reads the VerifyingKey and the domains of a ProvingKey dumped by SetupWithDump
+// for ccs under the given session prefix, the polynomials are left on disk and loaded by ProveRoll.
+//
+// The SRS isn't part of the dump, ProvingKey.InitKZG must be called on the result before proving.
+func ReadSegmentProveKey(ccs frontend.CompiledConstraintSystem, session string) (ProvingKey, error) {
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		return plonk_bn254.ReadSegmentProveKey(tccs, session)
+	case *cs_bls12381.SparseR1CS:
+		return plonk_bls12381.ReadSegmentProveKey(tccs, session)
+	case *cs_bls12377.SparseR1CS:
+		return plonk_bls12377.ReadSegmentProveKey(tccs, session)
+	case *cs_bw6761.SparseR1CS:
+		return plonk_bw6761.ReadSegmentProveKey(tccs, session)
+	case *cs_bw6633.SparseR1CS:
+		return plonk_bw6633.ReadSegmentProveKey(tccs, session)
+	case *cs_bls24315.SparseR1CS:
+		return plonk_bls24315.ReadSegmentProveKey(tccs, session)
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+}
+
+// circuitWriter is implemented by the SparseR1CS of each curve, see dump.Manifest.CheckCircuit
+type circuitWriter interface {
+	WriteCircuitTo(w io.Writer) (int64, error)
+}
+
+// LoadSparseR1CSFromFile reads the SparseR1CS dumped by SetupWithDump under the given session prefix
+func LoadSparseR1CSFromFile(curveID ecc.ID, session string) (frontend.CompiledConstraintSystem, error) {
+	m, err := dump.ReadManifest(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.CheckCurve(curveID); err != nil {
+		return nil, err
+	}
+
+	ccs := NewCS(curveID)
+	if err := m.ReadSegment(dump.ConstraintSystem, ccs.ReadFrom); err != nil {
+		return nil, err
+	}
+
+	// the segment matches its checksum, check that nothing was lost decoding it
+	if err := m.CheckCircuit(ccs.(circuitWriter).WriteCircuitTo, nil); err != nil {
+		return nil, err
+	}
+	return ccs, nil
+}
+
+// VerifyDump checks that the files dumped by SetupWithDump under the given session prefix were
+// written for ccs and match the size and checksum recorded in their manifest
+func VerifyDump(ccs frontend.CompiledConstraintSystem, session string) error {
+	m, err := dump.ReadManifest(session)
+	if err != nil {
+		return err
+	}
+	if err := m.CheckCurve(ccs.CurveID()); err != nil {
+		return err
+	}
+	if err := m.CheckCircuit(ccs.(circuitWriter).WriteCircuitTo, nil); err != nil {
+		return err
+	}
+	return m.Verify()
+}
+
+// ProveRoll behaves as Prove, loading the polynomials of the ProvingKey from the files dumped
+// by SetupWithDump under session when a round of the prover needs them, and releasing them along
+// with the evaluations on the big domain as soon as the round is done.
+//
+// pk is the ProvingKey returned by ReadSegmentProveKey, with its SRS initialized, an error is
+// returned if ccs isn't the constraint system the dump was written for.
+func ProveRoll(ccs frontend.CompiledConstraintSystem, pk ProvingKey, fullWitness *witness.Witness, session string, opts ...backend.ProverOption) (Proof, error) {
+
+	// apply options
+	opt, err := backend.NewProverConfig(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	switch tccs := ccs.(type) {
+	case *cs_bn254.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bn254.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return plonk_bn254.ProveRoll(tccs, pk.(*plonk_bn254.ProvingKey), *w, opt, session)
+
+	case *cs_bls12381.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12381.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return plonk_bls12381.ProveRoll(tccs, pk.(*plonk_bls12381.ProvingKey), *w, opt, session)
+
+	case *cs_bls12377.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls12377.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return plonk_bls12377.ProveRoll(tccs, pk.(*plonk_bls12377.ProvingKey), *w, opt, session)
+
+	case *cs_bw6761.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6761.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return plonk_bw6761.ProveRoll(tccs, pk.(*plonk_bw6761.ProvingKey), *w, opt, session)
+
+	case *cs_bw6633.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bw6633.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return plonk_bw6633.ProveRoll(tccs, pk.(*plonk_bw6633.ProvingKey), *w, opt, session)
+
+	case *cs_bls24315.SparseR1CS:
+		w, ok := fullWitness.Vector.(*witness_bls24315.Witness)
+		if !ok {
+			return nil, witness.ErrInvalidWitness
+		}
+		return plonk_bls24315.ProveRoll(tccs, pk.(*plonk_bls24315.ProvingKey), *w, opt, session)
+	default:
+		panic("unrecognized SparseR1CS curve type")
+	}
+}
+
 // Prove generates PLONK proof from a circuit, associated preprocessed public data, and the witness
 // if the force flag is set:
 // 	will executes all the prover computations, even if the witness is invalid
@@ -220,6 +403,123 @@ func Verify(proof Proof, vk VerifyingKey, publicWitness *witness.Witness) error
 	}
 }
 
+var errCurveMismatch = errors.New("proof and verifying key are not defined over the same curve")
+
+// BatchVerify verifies PLONK proofs with given VerifyingKey and public witnesses, checking the KZG
+// openings of all the proofs in a single multi-pairing. If a proof is invalid, the returned error is
+// a *backend.BatchVerifyError holding its index.
+func BatchVerify(proofs []Proof, vk VerifyingKey, publicWitnesses []*witness.Witness) error {
+	if len(proofs) != len(publicWitnesses) {
+		return fmt.Errorf("got %d proofs for %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+
+	switch _vk := vk.(type) {
+	case *plonk_bn254.VerifyingKey:
+		_proofs := make([]*plonk_bn254.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bn254.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*plonk_bn254.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bn254.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return plonk_bn254.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *plonk_bls12381.VerifyingKey:
+		_proofs := make([]*plonk_bls12381.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bls12381.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*plonk_bls12381.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bls12381.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return plonk_bls12381.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *plonk_bls12377.VerifyingKey:
+		_proofs := make([]*plonk_bls12377.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bls12377.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*plonk_bls12377.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bls12377.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return plonk_bls12377.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *plonk_bw6761.VerifyingKey:
+		_proofs := make([]*plonk_bw6761.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bw6761.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*plonk_bw6761.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bw6761.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return plonk_bw6761.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *plonk_bw6633.VerifyingKey:
+		_proofs := make([]*plonk_bw6633.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bw6633.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*plonk_bw6633.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bw6633.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return plonk_bw6633.BatchVerify(_proofs, _vk, _publicWitnesses)
+	case *plonk_bls24315.VerifyingKey:
+		_proofs := make([]*plonk_bls24315.Proof, len(proofs))
+		_publicWitnesses := make([]witness_bls24315.Witness, len(proofs))
+		for i := range proofs {
+			p, ok := proofs[i].(*plonk_bls24315.Proof)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: errCurveMismatch}
+			}
+			w, ok := publicWitnesses[i].Vector.(*witness_bls24315.Witness)
+			if !ok {
+				return &backend.BatchVerifyError{Index: i, Err: witness.ErrInvalidWitness}
+			}
+			_proofs[i], _publicWitnesses[i] = p, *w
+		}
+		return plonk_bls24315.BatchVerify(_proofs, _vk, _publicWitnesses)
+	default:
+		panic("unrecognized verifying key curve type")
+	}
+}
+
+// MarshalSolidity returns the proof encoded as expected by the contract written by
+// VerifyingKey.ExportSolidity. It is only implemented for BN254.
+func MarshalSolidity(proof Proof) ([]byte, error) {
+	switch _proof := proof.(type) {
+	case *plonk_bn254.Proof:
+		return _proof.MarshalSolidity(), nil
+	default:
+		return nil, errors.New("not implemented")
+	}
+}
+
 // NewCS instantiate a concrete curved-typed SparseR1CS and return a ConstraintSystem interface
 // This method exists for (de)serialization purposes
 func NewCS(curveID ecc.ID) frontend.CompiledConstraintSystem {
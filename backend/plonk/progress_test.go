@@ -0,0 +1,99 @@
+package plonk_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgress(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &lazyPoseidonCircuit{})
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	assert.NoError(plonk.LazifySparseR1cs(ccs))
+	pk, vk, err := plonk.Setup(ccs, srs)
+	assert.NoError(err)
+
+	good := lazyPoseidonCircuit{Data: [4]frontend.Variable{1, 2, 1, 2}, Hash: 42}
+	fullWitness, err := frontend.NewWitness(&good, ecc.BN254)
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+
+	var phases []string
+	var last backend.Progress
+	proof, err := plonk.Prove(ccs, pk, fullWitness, backend.WithProgress(func(p backend.Progress) {
+		phases = append(phases, p.Phase)
+		last = p
+	}))
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+	assert.Len(phases, 8)
+	assert.Equal([]string{"solve", "commit.LRO"}, phases[:2])
+	assert.Contains(phases, "commit.Z")
+	assert.Contains(phases, "linearize")
+	assert.Equal("open.batch", phases[7])
+	assert.Equal(100.0, last.Percent())
+
+	// a cancelled context stops the prover
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = plonk.Prove(ccs, pk, fullWitness, backend.WithContext(ctx))
+	assert.ErrorIs(err, context.Canceled)
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	phases = nil
+	_, err = plonk.Prove(ccs, pk, fullWitness, backend.WithContext(ctx), backend.WithProgress(func(p backend.Progress) {
+		phases = append(phases, p.Phase)
+		if p.Phase == "commit.LRO" {
+			cancel()
+		}
+	}))
+	assert.ErrorIs(err, context.Canceled)
+	assert.NotContains(phases, "open.batch")
+}
+
+func TestCancelJoinsProver(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &lazyPoseidonCircuit{})
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	assert.NoError(plonk.LazifySparseR1cs(ccs))
+	pk, _, err := plonk.Setup(ccs, srs)
+	assert.NoError(err)
+	fullWitness, err := frontend.NewWitness(&lazyPoseidonCircuit{Data: [4]frontend.Variable{1, 2, 1, 2}, Hash: 42}, ecc.BN254)
+	assert.NoError(err)
+
+	// these phases are reported by goroutines of the prover, which must be joined before Prove
+	// returns: the report cancelling the prover is still running otherwise
+	for _, cancelAt := range []string{"commit.Z", "linearize"} {
+		ctx, cancel := context.WithCancel(context.Background())
+		var reporting int32
+		_, err = plonk.Prove(ccs, pk, fullWitness, backend.WithContext(ctx), backend.WithProgress(func(p backend.Progress) {
+			if p.Phase == cancelAt && ctx.Err() == nil {
+				atomic.StoreInt32(&reporting, 1)
+				cancel()
+				time.Sleep(50 * time.Millisecond)
+				atomic.StoreInt32(&reporting, 0)
+			}
+		}))
+		assert.Equal(int32(0), atomic.LoadInt32(&reporting), "Prove returned before its goroutines when cancelled at %s", cancelAt)
+		cancel()
+		assert.ErrorIs(err, context.Canceled, cancelAt)
+	}
+}
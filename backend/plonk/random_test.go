@@ -0,0 +1,59 @@
+package plonk_test
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+func serialize(t *testing.T, o io.WriterTo) []byte {
+	var buf bytes.Buffer
+	_, err := o.WriteTo(&buf)
+	require.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestRandomSource(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, scs.NewBuilder, &lazyPoseidonCircuit{})
+			assert.NoError(err)
+			srs, err := test.NewKZGSRS(ccs)
+			assert.NoError(err)
+			assert.NoError(plonk.LazifySparseR1cs(ccs))
+			pk, vk, err := plonk.Setup(ccs, srs)
+			assert.NoError(err)
+
+			good := lazyPoseidonCircuit{Data: [4]frontend.Variable{1, 2, 1, 2}, Hash: 42}
+			fullWitness, err := frontend.NewWitness(&good, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+
+			// the same source gives the same proof
+			proof, err := plonk.Prove(ccs, pk, fullWitness, backend.WithRandomSource(rand.New(rand.NewSource(1))))
+			assert.NoError(err)
+			_proof, err := plonk.Prove(ccs, pk, fullWitness, backend.WithRandomSource(rand.New(rand.NewSource(1))))
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, publicWitness))
+			assert.Equal(serialize(t, proof), serialize(t, _proof))
+
+			// crypto/rand is used by default
+			_proof, err = plonk.Prove(ccs, pk, fullWitness)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(_proof, vk, publicWitness))
+			assert.NotEqual(serialize(t, proof), serialize(t, _proof))
+		})
+	}
+}
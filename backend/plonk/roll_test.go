@@ -0,0 +1,138 @@
+package plonk_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+	"github.com/stretchr/testify/require"
+)
+
+func TestProveRoll(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BLS24_315, ecc.BW6_633, ecc.BW6_761} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, scs.NewBuilder, &lazyPoseidonCircuit{})
+			assert.NoError(err)
+			srs, err := test.NewKZGSRS(ccs)
+			assert.NoError(err)
+			assert.NoError(plonk.LazifySparseR1cs(ccs))
+
+			session := filepath.Join(t.TempDir(), "poseidon")
+			assert.NoError(plonk.SetupWithDump(ccs, srs, session))
+			assert.NoError(plonk.VerifyDump(ccs, session))
+
+			// the setup is deterministic, the dumped verifying key matches plonk.Setup
+			_, vk, err := plonk.Setup(ccs, srs)
+			assert.NoError(err)
+			pk, err := plonk.ReadSegmentProveKey(ccs, session)
+			assert.NoError(err)
+			var expected, dumped bytes.Buffer
+			_, err = vk.WriteTo(&expected)
+			assert.NoError(err)
+			_, err = pk.VerifyingKey().(plonk.VerifyingKey).WriteTo(&dumped)
+			assert.NoError(err)
+			assert.Equal(expected.Bytes(), dumped.Bytes())
+
+			_ccs, err := plonk.LoadSparseR1CSFromFile(curve, session)
+			assert.NoError(err)
+			assert.NoError(pk.InitKZG(srs))
+
+			good := lazyPoseidonCircuit{Data: [4]frontend.Variable{1, 2, 1, 2}, Hash: 42}
+			fullWitness, err := frontend.NewWitness(&good, curve)
+			assert.NoError(err)
+			publicWitness, err := fullWitness.Public()
+			assert.NoError(err)
+			proof, err := plonk.ProveRoll(_ccs, pk, fullWitness, session)
+			assert.NoError(err)
+			assert.NoError(plonk.Verify(proof, vk, publicWitness))
+
+			bad := lazyPoseidonCircuit{Data: [4]frontend.Variable{1, 2, 3, 4}, Hash: 42}
+			fullWitness, err = frontend.NewWitness(&bad, curve)
+			assert.NoError(err)
+			_, err = plonk.ProveRoll(_ccs, pk, fullWitness, session)
+			assert.Error(err)
+		})
+	}
+}
+
+func TestReadSegmentProveKeyWrongCurve(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &lazyPoseidonCircuit{})
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+	assert.NoError(plonk.LazifySparseR1cs(ccs))
+
+	session := filepath.Join(t.TempDir(), "poseidon")
+	assert.NoError(plonk.SetupWithDump(ccs, srs, session))
+
+	other, err := frontend.Compile(ecc.BLS12_381, scs.NewBuilder, &lazyPoseidonCircuit{})
+	assert.NoError(err)
+	assert.NoError(plonk.LazifySparseR1cs(other))
+	_, err = plonk.ReadSegmentProveKey(other, session)
+	assert.ErrorContains(err, "written for curve")
+	assert.ErrorContains(plonk.VerifyDump(other, session), "written for curve")
+	_, err = plonk.LoadSparseR1CSFromFile(ecc.BLS12_381, session)
+	assert.Error(err)
+}
+
+type cubeCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubeCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+// offsetCubeCircuit has the shape of cubeCircuit, with another constant
+type offsetCubeCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *offsetCubeCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Add(api.Mul(circuit.X, circuit.X, circuit.X), 1), circuit.Y)
+	return nil
+}
+
+func TestProveRollWrongDump(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &cubeCircuit{})
+	assert.NoError(err)
+	other, err := frontend.Compile(ecc.BN254, scs.NewBuilder, &offsetCubeCircuit{})
+	assert.NoError(err)
+	srs, err := test.NewKZGSRS(ccs)
+	assert.NoError(err)
+
+	session := filepath.Join(t.TempDir(), "cube")
+	assert.NoError(plonk.SetupWithDump(ccs, srs, session))
+	assert.NoError(plonk.VerifyDump(ccs, session))
+	assert.ErrorContains(plonk.VerifyDump(other, session), "written for another constraint system")
+	_, err = plonk.ReadSegmentProveKey(other, session)
+	assert.ErrorContains(err, "written for another constraint system")
+
+	// a valid witness of the other circuit, proven with the dump of cubeCircuit
+	pk, err := plonk.ReadSegmentProveKey(ccs, session)
+	assert.NoError(err)
+	assert.NoError(pk.InitKZG(srs))
+	fullWitness, err := frontend.NewWitness(&offsetCubeCircuit{X: 3, Y: 28}, ecc.BN254)
+	assert.NoError(err)
+	_, err = plonk.ProveRoll(other, pk, fullWitness, session)
+	assert.ErrorContains(err, "written for another constraint system")
+
+	fullWitness, err = frontend.NewWitness(&cubeCircuit{X: 3, Y: 27}, ecc.BN254)
+	assert.NoError(err)
+	_, err = plonk.ProveRoll(ccs, pk, fullWitness, session)
+	assert.NoError(err)
+}
@@ -0,0 +1,117 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package srs
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync/atomic"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
+
+	curve_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+)
+
+// ethereumCeremony is the transcript of the Ethereum KZG ceremony, it holds the powers of τ
+// of several sizes
+type ethereumCeremony struct {
+	Transcripts []struct {
+		NumG1Powers int `json:"numG1Powers"`
+		NumG2Powers int `json:"numG2Powers"`
+		PowersOfTau struct {
+			G1Powers []string `json:"G1Powers"`
+			G2Powers []string `json:"G2Powers"`
+		} `json:"powersOfTau"`
+	} `json:"transcripts"`
+}
+
+// ReadEthereumCeremony reads the transcript.json of the Ethereum KZG ceremony and returns the kzg.SRS
+// needed by plonk.Setup for ccs, which must be defined over BLS12-381.
+//
+// The transcript holds the powers of τ of several sizes, the powers of the smallest transcript large
+// enough for ccs are used. Points are hexadecimal strings, compressed as in ZCash.
+func ReadEthereumCeremony(r io.Reader, ccs frontend.CompiledConstraintSystem) (kzg.SRS, error) {
+	if ccs.CurveID() != ecc.BLS12_381 {
+		return nil, notSupported("Ethereum KZG ceremony", ccs.CurveID())
+	}
+	size := Size(ccs)
+
+	var ceremony ethereumCeremony
+	if err := json.NewDecoder(r).Decode(&ceremony); err != nil {
+		return nil, err
+	}
+	t := -1
+	for i, transcript := range ceremony.Transcripts {
+		if transcript.NumG1Powers != len(transcript.PowersOfTau.G1Powers) || transcript.NumG2Powers != len(transcript.PowersOfTau.G2Powers) {
+			return nil, fmt.Errorf("transcript %d has an invalid number of powers", i)
+		}
+		if uint64(transcript.NumG1Powers) >= size && transcript.NumG2Powers >= 2 && (t == -1 || transcript.NumG1Powers < ceremony.Transcripts[t].NumG1Powers) {
+			t = i
+		}
+	}
+	if t == -1 {
+		return nil, fmt.Errorf("the ceremony doesn't hold the %d powers needed by the circuit", size)
+	}
+	powers := ceremony.Transcripts[t].PowersOfTau
+
+	srs := &kzg_bls12381.SRS{G1: make([]curve_bls12381.G1Affine, size)}
+	var nbInvalid uint64
+	utils.Parallelize(len(srs.G1), func(start, end int) {
+		for i := start; i < end; i++ {
+			if err := setHex(&srs.G1[i], powers.G1Powers[i]); err != nil {
+				atomic.AddUint64(&nbInvalid, 1)
+				return
+			}
+		}
+	})
+	if nbInvalid != 0 {
+		return nil, errInconsistentPowers
+	}
+	for i := range srs.G2 {
+		if err := setHex(&srs.G2[i], powers.G2Powers[i]); err != nil {
+			return nil, err
+		}
+	}
+	if err := checkBLS12381(srs); err != nil {
+		return nil, err
+	}
+	return srs, nil
+}
+
+// setHex sets p from its 0x-prefixed hexadecimal compressed encoding
+func setHex(p interface{ SetBytes([]byte) (int, error) }, s string) error {
+	if !strings.HasPrefix(s, "0x") {
+		return fmt.Errorf("%q is not an hexadecimal string", s)
+	}
+	b, err := hex.DecodeString(s[2:])
+	if err != nil {
+		return err
+	}
+	n, err := p.SetBytes(b)
+	if err != nil {
+		return err
+	}
+	if n != len(b) {
+		return fmt.Errorf("%q is not a compressed point", s)
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package srs
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
+
+	curve_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+	curve_bn254 "github.com/consensys/gnark-crypto/ecc/bn254"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+)
+
+// sections of a .ptau file
+const (
+	ptauHeader = 1
+	ptauTauG1  = 2
+	ptauTauG2  = 3
+)
+
+var errInvalidPtau = errors.New("invalid ptau file")
+
+// ReadPtau reads the powers of τ of a .ptau file of snarkjs, the output of a phase 1, and returns the
+// kzg.SRS needed by plonk.Setup for ccs. The file must be defined over the curve of ccs.
+//
+// A .ptau file starts with the magic "ptau", a version and the number of sections. Each section has
+// a type, a size and its content, the ones read are:
+//
+//	1: the size of the base field elements n8, the modulus, the power of the file and of the ceremony
+//	2: the [τⁱ]₁, for i < 2·2ᵖᵒʷᵉʳ - 1
+//	3: the [τⁱ]₂, for i < 2ᵖᵒʷᵉʳ
+//
+// Points are encoded with their affine coordinates in Montgomery form, in n8 little-endian bytes,
+// and the coordinates in Fp² are encoded as (c₀, c₁).
+func ReadPtau(r io.Reader, ccs frontend.CompiledConstraintSystem) (kzg.SRS, error) {
+	curveID := ccs.CurveID()
+	if curveID != ecc.BN254 && curveID != ecc.BLS12_381 {
+		return nil, notSupported("ptau", curveID)
+	}
+	size := Size(ccs)
+	q := curveID.Info().Fp.Modulus()
+	g1, g2, err := readPtauPowers(bufio.NewReader(r), q, size)
+	if err != nil {
+		return nil, err
+	}
+
+	switch curveID {
+	case ecc.BN254:
+		srs := &kzg_bn254.SRS{G1: make([]curve_bn254.G1Affine, size)}
+		utils.Parallelize(len(srs.G1), func(start, end int) {
+			for i := start; i < end; i++ {
+				srs.G1[i].X.SetBigInt(g1[2*i])
+				srs.G1[i].Y.SetBigInt(g1[2*i+1])
+			}
+		})
+		for i := range srs.G2 {
+			srs.G2[i].X.A0.SetBigInt(g2[4*i])
+			srs.G2[i].X.A1.SetBigInt(g2[4*i+1])
+			srs.G2[i].Y.A0.SetBigInt(g2[4*i+2])
+			srs.G2[i].Y.A1.SetBigInt(g2[4*i+3])
+		}
+		if err := checkBN254(srs); err != nil {
+			return nil, err
+		}
+		return srs, nil
+	default:
+		srs := &kzg_bls12381.SRS{G1: make([]curve_bls12381.G1Affine, size)}
+		utils.Parallelize(len(srs.G1), func(start, end int) {
+			for i := start; i < end; i++ {
+				srs.G1[i].X.SetBigInt(g1[2*i])
+				srs.G1[i].Y.SetBigInt(g1[2*i+1])
+			}
+		})
+		for i := range srs.G2 {
+			srs.G2[i].X.A0.SetBigInt(g2[4*i])
+			srs.G2[i].X.A1.SetBigInt(g2[4*i+1])
+			srs.G2[i].Y.A0.SetBigInt(g2[4*i+2])
+			srs.G2[i].Y.A1.SetBigInt(g2[4*i+3])
+		}
+		if err := checkBLS12381(srs); err != nil {
+			return nil, err
+		}
+		return srs, nil
+	}
+}
+
+// readPtauPowers returns the coordinates of the first size [τⁱ]₁ and of [1]₂, [τ]₂ of a .ptau file
+// defined over the base field of modulus q
+func readPtauPowers(r io.Reader, q *big.Int, size uint64) (g1, g2 []*big.Int, err error) {
+	var magic [4]byte
+	if _, err := io.ReadFull(r, magic[:]); err != nil {
+		return nil, nil, err
+	}
+	if string(magic[:]) != "ptau" {
+		return nil, nil, errInvalidPtau
+	}
+	var header struct {
+		Version, NbSections uint32
+	}
+	if err := binary.Read(r, binary.LittleEndian, &header); err != nil {
+		return nil, nil, err
+	}
+
+	n8 := 0
+	for s := uint32(0); s < header.NbSections && (g1 == nil || g2 == nil); s++ {
+		var section struct {
+			Type uint32
+			Size uint64
+		}
+		if err := binary.Read(r, binary.LittleEndian, &section); err != nil {
+			return nil, nil, err
+		}
+		content := io.LimitReader(r, int64(section.Size))
+
+		switch section.Type {
+		case ptauHeader:
+			var _n8 uint32
+			if err := binary.Read(content, binary.LittleEndian, &_n8); err != nil {
+				return nil, nil, err
+			}
+			if _n8 == 0 || _n8 > 64 {
+				return nil, nil, errInvalidPtau
+			}
+			n8 = int(_n8)
+			buf := make([]byte, n8)
+			if _, err := io.ReadFull(content, buf); err != nil {
+				return nil, nil, err
+			}
+			if new(big.Int).SetBytes(reverse(buf)).Cmp(q) != 0 {
+				return nil, nil, errors.New("the ptau file is not defined over the curve of the circuit")
+			}
+			var power uint32
+			if err := binary.Read(content, binary.LittleEndian, &power); err != nil {
+				return nil, nil, err
+			}
+			if power >= 63 || (uint64(2)<<power)-1 < size {
+				return nil, nil, fmt.Errorf("the ptau file holds 2^%d powers, the circuit needs %d", power, size)
+			}
+		case ptauTauG1:
+			if n8 == 0 {
+				return nil, nil, errInvalidPtau
+			}
+			if g1, err = readPtauCoordinates(content, q, n8, 2*int(size)); err != nil {
+				return nil, nil, err
+			}
+		case ptauTauG2:
+			if n8 == 0 {
+				return nil, nil, errInvalidPtau
+			}
+			if g2, err = readPtauCoordinates(content, q, n8, 2*4); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// skip the rest of the section
+		if _, err := io.Copy(io.Discard, content); err != nil {
+			return nil, nil, err
+		}
+	}
+	if g1 == nil || g2 == nil {
+		return nil, nil, errInvalidPtau
+	}
+	return g1, g2, nil
+}
+
+// readPtauCoordinates reads n base field elements of n8 bytes in Montgomery form, and returns them
+// in regular form
+func readPtauCoordinates(r io.Reader, q *big.Int, n8, n int) ([]*big.Int, error) {
+	buf := make([]byte, n*n8)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+
+	// x = xR·R⁻¹ with R = 2⁸ⁿ⁸
+	rInv := new(big.Int).Lsh(big.NewInt(1), uint(8*n8))
+	rInv.ModInverse(rInv, q)
+
+	res := make([]*big.Int, n)
+	for i := range res {
+		res[i] = new(big.Int).SetBytes(reverse(buf[i*n8 : (i+1)*n8]))
+		if res[i].Cmp(q) >= 0 {
+			return nil, errInvalidPtau
+		}
+	}
+	utils.Parallelize(n, func(start, end int) {
+		for i := start; i < end; i++ {
+			res[i].Mul(res[i], rInv).Mod(res[i], q)
+		}
+	})
+	return res, nil
+}
+
+// reverse reverses b in place and returns it
+func reverse(b []byte) []byte {
+	for i, j := 0, len(b)-1; i < j; i, j = i+1, j-1 {
+		b[i], b[j] = b[j], b[i]
+	}
+	return b
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package srs imports the KZG SRS used by plonk.Setup from the output of public powers of tau ceremonies.
+//
+// ReadPtau reads the .ptau files of snarkjs and of the Perpetual Powers of Tau (BN254 and BLS12-381),
+// ReadEthereumCeremony reads the transcript of the Ethereum KZG ceremony (BLS12-381).
+// The SRS is truncated to the size needed by the circuit, and the powers are checked to be consistent:
+// the points are in the correct subgroups and [τⁱ⁺¹]₁ = τ·[τⁱ]₁, which is checked with one multi-pairing
+// on a random linear combination of the powers.
+//
+// # See also
+//
+// https://github.com/iden3/snarkjs#7-prepare-phase-2
+//
+// https://github.com/ethereum/kzg-ceremony-specs
+package srs
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
+
+	curve_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381"
+	fr_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr"
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+	curve_bn254 "github.com/consensys/gnark-crypto/ecc/bn254"
+	fr_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+)
+
+var errInconsistentPowers = errors.New("the powers of the SRS are not consistent")
+
+// Size returns the number of G1 powers of the SRS needed by plonk.Setup for ccs
+func Size(ccs frontend.CompiledConstraintSystem) uint64 {
+	nbConstraints := ccs.GetNbConstraints()
+	_, _, public := ccs.GetNbVariables()
+	sizeSystem := nbConstraints + public
+	if t, ok := ccs.(interface{ NbTableRows() int }); ok && t.NbTableRows() > sizeSystem {
+		sizeSystem = t.NbTableRows() // the lookup tables are interpolated on the same domain
+	}
+	return ecc.NextPowerOfTwo(uint64(sizeSystem)) + 3
+}
+
+// checkBN254 checks that srs holds the powers of the same τ, starting with the generators
+func checkBN254(srs *kzg_bn254.SRS) error {
+	_, _, g1, g2 := curve_bn254.Generators()
+	if !srs.G1[0].Equal(&g1) || !srs.G2[0].Equal(&g2) {
+		return errors.New("the SRS doesn't start with the generators")
+	}
+	if srs.G2[1].IsInfinity() || !srs.G2[1].IsOnCurve() || !srs.G2[1].IsInSubGroup() {
+		return errInconsistentPowers
+	}
+	var nbInvalid uint64
+	utils.Parallelize(len(srs.G1), func(start, end int) {
+		for i := start; i < end; i++ {
+			if srs.G1[i].IsInfinity() || !srs.G1[i].IsOnCurve() || !srs.G1[i].IsInSubGroup() {
+				atomic.AddUint64(&nbInvalid, 1)
+				return
+			}
+		}
+	})
+	if nbInvalid != 0 {
+		return errInconsistentPowers
+	}
+
+	// e(Σ ρᵢ·[τⁱ⁺¹]₁, [1]₂) = e(Σ ρᵢ·[τⁱ]₁, [τ]₂)
+	rho := make([]fr_bn254.Element, len(srs.G1)-1)
+	for i := range rho {
+		if _, err := rho[i].SetRandom(); err != nil {
+			return err
+		}
+	}
+	var left, right curve_bn254.G1Affine
+	config := ecc.MultiExpConfig{ScalarsMont: true}
+	if _, err := left.MultiExp(srs.G1[1:], rho, config); err != nil {
+		return err
+	}
+	if _, err := right.MultiExp(srs.G1[:len(srs.G1)-1], rho, config); err != nil {
+		return err
+	}
+	right.Neg(&right)
+	check, err := curve_bn254.PairingCheck([]curve_bn254.G1Affine{left, right}, []curve_bn254.G2Affine{srs.G2[0], srs.G2[1]})
+	if err != nil {
+		return err
+	}
+	if !check {
+		return errInconsistentPowers
+	}
+	return nil
+}
+
+// checkBLS12381 checks that srs holds the powers of the same τ, starting with the generators
+func checkBLS12381(srs *kzg_bls12381.SRS) error {
+	_, _, g1, g2 := curve_bls12381.Generators()
+	if !srs.G1[0].Equal(&g1) || !srs.G2[0].Equal(&g2) {
+		return errors.New("the SRS doesn't start with the generators")
+	}
+	if srs.G2[1].IsInfinity() || !srs.G2[1].IsOnCurve() || !srs.G2[1].IsInSubGroup() {
+		return errInconsistentPowers
+	}
+	var nbInvalid uint64
+	utils.Parallelize(len(srs.G1), func(start, end int) {
+		for i := start; i < end; i++ {
+			if srs.G1[i].IsInfinity() || !srs.G1[i].IsOnCurve() || !srs.G1[i].IsInSubGroup() {
+				atomic.AddUint64(&nbInvalid, 1)
+				return
+			}
+		}
+	})
+	if nbInvalid != 0 {
+		return errInconsistentPowers
+	}
+
+	// e(Σ ρᵢ·[τⁱ⁺¹]₁, [1]₂) = e(Σ ρᵢ·[τⁱ]₁, [τ]₂)
+	rho := make([]fr_bls12381.Element, len(srs.G1)-1)
+	for i := range rho {
+		if _, err := rho[i].SetRandom(); err != nil {
+			return err
+		}
+	}
+	var left, right curve_bls12381.G1Affine
+	config := ecc.MultiExpConfig{ScalarsMont: true}
+	if _, err := left.MultiExp(srs.G1[1:], rho, config); err != nil {
+		return err
+	}
+	if _, err := right.MultiExp(srs.G1[:len(srs.G1)-1], rho, config); err != nil {
+		return err
+	}
+	right.Neg(&right)
+	check, err := curve_bls12381.PairingCheck([]curve_bls12381.G1Affine{left, right}, []curve_bls12381.G2Affine{srs.G2[0], srs.G2[1]})
+	if err != nil {
+		return err
+	}
+	if !check {
+		return errInconsistentPowers
+	}
+	return nil
+}
+
+// notSupported returns the error of an import of an SRS on an unsupported curve
+func notSupported(format string, curveID ecc.ID) error {
+	return fmt.Errorf("%s SRS are not supported on %s", format, curveID)
+}
@@ -0,0 +1,249 @@
+package srs
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/stretchr/testify/require"
+
+	kzg_bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/kzg"
+	kzg_bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(api.Add(x3, circuit.X, 5), circuit.Y)
+	return nil
+}
+
+// ptauWriter writes .ptau files of given power for a known τ
+type ptauWriter struct {
+	buf bytes.Buffer
+	q   *big.Int
+	n8  int
+}
+
+func (w *ptauWriter) write(v interface{}) {
+	if err := binary.Write(&w.buf, binary.LittleEndian, v); err != nil {
+		panic(err)
+	}
+}
+
+// writeCoordinates writes the coordinates given in big-endian regular form
+func (w *ptauWriter) writeCoordinates(coordinates ...[]byte) {
+	r := new(big.Int).Lsh(big.NewInt(1), uint(8*w.n8))
+	for _, c := range coordinates {
+		x := new(big.Int).SetBytes(c)
+		x.Mul(x, r).Mod(x, w.q)
+		b := make([]byte, w.n8)
+		x.FillBytes(b)
+		w.buf.Write(reverse(b))
+	}
+}
+
+func (w *ptauWriter) section(id uint32, content func(w *ptauWriter)) {
+	section := &ptauWriter{q: w.q, n8: w.n8}
+	content(section)
+	w.write(id)
+	w.write(uint64(section.buf.Len()))
+	w.buf.Write(section.buf.Bytes())
+}
+
+// newPtau returns a .ptau file of 2ᵖᵒʷᵉʳ powers of τ
+func newPtau(t *testing.T, curveID ecc.ID, power int, tau *big.Int) []byte {
+	n := 1 << power
+	w := &ptauWriter{q: curveID.Info().Fp.Modulus()}
+	w.n8 = (w.q.BitLen() + 63) / 64 * 8
+
+	w.buf.WriteString("ptau")
+	w.write(uint32(1))
+	w.write(uint32(4))
+	w.section(ptauHeader, func(w *ptauWriter) {
+		w.write(uint32(w.n8))
+		q := make([]byte, w.n8)
+		w.q.FillBytes(q)
+		w.buf.Write(reverse(q))
+		w.write(uint32(power))
+		w.write(uint32(power))
+	})
+
+	// a section which isn't read
+	w.section(7, func(w *ptauWriter) {
+		w.buf.Write([]byte{1, 2, 3})
+	})
+
+	switch curveID {
+	case ecc.BN254:
+		srs, err := kzg_bn254.NewSRS(uint64(2*n-1), tau)
+		require.NoError(t, err)
+		w.section(ptauTauG1, func(w *ptauWriter) {
+			for i := range srs.G1 {
+				x, y := srs.G1[i].X.Bytes(), srs.G1[i].Y.Bytes()
+				w.writeCoordinates(x[:], y[:])
+			}
+		})
+		w.section(ptauTauG2, func(w *ptauWriter) {
+			p := srs.G2[0]
+			for i := 0; i < n; i++ {
+				x0, x1, y0, y1 := p.X.A0.Bytes(), p.X.A1.Bytes(), p.Y.A0.Bytes(), p.Y.A1.Bytes()
+				w.writeCoordinates(x0[:], x1[:], y0[:], y1[:])
+				p.ScalarMultiplication(&p, tau)
+			}
+		})
+	case ecc.BLS12_381:
+		srs, err := kzg_bls12381.NewSRS(uint64(2*n-1), tau)
+		require.NoError(t, err)
+		w.section(ptauTauG1, func(w *ptauWriter) {
+			for i := range srs.G1 {
+				x, y := srs.G1[i].X.Bytes(), srs.G1[i].Y.Bytes()
+				w.writeCoordinates(x[:], y[:])
+			}
+		})
+		w.section(ptauTauG2, func(w *ptauWriter) {
+			p := srs.G2[0]
+			for i := 0; i < n; i++ {
+				x0, x1, y0, y1 := p.X.A0.Bytes(), p.X.A1.Bytes(), p.Y.A0.Bytes(), p.Y.A1.Bytes()
+				w.writeCoordinates(x0[:], x1[:], y0[:], y1[:])
+				p.ScalarMultiplication(&p, tau)
+			}
+		})
+	}
+	return w.buf.Bytes()
+}
+
+// newKZGSRS returns the SRS of size powers of τ
+func newKZGSRS(t *testing.T, curveID ecc.ID, size uint64, tau *big.Int) kzg.SRS {
+	var srs kzg.SRS
+	var err error
+	switch curveID {
+	case ecc.BN254:
+		srs, err = kzg_bn254.NewSRS(size, tau)
+	case ecc.BLS12_381:
+		srs, err = kzg_bls12381.NewSRS(size, tau)
+	}
+	require.NoError(t, err)
+	return srs
+}
+
+// checkSetup checks that srs can be used to prove and verify ccs with PLONK
+func checkSetup(assert *require.Assertions, ccs frontend.CompiledConstraintSystem, srs kzg.SRS) {
+	pk, vk, err := plonk.Setup(ccs, srs)
+	assert.NoError(err)
+	fullWitness, err := frontend.NewWitness(&cubicCircuit{X: 3, Y: 35}, ccs.CurveID())
+	assert.NoError(err)
+	publicWitness, err := fullWitness.Public()
+	assert.NoError(err)
+	proof, err := plonk.Prove(ccs, pk, fullWitness)
+	assert.NoError(err)
+	assert.NoError(plonk.Verify(proof, vk, publicWitness))
+}
+
+func TestReadPtau(t *testing.T) {
+	for _, curve := range []ecc.ID{ecc.BN254, ecc.BLS12_381} {
+		t.Run(curve.String(), func(t *testing.T) {
+			assert := require.New(t)
+
+			ccs, err := frontend.Compile(curve, scs.NewBuilder, &cubicCircuit{})
+			assert.NoError(err)
+			tau := big.NewInt(42)
+			ptau := newPtau(t, curve, 4, tau)
+
+			srs, err := ReadPtau(bytes.NewReader(ptau), ccs)
+			assert.NoError(err)
+			assert.Equal(newKZGSRS(t, curve, Size(ccs), tau), srs)
+			checkSetup(assert, ccs, srs)
+
+			// the file is too small
+			_, err = ReadPtau(bytes.NewReader(newPtau(t, curve, 2, tau)), ccs)
+			assert.Error(err)
+
+			// the file is truncated
+			_, err = ReadPtau(bytes.NewReader(ptau[:200]), ccs)
+			assert.Error(err)
+
+			// the file is defined over another curve
+			other := ecc.BN254
+			if curve == ecc.BN254 {
+				other = ecc.BLS12_381
+			}
+			_, err = ReadPtau(bytes.NewReader(newPtau(t, other, 4, tau)), ccs)
+			assert.Error(err)
+
+			// the powers are not consistent: swap [τ]₁ and [τ²]₁
+			n8 := (curve.Info().Fp.Modulus().BitLen() + 63) / 64 * 8
+			g1 := bytes.Index(ptau, []byte{ptauTauG1, 0, 0, 0}) + 12
+			tampered := append([]byte{}, ptau...)
+			copy(tampered[g1+2*n8:], ptau[g1+4*n8:g1+6*n8])
+			copy(tampered[g1+4*n8:], ptau[g1+2*n8:g1+4*n8])
+			_, err = ReadPtau(bytes.NewReader(tampered), ccs)
+			assert.ErrorIs(err, errInconsistentPowers)
+		})
+	}
+}
+
+func TestReadEthereumCeremony(t *testing.T) {
+	assert := require.New(t)
+
+	ccs, err := frontend.Compile(ecc.BLS12_381, scs.NewBuilder, &cubicCircuit{})
+	assert.NoError(err)
+
+	// transcripts of 32 and 16 powers of different τ, the smallest one is used
+	var ceremony ethereumCeremony
+	ceremony.Transcripts = make([]struct {
+		NumG1Powers int `json:"numG1Powers"`
+		NumG2Powers int `json:"numG2Powers"`
+		PowersOfTau struct {
+			G1Powers []string `json:"G1Powers"`
+			G2Powers []string `json:"G2Powers"`
+		} `json:"powersOfTau"`
+	}, 2)
+	for i, n := range []int{32, 16} {
+		srs, err := kzg_bls12381.NewSRS(uint64(n), big.NewInt(int64(i+2)))
+		assert.NoError(err)
+		transcript := &ceremony.Transcripts[i]
+		transcript.NumG1Powers, transcript.NumG2Powers = n, 2
+		for j := range srs.G1 {
+			b := srs.G1[j].Bytes()
+			transcript.PowersOfTau.G1Powers = append(transcript.PowersOfTau.G1Powers, "0x"+hex.EncodeToString(b[:]))
+		}
+		for j := range srs.G2 {
+			b := srs.G2[j].Bytes()
+			transcript.PowersOfTau.G2Powers = append(transcript.PowersOfTau.G2Powers, "0x"+hex.EncodeToString(b[:]))
+		}
+	}
+	transcript, err := json.Marshal(&ceremony)
+	assert.NoError(err)
+
+	srs, err := ReadEthereumCeremony(bytes.NewReader(transcript), ccs)
+	assert.NoError(err)
+	assert.Equal(newKZGSRS(t, ecc.BLS12_381, Size(ccs), big.NewInt(3)), srs)
+	checkSetup(assert, ccs, srs)
+
+	// the powers are not consistent
+	p := ceremony.Transcripts[1].PowersOfTau.G1Powers
+	p[1], p[2] = p[2], p[1]
+	transcript, err = json.Marshal(&ceremony)
+	assert.NoError(err)
+	_, err = ReadEthereumCeremony(bytes.NewReader(transcript), ccs)
+	assert.ErrorIs(err, errInconsistentPowers)
+
+	// the ceremony is over BLS12-381
+	ccs, err = frontend.Compile(ecc.BN254, scs.NewBuilder, &cubicCircuit{})
+	assert.NoError(err)
+	_, err = ReadEthereumCeremony(bytes.NewReader(transcript), ccs)
+	assert.Error(err)
+}
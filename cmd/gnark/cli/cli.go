@@ -0,0 +1,272 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cli implements the gnark command-line tool, which runs the compile, setup, prove and
+// verify steps of the Groth16 lifecycle on circuits registered by name.
+//
+// The cmd/gnark binary registers the circuits of the examples. To run the tool on other circuits,
+// build a main package registering them before calling Run:
+//
+//	func main() {
+//		cli.Register("mycircuit", func() frontend.Circuit { return &MyCircuit{} })
+//		if err := cli.Run(os.Args[1:], os.Stdout); err != nil {
+//			...
+//		}
+//	}
+//
+// The tool reads and writes the formats of the library: constraint systems and proofs are
+// encoded with their WriteTo method, witnesses are JSON objects following the schema of the
+// circuit (see witness.Witness.UnmarshalJSON), and the setup is dumped under a session prefix
+// by groth16.SetupWithDump, so that prove and verify only need the session.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/internal/backend/dump"
+)
+
+var (
+	circuitsMu sync.RWMutex
+	circuits   = make(map[string]func() frontend.Circuit)
+)
+
+// Register makes a circuit available to the commands under the given name.
+// newCircuit returns a circuit to compile, its variables are not assigned.
+//
+// Register panics if it is called twice with the same name.
+func Register(name string, newCircuit func() frontend.Circuit) {
+	circuitsMu.Lock()
+	defer circuitsMu.Unlock()
+	if name == "" || newCircuit == nil {
+		panic("cli: Register requires a name and a circuit")
+	}
+	if _, dup := circuits[name]; dup {
+		panic("cli: Register called twice for circuit " + name)
+	}
+	circuits[name] = newCircuit
+}
+
+// Circuits returns the sorted names of the registered circuits
+func Circuits() []string {
+	circuitsMu.RLock()
+	defer circuitsMu.RUnlock()
+	names := make([]string, 0, len(circuits))
+	for name := range circuits {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// command is a subcommand of the tool
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, w io.Writer) error
+}
+
+var commands = []command{
+	{"compile", "compile a registered circuit and write its constraint system", runCompile},
+	{"setup", "run the Groth16 setup and dump the keys under a session prefix", runSetup},
+	{"prove", "prove a witness with the keys of a session", runProve},
+	{"verify", "verify a proof with the verifying key of a session", runVerify},
+	{"export-solidity", "write the Solidity verifier of a session (BN254 only)", runExportSolidity},
+	{"inspect", "print the size and the schema of a constraint system", runInspect},
+	{"bench", "measure the compile, setup, prove and verify steps of a circuit", runBench},
+}
+
+// Run runs the command described by args, the command line without the program name,
+// and writes its output and the usage messages to w.
+func Run(args []string, w io.Writer) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "-help" || args[0] == "help" {
+		usage(w)
+		if len(args) == 0 {
+			return errors.New("missing command")
+		}
+		return nil
+	}
+	for _, c := range commands {
+		if c.name == args[0] {
+			err := c.run(args[1:], w)
+			if errors.Is(err, flag.ErrHelp) {
+				return nil
+			}
+			return err
+		}
+	}
+	usage(w)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "usage: gnark <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "circuits: %s\n", strings.Join(Circuits(), ", "))
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "run gnark <command> -h for the flags of a command")
+}
+
+// newFlagSet returns the flag set of the command name, writing its errors and usage to w
+func newFlagSet(name string, w io.Writer) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	return fs
+}
+
+// parseCurve returns the curve named s, as printed by ecc.ID.String
+func parseCurve(s string) (ecc.ID, error) {
+	for _, id := range ecc.Implemented() {
+		if strings.EqualFold(id.String(), s) {
+			return id, nil
+		}
+	}
+	return ecc.UNKNOWN, fmt.Errorf("unknown curve %q", s)
+}
+
+// compile compiles the registered circuit name into an R1CS
+func compile(name string, curveID ecc.ID) (frontend.CompiledConstraintSystem, error) {
+	circuitsMu.RLock()
+	newCircuit, ok := circuits[name]
+	circuitsMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown circuit %q, registered circuits: %s", name, strings.Join(Circuits(), ", "))
+	}
+	return frontend.Compile(curveID, r1cs.NewBuilder, newCircuit())
+}
+
+// ccsFlags are the flags of the commands taking a constraint system, either compiled from a
+// registered circuit or read from a file written by the compile command
+type ccsFlags struct {
+	circuit, curve, ccs string
+}
+
+func (f *ccsFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.circuit, "circuit", "", "name of the registered circuit to compile")
+	fs.StringVar(&f.curve, "curve", ecc.BN254.String(), "curve of the constraint system")
+	fs.StringVar(&f.ccs, "ccs", "", "constraint system written by the compile command, instead of -circuit")
+}
+
+// load returns the constraint system described by the flags
+func (f *ccsFlags) load() (frontend.CompiledConstraintSystem, error) {
+	curveID, err := parseCurve(f.curve)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case f.circuit != "" && f.ccs != "":
+		return nil, errors.New("-circuit and -ccs are mutually exclusive")
+	case f.circuit != "":
+		return compile(f.circuit, curveID)
+	case f.ccs != "":
+		file, err := os.Open(f.ccs)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		ccs := groth16.NewCS(curveID)
+		if _, err := ccs.ReadFrom(file); err != nil {
+			return nil, fmt.Errorf("%s: %w", f.ccs, err)
+		}
+		return ccs, nil
+	default:
+		return nil, errors.New("-circuit or -ccs is required")
+	}
+}
+
+// sessionCurve returns the curve recorded in the manifest of the dump written under session
+func sessionCurve(session string) (ecc.ID, error) {
+	if session == "" {
+		return ecc.UNKNOWN, errors.New("-session is required")
+	}
+	m, err := dump.ReadManifest(session)
+	if err != nil {
+		return ecc.UNKNOWN, err
+	}
+	return parseCurve(m.Curve)
+}
+
+// readVerifyingKey reads the VerifyingKey dumped under session, checking it against the manifest
+func readVerifyingKey(session string, curveID ecc.ID) (groth16.VerifyingKey, error) {
+	m, err := dump.ReadManifest(session)
+	if err != nil {
+		return nil, err
+	}
+	vk := groth16.NewVerifyingKey(curveID)
+	if err := m.ReadSegment(dump.VerifyingKey, vk.ReadFrom); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// readWitness reads a JSON witness of ccs, if it only assigns the public variables the
+// witness is public
+func readWitness(path string, ccs frontend.CompiledConstraintSystem) (*witness.Witness, error) {
+	if path == "" {
+		return nil, errors.New("-witness is required")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := witness.New(ccs.CurveID(), ccs.GetSchema())
+	if err != nil {
+		return nil, err
+	}
+	if err := w.UnmarshalJSON(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+// writeFile calls write on the file path, or on w if path is empty
+func writeFile(path string, w io.Writer, write func(io.Writer) error) error {
+	if path == "" {
+		return write(w)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// writeTo adapts o.WriteTo to writeFile
+func writeTo(o io.WriterTo) func(io.Writer) error {
+	return func(w io.Writer) error {
+		_, err := o.WriteTo(w)
+		return err
+	}
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/require"
+)
+
+type cubicCircuit struct {
+	X frontend.Variable `gnark:"x"`
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(circuit.Y, api.Add(x3, circuit.X, 5))
+	return nil
+}
+
+func init() {
+	Register("cubic", func() frontend.Circuit { return &cubicCircuit{} })
+}
+
+func TestLifecycle(t *testing.T) {
+	assert := require.New(t)
+
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+	assert.NoError(os.WriteFile(path("full.json"), []byte(`{"x":3,"Y":35}`), 0o600))
+	assert.NoError(os.WriteFile(path("public.json"), []byte(`{"Y":35}`), 0o600))
+	assert.NoError(os.WriteFile(path("invalid.json"), []byte(`{"Y":36}`), 0o600))
+	session := path("setup/cubic")
+
+	var out bytes.Buffer
+	run := func(args ...string) error {
+		out.Reset()
+		return Run(args, &out)
+	}
+
+	assert.NoError(run("compile", "-circuit", "cubic", "-o", path("cubic.ccs")))
+	assert.NoError(run("inspect", "-ccs", path("cubic.ccs")))
+	assert.Contains(out.String(), "constraints: 3\n")
+	assert.Contains(out.String(), "public:\nY\nsecret:\nx\n")
+
+	assert.NoError(run("setup", "-ccs", path("cubic.ccs"), "-session", session))
+	assert.NoError(run("inspect", "-session", session))
+	assert.Contains(out.String(), "constraints: 3\n")
+
+	assert.NoError(run("prove", "-session", session, "-witness", path("full.json")))
+	assert.NoError(run("verify", "-session", session, "-witness", path("public.json")))
+	assert.NoError(run("verify", "-session", session, "-witness", path("full.json")), "the secret variables are ignored")
+	assert.Error(run("verify", "-session", session, "-witness", path("invalid.json")))
+
+	assert.NoError(run("prove", "-stream", "-session", session, "-witness", path("full.json"), "-proof", path("stream.proof")))
+	assert.NoError(run("verify", "-session", session, "-witness", path("public.json"), "-proof", path("stream.proof")))
+	assert.NoError(run("prove", "-stream", "-memory", "512", "-session", session, "-witness", path("full.json"), "-proof", path("stream.proof")))
+	assert.NoError(run("verify", "-session", session, "-witness", path("public.json"), "-proof", path("stream.proof")))
+
+	assert.NoError(run("export-solidity", "-session", session))
+	assert.Contains(out.String(), "pragma solidity")
+
+	// the lazy setup compiles the circuit
+	assert.NoError(run("setup", "-lazy", "-circuit", "cubic", "-session", path("lazy")))
+	assert.NoError(run("prove", "-session", path("lazy"), "-witness", path("full.json")))
+	assert.NoError(run("verify", "-session", path("lazy"), "-witness", path("public.json")))
+
+	assert.NoError(run("bench", "-circuit", "cubic", "-witness", path("full.json"), "-count", "2"))
+	assert.Contains(out.String(), "average of 2 runs")
+}
+
+func TestErrors(t *testing.T) {
+	assert := require.New(t)
+
+	var out bytes.Buffer
+	assert.Error(Run(nil, &out))
+	assert.Contains(out.String(), "circuits: cubic")
+	assert.Error(Run([]string{"unknown"}, &out))
+	assert.NoError(Run([]string{"prove", "-h"}, &out))
+
+	assert.ErrorContains(Run([]string{"compile", "-circuit", "unknown"}, &out), "unknown circuit")
+	assert.ErrorContains(Run([]string{"compile", "-circuit", "cubic", "-curve", "unknown"}, &out), "unknown curve")
+	assert.ErrorContains(Run([]string{"setup", "-circuit", "cubic"}, &out), "-session is required")
+	assert.ErrorContains(Run([]string{"inspect", "-circuit", "cubic", "-ccs", "cubic.ccs"}, &out), "mutually exclusive")
+	assert.Error(Run([]string{"prove", "-session", filepath.Join(t.TempDir(), "missing")}, &out))
+
+	assert.Panics(func() { Register("cubic", func() frontend.Circuit { return &cubicCircuit{} }) })
+}
@@ -0,0 +1,331 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+)
+
+// gnark compile -circuit name [-curve bn254] [-o file]
+func runCompile(args []string, w io.Writer) error {
+	fs := newFlagSet("compile", w)
+	var f ccsFlags
+	fs.StringVar(&f.circuit, "circuit", "", "name of the registered circuit to compile")
+	fs.StringVar(&f.curve, "curve", ecc.BN254.String(), "curve of the constraint system")
+	out := fs.String("o", "", "output file of the constraint system (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.circuit == "" {
+		return errors.New("-circuit is required")
+	}
+	ccs, err := f.load()
+	if err != nil {
+		return err
+	}
+	if err := writeFile(*out, w, writeTo(ccs)); err != nil || *out == "" {
+		return err
+	}
+	fmt.Fprintf(w, "%s: %d constraints\n", *out, ccs.GetNbConstraints())
+	return nil
+}
+
+// gnark setup -session prefix (-circuit name | -ccs file) [-curve bn254] [-lazy]
+func runSetup(args []string, w io.Writer) error {
+	fs := newFlagSet("setup", w)
+	var f ccsFlags
+	f.register(fs)
+	session := fs.String("session", "", "prefix of the files of the setup")
+	lazy := fs.Bool("lazy", false, "lazify the constraint system before the setup (see groth16.SetupLazyWithDump)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *session == "" {
+		return errors.New("-session is required")
+	}
+	ccs, err := f.load()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(*session), 0o755); err != nil {
+		return err
+	}
+
+	if *lazy {
+		err = groth16.SetupLazyWithDump(ccs, *session)
+	} else {
+		err = groth16.SetupWithDump(ccs, *session)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "setup of %d constraints written under %s\n", ccs.GetNbConstraints(), *session)
+	return nil
+}
+
+// gnark prove -session prefix -witness file.json [-proof file] [-stream [-memory bytes]]
+func runProve(args []string, w io.Writer) error {
+	fs := newFlagSet("prove", w)
+	session := fs.String("session", "", "prefix of the files written by the setup command")
+	witnessFile := fs.String("witness", "", "JSON file of the full witness")
+	proofFile := fs.String("proof", "", "output file of the proof (default <session>.proof)")
+	stream := fs.Bool("stream", false, "stream the bases of the proving key from the session instead of loading them (see groth16.ProveStream)")
+	memory := fs.Uint64("memory", 1<<30, "with -stream, bound in bytes of the memory holding the proving key (see backend.WithMemoryBudget)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	curveID, err := sessionCurve(*session)
+	if err != nil {
+		return err
+	}
+	if *proofFile == "" {
+		*proofFile = *session + ".proof"
+	}
+
+	ccs, err := groth16.LoadR1CSFromFile(curveID, *session)
+	if err != nil {
+		return err
+	}
+	fullWitness, err := readWitness(*witnessFile, ccs)
+	if err != nil {
+		return err
+	}
+
+	var proof groth16.Proof
+	if *stream {
+		// the other segments are streamed from the session
+		pkE, err := groth16.ReadProveKeyE(curveID, *session)
+		if err != nil {
+			return err
+		}
+		proof, err = groth16.ProveStream(ccs, pkE, fullWitness, *session, backend.WithMemoryBudget(*memory))
+		if err != nil {
+			return err
+		}
+	} else {
+		pks, err := groth16.ReadSegmentProveKey(curveID, *session)
+		if err != nil {
+			return err
+		}
+		if proof, err = groth16.ProveRoll(ccs, pks[0], pks[1], fullWitness, *session); err != nil {
+			return err
+		}
+	}
+	if err := writeFile(*proofFile, w, writeTo(proof)); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "proof written to %s\n", *proofFile)
+	return nil
+}
+
+// gnark verify -session prefix -witness file.json [-proof file]
+func runVerify(args []string, w io.Writer) error {
+	fs := newFlagSet("verify", w)
+	session := fs.String("session", "", "prefix of the files written by the setup command")
+	witnessFile := fs.String("witness", "", "JSON file of the public witness, the secret variables are ignored if present")
+	proofFile := fs.String("proof", "", "file of the proof (default <session>.proof)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	curveID, err := sessionCurve(*session)
+	if err != nil {
+		return err
+	}
+	if *proofFile == "" {
+		*proofFile = *session + ".proof"
+	}
+
+	// the schema of the witness is read from the constraint system
+	ccs, err := groth16.LoadR1CSFromFile(curveID, *session)
+	if err != nil {
+		return err
+	}
+	assignment, err := readWitness(*witnessFile, ccs)
+	if err != nil {
+		return err
+	}
+	publicWitness, err := assignment.Public()
+	if err != nil {
+		return err
+	}
+	vk, err := readVerifyingKey(*session, curveID)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(*proofFile)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	proof := groth16.NewProof(curveID)
+	if _, err := proof.ReadFrom(file); err != nil {
+		return fmt.Errorf("%s: %w", *proofFile, err)
+	}
+
+	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
+		return err
+	}
+	fmt.Fprintln(w, "proof verified")
+	return nil
+}
+
+// gnark export-solidity -session prefix [-o file]
+func runExportSolidity(args []string, w io.Writer) error {
+	fs := newFlagSet("export-solidity", w)
+	session := fs.String("session", "", "prefix of the files written by the setup command")
+	out := fs.String("o", "", "output file of the contract (default stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	curveID, err := sessionCurve(*session)
+	if err != nil {
+		return err
+	}
+	vk, err := readVerifyingKey(*session, curveID)
+	if err != nil {
+		return err
+	}
+	return writeFile(*out, w, vk.ExportSolidity)
+}
+
+// gnark inspect (-circuit name | -ccs file | -session prefix) [-curve bn254]
+func runInspect(args []string, w io.Writer) error {
+	fs := newFlagSet("inspect", w)
+	var f ccsFlags
+	f.register(fs)
+	session := fs.String("session", "", "prefix of the files written by the setup command, instead of -circuit or -ccs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var ccs frontend.CompiledConstraintSystem
+	if *session != "" {
+		if f.circuit != "" || f.ccs != "" {
+			return errors.New("-session is exclusive with -circuit and -ccs")
+		}
+		curveID, err := sessionCurve(*session)
+		if err != nil {
+			return err
+		}
+		if err := groth16.VerifyDump(curveID, *session); err != nil {
+			return err
+		}
+		if ccs, err = groth16.LoadR1CSFromFile(curveID, *session); err != nil {
+			return err
+		}
+	} else {
+		var err error
+		if ccs, err = f.load(); err != nil {
+			return err
+		}
+	}
+
+	internal, secret, public := ccs.GetNbVariables()
+	fmt.Fprintf(w, "curve:       %s\n", ccs.CurveID())
+	fmt.Fprintf(w, "constraints: %d\n", ccs.GetNbConstraints())
+	fmt.Fprintf(w, "variables:   %d public (including the constant 1), %d secret, %d internal\n", public, secret, internal)
+	if s := ccs.GetSchema(); s != nil {
+		fmt.Fprintln(w, "schema:")
+		return s.WriteSequence(w)
+	}
+	return nil
+}
+
+// gnark bench -circuit name -witness file.json [-curve bn254] [-count n]
+func runBench(args []string, w io.Writer) error {
+	fs := newFlagSet("bench", w)
+	var f ccsFlags
+	fs.StringVar(&f.circuit, "circuit", "", "name of the registered circuit to benchmark")
+	fs.StringVar(&f.curve, "curve", ecc.BN254.String(), "curve of the constraint system")
+	witnessFile := fs.String("witness", "", "JSON file of the full witness")
+	count := fs.Int("count", 1, "number of runs of each step")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if f.circuit == "" {
+		return errors.New("-circuit is required")
+	}
+	if *count < 1 {
+		return errors.New("-count must be positive")
+	}
+
+	var (
+		ccs           frontend.CompiledConstraintSystem
+		pk            groth16.ProvingKey
+		vk            groth16.VerifyingKey
+		proof         groth16.Proof
+		compileTime   time.Duration
+		setupTime     time.Duration
+		proveTime     time.Duration
+		verifyTime    time.Duration
+		fullWitness   *witness.Witness
+		publicWitness *witness.Witness
+	)
+	for i := 0; i < *count; i++ {
+		start := time.Now()
+		var err error
+		if ccs, err = f.load(); err != nil {
+			return err
+		}
+		compileTime += time.Since(start)
+
+		if fullWitness == nil {
+			if fullWitness, err = readWitness(*witnessFile, ccs); err != nil {
+				return err
+			}
+			if publicWitness, err = fullWitness.Public(); err != nil {
+				return err
+			}
+		}
+
+		start = time.Now()
+		if pk, vk, err = groth16.Setup(ccs); err != nil {
+			return err
+		}
+		setupTime += time.Since(start)
+
+		start = time.Now()
+		if proof, err = groth16.Prove(ccs, pk, fullWitness); err != nil {
+			return err
+		}
+		proveTime += time.Since(start)
+
+		start = time.Now()
+		if err = groth16.Verify(proof, vk, publicWitness); err != nil {
+			return err
+		}
+		verifyTime += time.Since(start)
+	}
+
+	n := time.Duration(*count)
+	fmt.Fprintf(w, "%s on %s, %d constraints, average of %d runs:\n", f.circuit, ccs.CurveID(), ccs.GetNbConstraints(), *count)
+	fmt.Fprintf(w, "compile: %s\n", compileTime/n)
+	fmt.Fprintf(w, "setup:   %s\n", setupTime/n)
+	fmt.Fprintf(w, "prove:   %s\n", proveTime/n)
+	fmt.Fprintf(w, "verify:  %s\n", verifyTime/n)
+	return nil
+}
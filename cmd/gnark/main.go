@@ -0,0 +1,42 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command gnark compiles, sets up, proves and verifies the circuits of the examples with Groth16.
+//
+// Run gnark -h for the list of commands and circuits. To use the tool with other circuits,
+// register them with the cli package in a copy of this file.
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/consensys/gnark/cmd/gnark/cli"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/consensys/gnark/examples/cubic"
+	"github.com/consensys/gnark/examples/exponentiate"
+	"github.com/consensys/gnark/examples/mimc"
+)
+
+func main() {
+	cli.Register("cubic", func() frontend.Circuit { return &cubic.Circuit{} })
+	cli.Register("exponentiate", func() frontend.Circuit { return &exponentiate.Circuit{} })
+	cli.Register("mimc", func() frontend.Circuit { return &mimc.Circuit{} })
+
+	if err := cli.Run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "gnark:", err)
+		os.Exit(1)
+	}
+}
@@ -103,7 +103,7 @@ func TestE2ESetup(t *testing.T) {
 	}
 	{
 		fmt.Printf("NbCons: %d, NbLazyCons: %d\n", cccs.GetNbConstraints(), len(cccs.LazyCons))
-		err := groth16_bn254.SetupLazyWithDump(cccs, session)
+		err := groth16_bn254.SetupLazyWithDump(cccs, session, backend.SetupConfig{})
 		assert.NoError(err, "setup")
 		fmt.Println("Finished setup", time.Since(mainStart))
 	}
@@ -283,7 +283,7 @@ func TestE2EWhole(t *testing.T) {
 	///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 	// Setup and dump pk, vk
 	{
-		err := groth16_bn254.SetupLazyWithDump(cccs, session)
+		err := groth16_bn254.SetupLazyWithDump(cccs, session, backend.SetupConfig{})
 		assert.NoError(err, "setup")
 		fmt.Println("Finished setup", time.Since(mainStart))
 	}
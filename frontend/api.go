@@ -42,12 +42,15 @@ type API interface {
 	Mul(i1, i2 Variable, in ...Variable) Variable
 
 	// MulModP returns res = i1 * i2 mod i3
+	// i1 * i2 must fit in the native field, see std/math/emulated for arbitrary moduli
 	MulModP(i1, i2, i3 Variable) Variable
 
 	// AddModP returns res = (i1 + i2) mod i3
+	// i1 + i2 must fit in the native field, see std/math/emulated for arbitrary moduli
 	AddModP(i1, i2, i3 Variable) Variable
 
 	// MultiBigMulAndAddGetMod the i1 is the mod, and the in params are the values added
+	// the sum of the products must fit in the native field, see std/math/emulated for arbitrary moduli
 	MultiBigMulAndAddGetMod(i1 Variable, in ...Variable) Variable
 
 	// DivUnchecked returns i1 / i2 . if i1 == i2 == 0, returns 0
@@ -163,4 +166,8 @@ type API interface {
 	AddLazyMimcEnc(s, h, v Variable)
 
 	AddLazyPoseidon(v Variable, s ...Variable)
+
+	// AddLazyGadget calls the gadget registered under name (see RegisterLazyGadget) on inputs
+	// and returns its outputs, recording its constraints as a lazy template
+	AddLazyGadget(name string, inputs ...Variable) []Variable
 }
@@ -125,6 +125,7 @@ type CompileOption func(opt *CompileConfig) error
 type CompileConfig struct {
 	Capacity                  int
 	IgnoreUnconstrainedInputs bool
+	LookupTables              bool
 }
 
 // WithCapacity is a compile option that specifies the estimated capacity needed
@@ -151,6 +152,16 @@ func IgnoreUnconstrainedInputs() CompileOption {
 	}
 }
 
+// WithLookupTables is a compile option which lets builders supporting lookups (see Lookuper)
+// implement ToBinary, Xor, And and AssertIsLessOrEqual with lookups in built-in tables,
+// which is cheaper than the equivalent arithmetic constraints. Other builders ignore it.
+func WithLookupTables() CompileOption {
+	return func(opt *CompileConfig) error {
+		opt.LookupTables = true
+		return nil
+	}
+}
+
 var tVariable reflect.Type
 
 func init() {
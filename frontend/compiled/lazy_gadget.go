@@ -0,0 +1,326 @@
+package compiled
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"sort"
+
+	"github.com/consensys/gnark/frontend/schema"
+)
+
+// LazyWires maps the wires of a lazy gadget call to the wires of its template: wires created by
+// the gadget are stored relative to Start and wires coming from outside of the gadget are stored
+// as virtual wires indexing Inputs.
+type LazyWires struct {
+	// Inputs are the wires referenced by the gadget which were created outside of it,
+	// in order of first appearance
+	Inputs LinearExpression
+
+	// Start is the id of the first wire created by the gadget, NbWires the number of such wires
+	Start, NbWires int
+}
+
+// normalizeTerm returns the template term of t, recording t in w.Inputs if it is a new input
+func (w *LazyWires) normalizeTerm(t Term, inputs map[Term]int) Term {
+	cID, vID, visibility := t.Unpack()
+	if visibility == schema.Unset {
+		// zero term of a sparse constraint
+		return t
+	}
+	if visibility == schema.Internal && vID >= w.Start && vID < w.Start+w.NbWires {
+		return Pack(vID-w.Start, cID, schema.Internal)
+	}
+
+	input := Pack(vID, CoeffIdOne, visibility)
+	k, ok := inputs[input]
+	if !ok {
+		k = len(w.Inputs)
+		inputs[input] = k
+		w.Inputs = append(w.Inputs, input)
+	}
+	return Pack(k, cID, schema.Virtual)
+}
+
+// instantiateTerm maps the template term t to the wires of w
+func (w *LazyWires) instantiateTerm(t Term) Term {
+	cID, vID, visibility := t.Unpack()
+	switch visibility {
+	case schema.Virtual:
+		res := w.Inputs[vID]
+		res.SetCoeffID(cID)
+		return res
+	case schema.Internal:
+		return Pack(w.Start+vID, cID, schema.Internal)
+	default:
+		return t
+	}
+}
+
+// inputsIndex returns the reverse mapping of w.Inputs
+func (w *LazyWires) inputsIndex() map[Term]int {
+	inputs := make(map[Term]int, len(w.Inputs))
+	for k, t := range w.Inputs {
+		inputs[t] = k
+	}
+	return inputs
+}
+
+// checkInputs returns an error if normalizing captured constraints recorded inputs
+// which were not present when the call was captured
+func (w *LazyWires) checkInputs(key string, nbInputs int) error {
+	if len(w.Inputs) != nbInputs {
+		w.Inputs = w.Inputs[:nbInputs]
+		return fmt.Errorf("lazy gadget %s: captured constraints reference unknown inputs", key)
+	}
+	return nil
+}
+
+func writeUint64(h hash.Hash, v uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	h.Write(buf[:])
+}
+
+func lazyGadgetKey(name string, h hash.Hash) string {
+	return "gadget-" + name + "-" + hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// LazyGadgetInputs is the LazyInputs of a gadget registered with frontend.RegisterLazyGadget.
+//
+// The static R1C template of a gadget is generated from the constraints it emitted the first
+// time it was called with a given shape, see LazyWires. Each call then only keeps its Inputs
+// and Start, and FetchLazy rebuilds its constraints from the template.
+type LazyGadgetInputs struct {
+	// Key identifies the template, it is made of the gadget name and of a digest of
+	// the shape of the captured constraints
+	Key string
+
+	LazyWires
+
+	Loc           int
+	NbConstraints int
+}
+
+// NewLazyGadgetInputs captures the constraints emitted by the gadget name, that is constraints[loc:],
+// which created the wires [start, start+nbWires)
+func NewLazyGadgetInputs(name string, constraints []R1C, loc, start, nbWires int) *LazyGadgetInputs {
+	le := &LazyGadgetInputs{
+		LazyWires: LazyWires{
+			Inputs:  make(LinearExpression, 0),
+			Start:   start,
+			NbWires: nbWires,
+		},
+		Loc:           loc,
+		NbConstraints: len(constraints) - loc,
+	}
+
+	inputs := make(map[Term]int)
+	h := sha256.New()
+	for _, r1c := range constraints[loc:] {
+		for _, l := range []LinearExpression{r1c.L, r1c.R, r1c.O} {
+			writeUint64(h, uint64(len(l)))
+			for _, t := range le.normalize(l, inputs) {
+				writeUint64(h, uint64(t))
+			}
+		}
+	}
+	le.Key = lazyGadgetKey(name, h)
+
+	return le
+}
+
+// template returns the normalized version of the constraints captured for le
+func (le *LazyGadgetInputs) template(constraints []R1C) ([]R1C, error) {
+	inputs := le.inputsIndex()
+	nbInputs := len(le.Inputs)
+
+	res := make([]R1C, len(constraints))
+	for i, r1c := range constraints {
+		res[i] = R1C{
+			L: le.normalize(r1c.L, inputs),
+			R: le.normalize(r1c.R, inputs),
+			O: le.normalize(r1c.O, inputs),
+		}
+	}
+	if err := le.checkInputs(le.Key, nbInputs); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// normalize returns the template of l, sorted so that calls whose inputs are ordered
+// differently in the linear expressions still share the same template
+func (le *LazyGadgetInputs) normalize(l LinearExpression, inputs map[Term]int) LinearExpression {
+	res := make(LinearExpression, len(l))
+	for i, t := range l {
+		res[i] = le.normalizeTerm(t, inputs)
+	}
+	sort.Sort(res)
+	return res
+}
+
+// instantiate maps the template linear expression l to the wires of le, sorted again
+// so that it is identical to the linear expression captured from the call
+func (le *LazyGadgetInputs) instantiate(l LinearExpression) LinearExpression {
+	res := make(LinearExpression, len(l))
+	for i, t := range l {
+		res[i] = le.instantiateTerm(t)
+	}
+	sort.Sort(res)
+	return res
+}
+
+func (le *LazyGadgetInputs) GetConstraintsNum() int {
+	return le.NbConstraints
+}
+
+func (le *LazyGadgetInputs) GetLoc() int {
+	return le.Loc
+}
+
+// FetchLazy returns the j-th constraint of the gadget with its actual wires,
+// so that all linear expressions are reported as inputs and are not shifted again
+func (le *LazyGadgetInputs) FetchLazy(j int, r1cs *R1CS, coefs CoeffTable) R1C {
+	r1c := r1cs.LazyConsStaticR1CMap[le.GetType(coefs)][j]
+	return R1C{
+		L: le.instantiate(r1c.L),
+		R: le.instantiate(r1c.R),
+		O: le.instantiate(r1c.O),
+	}
+}
+
+func (le *LazyGadgetInputs) GetType(coefs CoeffTable) string {
+	return le.Key
+}
+
+func (le *LazyGadgetInputs) SetConsStaticR1CMapIfNotExists(r1cs *R1CS, table CoeffTable) error {
+	if _, ok := r1cs.LazyConsStaticR1CMap[le.GetType(table)]; !ok {
+		staticR1c, err := le.template(r1cs.Constraints[le.Loc : le.Loc+le.NbConstraints])
+		if err != nil {
+			return err
+		}
+		r1cs.LazyConsOriginInputMap[le.GetType(table)] = le
+		r1cs.LazyConsStaticR1CMap[le.GetType(table)] = staticR1c
+	}
+	return nil
+}
+
+func (le *LazyGadgetInputs) GetInitialIndex() int {
+	return le.Start
+}
+
+func (le *LazyGadgetInputs) GetShift(r1cs *R1CS, table CoeffTable) int {
+	return le.Start - r1cs.LazyConsOriginInputMap[le.GetType(table)].(*LazyGadgetInputs).Start
+}
+
+func (le *LazyGadgetInputs) IsInput(j int, loc uint8) bool {
+	return true
+}
+
+// LazySparseGadgetInputs is the LazySparseInputs of a gadget registered with frontend.RegisterLazyGadget,
+// it is the SparseR1C counterpart of LazyGadgetInputs
+type LazySparseGadgetInputs struct {
+	// Key identifies the template, it is made of the gadget name and of a digest of
+	// the shape of the captured constraints
+	Key string
+
+	LazyWires
+
+	Loc           int
+	NbConstraints int
+}
+
+// NewLazySparseGadgetInputs captures the constraints emitted by the gadget name, that is constraints[loc:],
+// which created the wires [start, start+nbWires)
+func NewLazySparseGadgetInputs(name string, constraints []SparseR1C, loc, start, nbWires int) *LazySparseGadgetInputs {
+	le := &LazySparseGadgetInputs{
+		LazyWires: LazyWires{
+			Inputs:  make(LinearExpression, 0),
+			Start:   start,
+			NbWires: nbWires,
+		},
+		Loc:           loc,
+		NbConstraints: len(constraints) - loc,
+	}
+
+	inputs := make(map[Term]int)
+	h := sha256.New()
+	for _, c := range constraints[loc:] {
+		c = le.normalize(c, inputs)
+		for _, t := range []Term{c.L, c.R, c.O, c.M[0], c.M[1]} {
+			writeUint64(h, uint64(t))
+		}
+		writeUint64(h, uint64(c.K))
+		if c.IsLookup() {
+			writeUint64(h, uint64(c.Table))
+		}
+	}
+	le.Key = lazyGadgetKey(name, h)
+
+	return le
+}
+
+func (le *LazySparseGadgetInputs) normalize(c SparseR1C, inputs map[Term]int) SparseR1C {
+	return SparseR1C{
+		L:     le.normalizeTerm(c.L, inputs),
+		R:     le.normalizeTerm(c.R, inputs),
+		O:     le.normalizeTerm(c.O, inputs),
+		M:     [2]Term{le.normalizeTerm(c.M[0], inputs), le.normalizeTerm(c.M[1], inputs)},
+		K:     c.K,
+		Table: c.Table,
+	}
+}
+
+// template returns the normalized version of the constraints captured for le
+func (le *LazySparseGadgetInputs) template(constraints []SparseR1C) ([]SparseR1C, error) {
+	inputs := le.inputsIndex()
+	nbInputs := len(le.Inputs)
+
+	res := make([]SparseR1C, len(constraints))
+	for i, c := range constraints {
+		res[i] = le.normalize(c, inputs)
+	}
+	if err := le.checkInputs(le.Key, nbInputs); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (le *LazySparseGadgetInputs) GetConstraintsNum() int {
+	return le.NbConstraints
+}
+
+func (le *LazySparseGadgetInputs) GetLoc() int {
+	return le.Loc
+}
+
+func (le *LazySparseGadgetInputs) GetType() string {
+	return le.Key
+}
+
+// FetchLazy returns the j-th constraint of the gadget with its actual wires
+func (le *LazySparseGadgetInputs) FetchLazy(j int, cs *SparseR1CS) SparseR1C {
+	c := cs.LazyConsStaticR1CMap[le.GetType()][j]
+	return SparseR1C{
+		L:     le.instantiateTerm(c.L),
+		R:     le.instantiateTerm(c.R),
+		O:     le.instantiateTerm(c.O),
+		M:     [2]Term{le.instantiateTerm(c.M[0]), le.instantiateTerm(c.M[1])},
+		K:     c.K,
+		Table: c.Table,
+	}
+}
+
+func (le *LazySparseGadgetInputs) SetConsStaticR1CMapIfNotExists(cs *SparseR1CS) error {
+	if _, ok := cs.LazyConsStaticR1CMap[le.GetType()]; !ok {
+		staticR1c, err := le.template(cs.Constraints[le.Loc : le.Loc+le.NbConstraints])
+		if err != nil {
+			return err
+		}
+		cs.LazyConsStaticR1CMap[le.GetType()] = staticR1c
+	}
+	return nil
+}
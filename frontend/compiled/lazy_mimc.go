@@ -1,15 +1,73 @@
 package compiled
 
 import (
+	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/mimc"
+	bls12381 "github.com/consensys/gnark-crypto/ecc/bls12-381/fr/mimc"
+	bls24315 "github.com/consensys/gnark-crypto/ecc/bls24-315/fr/mimc"
 	bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	bw6633 "github.com/consensys/gnark-crypto/ecc/bw6-633/fr/mimc"
+	bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/schema"
 	"math/big"
-	"os"
-	"sort"
 )
 
+// MimcRound is a round function of the MiMC encryption
+type MimcRound uint8
+
+const (
+	// MimcPow5 is the round x ↦ x⁵, constrained by a·a = r, r·r = r², r²·a = x
+	MimcPow5 MimcRound = iota
+	// MimcInverse is the round x ↦ x⁻¹, constrained by x·a = 1
+	MimcInverse
+)
+
+// NbConstraints returns the number of constraints, and of wires, of a round
+func (r MimcRound) NbConstraints() int {
+	if r == MimcInverse {
+		return 1
+	}
+	return 3
+}
+
+// MimcRounds maps a curve to the round function of its MiMC encryption. std/hash/mimc
+// encrypts with the round function of this table, so that the lazy expansion of an
+// encryption constrains the function it was recorded from.
+var MimcRounds map[ecc.ID]MimcRound
+
+// mimcConstants maps a curve to the round constants of its MiMC encryption
+var mimcConstants map[ecc.ID][]big.Int
+
+func init() {
+	MimcRounds = make(map[ecc.ID]MimcRound)
+	MimcRounds[ecc.BN254] = MimcPow5
+	MimcRounds[ecc.BLS12_381] = MimcPow5
+	MimcRounds[ecc.BLS12_377] = MimcInverse
+	MimcRounds[ecc.BW6_761] = MimcPow5
+	MimcRounds[ecc.BW6_633] = MimcPow5
+	MimcRounds[ecc.BLS24_315] = MimcPow5
+
+	mimcConstants = make(map[ecc.ID][]big.Int)
+	mimcConstants[ecc.BN254] = bn254.GetConstants()
+	mimcConstants[ecc.BLS12_381] = bls12381.GetConstants()
+	mimcConstants[ecc.BLS12_377] = bls12377.GetConstants()
+	mimcConstants[ecc.BW6_761] = bw6761.GetConstants()
+	mimcConstants[ecc.BW6_633] = bw6633.GetConstants()
+	mimcConstants[ecc.BLS24_315] = bls24315.GetConstants()
+}
+
+// MimcNbRounds returns the number of rounds of a MiMC encryption on curveID
+func MimcNbRounds(curveID ecc.ID) int {
+	return len(mimcConstants[curveID])
+}
+
+// MimcEncConstraintsNum returns the number of constraints, and of wires, of a MiMC encryption on curveID
+func MimcEncConstraintsNum(curveID ecc.ID) int {
+	return MimcRounds[curveID].NbConstraints() * MimcNbRounds(curveID)
+}
+
 type LazyR1CS []LazyInputs
 
 func (l *LazyR1CS) GetConstraintsAll() int {
@@ -20,9 +78,13 @@ func (l *LazyR1CS) GetConstraintsAll() int {
 	return sum
 }
 
+// LazyMimcEncInputs records a MiMC encryption of S0 with the key HH, V being the wire following
+// the ones created by its rounds
 type LazyMimcEncInputs struct {
 	S0, HH, V LinearExpression
 	Loc       int
+	Round     MimcRound
+	NbRounds  int
 }
 
 type CoeffTable interface {
@@ -48,123 +110,80 @@ type LazyIndexedInputs struct {
 }
 
 func (le *LazyMimcEncInputs) GetConstraintsNum() int {
-	return 273
+	return le.Round.NbConstraints() * le.NbRounds
 }
 
 func (le *LazyMimcEncInputs) GetInitialIndex() int {
 	return le.V[0].WireID() - le.GetConstraintsNum()
 }
 
-func (le *LazyMimcEncInputs) IsInput(j int, loc uint8) bool {
-	if (j == 0) && loc == 1 {
-		return true
+// isAddRes returns whether the linear expression loc of the j-th constraint is the input
+// x + HH + c of its round, which is rebuilt by FetchLazy from the wires of the call
+func (le *LazyMimcEncInputs) isAddRes(j int, loc uint8) bool {
+	if le.Round == MimcInverse {
+		return loc == 2
 	}
-	// s0, s1
-	if (j == 0) && loc == 2 {
-		return true
+	switch j % 3 {
+	case 0:
+		return loc == 1 || loc == 2
+	case 2:
+		return loc == 2
 	}
-	// s0, s1
-	if (j == 2) && loc == 2 {
-		return true
-	}
-
 	return false
 }
 
-func getNewAddRes(S0, HH frontend.Variable, Coefs CoeffTable) LinearExpression {
-	// modify line 0 and 2
-	// update internal variable idx with shift V.idx - 272
-	params := bn254.GetConstants()
-	//shift := V.(.LinearExpression)[0].WireID() - V0.(.LinearExpression)[0].WireID()
-	//res := r1c
-
-	// start of wid
-	x := S0.(LinearExpression)
-	zero := LinearExpression{Pack(0, CoeffIdZero, schema.Public)}
-	addRes := make(LinearExpression, 0)
-	{
-		vars := make([]LinearExpression, 0, 3)
-		vars = append(vars, x)
-
-		HHL := HH.(LinearExpression)
-		vars = append(vars, HHL)
-
-		one := LinearExpression{Pack(0, CoeffIdOne, schema.Public)}
-		cID := Coefs.GetCoeffID(&params[0])
-		if cID == -1 {
-			os.Exit(-1)
-		}
-		one[0].SetCoeffID(cID) //TODO
-		vars = append(vars, one)
-
-		for _, v := range vars {
-			if v.Equal(zero) {
-				continue
-			}
-			addRes = append(addRes, v...)
-		}
-		if !sort.IsSorted(addRes) {
-			sort.Sort(addRes)
-		}
-		mod := ecc.BN254.Info().Fr.Modulus()
-		c := new(big.Int)
-		for i := 1; i < len(addRes); i++ {
-			pcID, pvID, pVis := addRes[i-1].Unpack()
-			rcID, rvID, rVis := addRes[i].Unpack()
-			if pVis == rVis && pvID == rvID {
-				// we have redundancy
-				c.Add(Coefs.GetCoeffsById(pcID), Coefs.GetCoeffsById(rcID))
-				c.Mod(c, mod)
-				ccID := Coefs.GetCoeffID(c)
-				addRes[i-1].SetCoeffID(ccID)
-				addRes = append(addRes[:i], addRes[i+1:]...)
-				i--
-			}
-		}
-	}
-
-	return addRes
+func (le *LazyMimcEncInputs) IsInput(j int, loc uint8) bool {
+	return le.isAddRes(j, loc)
 }
 
-func fetchMimcLazy(S0, HH frontend.Variable, staticR1c []R1C, j int, Coefs CoeffTable) R1C {
-	if j == 0 {
-		addRes := getNewAddRes(S0, HH, Coefs)
-		resL := addRes.Clone()
-		resR := addRes.Clone()
-		resO := staticR1c[0].O.Clone()
-		return R1C{
-			L: resL,
-			R: resR,
-			O: resO,
-		}
+// addRes returns x + HH + c, reduced as by the builder. It panics if c isn't in coefs, the
+// round constants being added to the coefficient table when the encryption is compiled
+func (le *LazyMimcEncInputs) addRes(x LinearExpression, c *big.Int, coefs CoeffTable, curveID ecc.ID) LinearExpression {
+	zero := LinearExpression{Pack(0, CoeffIdZero, schema.Public)}
+	cst := LinearExpression{Pack(0, CoeffIdOne, schema.Public)}
+	cID := coefs.GetCoeffID(c)
+	if cID == -1 {
+		panic(fmt.Sprintf("mimc round constant %s isn't in the coefficient table", c.String()))
 	}
+	cst[0].SetCoeffID(cID)
 
-	if j == 2 {
-		addRes := getNewAddRes(S0, HH, Coefs)
-		resL := staticR1c[2].L.Clone()
-		resR := addRes.Clone()
-		resO := staticR1c[2].O.Clone()
-		return R1C{
-			L: resL,
-			R: resR,
-			O: resO,
+	res := make(LinearExpression, 0, len(x)+len(le.HH)+1)
+	for _, v := range []LinearExpression{x, le.HH, cst} {
+		if v.Equal(zero) {
+			continue
 		}
-
+		res = append(res, v...)
 	}
+	return reduce(res, coefs, curveID)
+}
 
-	resL := staticR1c[j].L.Clone()
-	resR := staticR1c[j].R.Clone()
-	resO := staticR1c[j].O.Clone()
-
-	return R1C{
-		L: resL,
-		R: resR,
-		O: resO,
+// roundInput returns x + HH + c of the i-th round of le, x being S0 or the output of the previous round
+func (le *LazyMimcEncInputs) roundInput(i int, coefs CoeffTable, curveID ecc.ID) LinearExpression {
+	x := le.S0.Clone()
+	if i > 0 {
+		x = le.V.Clone()
+		x[0].SetWireID(le.GetInitialIndex() + i*le.Round.NbConstraints() - 1)
 	}
+	return le.addRes(x, &mimcConstants[curveID][i], coefs, curveID)
 }
 
 func (le *LazyMimcEncInputs) FetchLazy(j int, r1cs *R1CS, coefs CoeffTable) R1C {
-	return fetchMimcLazy(le.S0, le.HH, r1cs.LazyConsStaticR1CMap[le.GetType(coefs)], j, coefs)
+	staticR1c := r1cs.LazyConsStaticR1CMap[le.GetType(coefs)]
+	res := R1C{
+		L: staticR1c[j].L.Clone(),
+		R: staticR1c[j].R.Clone(),
+		O: staticR1c[j].O.Clone(),
+	}
+	if le.isAddRes(j, 1) || le.isAddRes(j, 2) {
+		addRes := le.roundInput(j/le.Round.NbConstraints(), coefs, r1cs.CurveID)
+		if le.isAddRes(j, 1) {
+			res.L = addRes.Clone()
+		}
+		if le.isAddRes(j, 2) {
+			res.R = addRes
+		}
+	}
+	return res
 }
 
 func (le *LazyMimcEncInputs) GetLoc() int {
@@ -175,95 +194,53 @@ func (le *LazyMimcEncInputs) GetType(coefs CoeffTable) string {
 	return "mimc-enc"
 }
 
-// StaticEncrypt static expanding constraints
-// should use HH=0 to init
-func staticEncrypt(S0, HH, V frontend.Variable, Coefs CoeffTable) []R1C {
-	params := bn254.GetConstants()
-	res := make([]R1C, 0)
-
-	// start of wid
-	wid := V.(LinearExpression)[0].WireID() - 272
-	x := S0.(LinearExpression)
-	for i := 0; i < len(params); i++ {
-		// x = h.api.Add(x, HH, h.params[i]).(LinearExpression)
-		zero := LinearExpression{Pack(0, CoeffIdZero, schema.Public)}
-		addRes := make(LinearExpression, 0)
-		{
-			vars := make([]LinearExpression, 0, 3)
-			vars = append(vars, x)
-
-			HHL := HH.(LinearExpression)
-			vars = append(vars, HHL)
-
-			one := LinearExpression{Pack(0, CoeffIdOne, schema.Public)}
-			cID := Coefs.GetCoeffID(&params[i])
-			if cID == -1 {
-				os.Exit(-1)
-			}
-			one[0].SetCoeffID(cID) //TODO
-			vars = append(vars, one)
-
-			for _, v := range vars {
-				if v.Equal(zero) {
-					continue
-				}
-				addRes = append(addRes, v...)
-			}
-			if !sort.IsSorted(addRes) {
-				sort.Sort(addRes)
-			}
-			// // TODO Reduce just check 0?
-			mod := ecc.BN254.Info().Fr.Modulus()
-			c := new(big.Int)
-			for i := 1; i < len(addRes); i++ {
-				pcID, pvID, pVis := addRes[i-1].Unpack()
-				ccID, cvID, cVis := addRes[i].Unpack()
-				if pVis == cVis && pvID == cvID {
-					// we have redundancy
-					c.Add(Coefs.GetCoeffsById(pcID), Coefs.GetCoeffsById(ccID))
-					c.Mod(c, mod)
-					ccID := Coefs.GetCoeffID(c)
-					addRes[i-1].SetCoeffID(ccID)
-					addRes = append(addRes[:i], addRes[i+1:]...)
-					i--
-				}
-			}
-		}
-
-		// x = pow5(h.api, addRes)
-		// // r = h.api.Mul(addRes, addRes)
-		// // r = h.api.Mul(r, r)
-		// // x = h.api.Mul(r, addRes)
-		r := V.(LinearExpression).Clone()
+// staticEncrypt expands the constraints of the encryption recorded by le, with the round
+// function of its curve:
+//
+//	x⁵:  a·a = r, r·r = r², r²·a = x
+//	x⁻¹: x·a = 1
+//
+// a being x + HH + c, see std/hash/mimc
+func staticEncrypt(le *LazyMimcEncInputs, coefs CoeffTable, curveID ecc.ID) []R1C {
+	res := make([]R1C, 0, le.GetConstraintsNum())
+	one := LinearExpression{Pack(0, CoeffIdOne, schema.Public)}
+
+	wid := le.GetInitialIndex()
+	wire := func() LinearExpression {
+		r := le.V.Clone()
 		r[0].SetWireID(wid)
-		res = append(res, R1C{
-			L: addRes,
-			R: addRes,
-			O: r})
-		r2 := r.Clone()
-		r2[0].SetWireID(wid + 1)
-		res = append(res, R1C{
-			L: r,
-			R: r,
-			O: r2})
-		r3 := r2.Clone()
-		r3[0].SetWireID(wid + 2)
-		res = append(res, R1C{
-			L: r2,
-			R: addRes,
-			O: r3})
-
-		wid += 3
-		x = r3.Clone() //TODO
+		wid++
+		return r
+	}
+	for i := 0; i < le.NbRounds; i++ {
+		addRes := le.roundInput(i, coefs, curveID)
+		switch le.Round {
+		case MimcInverse:
+			x := wire()
+			res = append(res, R1C{L: x, R: addRes, O: one.Clone()})
+		default:
+			r := wire()
+			res = append(res, R1C{L: addRes.Clone(), R: addRes.Clone(), O: r})
+			r2 := wire()
+			res = append(res, R1C{L: r.Clone(), R: r.Clone(), O: r2})
+			x := wire()
+			res = append(res, R1C{L: r2.Clone(), R: addRes, O: x})
+		}
 	}
 
 	return res
 }
 
 func (le *LazyMimcEncInputs) SetConsStaticR1CMapIfNotExists(r1cs *R1CS, table CoeffTable) error {
+	if _, ok := mimcConstants[r1cs.CurveID]; !ok {
+		return fmt.Errorf("lazy mimc: unsupported curve %s", r1cs.CurveID)
+	}
+	if le.Round != MimcRounds[r1cs.CurveID] || le.NbRounds != len(mimcConstants[r1cs.CurveID]) {
+		return fmt.Errorf("lazy mimc: encryption recorded with another round function than the one of %s", r1cs.CurveID)
+	}
 	if _, ok := r1cs.LazyConsStaticR1CMap[le.GetType(table)]; !ok {
 		r1cs.LazyConsOriginInputMap[le.GetType(table)] = le
-		r1cs.LazyConsStaticR1CMap[le.GetType(table)] = staticEncrypt(le.S0, le.HH, le.V, table)
+		r1cs.LazyConsStaticR1CMap[le.GetType(table)] = staticEncrypt(le, table, r1cs.CurveID)
 	}
 	return nil
 }
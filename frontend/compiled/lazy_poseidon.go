@@ -17,7 +17,7 @@ type LazyPoseidonInputs struct {
 	Loc int
 }
 
-func FetchLazyConstraint(S []LinearExpression, staticR1c []R1C, j int, Coefs CoeffTable) R1C {
+func FetchLazyConstraint(S []LinearExpression, staticR1c []R1C, j int, Coefs CoeffTable, curveID ecc.ID) R1C {
 	// s0 + v, s0 + v, s1 0
 	// s1, s1, s2 1
 	// s0 + v, s2, s3 2
@@ -45,7 +45,7 @@ func FetchLazyConstraint(S []LinearExpression, staticR1c []R1C, j int, Coefs Coe
 			if !sort.IsSorted(addRes) {
 				sort.Sort(addRes)
 			}
-			addRes = reduce(addRes, Coefs)
+			addRes = reduce(addRes, Coefs, curveID)
 			resL := addRes.Clone()
 			resR := addRes.Clone()
 			resO := staticR1c[i*3].O.Clone()
@@ -78,7 +78,7 @@ func FetchLazyConstraint(S []LinearExpression, staticR1c []R1C, j int, Coefs Coe
 			if !sort.IsSorted(addRes) {
 				sort.Sort(addRes)
 			}
-			addRes = reduce(addRes, Coefs)
+			addRes = reduce(addRes, Coefs, curveID)
 			resL := staticR1c[i*3+2].L.Clone()
 			resR := addRes.Clone()
 			resO := staticR1c[i*3+2].O.Clone()
@@ -115,7 +115,7 @@ func GetConstraintsNumLinear(variables []LinearExpression) int {
 	return ConstraintsMap[len(variables)-3]
 }
 
-func StaticPoseidonR1CS(v frontend.Variable, Coefs CoeffTable, data ...LinearExpression) []R1C {
+func StaticPoseidonR1CS(v frontend.Variable, Coefs CoeffTable, curveID ecc.ID, data ...LinearExpression) []R1C {
 	t := len(data)
 	if t < 3 || t > 13 {
 		panic("Not supported input size")
@@ -123,18 +123,18 @@ func StaticPoseidonR1CS(v frontend.Variable, Coefs CoeffTable, data ...LinearExp
 	state := make([]LinearExpression, t)
 	copy(state[:], data)
 
-	return StaticPermutation(v, state, Coefs)
+	return StaticPermutation(v, state, Coefs, curveID)
 }
 
-func StaticPermutation(V frontend.Variable, state []LinearExpression, Coefs CoeffTable) []R1C {
+func StaticPermutation(V frontend.Variable, state []LinearExpression, Coefs CoeffTable, curveID ecc.ID) []R1C {
 	roundCounter := 0
 	stateCopy := make([]LinearExpression, len(state))
 	for i := 0; i < len(stateCopy); i++ {
 		stateCopy[i] = state[i].Clone()
 	}
-	stateCopy, r1csFullRound1, wid := StaticFullRound(stateCopy, V, Coefs, &roundCounter, V.(LinearExpression)[0].WireID()-GetConstraintsNumLinear(state))
-	stateCopy, r1csPartial1, wid := StaticPartial(stateCopy, V, Coefs, &roundCounter, wid)
-	_, r1csFullRound2, _ := StaticFullRound(stateCopy, V, Coefs, &roundCounter, wid)
+	stateCopy, r1csFullRound1, wid := StaticFullRound(stateCopy, V, Coefs, curveID, &roundCounter, V.(LinearExpression)[0].WireID()-GetConstraintsNumLinear(state))
+	stateCopy, r1csPartial1, wid := StaticPartial(stateCopy, V, Coefs, curveID, &roundCounter, wid)
+	_, r1csFullRound2, _ := StaticFullRound(stateCopy, V, Coefs, curveID, &roundCounter, wid)
 
 	resutls := make([]R1C, 0)
 	resutls = append(resutls, r1csFullRound1...)
@@ -143,7 +143,7 @@ func StaticPermutation(V frontend.Variable, state []LinearExpression, Coefs Coef
 	return resutls
 }
 
-func StaticFullRound(state []LinearExpression, V frontend.Variable, Coefs CoeffTable, roundCounter *int, initWid int) ([]LinearExpression, []R1C, int) {
+func StaticFullRound(state []LinearExpression, V frontend.Variable, Coefs CoeffTable, curveID ecc.ID, roundCounter *int, initWid int) ([]LinearExpression, []R1C, int) {
 	width := len(state)
 	index := width - 3
 	wid := initWid
@@ -168,7 +168,7 @@ func StaticFullRound(state []LinearExpression, V frontend.Variable, Coefs CoeffT
 				}
 				state[j] = append(state[j], v...)
 			}
-			state[j] = reduce(state[j], Coefs)
+			state[j] = reduce(state[j], Coefs, curveID)
 			*roundCounter += 1
 
 			// Apply single s-box
@@ -177,7 +177,7 @@ func StaticFullRound(state []LinearExpression, V frontend.Variable, Coefs CoeffT
 			// // r = h.api.Mul(addRes, addRes)
 			// // r = h.api.Mul(r, r)
 			// // x = h.api.Mul(r, addRes)
-			mod := ecc.BN254.Info().Fr.Modulus()
+			mod := curveID.Info().Fr.Modulus()
 			addRes := state[j].Clone()
 			r0, isContant := ConstantValue(state[j], Coefs)
 			if isContant {
@@ -213,14 +213,14 @@ func StaticFullRound(state []LinearExpression, V frontend.Variable, Coefs CoeffT
 		}
 
 		// Apply mix layer
-		stateMix := StaticMixR1C(state, Coefs)
+		stateMix := StaticMixR1C(state, Coefs, curveID)
 		state = stateMix
 	}
 
 	return state, res, wid
 }
 
-func StaticPartial(state []LinearExpression, V frontend.Variable, Coefs CoeffTable, roundCounter *int, wid int) ([]LinearExpression, []R1C, int) {
+func StaticPartial(state []LinearExpression, V frontend.Variable, Coefs CoeffTable, curveID ecc.ID, roundCounter *int, wid int) ([]LinearExpression, []R1C, int) {
 	width := len(state)
 	index := width - 3
 	res := make([]R1C, 0)
@@ -246,7 +246,7 @@ func StaticPartial(state []LinearExpression, V frontend.Variable, Coefs CoeffTab
 				state[j] = append(state[j], v...)
 			}
 
-			state[j] = reduce(state[j], Coefs)
+			state[j] = reduce(state[j], Coefs, curveID)
 
 			*roundCounter += 1
 		}
@@ -257,7 +257,7 @@ func StaticPartial(state []LinearExpression, V frontend.Variable, Coefs CoeffTab
 		// // r = h.api.Mul(addRes, addRes)
 		// // r = h.api.Mul(r, r)
 		// // x = h.api.Mul(r, addRes)
-		mod := ecc.BN254.Info().Fr.Modulus()
+		mod := curveID.Info().Fr.Modulus()
 		addRes := state[0].Clone()
 		r0, isContant := ConstantValue(state[0], Coefs)
 		if isContant {
@@ -292,17 +292,17 @@ func StaticPartial(state []LinearExpression, V frontend.Variable, Coefs CoeffTab
 		}
 
 		// Apply mix layer
-		stateMix := StaticMixR1C(state, Coefs)
+		stateMix := StaticMixR1C(state, Coefs, curveID)
 		state = stateMix
 	}
 	return state, res, wid
 }
 
-func StaticMixR1C(state []LinearExpression, Coefs CoeffTable) []LinearExpression {
+func StaticMixR1C(state []LinearExpression, Coefs CoeffTable, curveID ecc.ID) []LinearExpression {
 	width := len(state)
 	index := width - 3
 	newState := make([]LinearExpression, width)
-	mod := ecc.BN254.Info().Fr.Modulus()
+	mod := curveID.Info().Fr.Modulus()
 
 	for i := 0; i < width; i++ {
 		addRes := make(LinearExpression, 0)
@@ -335,9 +335,9 @@ func StaticMixR1C(state []LinearExpression, Coefs CoeffTable) []LinearExpression
 				}
 				addRes = append(addRes, v...)
 			}
-			addRes = reduce(addRes, Coefs)
+			addRes = reduce(addRes, Coefs, curveID)
 		}
-		newState[i] = reduce(addRes, Coefs)
+		newState[i] = reduce(addRes, Coefs, curveID)
 	}
 	return newState
 }
@@ -368,12 +368,12 @@ func mulConstant(v1 LinearExpression, lambda *big.Int, Coefs CoeffTable) LinearE
 	return res
 }
 
-func reduce(state LinearExpression, Coefs CoeffTable) LinearExpression {
+func reduce(state LinearExpression, Coefs CoeffTable, curveID ecc.ID) LinearExpression {
 	if !sort.IsSorted(state) {
 		sort.Sort(state)
 	}
 	// // TODO Reduce just check 0?
-	mod := ecc.BN254.Info().Fr.Modulus()
+	mod := curveID.Info().Fr.Modulus()
 	c := new(big.Int)
 	for i := 1; i < len(state); i++ {
 		pcID, pvID, pVis := state[i-1].Unpack()
@@ -408,7 +408,7 @@ func (le *LazyPoseidonInputs) GetConstraintsNum() int {
 }
 
 func (le *LazyPoseidonInputs) FetchLazy(j int, r1cs *R1CS, coefs CoeffTable) R1C {
-	return FetchLazyConstraint(le.S, r1cs.LazyConsStaticR1CMap[le.GetType(coefs)], j, coefs)
+	return FetchLazyConstraint(le.S, r1cs.LazyConsStaticR1CMap[le.GetType(coefs)], j, coefs, r1cs.CurveID)
 }
 
 func (le *LazyPoseidonInputs) GetLoc() int {
@@ -430,7 +430,7 @@ func (le *LazyPoseidonInputs) GetType(coefs CoeffTable) string {
 func (le *LazyPoseidonInputs) SetConsStaticR1CMapIfNotExists(r1cs *R1CS, table CoeffTable) error {
 	if _, ok := r1cs.LazyConsStaticR1CMap[le.GetType(table)]; !ok {
 		r1cs.LazyConsOriginInputMap[le.GetType(table)] = le
-		r1cs.LazyConsStaticR1CMap[le.GetType(table)] = StaticPoseidonR1CS(le.V, table, le.S...)
+		r1cs.LazyConsStaticR1CMap[le.GetType(table)] = StaticPoseidonR1CS(le.V, table, r1cs.CurveID, le.S...)
 	}
 	return nil
 }
@@ -15,6 +15,7 @@
 package compiled
 
 import (
+	"fmt"
 	"math/big"
 	"strings"
 )
@@ -22,21 +23,147 @@ import (
 // R1CS decsribes a set of SparseR1C constraint
 type SparseR1CS struct {
 	ConstraintSystem
-	Constraints []SparseR1C
+	Constraints          []SparseR1C
+	LazyCons             LazySparseR1CS
+	LazyConsMap          map[int]LazyIndexedInputs
+	LazyConsStaticR1CMap map[string][]SparseR1C
+
+	// Tables are the lookup tables queried by the constraints (see SparseR1C.Table)
+	Tables []Table
+}
+
+// Table is a lookup table, each row stores the IDs of the coefficients of its three entries
+type Table [][3]int
+
+// NbTableRows returns the total number of rows of the lookup tables of cs
+func (cs *SparseR1CS) NbTableRows() int {
+	n := 0
+	for _, t := range cs.Tables {
+		n += len(t)
+	}
+	return n
 }
 
-// GetNbConstraints returns the number of constraints
+// GetNbConstraints returns the number of constraints, including the lazy constraints
+// once cs is lazified
 func (cs *SparseR1CS) GetNbConstraints() int {
+	if len(cs.LazyConsMap) > 0 {
+		return len(cs.Constraints) + cs.LazyCons.GetConstraintsAll()
+	}
 	return len(cs.Constraints)
 }
 
+// FetchConstraint returns the i-th constraint, i may point after cs.Constraints
+// to a lazy constraint once cs is lazified
+func (cs *SparseR1CS) FetchConstraint(i int) SparseR1C {
+	if i < len(cs.Constraints) {
+		return cs.Constraints[i]
+	}
+	idx := cs.LazyConsMap[i]
+	return cs.LazyCons[idx.LazyIndex].FetchLazy(idx.Index, cs)
+}
+
+// Lazify removes the constraints recorded by lazy gadgets from cs.Constraints,
+// they are then fetched from their template after cs.Constraints (see FetchConstraint)
+//
+// it returns the mapping from the constraint ids of the full system to the lazified one
+func (cs *SparseR1CS) Lazify() (map[int]int, error) {
+	mapFromFull := make(map[int]int)
+	lastEnd := 0
+	offset := 0
+	bar := len(cs.Constraints) - cs.LazyCons.GetConstraintsAll()
+	ret := make([]SparseR1C, 0, bar)
+
+	lazyIdx := 0
+	for lazyIndex, con := range cs.LazyCons {
+		start := con.GetLoc()
+		end := con.GetLoc() + con.GetConstraintsNum()
+		ret = append(ret, cs.Constraints[lastEnd:start]...)
+
+		// map [lastend, start)
+		for j := lastEnd; j < start; j++ {
+			mapFromFull[j] = j - offset
+		}
+		lastEnd = end
+		// map [start, end)
+		for j := start; j < end; j++ {
+			mapFromFull[j] = bar + offset + (j - start)
+		}
+
+		// record the index to cons
+		if err := con.SetConsStaticR1CMapIfNotExists(cs); err != nil {
+			return nil, err
+		}
+		for i := 0; i < con.GetConstraintsNum(); i++ {
+			cs.LazyConsMap[bar+lazyIdx] = LazyIndexedInputs{Index: i, LazyIndex: lazyIndex}
+			lazyIdx++
+		}
+
+		offset += con.GetConstraintsNum()
+	}
+	ret = append(ret, cs.Constraints[lastEnd:]...)
+	// map [end, endCons)
+	for j := lastEnd; j < len(cs.Constraints); j++ {
+		mapFromFull[j] = j - offset
+	}
+	cs.Constraints = ret
+
+	for i, row := range cs.Levels {
+		for j, val := range row {
+			v, ok := mapFromFull[val]
+			if !ok {
+				return nil, fmt.Errorf("bad map loc at %d, %d", i, j)
+			}
+			cs.Levels[i][j] = v
+		}
+	}
+
+	// debug info is attached to constraint ids
+	mDebug := make(map[int]int, len(cs.MDebug))
+	for cID, dID := range cs.MDebug {
+		mDebug[mapFromFull[cID]] = dID
+	}
+	cs.MDebug = mDebug
+
+	return mapFromFull, nil
+}
+
+// LazySparseR1CS is the SparseR1CS counterpart of LazyR1CS
+type LazySparseR1CS []LazySparseInputs
+
+func (l *LazySparseR1CS) GetConstraintsAll() int {
+	sum := 0
+	for _, v := range *l {
+		sum += v.GetConstraintsNum()
+	}
+	return sum
+}
+
+// LazySparseInputs is the SparseR1CS counterpart of LazyInputs
+type LazySparseInputs interface {
+	GetConstraintsNum() int
+	GetLoc() int
+	GetType() string
+	FetchLazy(j int, cs *SparseR1CS) SparseR1C
+	SetConsStaticR1CMapIfNotExists(cs *SparseR1CS) error
+}
+
 // SparseR1C used to compute the wires
 // L+R+M[0]M[1]+O+k=0
 // if a Term is zero, it means the field doesn't exist (ex M=[0,0] means there is no multiplicative term)
+//
+// if Table is not zero, the constraint is a lookup: all its coefficients are zero and
+// (L, R, O) must be a row of the lookup table Tables[Table-1]
 type SparseR1C struct {
 	L, R, O Term
 	M       [2]Term
 	K       int // stores only the ID of the constant term that is used
+	Table   int
+}
+
+// IsLookup returns true if r1c is a lookup constraint
+func (r1c *SparseR1C) IsLookup() bool {
+	return r1c.Table != 0
 }
 
 func (r1c *SparseR1C) String(coeffs []big.Int) string {
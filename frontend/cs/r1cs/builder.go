@@ -59,6 +59,9 @@ type r1cs struct {
 
 	// map for recording boolean constrained variables (to not constrain them twice)
 	mtBooleans map[uint64][]compiled.LinearExpression
+
+	// set while a lazy gadget is recorded, nested lazy constraints are then part of its template
+	recordingLazy bool
 }
 
 // initialCapacity has quite some impact on frontend performance, especially on large circuits size
@@ -182,11 +185,12 @@ func (system *r1cs) addConstraint(r1c compiled.R1C, debugID ...int) {
 	}
 }
 
-func newLazyMimcEncInputs(_s0, _hh, _v compiled.LinearExpression, loc int) compiled.LazyMimcEncInputs {
+func newLazyMimcEncInputs(_s0, _hh, _v compiled.LinearExpression, loc int, curveID ecc.ID) compiled.LazyMimcEncInputs {
 	// s0 := _s0.(compiled.LinearExpression)
 	// hh := _hh.(compiled.LinearExpression)
 
-	return compiled.LazyMimcEncInputs{S0: _s0, HH: _hh, V: _v, Loc: loc}
+	return compiled.LazyMimcEncInputs{S0: _s0, HH: _hh, V: _v, Loc: loc,
+		Round: compiled.MimcRounds[curveID], NbRounds: compiled.MimcNbRounds(curveID)}
 }
 
 func newLazyPoseidonEncInputs(s []compiled.LinearExpression, v compiled.LinearExpression, loc int) compiled.LazyPoseidonInputs {
@@ -196,17 +200,29 @@ func newLazyPoseidonEncInputs(s []compiled.LinearExpression, v compiled.LinearEx
 }
 
 func (system *r1cs) AddLazyMimcEnc(s, h, v frontend.Variable) {
+	if system.recordingLazy {
+		return
+	}
 	s0 := system.toVariable(s)
 	s0 = system.reduce(s0.(compiled.LinearExpression))
 	hh := system.toVariable(h)
 	hh = system.reduce(hh.(compiled.LinearExpression))
+	_, s0Constant := system.ConstantValue(s0)
+	_, hhConstant := system.ConstantValue(hh)
+	// if the message and the key are constant, the encryption has no constraint
+	if s0Constant && hhConstant {
+		return
+	}
 	lazyMimc := newLazyMimcEncInputs(s0.(compiled.LinearExpression), hh.(compiled.LinearExpression),
-		v.(compiled.LinearExpression), len(system.Constraints))
+		v.(compiled.LinearExpression), len(system.Constraints), system.CurveID)
 	system.LazyCons = append(system.LazyCons, &lazyMimc)
 }
 
 // AddLazyPoseidon for Dynamic expanding of poseidon
 func (system *r1cs) AddLazyPoseidon(v frontend.Variable, s ...frontend.Variable) {
+	if system.recordingLazy {
+		return
+	}
 	sLinear, _ := system.toVariables(s...)
 	constantCounts := 0
 	for _, ss := range s {
@@ -222,6 +238,29 @@ func (system *r1cs) AddLazyPoseidon(v frontend.Variable, s ...frontend.Variable)
 	system.LazyCons = append(system.LazyCons, &lazyPosiedonCons)
 }
 
+// AddLazyGadget for Dynamic expanding of a registered gadget
+func (system *r1cs) AddLazyGadget(name string, inputs ...frontend.Variable) []frontend.Variable {
+	gadget := frontend.GetLazyGadget(name)
+	if system.recordingLazy {
+		return gadget(system, inputs...)
+	}
+
+	loc := len(system.Constraints)
+	start := system.NbInternalVariables + system.NbPublicVariables + system.NbSecretVariables
+	system.recordingLazy = true
+	outputs := gadget(system, inputs...)
+	system.recordingLazy = false
+
+	// a gadget folded into constants has nothing to expand
+	if len(system.Constraints) == loc {
+		return outputs
+	}
+	end := system.NbInternalVariables + system.NbPublicVariables + system.NbSecretVariables
+	system.LazyCons = append(system.LazyCons, compiled.NewLazyGadgetInputs(name, system.Constraints, loc, start, end-start))
+
+	return outputs
+}
+
 // Term packs a Variable and a coeff in a Term and returns it.
 // func (system *R1CSRefactor) setCoeff(v Variable, coeff *big.Int) Term {
 func (system *r1cs) setCoeff(v compiled.Term, coeff *big.Int) compiled.Term {
@@ -431,17 +470,17 @@ func (cs *r1cs) Compile() (frontend.CompiledConstraintSystem, error) {
 
 	switch cs.CurveID {
 	case ecc.BLS12_377:
-		return bls12377r1cs.NewR1CS(res, cs.st.Coeffs), nil
+		return bls12377r1cs.NewR1CS(res, cs.st.Coeffs, cs.st), nil
 	case ecc.BLS12_381:
-		return bls12381r1cs.NewR1CS(res, cs.st.Coeffs), nil
+		return bls12381r1cs.NewR1CS(res, cs.st.Coeffs, cs.st), nil
 	case ecc.BN254:
 		return bn254r1cs.NewR1CS(res, cs.st.Coeffs, cs.st), nil
 	case ecc.BW6_761:
-		return bw6761r1cs.NewR1CS(res, cs.st.Coeffs), nil
+		return bw6761r1cs.NewR1CS(res, cs.st.Coeffs, cs.st), nil
 	case ecc.BW6_633:
-		return bw6633r1cs.NewR1CS(res, cs.st.Coeffs), nil
+		return bw6633r1cs.NewR1CS(res, cs.st.Coeffs, cs.st), nil
 	case ecc.BLS24_315:
-		return bls24315r1cs.NewR1CS(res, cs.st.Coeffs), nil
+		return bls24315r1cs.NewR1CS(res, cs.st.Coeffs, cs.st), nil
 	default:
 		panic("not implemtented")
 	}
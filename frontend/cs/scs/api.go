@@ -218,6 +218,9 @@ func (system *scs) ToBinary(i1 frontend.Variable, n ...int) []frontend.Variable
 		}
 	}
 
+	if system.config.LookupTables {
+		return system.toBinaryLookup(i1, nbBits)
+	}
 	return bits.ToBinary(system, i1, bits.WithNbDigits(nbBits))
 }
 
@@ -245,10 +248,19 @@ func (system *scs) Xor(a, b frontend.Variable) frontend.Variable {
 	if bConstant {
 		l := a.(compiled.Term)
 		r := l
+		// a ^ b = (1 - 2b)·a + b, i.e. (2b - 1)·a + res - b = 0
+		var k big.Int
+		k.Neg(_b)
+		idk := system.st.CoeffID(&k)
 		one := big.NewInt(1)
 		_b.Lsh(_b, 1).Sub(_b, one)
 		idl := system.st.CoeffID(_b)
-		system.addPlonkConstraint(l, r, res, idl, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, compiled.CoeffIdZero)
+		system.addPlonkConstraint(l, r, res, idl, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, idk)
+		return res
+	}
+	if system.config.LookupTables {
+		// the lookup also constrains a and b to be boolean
+		system.AssertInTable(system.builtinTable(tableXor), a, b, res)
 		return res
 	}
 	l := a.(compiled.Term)
@@ -282,10 +294,14 @@ func (system *scs) Or(a, b frontend.Variable) frontend.Variable {
 		}
 		system.AssertIsBoolean(a)
 
+		// a | b = (1 - b)·a + b, i.e. (b - 1)·a + res - b = 0
+		var k big.Int
+		k.Neg(_b)
+		idk := system.st.CoeffID(&k)
 		one := big.NewInt(1)
 		_b.Sub(_b, one)
 		idl := system.st.CoeffID(_b)
-		system.addPlonkConstraint(l, r, res, idl, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, compiled.CoeffIdZero)
+		system.addPlonkConstraint(l, r, res, idl, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdOne, idk)
 		return res
 	}
 	l := a.(compiled.Term)
@@ -299,6 +315,12 @@ func (system *scs) Or(a, b frontend.Variable) frontend.Variable {
 // Or returns a & b
 // a and b must be 0 or 1
 func (system *scs) And(a, b frontend.Variable) frontend.Variable {
+	_, aConstant := system.ConstantValue(a)
+	_, bConstant := system.ConstantValue(b)
+	if system.config.LookupTables && !aConstant && !bConstant {
+		// the lookup also constrains a and b to be boolean
+		return system.Lookup(system.builtinTable(tableAnd), a, b)
+	}
 	system.AssertIsBoolean(a)
 	system.AssertIsBoolean(b)
 	return system.Mul(a, b)
@@ -184,6 +184,14 @@ func (system *scs) mustBeLessOrEqCst(a compiled.Term, bound big.Int) {
 		panic("AssertIsLessOrEqual: bound is too large, constraint will never be satisfied")
 	}
 
+	// with lookups, 0 ⩽ a < 2ᵏ and 0 ⩽ bound - a < 2ᵏ where k is the bit length of bound
+	// imply a ⩽ bound, as long as 2ᵏ⁺¹ doesn't exceed the modulus
+	if k := bound.BitLen(); system.config.LookupTables && k < nbBits-1 {
+		system.rangeCheckLookup(a, k)
+		system.rangeCheckLookup(system.Sub(bound, a), k)
+		return
+	}
+
 	// debug info
 	debug := system.AddDebugInfo("mustBeLessOrEq", a, " <= ", bound)
 
@@ -57,6 +57,15 @@ type scs struct {
 
 	// map for recording boolean constrained variables (to not constrain them twice)
 	mtBooleans map[int]struct{}
+
+	LazyCons []compiled.LazySparseInputs
+
+	// set while a lazy gadget is recorded, nested lazy constraints are then part of its template
+	recordingLazy bool
+
+	// lookup tables, and the identifiers of the built-in ones already defined
+	Tables          []compiled.Table
+	mtBuiltinTables map[int]int
 }
 
 // initialCapacity has quite some impact on frontend performance, especially on large circuits size
@@ -68,10 +77,11 @@ func newBuilder(curveID ecc.ID, config frontend.CompileConfig) *scs {
 			MHints:             make(map[int]*compiled.Hint),
 			MHintsDependencies: make(map[hint.ID]string),
 		},
-		mtBooleans:  make(map[int]struct{}),
-		Constraints: make([]compiled.SparseR1C, 0, config.Capacity),
-		st:          cs.NewCoeffTable(),
-		config:      config,
+		mtBooleans:      make(map[int]struct{}),
+		mtBuiltinTables: make(map[int]int),
+		Constraints:     make([]compiled.SparseR1C, 0, config.Capacity),
+		st:              cs.NewCoeffTable(),
+		config:          config,
 	}
 
 	system.Public = make([]string, 0)
@@ -87,7 +97,30 @@ func (system *scs) AddLazyMimcEnc(s, h, v frontend.Variable) {
 }
 
 func (system *scs) AddLazyPoseidon(v frontend.Variable, s ...frontend.Variable) {
-	// not implemented
+	// not implemented, std/hash/poseidon records its permutation with AddLazyGadget instead
+}
+
+// AddLazyGadget for Dynamic expanding of a registered gadget
+func (system *scs) AddLazyGadget(name string, inputs ...frontend.Variable) []frontend.Variable {
+	gadget := frontend.GetLazyGadget(name)
+	if system.recordingLazy {
+		return gadget(system, inputs...)
+	}
+
+	loc := len(system.Constraints)
+	start := system.NbInternalVariables + system.NbPublicVariables + system.NbSecretVariables
+	system.recordingLazy = true
+	outputs := gadget(system, inputs...)
+	system.recordingLazy = false
+
+	// a gadget folded into constants has nothing to expand
+	if len(system.Constraints) == loc {
+		return outputs
+	}
+	end := system.NbInternalVariables + system.NbPublicVariables + system.NbSecretVariables
+	system.LazyCons = append(system.LazyCons, compiled.NewLazySparseGadgetInputs(name, system.Constraints, loc, start, end-start))
+
+	return outputs
 }
 
 func (system *scs) AddInternalVariableWithLazy(lazyCnt int) frontend.Variable {
@@ -252,6 +285,12 @@ func (system *scs) checkVariables() error {
 
 	}
 	for _, c := range system.Constraints {
+		if c.IsLookup() {
+			// the wires of a lookup are constrained by the table, not by the coefficients
+			c.L.SetCoeffID(compiled.CoeffIdOne)
+			c.R.SetCoeffID(compiled.CoeffIdOne)
+			c.O.SetCoeffID(compiled.CoeffIdOne)
+		}
 		processTerm(c.L)
 		processTerm(c.R)
 		processTerm(c.M[0])
@@ -326,8 +365,12 @@ func (cs *scs) Compile() (frontend.CompiledConstraintSystem, error) {
 	}
 
 	res := compiled.SparseR1CS{
-		ConstraintSystem: cs.ConstraintSystem,
-		Constraints:      cs.Constraints,
+		ConstraintSystem:     cs.ConstraintSystem,
+		Constraints:          cs.Constraints,
+		LazyCons:             cs.LazyCons,
+		LazyConsMap:          map[int]compiled.LazyIndexedInputs{},
+		LazyConsStaticR1CMap: map[string][]compiled.SparseR1C{},
+		Tables:               cs.Tables,
 	}
 	// sanity check
 	if res.NbPublicVariables != len(cs.Public) || res.NbPublicVariables != cs.Schema.NbPublic {
@@ -0,0 +1,166 @@
+/*
+Copyright © 2021 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package scs
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// built-in tables, defined on first use when the circuit is compiled with frontend.WithLookupTables
+const (
+	tableBits = iota // (b₀, b₁, b₀+2b₁) for b₀, b₁ ∈ {0, 1}
+	tableXor         // (a, b, a ^ b) for a, b ∈ {0, 1}
+	tableAnd         // (a, b, a & b) for a, b ∈ {0, 1}
+)
+
+// DefineTable registers a lookup table whose rows are the given triples of constants
+// and returns its identifier
+func (system *scs) DefineTable(rows ...[3]frontend.Variable) int {
+	if len(rows) == 0 {
+		panic("a lookup table must have at least one row")
+	}
+	mod := system.CurveID.Info().Fr.Modulus()
+	table := make(compiled.Table, len(rows))
+	for i, row := range rows {
+		for j, v := range row {
+			c, ok := system.ConstantValue(v)
+			if !ok {
+				panic(fmt.Sprintf("entry (%d, %d) of the lookup table is not a constant", i, j))
+			}
+			c.Mod(c, mod)
+			table[i][j] = system.st.CoeffID(c)
+		}
+	}
+	system.Tables = append(system.Tables, table)
+	return len(system.Tables) - 1
+}
+
+// Lookup returns c such that (a, b, c) is a row of the table, the first one if several rows
+// start with (a, b). Solving fails if there is no such row.
+func (system *scs) Lookup(table int, a, b frontend.Variable) frontend.Variable {
+	res := system.newInternalVariable()
+	system.addLookupConstraint(table, a, b, res)
+	return res
+}
+
+// AssertInTable fails if (a, b, c) is not a row of the table
+func (system *scs) AssertInTable(table int, a, b, c frontend.Variable) {
+	system.addLookupConstraint(table, a, b, c)
+}
+
+// addLookupConstraint records (a, b, c) ∈ table as a constraint with zero coefficients,
+// the wire of c is solved from the table if it isn't known when the constraint is reached
+func (system *scs) addLookupConstraint(table int, a, b, c frontend.Variable) {
+	if table < 0 || table >= len(system.Tables) {
+		panic(fmt.Sprintf("lookup table %d is not defined", table))
+	}
+	l, r, o := system.toWire(a), system.toWire(b), system.toWire(c)
+	l.SetCoeffID(compiled.CoeffIdZero)
+	r.SetCoeffID(compiled.CoeffIdZero)
+	o.SetCoeffID(compiled.CoeffIdZero)
+	system.Constraints = append(system.Constraints, compiled.SparseR1C{L: l, R: r, O: o, M: [2]compiled.Term{l, r}, K: compiled.CoeffIdZero, Table: table + 1})
+}
+
+// toWire returns a term of coefficient one whose wire holds the value of v, adding a constraint
+// if v is a constant or a term with another coefficient
+func (system *scs) toWire(v frontend.Variable) compiled.Term {
+	if c, ok := system.ConstantValue(v); ok {
+		res := system.newInternalVariable()
+		c.Neg(c)
+		system.addPlonkConstraint(res, system.zero(), system.zero(), compiled.CoeffIdOne, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, system.st.CoeffID(c))
+		return res
+	}
+	t := v.(compiled.Term)
+	cID, _, _ := t.Unpack()
+	if cID == compiled.CoeffIdOne {
+		return t
+	}
+	res := system.newInternalVariable()
+	system.addPlonkConstraint(t, system.zero(), res, cID, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdZero, compiled.CoeffIdMinusOne, compiled.CoeffIdZero)
+	return res
+}
+
+// builtinTable returns the identifier of the built-in table id, defining it on first use
+func (system *scs) builtinTable(id int) int {
+	if t, ok := system.mtBuiltinTables[id]; ok {
+		return t
+	}
+	var rows [][3]frontend.Variable
+	for a := 0; a < 2; a++ {
+		for b := 0; b < 2; b++ {
+			switch id {
+			case tableBits:
+				rows = append(rows, [3]frontend.Variable{a, b, a + 2*b})
+			case tableXor:
+				rows = append(rows, [3]frontend.Variable{a, b, a ^ b})
+			case tableAnd:
+				rows = append(rows, [3]frontend.Variable{a, b, a & b})
+			}
+		}
+	}
+	t := system.DefineTable(rows...)
+	system.mtBuiltinTables[id] = t
+	return t
+}
+
+// toBinaryLookup is ToBinary when lookups are enabled: the bits are looked up two by two in the
+// table of (b₀, b₁, b₀+2b₁), which constrains them to be boolean, and v is recomposed from the
+// 2-bit chunks. It costs about nbBits constraints instead of 2*nbBits.
+func (system *scs) toBinaryLookup(v frontend.Variable, nbBits int) []frontend.Variable {
+	if _, ok := system.ConstantValue(v); ok {
+		return bits.ToBinary(system, v, bits.WithNbDigits(nbBits))
+	}
+
+	res, err := system.NewHint(bits.NBits, nbBits, v)
+	if err != nil {
+		panic(err)
+	}
+
+	table := system.builtinTable(tableBits)
+	var Σ frontend.Variable = 0
+	c := big.NewInt(1)
+	for i := 0; i < nbBits; i += 2 {
+		var chunk frontend.Variable
+		if i+1 < nbBits {
+			chunk = system.Lookup(table, res[i], res[i+1])
+			system.MarkBoolean(res[i])
+			system.MarkBoolean(res[i+1])
+		} else {
+			system.AssertIsBoolean(res[i])
+			chunk = res[i]
+		}
+		Σ = system.Add(Σ, system.Mul(chunk, c))
+		c.Lsh(c, 2)
+	}
+	system.AssertIsEqual(Σ, v)
+
+	return res
+}
+
+// rangeCheckLookup asserts that v < 2ⁿᵇᴮⁱᵗˢ
+func (system *scs) rangeCheckLookup(v frontend.Variable, nbBits int) {
+	if nbBits == 0 {
+		system.AssertIsEqual(v, 0)
+		return
+	}
+	system.toBinaryLookup(v, nbBits)
+}
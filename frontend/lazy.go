@@ -0,0 +1,41 @@
+package frontend
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LazyGadget is a sub-circuit which can be called through API.AddLazyGadget.
+//
+// The constraints emitted by a call are recorded as a lazy template: repeated calls with the
+// same shape share a single static R1C template once the constraint system is lazified, each
+// call only keeping the wires it reads from outside of the gadget. Two calls have the same shape
+// if their inputs are linear expressions with the same coefficients, for instance single wires;
+// a constant input or an input already constrained to be boolean yield a different template.
+type LazyGadget func(api API, inputs ...Variable) []Variable
+
+var lazyGadgets = make(map[string]LazyGadget)
+var lazyGadgetsM sync.RWMutex
+
+// RegisterLazyGadget registers a lazy gadget under name in the global registry.
+// It panics if another gadget is already registered under the same name.
+func RegisterLazyGadget(name string, gadget LazyGadget) {
+	lazyGadgetsM.Lock()
+	defer lazyGadgetsM.Unlock()
+	if _, ok := lazyGadgets[name]; ok {
+		panic(fmt.Sprintf("lazy gadget %s registered multiple times", name))
+	}
+	lazyGadgets[name] = gadget
+}
+
+// GetLazyGadget returns the lazy gadget registered under name.
+// It panics if no gadget is registered under that name.
+func GetLazyGadget(name string) LazyGadget {
+	lazyGadgetsM.RLock()
+	defer lazyGadgetsM.RUnlock()
+	gadget, ok := lazyGadgets[name]
+	if !ok {
+		panic(fmt.Sprintf("lazy gadget %s is not registered", name))
+	}
+	return gadget
+}
@@ -0,0 +1,18 @@
+package frontend
+
+// Lookuper is implemented by the builders supporting lookup arguments, that is the PLONK
+// builder, and by the test engine. A circuit accesses it with a type assertion on its API:
+//
+//	lookuper, ok := api.(frontend.Lookuper)
+type Lookuper interface {
+	// DefineTable registers a lookup table whose rows are the given triples of constants
+	// and returns its identifier
+	DefineTable(rows ...[3]Variable) int
+
+	// Lookup returns c such that (a, b, c) is a row of the table, the first one if several rows
+	// start with (a, b). Solving fails if there is no such row.
+	Lookup(table int, a, b Variable) Variable
+
+	// AssertInTable fails if (a, b, c) is not a row of the table
+	AssertInTable(table int, a, b, c Variable)
+}
@@ -17,6 +17,7 @@
 package cs
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
@@ -24,6 +25,7 @@ import (
 	"io"
 	"math"
 	"math/big"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
@@ -109,7 +111,7 @@ func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]f
 		coefficientsNegInv[i].Neg(&coefficientsNegInv[i])
 	}
 
-	if err := cs.parallelSolve(&solution, coefficientsNegInv); err != nil {
+	if err := cs.parallelSolve(opt.Ctx, &solution, coefficientsNegInv, cs.lookupTables()); err != nil {
 		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
 			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
 		} else {
@@ -130,7 +132,7 @@ func (cs *SparseR1CS) Solve(witness []fr.Element, opt backend.ProverConfig) ([]f
 
 }
 
-func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.Element) error {
+func (cs *SparseR1CS) parallelSolve(ctx context.Context, solution *solution, coefficientsNegInv []fr.Element, tables lookupTables) error {
 	// minWorkPerCPU is the minimum target number of constraint a task should hold
 	// in other words, if a level has less than minWorkPerCPU, it will not be parallelized and executed
 	// sequentially without sync.
@@ -151,12 +153,13 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 			for t := range chTasks {
 				for _, i := range t {
 					// for each constraint in the task, solve it.
-					if err := cs.solveConstraint(cs.Constraints[i], solution, coefficientsNegInv); err != nil {
+					c := cs.FetchConstraint(i)
+					if err := cs.solveConstraint(c, solution, coefficientsNegInv, tables); err != nil {
 						chError <- &UnsatisfiedConstraintError{CID: i, Err: err}
 						wg.Done()
 						return
 					}
-					if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
+					if err := cs.checkConstraint(c, solution); err != nil {
 						if dID, ok := cs.MDebug[i]; ok {
 							errMsg := solution.logValue(cs.DebugInfo[dID])
 							chError <- &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
@@ -180,6 +183,10 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 
 	// for each level, we push the tasks
 	for _, level := range cs.Levels {
+		// stop between levels if the prover was cancelled, see backend.WithContext
+		if ctx != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
 
 		// max CPU to use
 		maxCPU := float64(len(level)) / minWorkPerCPU
@@ -187,10 +194,11 @@ func (cs *SparseR1CS) parallelSolve(solution *solution, coefficientsNegInv []fr.
 		if maxCPU <= 1.0 {
 			// we do it sequentially
 			for _, i := range level {
-				if err := cs.solveConstraint(cs.Constraints[i], solution, coefficientsNegInv); err != nil {
+				c := cs.FetchConstraint(i)
+				if err := cs.solveConstraint(c, solution, coefficientsNegInv, tables); err != nil {
 					return &UnsatisfiedConstraintError{CID: i, Err: err}
 				}
-				if err := cs.checkConstraint(cs.Constraints[i], solution); err != nil {
+				if err := cs.checkConstraint(c, solution); err != nil {
 					if dID, ok := cs.MDebug[i]; ok {
 						errMsg := solution.logValue(cs.DebugInfo[dID])
 						return &UnsatisfiedConstraintError{CID: i, DebugInfo: &errMsg}
@@ -291,7 +299,10 @@ func (cs *SparseR1CS) computeHints(c compiled.SparseR1C, solution *solution) (in
 // solveConstraint solve any unsolved wire in given constraint and update the solution
 // a SparseR1C may have up to one unsolved wire (excluding hints)
 // if it doesn't, then this function returns and does nothing
-func (cs *SparseR1CS) solveConstraint(c compiled.SparseR1C, solution *solution, coefficientsNegInv []fr.Element) error {
+func (cs *SparseR1CS) solveConstraint(c compiled.SparseR1C, solution *solution, coefficientsNegInv []fr.Element, tables lookupTables) error {
+	if c.IsLookup() {
+		return cs.solveLookup(c, solution, tables)
+	}
 
 	lro, err := cs.computeHints(c, solution)
 	if err != nil {
@@ -360,6 +371,55 @@ func (cs *SparseR1CS) solveConstraint(c compiled.SparseR1C, solution *solution,
 	return nil
 }
 
+// lookupTables indexes the rows of each lookup table by their first two entries
+type lookupTables []map[[2]fr.Element][]fr.Element
+
+// lookupTables returns the lookup tables of cs, indexed to solve the lookup constraints
+func (cs *SparseR1CS) lookupTables() lookupTables {
+	res := make(lookupTables, len(cs.Tables))
+	for i, table := range cs.Tables {
+		res[i] = make(map[[2]fr.Element][]fr.Element, len(table))
+		for _, row := range table {
+			key := [2]fr.Element{cs.Coefficients[row[0]], cs.Coefficients[row[1]]}
+			res[i][key] = append(res[i][key], cs.Coefficients[row[2]])
+		}
+	}
+	return res
+}
+
+// solveLookup solves the wires of the lookup constraint c, O being set from the table if
+// needed, and checks that (L, R, O) is a row of its table
+func (cs *SparseR1CS) solveLookup(c compiled.SparseR1C, solution *solution, tables lookupTables) error {
+	for _, t := range []compiled.Term{c.L, c.R, c.O} {
+		vID := t.WireID()
+		if solution.solved[vID] {
+			continue
+		}
+		if hint, ok := cs.MHints[vID]; ok {
+			if err := solution.solveWithHint(vID, hint); err != nil {
+				return err
+			}
+		}
+	}
+
+	lID, rID, oID := c.L.WireID(), c.R.WireID(), c.O.WireID()
+	if !solution.solved[lID] || !solution.solved[rID] {
+		return errors.New("the inputs of a lookup must be instantiated before it")
+	}
+	key := [2]fr.Element{solution.values[lID], solution.values[rID]}
+	values := tables[c.Table-1][key]
+	if !solution.solved[oID] && len(values) != 0 {
+		solution.set(oID, values[0])
+		return nil
+	}
+	for i := range values {
+		if values[i].Equal(&solution.values[oID]) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no row of lookup table %d matches (%s, %s, %s)", c.Table-1, key[0].String(), key[1].String(), solution.values[oID].String())
+}
+
 // IsSolved returns nil if given witness solves the SparseR1CS and error otherwise
 // this method wraps cs.Solve() and allocates cs.Solve() inputs
 func (cs *SparseR1CS) IsSolved(witness *witness.Witness, opts ...backend.ProverOption) error {
@@ -507,10 +567,24 @@ func (cs *SparseR1CS) CurveID() ecc.ID {
 	return ecc.BLS12_377
 }
 
+// add cbor tags to clarify lazy gadget inputs
+func (cs *SparseR1CS) inputsCBORTags() (cbor.TagSet, error) {
+	defTagOpts := cbor.TagOptions{EncTag: cbor.EncTagRequired, DecTag: cbor.DecTagRequired}
+	tags := cbor.NewTagSet()
+	if err := tags.Add(defTagOpts, reflect.TypeOf(compiled.LazySparseGadgetInputs{}), 25451); err != nil {
+		return nil, fmt.Errorf("new LE tag: %w", err)
+	}
+	return tags, nil
+}
+
 // WriteTo encodes SparseR1CS into provided io.Writer using cbor
 func (cs *SparseR1CS) WriteTo(w io.Writer) (int64, error) {
 	_w := ioutils.WriterCounter{W: w} // wraps writer to count the bytes written
-	enc, err := cbor.CoreDetEncOptions().EncMode()
+	tags, err := cs.inputsCBORTags()
+	if err != nil {
+		return 0, fmt.Errorf("cbor tags: %w", err)
+	}
+	enc, err := cbor.CoreDetEncOptions().EncModeWithTags(tags)
 	if err != nil {
 		return 0, err
 	}
@@ -521,12 +595,25 @@ func (cs *SparseR1CS) WriteTo(w io.Writer) (int64, error) {
 	return _w.N, err
 }
 
+// WriteCircuitTo encodes SparseR1CS as WriteTo without its debug information, so that the encoding
+// doesn't depend on where the circuit was defined. It is the encoding of the circuit hash of a dump.
+func (cs *SparseR1CS) WriteCircuitTo(w io.Writer) (int64, error) {
+	_cs := *cs
+	_cs.DebugInfo = make([]compiled.LogEntry, 0)
+	_cs.MDebug = make(map[int]int, 0)
+	return _cs.WriteTo(w)
+}
+
 // ReadFrom attempts to decode SparseR1CS from io.Reader using cbor
 func (cs *SparseR1CS) ReadFrom(r io.Reader) (int64, error) {
+	tags, err := cs.inputsCBORTags()
+	if err != nil {
+		return 0, fmt.Errorf("cbor tags: %w", err)
+	}
 	dm, err := cbor.DecOptions{
 		MaxArrayElements: 134217728,
 		MaxMapPairs:      134217728,
-	}.DecMode()
+	}.DecModeWithTags(tags)
 	if err != nil {
 		return 0, err
 	}
@@ -23,11 +23,17 @@ import (
 
 	"github.com/consensys/gnark/internal/backend/bls12-377/cs"
 
+	"crypto/rand"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr/fft"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/internal/backend/dump"
+	"github.com/consensys/gnark/internal/backend/progress"
+	"io"
 	"math/big"
 	"math/bits"
+	"time"
 )
 
 // ProvingKey is used by a Groth16 prover to encode a proof of a statement
@@ -35,6 +41,7 @@ import (
 type ProvingKey struct {
 	// domain
 	Domain fft.Domain
+	Card   uint64
 
 	// [α]1, [β]1, [δ]1
 	// [A(t)]1, [B(t)]1, [Kpk(t)]1, [Z(t)]1
@@ -76,8 +83,592 @@ type VerifyingKey struct {
 	e curve.GT // not serialized
 }
 
+// SetupWithDump runs Setup and writes the R1CS, the VerifyingKey and the segments of the ProvingKey
+// under the session prefix instead of returning them, see package dump for the layout of the files.
+//
+// If the setup is cancelled, the segments already written are left without a manifest.
+func SetupWithDump(r1cs *cs.R1CS, session string, opt backend.SetupConfig) error {
+	t := progress.New(opt.Ctx, opt.Progress, 7)
+	w := dump.NewWriter(session, r1cs.CurveID())
+
+	// dump r1cs to file, the coefficient table is written apart from the cbor encoded constraints
+	if err := w.Write(dump.CoeffTable, r1cs.WriteCTTo); err != nil {
+		return err
+	}
+	if err := w.Write(dump.ConstraintSystem, r1cs.WriteTo); err != nil {
+		return err
+	}
+	if err := w.HashCircuit(r1cs.WriteCircuitTo, r1cs.WriteCTTo); err != nil {
+		return err
+	}
+
+	var pk ProvingKey
+	var vk VerifyingKey
+
+	// get R1CS nb constraints, wires and public/private inputs
+	nbWires := r1cs.NbInternalVariables + r1cs.NbPublicVariables + r1cs.NbSecretVariables
+	nbPublicWires := int(r1cs.NbPublicVariables)
+	nbPrivateWires := r1cs.NbSecretVariables + r1cs.NbInternalVariables
+
+	// Setting group for fft
+	start := time.Now()
+	domain := fft.NewDomain(uint64(len(r1cs.Constraints)))
+
+	// samples toxic waste
+	toxicWaste, err := sampleToxicWaste(opt.RandomSource)
+	if err != nil {
+		return err
+	}
+
+	// Setup coeffs to compute pk.G1.A, pk.G1.B, pk.G1.K
+	A, B, C := setupABC(r1cs, domain, toxicWaste)
+
+	// To fill in the Proving and Verifying keys, we need to perform a lot of ecc scalar multiplication (with generator)
+	// and convert the resulting points to affine
+	// this is done using the curve.BatchScalarMultiplicationGX API, which takes as input the base point
+	// (in our case the generator) and the list of scalars, and outputs a list of points (len(points) == len(scalars))
+	// to use this batch call, we need to order our scalars in the same slice
+	// we have 1 batch call for G1 and 1 batch call for G1
+	// scalars are fr.Element in non montgomery form
+	_, _, g1, g2 := curve.Generators()
+
+	// ---------------------------------------------------------------------------------------------
+	// G1 scalars
+
+	// the G1 scalars are ordered (arbitrary) as follow:
+	//
+	// [[α], [β], [δ], [A(i)], [B(i)], [pk.K(i)], [Z(i)], [vk.K(i)]]
+	// len(A) == len(B) == nbWires
+	// len(pk.K) == nbPrivateWires
+	// len(vk.K) == nbPublicWires
+	// len(Z) == domain.Cardinality
+
+	// compute scalars for pkK and vkK
+	pkK := make([]fr.Element, nbPrivateWires)
+	vkK := make([]fr.Element, nbPublicWires)
+
+	var t0, t1 fr.Element
+
+	for i := 0; i < nbPublicWires; i++ {
+		t1.Mul(&A[i], &toxicWaste.beta)
+		t0.Mul(&B[i], &toxicWaste.alpha)
+		t1.Add(&t1, &t0).
+			Add(&t1, &C[i]).
+			Mul(&t1, &toxicWaste.gammaInv)
+		vkK[i] = t1.ToRegular()
+	}
+
+	for i := 0; i < nbPrivateWires; i++ {
+		t1.Mul(&A[i+nbPublicWires], &toxicWaste.beta)
+		t0.Mul(&B[i+nbPublicWires], &toxicWaste.alpha)
+		t1.Add(&t1, &t0).
+			Add(&t1, &C[i+nbPublicWires]).
+			Mul(&t1, &toxicWaste.deltaInv)
+		pkK[i] = t1.ToRegular()
+	}
+
+	// convert A and B to regular form
+	for i := 0; i < nbWires; i++ {
+		A[i].FromMont()
+	}
+	for i := 0; i < nbWires; i++ {
+		B[i].FromMont()
+	}
+
+	// Z part of the proving key (scalars)
+	Z := make([]fr.Element, domain.Cardinality)
+	one := fr.One()
+	var zdt fr.Element
+
+	zdt.Exp(toxicWaste.t, new(big.Int).SetUint64(domain.Cardinality)).
+		Sub(&zdt, &one).
+		Mul(&zdt, &toxicWaste.deltaInv) // sets Zdt to Zdt/delta
+
+	for i := 0; i < int(domain.Cardinality); i++ {
+		Z[i] = zdt.ToRegular()
+		zdt.Mul(&zdt, &toxicWaste.t)
+	}
+
+	// mark points at infinity and filter them
+	pk.InfinityA = make([]bool, len(A))
+	pk.InfinityB = make([]bool, len(B))
+
+	n := 0
+	for i, e := range A {
+		if e.IsZero() {
+			pk.InfinityA[i] = true
+			continue
+		}
+		A[n] = A[i]
+		n++
+	}
+	A = A[:n]
+	pk.NbInfinityA = uint64(nbWires - n)
+	n = 0
+	for i, e := range B {
+		if e.IsZero() {
+			pk.InfinityB[i] = true
+			continue
+		}
+		B[n] = B[i]
+		n++
+	}
+	B = B[:n]
+	pk.NbInfinityB = uint64(nbWires - n)
+
+	t.Phase("qap", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// E part
+	{
+		g1Scalars := make([]fr.Element, 0, 3)
+		g1Scalars = append(g1Scalars, toxicWaste.alphaReg, toxicWaste.betaReg, toxicWaste.deltaReg)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		// sets pk: [α]1, [β]1, [δ]1
+		pk.G1.Alpha = g1PointsAff[0]
+		pk.G1.Beta = g1PointsAff[1]
+		pk.G1.Delta = g1PointsAff[2]
+
+		g2Scalars := make([]fr.Element, 0, 2)
+		g2Scalars = append(g2Scalars, toxicWaste.betaReg, toxicWaste.deltaReg)
+		g2PointsAff := curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
+		pk.G2.Beta = g2PointsAff[0]
+		pk.G2.Delta = g2PointsAff[1]
+
+		// set domain, its cardinality is part of the E segment
+		pk.Domain = *domain
+		if err := w.Write(dump.ProvingKeyE, pk.WriteRawETo); err != nil {
+			return err
+		}
+
+		// vk
+		g1Scalars = make([]fr.Element, 0, nbPublicWires)
+		g1Scalars = append(g1Scalars, vkK...)
+		g1PointsAff = curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		vk.G1.K = g1PointsAff
+
+		g2Scalars = make([]fr.Element, 0, 2)
+		g2Scalars = append(g2Scalars, toxicWaste.deltaReg, toxicWaste.gammaReg)
+		g2PointsAff = curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
+
+		vk.G2.Delta = g2PointsAff[0]
+		vk.G2.Gamma = g2PointsAff[1]
+		vk.G2.deltaNeg.Neg(&vk.G2.Delta)
+		vk.G2.gammaNeg.Neg(&vk.G2.Gamma)
+
+		// ---------------------------------------------------------------------------------------------
+		// Pairing: vk.e
+		vk.G1.Alpha = pk.G1.Alpha
+		vk.G2.Beta = pk.G2.Beta
+
+		// unused, here for compatibility purposes
+		vk.G1.Beta = pk.G1.Beta
+		vk.G1.Delta = pk.G1.Delta
+
+		vk.e, err = curve.Pair([]curve.G1Affine{pk.G1.Alpha}, []curve.G2Affine{pk.G2.Beta})
+		if err != nil {
+			return err
+		}
+
+		if err := w.Write(dump.VerifyingKey, vk.WriteRawTo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.E", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// A part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, nbWires)
+		g1Scalars = append(g1Scalars, A...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.A = g1PointsAff
+
+		if err := w.Write(dump.ProvingKeyA, pk.WriteRawATo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.A", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// B1 part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, nbWires)
+		g1Scalars = append(g1Scalars, B...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.B = g1PointsAff
+
+		if err := w.Write(dump.ProvingKeyB1, pk.WriteRawB1To); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.B1", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// K part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, nbPrivateWires)
+		g1Scalars = append(g1Scalars, pkK...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.K = g1PointsAff
+
+		if err := w.Write(dump.ProvingKeyK, pk.WriteRawKTo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.K", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// Z part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, int(domain.Cardinality))
+		g1Scalars = append(g1Scalars, Z...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.Z = g1PointsAff
+		bitReverse(pk.G1.Z)
+
+		if err := w.Write(dump.ProvingKeyZ, pk.WriteRawZTo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.Z", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// B2 part
+	{
+		var pk ProvingKey
+		g2Scalars := make([]fr.Element, 0, nbWires)
+		g2Scalars = append(g2Scalars, B...)
+		g2PointsAff := curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
+		pk.G2.B = g2PointsAff
+
+		if err := w.Write(dump.ProvingKeyB2, pk.WriteRawB2To); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.B2", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
+// SetupLazyWithDump behaves as SetupWithDump on a lazified R1CS
+func SetupLazyWithDump(r1cs *cs.R1CS, session string, opt backend.SetupConfig) error {
+	t := progress.New(opt.Ctx, opt.Progress, 7)
+	w := dump.NewWriter(session, r1cs.CurveID())
+
+	// dump r1cs to file, the coefficient table is written apart from the cbor encoded constraints
+	if err := w.Write(dump.CoeffTable, r1cs.WriteCTTo); err != nil {
+		return err
+	}
+	if err := w.Write(dump.ConstraintSystem, r1cs.WriteTo); err != nil {
+		return err
+	}
+	if err := w.HashCircuit(r1cs.WriteCircuitTo, r1cs.WriteCTTo); err != nil {
+		return err
+	}
+
+	var pk ProvingKey
+	var vk VerifyingKey
+
+	// get R1CS nb constraints, wires and public/private inputs
+	nbWires := r1cs.NbInternalVariables + r1cs.NbPublicVariables + r1cs.NbSecretVariables
+	nbPublicWires := int(r1cs.NbPublicVariables)
+	nbPrivateWires := r1cs.NbSecretVariables + r1cs.NbInternalVariables
+
+	// Setting group for fft
+	start := time.Now()
+	domain := fft.NewDomain(uint64(len(r1cs.Constraints) + r1cs.LazyCons.GetConstraintsAll()))
+
+	// samples toxic waste
+	toxicWaste, err := sampleToxicWaste(opt.RandomSource)
+	if err != nil {
+		return err
+	}
+
+	// Setup coeffs to compute pk.G1.A, pk.G1.B, pk.G1.K
+	A, B, C := setupLazyABC(r1cs, domain, toxicWaste)
+
+	// To fill in the Proving and Verifying keys, we need to perform a lot of ecc scalar multiplication (with generator)
+	// and convert the resulting points to affine
+	// this is done using the curve.BatchScalarMultiplicationGX API, which takes as input the base point
+	// (in our case the generator) and the list of scalars, and outputs a list of points (len(points) == len(scalars))
+	// to use this batch call, we need to order our scalars in the same slice
+	// we have 1 batch call for G1 and 1 batch call for G1
+	// scalars are fr.Element in non montgomery form
+	_, _, g1, g2 := curve.Generators()
+
+	// ---------------------------------------------------------------------------------------------
+	// G1 scalars
+
+	// the G1 scalars are ordered (arbitrary) as follow:
+	//
+	// [[α], [β], [δ], [A(i)], [B(i)], [pk.K(i)], [Z(i)], [vk.K(i)]]
+	// len(A) == len(B) == nbWires
+	// len(pk.K) == nbPrivateWires
+	// len(vk.K) == nbPublicWires
+	// len(Z) == domain.Cardinality
+
+	// compute scalars for pkK and vkK
+	pkK := make([]fr.Element, nbPrivateWires)
+	vkK := make([]fr.Element, nbPublicWires)
+
+	var t0, t1 fr.Element
+
+	for i := 0; i < nbPublicWires; i++ {
+		t1.Mul(&A[i], &toxicWaste.beta)
+		t0.Mul(&B[i], &toxicWaste.alpha)
+		t1.Add(&t1, &t0).
+			Add(&t1, &C[i]).
+			Mul(&t1, &toxicWaste.gammaInv)
+		vkK[i] = t1.ToRegular()
+	}
+
+	for i := 0; i < nbPrivateWires; i++ {
+		t1.Mul(&A[i+nbPublicWires], &toxicWaste.beta)
+		t0.Mul(&B[i+nbPublicWires], &toxicWaste.alpha)
+		t1.Add(&t1, &t0).
+			Add(&t1, &C[i+nbPublicWires]).
+			Mul(&t1, &toxicWaste.deltaInv)
+		pkK[i] = t1.ToRegular()
+	}
+
+	// convert A and B to regular form
+	for i := 0; i < nbWires; i++ {
+		A[i].FromMont()
+	}
+	for i := 0; i < nbWires; i++ {
+		B[i].FromMont()
+	}
+
+	// Z part of the proving key (scalars)
+	Z := make([]fr.Element, domain.Cardinality)
+	one := fr.One()
+	var zdt fr.Element
+
+	zdt.Exp(toxicWaste.t, new(big.Int).SetUint64(domain.Cardinality)).
+		Sub(&zdt, &one).
+		Mul(&zdt, &toxicWaste.deltaInv) // sets Zdt to Zdt/delta
+
+	for i := 0; i < int(domain.Cardinality); i++ {
+		Z[i] = zdt.ToRegular()
+		zdt.Mul(&zdt, &toxicWaste.t)
+	}
+
+	// mark points at infinity and filter them
+	pk.InfinityA = make([]bool, len(A))
+	pk.InfinityB = make([]bool, len(B))
+
+	n := 0
+	for i, e := range A {
+		if e.IsZero() {
+			pk.InfinityA[i] = true
+			continue
+		}
+		A[n] = A[i]
+		n++
+	}
+	A = A[:n]
+	pk.NbInfinityA = uint64(nbWires - n)
+	n = 0
+	for i, e := range B {
+		if e.IsZero() {
+			pk.InfinityB[i] = true
+			continue
+		}
+		B[n] = B[i]
+		n++
+	}
+	B = B[:n]
+	pk.NbInfinityB = uint64(nbWires - n)
+
+	// set domain
+	pk.Domain = *domain
+
+	t.Phase("qap", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// E part and VK
+	{
+		g1Scalars := make([]fr.Element, 0, 3)
+		g1Scalars = append(g1Scalars, toxicWaste.alphaReg, toxicWaste.betaReg, toxicWaste.deltaReg)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		// sets pk: [α]1, [β]1, [δ]1
+		pk.G1.Alpha = g1PointsAff[0]
+		pk.G1.Beta = g1PointsAff[1]
+		pk.G1.Delta = g1PointsAff[2]
+
+		g2Scalars := make([]fr.Element, 0, 2)
+		g2Scalars = append(g2Scalars, toxicWaste.betaReg, toxicWaste.deltaReg)
+		g2PointsAff := curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
+		pk.G2.Beta = g2PointsAff[0]
+		pk.G2.Delta = g2PointsAff[1]
+
+		if err := w.Write(dump.ProvingKeyE, pk.WriteRawETo); err != nil {
+			return err
+		}
+
+		// vk
+		g1Scalars = make([]fr.Element, 0, nbPublicWires)
+		g1Scalars = append(g1Scalars, vkK...)
+		g1PointsAff = curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		vk.G1.K = g1PointsAff
+
+		g2Scalars = make([]fr.Element, 0, 2)
+		g2Scalars = append(g2Scalars, toxicWaste.deltaReg, toxicWaste.gammaReg)
+		g2PointsAff = curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
+
+		vk.G2.Delta = g2PointsAff[0]
+		vk.G2.Gamma = g2PointsAff[1]
+		vk.G2.deltaNeg.Neg(&vk.G2.Delta)
+		vk.G2.gammaNeg.Neg(&vk.G2.Gamma)
+
+		// ---------------------------------------------------------------------------------------------
+		// Pairing: vk.e
+		vk.G1.Alpha = pk.G1.Alpha
+		vk.G2.Beta = pk.G2.Beta
+
+		// unused, here for compatibility purposes
+		vk.G1.Beta = pk.G1.Beta
+		vk.G1.Delta = pk.G1.Delta
+
+		vk.e, err = curve.Pair([]curve.G1Affine{pk.G1.Alpha}, []curve.G2Affine{pk.G2.Beta})
+		if err != nil {
+			return err
+		}
+
+		if err := w.Write(dump.VerifyingKey, vk.WriteRawTo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.E", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// A part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, nbWires)
+		g1Scalars = append(g1Scalars, A...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.A = g1PointsAff
+
+		if err := w.Write(dump.ProvingKeyA, pk.WriteRawATo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.A", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// B1 part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, nbWires)
+		g1Scalars = append(g1Scalars, B...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.B = g1PointsAff
+
+		if err := w.Write(dump.ProvingKeyB1, pk.WriteRawB1To); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.B1", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// K part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, nbPrivateWires)
+		g1Scalars = append(g1Scalars, pkK...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.K = g1PointsAff
+
+		if err := w.Write(dump.ProvingKeyK, pk.WriteRawKTo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.K", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// Z part
+	{
+		var pk ProvingKey
+		g1Scalars := make([]fr.Element, 0, int(domain.Cardinality))
+		g1Scalars = append(g1Scalars, Z...)
+		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
+		pk.G1.Z = g1PointsAff
+		bitReverse(pk.G1.Z)
+
+		if err := w.Write(dump.ProvingKeyZ, pk.WriteRawZTo); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.Z", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
+	// B2 part
+	{
+		var pk ProvingKey
+		g2Scalars := make([]fr.Element, 0, nbWires)
+		g2Scalars = append(g2Scalars, B...)
+		g2PointsAff := curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
+		pk.G2.B = g2PointsAff
+
+		if err := w.Write(dump.ProvingKeyB2, pk.WriteRawB2To); err != nil {
+			return err
+		}
+	}
+	t.Phase("pk.B2", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+
+	return w.Close()
+}
+
 // Setup constructs the SRS
-func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
+func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey, opt backend.SetupConfig) error {
 	/*
 		Setup
 		-----
@@ -94,10 +685,12 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	nbPrivateWires := r1cs.NbSecretVariables + r1cs.NbInternalVariables
 
 	// Setting group for fft
+	t := progress.New(opt.Ctx, opt.Progress, 3)
+	start := time.Now()
 	domain := fft.NewDomain(uint64(len(r1cs.Constraints)))
 
 	// samples toxic waste
-	toxicWaste, err := sampleToxicWaste()
+	toxicWaste, err := sampleToxicWaste(opt.RandomSource)
 	if err != nil {
 		return err
 	}
@@ -198,6 +791,12 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	B = B[:n]
 	pk.NbInfinityB = uint64(nbWires - n)
 
+	t.Phase("qap", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
 	// compute our batch scalar multiplication with g1 elements
 	g1Scalars := make([]fr.Element, 0, (nbWires*3)+int(domain.Cardinality)+3)
 	g1Scalars = append(g1Scalars, toxicWaste.alphaReg, toxicWaste.betaReg, toxicWaste.deltaReg)
@@ -231,6 +830,12 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 
 	vk.G1.K = g1PointsAff[offset:]
 
+	t.Phase("pk.G1", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
 	// ---------------------------------------------------------------------------------------------
 	// G2 scalars
 
@@ -272,6 +877,8 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	// set domain
 	pk.Domain = *domain
 
+	t.Phase("pk.G2", start)
+
 	return nil
 }
 
@@ -354,6 +961,128 @@ func setupABC(r1cs *cs.R1CS, domain *fft.Domain, toxicWaste toxicWaste) (A []fr.
 
 }
 
+func setupLazyABC(r1cs *cs.R1CS, domain *fft.Domain, toxicWaste toxicWaste) (A []fr.Element, B []fr.Element, C []fr.Element) {
+
+	nbWires := r1cs.NbInternalVariables + r1cs.NbPublicVariables + r1cs.NbSecretVariables
+
+	A = make([]fr.Element, nbWires)
+	B = make([]fr.Element, nbWires)
+	C = make([]fr.Element, nbWires)
+
+	one := fr.One()
+
+	// first we compute [t-w^i] and its inverse
+	var w fr.Element
+	w.Set(&domain.Generator)
+	wi := fr.One()
+	t := make([]fr.Element, len(r1cs.Constraints)+r1cs.LazyCons.GetConstraintsAll()+1)
+	for i := 0; i < len(t); i++ {
+		t[i].Sub(&toxicWaste.t, &wi)
+		wi.Mul(&wi, &w) // TODO this is already pre computed in fft.Domain
+	}
+	tInv := fr.BatchInvert(t)
+
+	// evaluation of the i-th lagrange polynomial at t
+	var L fr.Element
+
+	// L = 1/n*(t^n-1)/(t-1), Li+1 = w*Li*(t-w^i)/(t-w^(i+1))
+
+	// Setting L0
+	L.Exp(toxicWaste.t, new(big.Int).SetUint64(domain.Cardinality)).
+		Sub(&L, &one)
+	L.Mul(&L, &tInv[0]).
+		Mul(&L, &domain.CardinalityInv)
+
+	accumulate := func(res *fr.Element, t compiled.Term, value *fr.Element) {
+		cID := t.CoeffID()
+		switch cID {
+		case compiled.CoeffIdZero:
+			return
+		case compiled.CoeffIdOne:
+			res.Add(res, value)
+		case compiled.CoeffIdMinusOne:
+			res.Sub(res, value)
+		case compiled.CoeffIdTwo:
+			var buffer fr.Element
+			buffer.Double(value)
+			res.Add(res, &buffer)
+		default:
+			var buffer fr.Element
+			buffer.Mul(&r1cs.Coefficients[cID], value)
+			res.Add(res, &buffer)
+		}
+	}
+
+	// each constraint is in the form
+	// L * R == O
+	// L, R and O being linear expressions
+	// for each term appearing in the linear expression,
+	// we compute term.Coefficient * L, and cumulate it in
+	// A, B or C at the indice of the variable
+	for i, c := range r1cs.Constraints {
+
+		for _, t := range c.L {
+			accumulate(&A[t.WireID()], t, &L)
+		}
+		for _, t := range c.R {
+			accumulate(&B[t.WireID()], t, &L)
+		}
+		for _, t := range c.O {
+			accumulate(&C[t.WireID()], t, &L)
+		}
+
+		// Li+1 = w*Li*(t-w^i)/(t-w^(i+1))
+		L.Mul(&L, &w)
+		L.Mul(&L, &t[i])
+		L.Mul(&L, &tInv[i+1])
+	}
+	idx := len(r1cs.Constraints)
+	for _, li := range r1cs.LazyCons {
+		numJ := li.GetConstraintsNum()
+		shift := li.GetShift(&r1cs.R1CS, &r1cs.CoefT)
+		for j := 0; j < numJ; j++ {
+			row := li.FetchLazy(j, &r1cs.R1CS, &r1cs.CoefT)
+
+			for _, t := range row.L {
+				wID := lazyWireID(t, li, shift, j, 1)
+				accumulate(&A[wID], t, &L)
+			}
+			for _, t := range row.R {
+				wID := lazyWireID(t, li, shift, j, 2)
+				accumulate(&B[wID], t, &L)
+			}
+			for _, t := range row.O {
+				wID := lazyWireID(t, li, shift, j, 3)
+				accumulate(&C[wID], t, &L)
+			}
+
+			// Li+1 = w*Li*(t-w^i)/(t-w^(i+1))
+			L.Mul(&L, &w)
+			L.Mul(&L, &t[idx])
+			L.Mul(&L, &tInv[idx+1])
+
+			idx++
+		}
+	}
+	return
+
+}
+
+// lazyWireID returns the wire id of the term t of the j-th constraint of li, loc being 1, 2 or 3
+// for its L, R or O linear expression: the wires of a lazy constraint are the ones of the constraint
+// it was generated from, shifted by shift unless they are inputs
+func lazyWireID(t compiled.Term, li compiled.LazyInputs, shift, j, loc int) int {
+	shiftI := shift
+	if li.IsInput(j, uint8(loc)) {
+		shiftI = 0
+	}
+	wID := t.WireID()
+	if wID != 0 {
+		wID += shiftI
+	}
+	return wID
+}
+
 // toxicWaste toxic waste
 type toxicWaste struct {
 
@@ -365,32 +1094,33 @@ type toxicWaste struct {
 	alphaReg, betaReg, gammaReg, deltaReg fr.Element
 }
 
-func sampleToxicWaste() (toxicWaste, error) {
+// sampleToxicWaste samples the toxic waste from rnd, or from crypto/rand if rnd is nil
+func sampleToxicWaste(rnd io.Reader) (toxicWaste, error) {
 
 	res := toxicWaste{}
 
 	for res.t.IsZero() {
-		if _, err := res.t.SetRandom(); err != nil {
+		if err := setRandom(&res.t, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.alpha.IsZero() {
-		if _, err := res.alpha.SetRandom(); err != nil {
+		if err := setRandom(&res.alpha, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.beta.IsZero() {
-		if _, err := res.beta.SetRandom(); err != nil {
+		if err := setRandom(&res.beta, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.gamma.IsZero() {
-		if _, err := res.gamma.SetRandom(); err != nil {
+		if err := setRandom(&res.gamma, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.delta.IsZero() {
-		if _, err := res.delta.SetRandom(); err != nil {
+		if err := setRandom(&res.delta, rnd); err != nil {
 			return res, err
 		}
 	}
@@ -406,6 +1136,20 @@ func sampleToxicWaste() (toxicWaste, error) {
 	return res, nil
 }
 
+// setRandom sets x to a uniformly random element read from rnd, or from crypto/rand if rnd is nil
+func setRandom(x *fr.Element, rnd io.Reader) error {
+	if rnd == nil {
+		_, err := x.SetRandom()
+		return err
+	}
+	v, err := rand.Int(rnd, fr.Modulus())
+	if err != nil {
+		return err
+	}
+	x.SetBigInt(v)
+	return nil
+}
+
 // DummySetup fills a random ProvingKey
 // used for test or benchmarking purposes
 func DummySetup(r1cs *cs.R1CS, pk *ProvingKey) error {
@@ -440,7 +1184,7 @@ func DummySetup(r1cs *cs.R1CS, pk *ProvingKey) error {
 	}
 
 	// samples toxic waste
-	toxicWaste, err := sampleToxicWaste()
+	toxicWaste, err := sampleToxicWaste(nil)
 	if err != nil {
 		return err
 	}
@@ -19,6 +19,7 @@ package plonk
 import (
 	curve "github.com/consensys/gnark-crypto/ecc/bls12-377"
 
+	"bufio"
 	"errors"
 	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
 	"io"
@@ -49,8 +50,25 @@ func (proof *Proof) WriteTo(w io.Writer) (int64, error) {
 		return n + enc.BytesWritten(), err
 	}
 	n2, err := proof.ZShiftedOpening.WriteTo(w)
+	n += n2
+	if err != nil {
+		return n + enc.BytesWritten(), err
+	}
+
+	// lookup argument, an empty list of commitments if there is none
+	var lookup []curve.G1Affine
+	if proof.Lookup != nil {
+		lookup = []curve.G1Affine{proof.Lookup.F, proof.Lookup.H1, proof.Lookup.H2, proof.Lookup.Z}
+	}
+	if err := encodePoints(enc, lookup); err != nil {
+		return n + enc.BytesWritten(), err
+	}
+	if proof.Lookup != nil {
+		n2, err = proof.Lookup.ShiftedOpening.WriteTo(w)
+		n += n2
+	}
 
-	return n + n2 + enc.BytesWritten(), err
+	return n + enc.BytesWritten(), err
 }
 
 // ReadFrom reads binary representation of Proof from r
@@ -77,7 +95,27 @@ func (proof *Proof) ReadFrom(r io.Reader) (int64, error) {
 		return n + dec.BytesRead(), err
 	}
 	n2, err := proof.ZShiftedOpening.ReadFrom(r)
-	return n + n2 + dec.BytesRead(), err
+	n += n2
+	if err != nil {
+		return n + dec.BytesRead(), err
+	}
+
+	lookup, err := decodePoints(dec)
+	if err != nil {
+		return n + dec.BytesRead(), err
+	}
+	proof.Lookup = nil
+	switch len(lookup) {
+	case 0:
+	case 4:
+		proof.Lookup = &LookupProof{F: lookup[0], H1: lookup[1], H2: lookup[2], Z: lookup[3]}
+		n2, err = proof.Lookup.ShiftedOpening.ReadFrom(r)
+		n += n2
+	default:
+		err = errors.New("invalid lookup commitments, expected 0 or 4")
+	}
+
+	return n + dec.BytesRead(), err
 }
 
 // WriteTo writes binary encoding of ProvingKey to w
@@ -121,6 +159,12 @@ func (pk *ProvingKey) WriteTo(w io.Writer) (n int64, err error) {
 		([]fr.Element)(pk.S2Canonical),
 		([]fr.Element)(pk.S3Canonical),
 		pk.Permutation,
+		pk.Qlookup,
+		pk.Qtable,
+		pk.LTable[0],
+		pk.LTable[1],
+		pk.LTable[2],
+		pk.LTable[3],
 	}
 
 	for _, v := range toEncode {
@@ -166,6 +210,12 @@ func (pk *ProvingKey) ReadFrom(r io.Reader) (int64, error) {
 		(*[]fr.Element)(&pk.S2Canonical),
 		(*[]fr.Element)(&pk.S3Canonical),
 		&pk.Permutation,
+		&pk.Qlookup,
+		&pk.Qtable,
+		&pk.LTable[0],
+		&pk.LTable[1],
+		&pk.LTable[2],
+		&pk.LTable[3],
 	}
 
 	for _, v := range toDecode {
@@ -203,9 +253,21 @@ func (vk *VerifyingKey) WriteTo(w io.Writer) (n int64, err error) {
 		}
 	}
 
+	if err := encodePoints(enc, vk.lookupDigests()); err != nil {
+		return enc.BytesWritten(), err
+	}
+
 	return enc.BytesWritten(), nil
 }
 
+// lookupDigests returns the commitments of vk.Lookup, Qlookup, Qtable, T₀..T₃, or nil if there is none
+func (vk *VerifyingKey) lookupDigests() []curve.G1Affine {
+	if vk.Lookup == nil {
+		return nil
+	}
+	return append([]curve.G1Affine{vk.Lookup.Qlookup, vk.Lookup.Qtable}, vk.Lookup.T[:]...)
+}
+
 // ReadFrom reads from binary representation in r into VerifyingKey
 func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
 	dec := curve.NewDecoder(r)
@@ -230,5 +292,115 @@ func (vk *VerifyingKey) ReadFrom(r io.Reader) (int64, error) {
 		}
 	}
 
+	lookup, err := decodePoints(dec)
+	if err != nil {
+		return dec.BytesRead(), err
+	}
+	vk.Lookup = nil
+	switch len(lookup) {
+	case 0:
+	case 6:
+		vk.Lookup = &LookupVerifyingKey{Qlookup: lookup[0], Qtable: lookup[1]}
+		copy(vk.Lookup.T[:], lookup[2:])
+	default:
+		return dec.BytesRead(), errors.New("invalid lookup commitments, expected 0 or 6")
+	}
+
 	return dec.BytesRead(), nil
 }
+
+// maxLookupPoints bounds the number of points read by decodePoints
+const maxLookupPoints = 6
+
+// encodePoints writes the number of points followed by the points, which are the optional
+// commitments of the lookup argument
+func encodePoints(enc *curve.Encoder, points []curve.G1Affine) error {
+	if err := enc.Encode(uint64(len(points))); err != nil {
+		return err
+	}
+	for i := range points {
+		if err := enc.Encode(&points[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodePoints reads the output of encodePoints
+func decodePoints(dec *curve.Decoder) ([]curve.G1Affine, error) {
+	var nbPoints uint64
+	if err := dec.Decode(&nbPoints); err != nil {
+		return nil, err
+	}
+	if nbPoints > maxLookupPoints {
+		return nil, errors.New("invalid number of lookup commitments")
+	}
+	points := make([]curve.G1Affine, nbPoints)
+	for i := range points {
+		if err := dec.Decode(&points[i]); err != nil {
+			return nil, err
+		}
+	}
+	return points, nil
+}
+
+// writeDomainsTo writes the binary encoding of pk.Domain to w, it is the ProvingKeyDomain segment
+// of a dump (see SetupWithDump)
+func (pk *ProvingKey) writeDomainsTo(w io.Writer) (int64, error) {
+	n, err := pk.Domain[0].WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	n2, err := pk.Domain[1].WriteTo(w)
+	return n + n2, err
+}
+
+// readDomainsFrom reads the output of writeDomainsTo in pk.Domain
+func (pk *ProvingKey) readDomainsFrom(r io.Reader) (int64, error) {
+	n, err := pk.Domain[0].ReadFrom(r)
+	if err != nil {
+		return n, err
+	}
+	n2, err := pk.Domain[1].ReadFrom(r)
+	return n + n2, err
+}
+
+// writeElements returns a function writing the binary encoding of v, as a segment of a dump
+func writeElements(v []fr.Element) func(io.Writer) (int64, error) {
+	return func(w io.Writer) (int64, error) {
+		bw := bufio.NewWriter(w)
+		enc := curve.NewEncoder(bw)
+		if err := enc.Encode(v); err != nil {
+			return enc.BytesWritten(), err
+		}
+		return enc.BytesWritten(), bw.Flush()
+	}
+}
+
+// readElements returns a function reading the output of writeElements in v,
+// the memory of v is reused if it has the right length
+func readElements(v *[]fr.Element) func(io.Reader) (int64, error) {
+	return func(r io.Reader) (int64, error) {
+		dec := curve.NewDecoder(bufio.NewReader(r))
+		err := dec.Decode(v)
+		return dec.BytesRead(), err
+	}
+}
+
+// writePermutationTo writes the binary encoding of pk.Permutation to w
+func (pk *ProvingKey) writePermutationTo(w io.Writer) (int64, error) {
+	bw := bufio.NewWriter(w)
+	enc := curve.NewEncoder(bw)
+	if err := enc.Encode(pk.Permutation); err != nil {
+		return enc.BytesWritten(), err
+	}
+	return enc.BytesWritten(), bw.Flush()
+}
+
+// readPermutationFrom reads the output of writePermutationTo in pk.Permutation, pk.Domain must be set
+func (pk *ProvingKey) readPermutationFrom(r io.Reader) (int64, error) {
+	pk.Permutation = make([]int64, 3*pk.Domain[0].Cardinality)
+	dec := curve.NewDecoder(bufio.NewReader(r))
+	err := dec.Decode(&pk.Permutation)
+	return dec.BytesRead(), err
+}
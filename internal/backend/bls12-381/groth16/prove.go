@@ -25,12 +25,16 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc/bls12-381/fr/fft"
 
+	"errors"
 	"fmt"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend"
 	bls12_381witness "github.com/consensys/gnark/internal/backend/bls12-381/witness"
+	"github.com/consensys/gnark/internal/backend/dump"
+	"github.com/consensys/gnark/internal/backend/progress"
 	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
+	"io"
 	"math/big"
 	"runtime"
 	"time"
@@ -61,6 +65,7 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 	}
 
 	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Int("nbConstraints", len(r1cs.Constraints)).Str("backend", "groth16").Logger()
+	t := progress.New(opt.Ctx, opt.Progress, 7)
 
 	// solve the R1CS and compute the a, b, c vectors
 	a := make([]fr.Element, len(r1cs.Constraints), pk.Domain.Cardinality)
@@ -68,19 +73,24 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 	c := make([]fr.Element, len(r1cs.Constraints), pk.Domain.Cardinality)
 	var wireValues []fr.Element
 	var err error
+	solveStart := time.Now()
 	if wireValues, err = r1cs.Solve(witness, a, b, c, opt); err != nil {
 		if !opt.Force {
 			return nil, err
 		} else {
 			// we need to fill wireValues with random values else multi exps don't do much
 			var r fr.Element
-			_, _ = r.SetRandom()
+			_ = setRandom(&r, opt.RandomSource)
 			for i := r1cs.NbPublicVariables + r1cs.NbSecretVariables; i < len(wireValues); i++ {
 				wireValues[i] = r
 				r.Double(&r)
 			}
 		}
 	}
+	t.Phase("solve", solveStart)
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
 	start := time.Now()
 
 	// set the wire values in regular form
@@ -90,15 +100,36 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 		}
 	})
 
+	// sample random r and s
+	var r, s big.Int
+	var _r, _s, _kr fr.Element
+	if err := setRandom(&_r, opt.RandomSource); err != nil {
+		return nil, err
+	}
+	if err := setRandom(&_s, opt.RandomSource); err != nil {
+		return nil, err
+	}
+	_kr.Mul(&_r, &_s).Neg(&_kr)
+
+	_r.FromMont()
+	_s.FromMont()
+	_kr.FromMont()
+	_r.ToBigInt(&r)
+	_s.ToBigInt(&s)
+
+	// computes r[δ], s[δ], kr[δ]
+	deltas := curve.BatchScalarMultiplicationG1(&pk.G1.Delta, []fr.Element{_r, _s, _kr})
+
 	// H (witness reduction / FFT part)
 	var h []fr.Element
-	chHDone := make(chan struct{}, 1)
+	chHDone := make(chan error, 1)
 	go func() {
-		h = computeH(a, b, c, &pk.Domain)
+		var err error
+		h, err = computeH(a, b, c, &pk.Domain, t)
 		a = nil
 		b = nil
 		c = nil
-		chHDone <- struct{}{}
+		chHDone <- err
 	}()
 
 	// we need to copy and filter the wireValues for each multi exp
@@ -129,26 +160,6 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 		close(chWireValuesB)
 	}()
 
-	// sample random r and s
-	var r, s big.Int
-	var _r, _s, _kr fr.Element
-	if _, err := _r.SetRandom(); err != nil {
-		return nil, err
-	}
-	if _, err := _s.SetRandom(); err != nil {
-		return nil, err
-	}
-	_kr.Mul(&_r, &_s).Neg(&_kr)
-
-	_r.FromMont()
-	_s.FromMont()
-	_kr.FromMont()
-	_r.ToBigInt(&r)
-	_s.ToBigInt(&s)
-
-	// computes r[δ], s[δ], kr[δ]
-	deltas := curve.BatchScalarMultiplicationG1(&pk.G1.Delta, []fr.Element{_r, _s, _kr})
-
 	proof := &Proof{}
 	var bs1, ar curve.G1Jac
 
@@ -157,11 +168,13 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 	chBs1Done := make(chan error, 1)
 	computeBS1 := func() {
 		<-chWireValuesB
-		if _, err := bs1.MultiExp(pk.G1.B, wireValuesB, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		start := time.Now()
+		var err error
+		if bs1, err = multiExpG1(pk.G1.B, wireValuesB, n/2, t); err != nil {
 			chBs1Done <- err
-			close(chBs1Done)
 			return
 		}
+		t.Phase("msm.B1", start)
 		bs1.AddMixed(&pk.G1.Beta)
 		bs1.AddMixed(&deltas[1])
 		chBs1Done <- nil
@@ -170,11 +183,13 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 	chArDone := make(chan error, 1)
 	computeAR1 := func() {
 		<-chWireValuesA
-		if _, err := ar.MultiExp(pk.G1.A, wireValuesA, ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
+		start := time.Now()
+		var err error
+		if ar, err = multiExpG1(pk.G1.A, wireValuesA, n/2, t); err != nil {
 			chArDone <- err
-			close(chArDone)
 			return
 		}
+		t.Phase("msm.A", start)
 		ar.AddMixed(&pk.G1.Alpha)
 		ar.AddMixed(&deltas[0])
 		proof.Ar.FromJacobian(&ar)
@@ -186,51 +201,59 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 		// we could NOT split the Krs multiExp in 2, and just append pk.G1.K and pk.G1.Z
 		// however, having similar lengths for our tasks helps with parallelism
 
-		var krs, krs2, p1 curve.G1Jac
+		var krs2, p1 curve.G1Jac
 		chKrs2Done := make(chan error, 1)
 		go func() {
-			_, err := krs2.MultiExp(pk.G1.Z, h, ecc.MultiExpConfig{NbTasks: n / 2})
+			start := time.Now()
+			var err error
+			if krs2, err = multiExpG1(pk.G1.Z, h, n/2, t); err == nil {
+				t.Phase("msm.Z", start)
+			}
 			chKrs2Done <- err
 		}()
-		if _, err := krs.MultiExp(pk.G1.K, wireValues[r1cs.NbPublicVariables:], ecc.MultiExpConfig{NbTasks: n / 2}); err != nil {
-			chKrsDone <- err
-			return
+		start := time.Now()
+		krs, err := multiExpG1(pk.G1.K, wireValues[r1cs.NbPublicVariables:], n/2, t)
+		if err == nil {
+			t.Phase("msm.K", start)
+			krs.AddMixed(&deltas[2])
 		}
-		krs.AddMixed(&deltas[2])
-		n := 3
-		for n != 0 {
+
+		// the other multi exps are awaited even if one of them failed, so that none is left running
+		for i := 0; i < 3; i++ {
+			var _err error
 			select {
-			case err := <-chKrs2Done:
-				if err != nil {
-					chKrsDone <- err
-					return
+			case _err = <-chKrs2Done:
+				if _err == nil && err == nil {
+					krs.AddAssign(&krs2)
 				}
-				krs.AddAssign(&krs2)
-			case err := <-chArDone:
-				if err != nil {
-					chKrsDone <- err
-					return
+			case _err = <-chArDone:
+				if _err == nil && err == nil {
+					p1.ScalarMultiplication(&ar, &s)
+					krs.AddAssign(&p1)
 				}
-				p1.ScalarMultiplication(&ar, &s)
-				krs.AddAssign(&p1)
-			case err := <-chBs1Done:
-				if err != nil {
-					chKrsDone <- err
-					return
+			case _err = <-chBs1Done:
+				if _err == nil && err == nil {
+					p1.ScalarMultiplication(&bs1, &r)
+					krs.AddAssign(&p1)
 				}
-				p1.ScalarMultiplication(&bs1, &r)
-				krs.AddAssign(&p1)
 			}
-			n--
+			if err == nil {
+				err = _err
+			}
+		}
+		if err != nil {
+			chKrsDone <- err
+			return
 		}
 
 		proof.Krs.FromJacobian(&krs)
 		chKrsDone <- nil
 	}
 
-	computeBS2 := func() error {
+	chBs2Done := make(chan error, 1)
+	computeBS2 := func() {
 		// Bs2 (1 multi exp G2 - size = len(wires))
-		var Bs, deltaS curve.G2Jac
+		var deltaS curve.G2Jac
 
 		nbTasks := n
 		if nbTasks <= 16 {
@@ -238,9 +261,13 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 			nbTasks *= 2
 		}
 		<-chWireValuesB
-		if _, err := Bs.MultiExp(pk.G2.B, wireValuesB, ecc.MultiExpConfig{NbTasks: nbTasks}); err != nil {
-			return err
+		start := time.Now()
+		Bs, err := multiExpG2(pk.G2.B, wireValuesB, nbTasks, t)
+		if err != nil {
+			chBs2Done <- err
+			return
 		}
+		t.Phase("msm.B2", start)
 
 		deltaS.FromAffine(&pk.G2.Delta)
 		deltaS.ScalarMultiplication(&deltaS, &s)
@@ -248,36 +275,103 @@ func Prove(r1cs *cs.R1CS, pk *ProvingKey, witness bls12_381witness.Witness, opt
 		Bs.AddMixed(&pk.G2.Beta)
 
 		proof.Bs.FromJacobian(&Bs)
-		return nil
+		chBs2Done <- nil
 	}
 
-	// wait for FFT to end, as it uses all our CPUs
-	<-chHDone
+	// wait for FFT to end, as it uses all our CPUs. computeH stops between the FFTs if the
+	// prover is cancelled, and the goroutines are joined before returning so that none of them
+	// keeps running, or reporting its progress, once Prove returned
+	if err := <-chHDone; err != nil {
+		<-chWireValuesA
+		<-chWireValuesB
+		return nil, err
+	}
 
 	// schedule our proof part computations
 	go computeKRS()
 	go computeAR1()
 	go computeBS1()
-	if err := computeBS2(); err != nil {
-		return nil, err
-	}
+	go computeBS2()
 
-	// wait for all parts of the proof to be computed.
-	if err := <-chKrsDone; err != nil {
+	// wait for all parts of the proof to be computed, the multi exps stop between their chunks
+	// if the prover is cancelled
+	errBs2, errKrs := <-chBs2Done, <-chKrsDone
+	if err := t.Err(); err != nil {
 		return nil, err
 	}
+	if errBs2 != nil {
+		return nil, errBs2
+	}
+	if errKrs != nil {
+		return nil, errKrs
+	}
 
 	log.Debug().Dur("took", time.Since(start)).Msg("prover done")
 
 	return proof, nil
 }
 
-func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
+// msmChunkSize is the number of points of the multi exponentiations of the provers between
+// two checks of their context
+const msmChunkSize = 1 << 20
+
+// multiExpG1 computes the multi exponentiation of scalars with points by chunks of msmChunkSize points,
+// it stops between chunks if the prover is cancelled
+func multiExpG1(points []curve.G1Affine, scalars []fr.Element, nbTasks int, t *progress.Tracker) (curve.G1Jac, error) {
+	var res curve.G1Jac
+	if len(points) != len(scalars) {
+		return res, errors.New("len(points) != len(scalars)")
+	}
+	for offset := 0; offset < len(scalars); offset += msmChunkSize {
+		if err := t.Err(); err != nil {
+			return res, err
+		}
+		end := offset + msmChunkSize
+		if end > len(scalars) {
+			end = len(scalars)
+		}
+		var partial curve.G1Jac
+		if _, err := partial.MultiExp(points[offset:end], scalars[offset:end], ecc.MultiExpConfig{NbTasks: nbTasks}); err != nil {
+			return res, err
+		}
+		res.AddAssign(&partial)
+	}
+	return res, nil
+}
+
+// multiExpG2 computes the multi exponentiation of scalars with points by chunks of msmChunkSize points,
+// it stops between chunks if the prover is cancelled
+func multiExpG2(points []curve.G2Affine, scalars []fr.Element, nbTasks int, t *progress.Tracker) (curve.G2Jac, error) {
+	var res curve.G2Jac
+	if len(points) != len(scalars) {
+		return res, errors.New("len(points) != len(scalars)")
+	}
+	for offset := 0; offset < len(scalars); offset += msmChunkSize {
+		if err := t.Err(); err != nil {
+			return res, err
+		}
+		end := offset + msmChunkSize
+		if end > len(scalars) {
+			end = len(scalars)
+		}
+		var partial curve.G2Jac
+		if _, err := partial.MultiExp(points[offset:end], scalars[offset:end], ecc.MultiExpConfig{NbTasks: nbTasks}); err != nil {
+			return res, err
+		}
+		res.AddAssign(&partial)
+	}
+	return res, nil
+}
+
+// computeH returns the quotient of a·b-c by the vanishing polynomial of domain, it stops between the FFTs
+// if the prover is cancelled
+func computeH(a, b, c []fr.Element, domain *fft.Domain, t *progress.Tracker) ([]fr.Element, error) {
 	// H part of Krs
 	// Compute H (hz=ab-c, where z=-2 on ker X^n+1 (z(x)=x^n-1))
 	// 	1 - _a = ifft(a), _b = ifft(b), _c = ifft(c)
 	// 	2 - ca = fft_coset(_a), ba = fft_coset(_b), cc = fft_coset(_c)
 	// 	3 - h = ifft_coset(ca o cb - cc)
+	start := time.Now()
 
 	n := len(a)
 
@@ -288,13 +382,13 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	c = append(c, padding...)
 	n = len(a)
 
-	domain.FFTInverse(a, fft.DIF)
-	domain.FFTInverse(b, fft.DIF)
-	domain.FFTInverse(c, fft.DIF)
-
-	domain.FFT(a, fft.DIT, true)
-	domain.FFT(b, fft.DIT, true)
-	domain.FFT(c, fft.DIT, true)
+	for _, p := range [][]fr.Element{a, b, c} {
+		if err := t.Err(); err != nil {
+			return nil, err
+		}
+		domain.FFTInverse(p, fft.DIF)
+		domain.FFT(p, fft.DIT, true)
+	}
 
 	var den, one fr.Element
 	one.SetOne()
@@ -312,6 +406,9 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 	})
 
 	// ifft_coset
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
 	domain.FFTInverse(a, fft.DIF, true)
 
 	utils.Parallelize(len(a), func(start, end int) {
@@ -320,5 +417,346 @@ func computeH(a, b, c []fr.Element, domain *fft.Domain) []fr.Element {
 		}
 	})
 
-	return a
+	t.Phase("computeH", start)
+	return a, nil
+}
+
+// ProveRoll behaves as Prove, loading the A, B1, Z, K and B2 segments of the ProvingKey dumped
+// by SetupWithDump under session one at a time. pkE holds the E segment of the ProvingKey and
+// pkB2.G2.B is set to the B2 segment once the proof is computed.
+//
+// r1cs must be the constraint system the dump was written for, and each segment is checked
+// against the manifest of the dump before being used.
+func ProveRoll(r1cs *cs.R1CS, pkE, pkB2 *ProvingKey, witness bls12_381witness.Witness, opt backend.ProverConfig,
+	session string) (*Proof, error) {
+	log := logger.Logger().With().Str("curve", r1cs.CurveID().String()).Int("nbConstraints", len(r1cs.Constraints)+r1cs.LazyCons.GetConstraintsAll()).Str("backend", "groth16").Logger()
+	timeS := time.Now()
+	t := progress.New(opt.Ctx, opt.Progress, 7)
+
+	m, err := dump.ReadManifest(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.CheckCurve(r1cs.CurveID()); err != nil {
+		return nil, err
+	}
+	if err := m.CheckCircuit(r1cs.WriteCircuitTo, r1cs.WriteCTTo); err != nil {
+		return nil, err
+	}
+
+	proof := &Proof{}
+
+	var wireValues []fr.Element
+	var h []fr.Element
+
+	{
+		card := pkE.Card
+		nbCons := r1cs.GetNbConstraints() + r1cs.LazyCons.GetConstraintsAll()
+		if uint64(nbCons) > card {
+			return nil, fmt.Errorf("proving key domain of size %d can't hold %d constraints", card, nbCons)
+		}
+		a := make([]fr.Element, nbCons, card)
+		b := make([]fr.Element, nbCons, card)
+		c := make([]fr.Element, nbCons, card)
+
+		var err error
+		solveStart := time.Now()
+		if wireValues, err = r1cs.Solve(witness, a, b, c, opt); err != nil {
+			return nil, err
+		}
+		t.Phase("solve", solveStart)
+
+		// set the wire values in regular form
+		utils.Parallelize(len(wireValues), func(start, end int) {
+			for i := start; i < end; i++ {
+				wireValues[i].FromMont()
+			}
+		})
+
+		// H (witness reduction / FFT part)
+		chHDone := make(chan error, 1)
+		go func() {
+			var err error
+			domain := fft.NewDomain(uint64(card))
+			h, err = computeH(a, b, c, domain, t)
+			a = nil
+			b = nil
+			c = nil
+			chHDone <- err
+		}()
+		if err := <-chHDone; err != nil {
+			return nil, err
+		}
+	}
+	runtime.GC()
+
+	// we need to copy and filter the wireValues for each multi exp
+	// as pk.G1.A, pk.G1.B and pk.G2.B may have (a significant) number of point at infinity
+	var deltas []curve.G1Affine
+	var r, s big.Int
+	{
+		// sample random r and s
+		var _r, _s, _kr fr.Element
+		if err := setRandom(&_r, opt.RandomSource); err != nil {
+			return nil, err
+		}
+		if err := setRandom(&_s, opt.RandomSource); err != nil {
+			return nil, err
+		}
+		_kr.Mul(&_r, &_s).Neg(&_kr)
+
+		_r.FromMont()
+		_s.FromMont()
+		_kr.FromMont()
+		_r.ToBigInt(&r)
+		_s.ToBigInt(&s)
+
+		// computes r[δ], s[δ], kr[δ]
+		deltas = curve.BatchScalarMultiplicationG1(&pkE.G1.Delta, []fr.Element{_r, _s, _kr})
+	}
+	n := runtime.NumCPU()
+
+	//Bs2
+	var pkA *ProvingKey
+	var chPkA <-chan loadedSegment
+	var wireValuesB []fr.Element
+	{
+		chWireValuesB := make(chan struct{}, 1)
+		go func() {
+			wireValuesB = make([]fr.Element, len(wireValues)-int(pkE.NbInfinityB))
+			for i, j := 0, 0; j < len(wireValuesB); i++ {
+				if pkE.InfinityB[i] {
+					continue
+				}
+				wireValuesB[j] = wireValues[i]
+				j++
+			}
+			close(chWireValuesB)
+		}()
+		<-chWireValuesB
+
+		chBs2Done := make(chan error, 1)
+		// computeBS2 := func() {
+		go func() {
+			// Bs2 (1 multi exp G2 - size = len(wires))
+			var deltaS curve.G2Jac
+
+			nbTasks := n
+			if nbTasks <= 16 {
+				// if we don't have a lot of CPUs, this may artificially split the MSM
+				nbTasks *= 2
+			}
+			start := time.Now()
+			Bs, err := multiExpG2(pkB2.G2.B, wireValuesB, nbTasks, t)
+			if err != nil {
+				chBs2Done <- err
+				return
+			}
+			t.Phase("msm.B2", start)
+
+			deltaS.FromAffine(&pkE.G2.Delta)
+			deltaS.ScalarMultiplication(&deltaS, &s)
+			Bs.AddAssign(&deltaS)
+			Bs.AddMixed(&pkE.G2.Beta)
+
+			proof.Bs.FromJacobian(&Bs)
+			chBs2Done <- nil
+		}()
+
+		chPkA = loadSegment(m, dump.ProvingKeyA, (*ProvingKey).UnsafeReadAFrom)
+		if err := <-chBs2Done; err != nil {
+			<-chPkA
+			return nil, err
+		}
+
+		pkB2.G2.B = make([]curve.G2Affine, 0)
+	}
+	runtime.GC()
+
+	var pkB1 *ProvingKey
+	var chPkB1 <-chan loadedSegment
+	var ar curve.G1Jac
+	var wireValuesA []fr.Element
+	{
+		res := <-chPkA
+		if res.err != nil {
+			return nil, res.err
+		}
+		pkA = res.pk
+
+		chWireValuesA := make(chan struct{}, 1)
+		go func() {
+			wireValuesA = make([]fr.Element, len(wireValues)-int(pkE.NbInfinityA))
+			for i, j := 0, 0; j < len(wireValuesA); i++ {
+				if pkE.InfinityA[i] {
+					continue
+				}
+				wireValuesA[j] = wireValues[i]
+				j++
+			}
+			close(chWireValuesA)
+		}()
+		<-chWireValuesA
+
+		chArDone := make(chan error, 1)
+		computeAR1 := func() {
+			// <-chWireValuesA
+			start := time.Now()
+			var err error
+			if ar, err = multiExpG1(pkA.G1.A, wireValuesA, n/2, t); err != nil {
+				chArDone <- err
+				return
+			}
+			t.Phase("msm.A", start)
+			ar.AddMixed(&pkE.G1.Alpha)
+			ar.AddMixed(&deltas[0])
+			proof.Ar.FromJacobian(&ar)
+			chArDone <- nil
+		}
+		go computeAR1()
+
+		chPkB1 = loadSegment(m, dump.ProvingKeyB1, (*ProvingKey).UnsafeReadB1From)
+		if err := <-chArDone; err != nil {
+			<-chPkB1
+			return nil, err
+		}
+
+		pkA = nil
+	}
+	runtime.GC()
+
+	var pkZ *ProvingKey
+	var chPkZ <-chan loadedSegment
+	var bs1 curve.G1Jac
+	{
+		res := <-chPkB1
+		if res.err != nil {
+			return nil, res.err
+		}
+		pkB1 = res.pk
+
+		chBs1Done := make(chan error, 1)
+		computeBS1 := func() {
+			// <-chWireValuesB
+			start := time.Now()
+			var err error
+			if bs1, err = multiExpG1(pkB1.G1.B, wireValuesB, n/2, t); err != nil {
+				chBs1Done <- err
+				return
+			}
+			t.Phase("msm.B1", start)
+			bs1.AddMixed(&pkE.G1.Beta)
+			bs1.AddMixed(&deltas[1])
+			chBs1Done <- nil
+		}
+		go computeBS1()
+		chPkZ = loadSegment(m, dump.ProvingKeyZ, (*ProvingKey).UnsafeReadZFrom)
+		if err := <-chBs1Done; err != nil {
+			<-chPkZ
+			return nil, err
+		}
+
+		pkB1 = nil
+	}
+	runtime.GC()
+
+	var pkK *ProvingKey
+	var chPkK <-chan loadedSegment
+	var krs2 curve.G1Jac
+	{
+		res := <-chPkZ
+		if res.err != nil {
+			return nil, res.err
+		}
+		pkZ = res.pk
+
+		chKrs2Done := make(chan error, 1)
+		go func() {
+			// pkZ := <-chPkZ
+			start := time.Now()
+			var err error
+			if krs2, err = multiExpG1(pkZ.G1.Z, h, n/2, t); err == nil {
+				t.Phase("msm.Z", start)
+			}
+			chKrs2Done <- err
+		}()
+		chPkK = loadSegment(m, dump.ProvingKeyK, (*ProvingKey).UnsafeReadKFrom)
+		if err := <-chKrs2Done; err != nil {
+			<-chPkK
+			return nil, err
+		}
+
+		pkZ = nil
+	}
+	runtime.GC()
+
+	{
+		res := <-chPkK
+		if res.err != nil {
+			return nil, res.err
+		}
+		pkK = res.pk
+
+		chKrsDone := make(chan error, 1)
+		// computeKRS := func() {
+		go func() {
+			// we could NOT split the Krs multiExp in 2, and just append pk.G1.K and pk.G1.Z
+			// however, having similar lengths for our tasks helps with parallelism
+
+			var p1 curve.G1Jac
+			start := time.Now()
+			krs, err := multiExpG1(pkK.G1.K, wireValues[r1cs.NbPublicVariables:], n/2, t)
+			if err != nil {
+				chKrsDone <- err
+				return
+			}
+			t.Phase("msm.K", start)
+			krs.AddMixed(&deltas[2])
+			p1.ScalarMultiplication(&bs1, &r)
+			krs.AddAssign(&p1)
+			p1.ScalarMultiplication(&ar, &s)
+			krs.AddAssign(&p1)
+			krs.AddAssign(&krs2)
+
+			proof.Krs.FromJacobian(&krs)
+			chKrsDone <- nil
+		}()
+
+		chPkB2 := loadSegment(m, dump.ProvingKeyB2, (*ProvingKey).UnsafeReadB2From)
+		res = <-chPkB2
+		if err := <-chKrsDone; err != nil {
+			return nil, err
+		}
+		if res.err != nil {
+			return nil, res.err
+		}
+		pkB2.G2.B = res.pk.G2.B
+
+		pkK = nil
+	}
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
+	log.Debug().Dur("took", time.Since(timeS)).Msg("prover done")
+
+	return proof, nil
+}
+
+// loadedSegment is a ProvingKey segment read by loadSegment
+type loadedSegment struct {
+	pk  *ProvingKey
+	err error
+}
+
+// loadSegment reads the segment name of the dump m in a new ProvingKey in the background
+func loadSegment(m *dump.Manifest, name string, read func(*ProvingKey, io.Reader) (int64, error)) <-chan loadedSegment {
+	ch := make(chan loadedSegment, 1)
+	go func() {
+		pk := &ProvingKey{}
+		err := m.ReadSegment(name, func(r io.Reader) (int64, error) {
+			return read(pk, r)
+		})
+		ch <- loadedSegment{pk: pk, err: err}
+	}()
+	return ch
 }
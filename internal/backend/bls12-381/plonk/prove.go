@@ -17,7 +17,9 @@
 package plonk
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
+	"io"
 	"math/big"
 	"math/bits"
 	"runtime"
@@ -38,6 +40,7 @@ import (
 
 	"github.com/consensys/gnark-crypto/fiat-shamir"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/internal/backend/progress"
 	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
 )
@@ -58,6 +61,9 @@ type Proof struct {
 
 	// Opening proof of Z at zeta*mu
 	ZShiftedOpening kzg.OpeningProof
+
+	// Commitments and opening proof of the lookup argument, nil if the constraint system has no lookups
+	Lookup *LookupProof
 }
 
 // Prove from the public data
@@ -65,11 +71,17 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 
 	log := logger.Logger().With().Str("curve", spr.CurveID().String()).Int("nbConstraints", len(spr.Constraints)).Str("backend", "plonk").Logger()
 	start := time.Now()
+	nbPhases := 8
+	if pk.Vk.Lookup != nil {
+		nbPhases++
+	}
+	t := progress.New(opt.Ctx, opt.Progress, nbPhases)
+
 	// pick a hash function that will be used to derive the challenges
 	hFunc := sha256.New()
 
 	// create a transcript manager to apply Fiat Shamir
-	fs := fiatshamir.NewTranscript(hFunc, "gamma", "beta", "alpha", "zeta")
+	fs := fiatshamir.NewTranscript(hFunc, challenges(pk.Vk)...)
 
 	// result
 	proof := &Proof{}
@@ -83,15 +95,20 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		} else {
 			// we need to fill solution with random values
 			var r fr.Element
-			_, _ = r.SetRandom()
+			_ = setRandom(&r, opt.RandomSource)
 			for i := spr.NbPublicVariables + spr.NbSecretVariables; i < len(solution); i++ {
 				solution[i] = r
 				r.Double(&r)
 			}
 		}
 	}
+	t.Phase("solve", start)
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
 
 	// query l, r, o in Lagrange basis, not blinded
+	phaseStart := time.Now()
 	evaluationLDomainSmall, evaluationRDomainSmall, evaluationODomainSmall := evaluateLROSmallDomain(spr, pk, solution)
 
 	// save ll, lr, lo, and make a copy of them in canonical basis.
@@ -100,7 +117,8 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		evaluationLDomainSmall,
 		evaluationRDomainSmall,
 		evaluationODomainSmall,
-		&pk.Domain[0])
+		&pk.Domain[0],
+		opt.RandomSource)
 	if err != nil {
 		return nil, err
 	}
@@ -109,6 +127,10 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	if err := commitToLRO(blindedLCanonical, blindedRCanonical, blindedOCanonical, proof, pk.Vk.KZGSRS); err != nil {
 		return nil, err
 	}
+	t.Phase("commit.LRO", phaseStart)
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
 
 	// The first challenge is derived using the public data: the commitments to the permutation,
 	// the coefficients of the circuit, and the public inputs.
@@ -129,6 +151,22 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		return nil, err
 	}
 
+	// compute and commit to the polynomials of the lookup argument, which are bound in alpha
+	var lookup *lookupProver
+	if pk.Vk.Lookup != nil {
+		phaseStart = time.Now()
+		if lookup, err = newLookupProver(spr, pk, &fs, proof, evaluationLDomainSmall, evaluationRDomainSmall, evaluationODomainSmall, opt.RandomSource); err != nil {
+			return nil, err
+		}
+		t.Phase("lookup", phaseStart)
+		if err := t.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Z and the quotient are computed concurrently
+	quotientStart := time.Now()
+
 	// compute Z, the permutation accumulator polynomial, in canonical basis
 	// ll, lr, lo are NOT blinded
 	var blindedZCanonical []fr.Element
@@ -140,7 +178,10 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			evaluationLDomainSmall,
 			evaluationRDomainSmall,
 			evaluationODomainSmall,
-			pk, beta, gamma)
+			pk, beta, gamma, opt.RandomSource)
+		if err == nil {
+			err = t.Err()
+		}
 		if err != nil {
 			chZ <- err
 			close(chZ)
@@ -157,9 +198,14 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			close(chZ)
 			return
 		}
+		t.Phase("commit.Z", quotientStart)
 
-		// derive alpha from the Comm(l), Comm(r), Comm(o), Com(Z)
-		alpha, err = deriveRandomness(&fs, "alpha", &proof.Z)
+		// derive alpha from the Comm(l), Comm(r), Comm(o), Com(Z) (and the Com(Z) of the lookup argument)
+		alphaPoints := []*curve.G1Affine{&proof.Z}
+		if lookup != nil {
+			alphaPoints = append(alphaPoints, &proof.Lookup.Z)
+		}
+		alpha, err = deriveRandomness(&fs, "alpha", alphaPoints...)
 		chZ <- err
 		close(chZ)
 	}()
@@ -188,8 +234,10 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		close(chEvalBO)
 	}()
 
+	// the goroutines below always wait for the evaluations of l, r and o, and are joined before
+	// returning, the context being checked between their steps
 	var constraintsInd, constraintsOrdering []fr.Element
-	chConstraintInd := make(chan struct{}, 1)
+	chConstraintInd := make(chan error, 1)
 	go func() {
 		// compute qk in canonical basis, completed with the public inputs
 		qkCompletedCanonical := make([]fr.Element, pk.Domain[0].Cardinality)
@@ -203,17 +251,24 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		<-chEvalBL
 		<-chEvalBR
 		<-chEvalBO
+		if err := t.Err(); err != nil {
+			chConstraintInd <- err
+			return
+		}
 		constraintsInd = evaluateConstraintsDomainBigBitReversed(
 			pk,
 			evaluationBlindedLDomainBigBitReversed,
 			evaluationBlindedRDomainBigBitReversed,
 			evaluationBlindedODomainBigBitReversed,
 			qkCompletedCanonical)
-		close(chConstraintInd)
+		chConstraintInd <- nil
 	}()
 
 	chConstraintOrdering := make(chan error, 1)
 	go func() {
+		<-chEvalBL
+		<-chEvalBR
+		<-chEvalBO
 		if err := <-chZ; err != nil {
 			chConstraintOrdering <- err
 			return
@@ -222,9 +277,10 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 		evaluationBlindedZDomainBigBitReversed = evaluateDomainBigBitReversed(blindedZCanonical, &pk.Domain[1])
 		// compute zu*g1*g2*g3-z*f1*f2*f3 on the coset of the big domain
 		// evalL, evalO, evalR are the evaluations of the blinded versions of l, r, o.
-		<-chEvalBL
-		<-chEvalBR
-		<-chEvalBO
+		if err := t.Err(); err != nil {
+			chConstraintOrdering <- err
+			return
+		}
 		constraintsOrdering = evaluateOrderingDomainBigBitReversed(
 			pk,
 			evaluationBlindedZDomainBigBitReversed,
@@ -234,22 +290,45 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			beta,
 			gamma)
 		chConstraintOrdering <- nil
-		close(chConstraintOrdering)
 	}()
 
-	if err := <-chConstraintOrdering; err != nil {
+	errOrdering, errInd := <-chConstraintOrdering, <-chConstraintInd
+	if err := t.Err(); err != nil {
 		return nil, err
 	}
+	if errOrdering != nil {
+		return nil, errOrdering
+	}
+	if errInd != nil {
+		return nil, errInd
+	}
 
-	<-chConstraintInd
+	// compute the lookup part of the numerator of the quotient on the coset of the big domain
+	var constraintsLookup []fr.Element
+	if lookup != nil {
+		constraintsLookup = lookup.evaluateDomainBigBitReversed(
+			evaluationBlindedLDomainBigBitReversed,
+			evaluationBlindedRDomainBigBitReversed,
+			evaluationBlindedODomainBigBitReversed,
+			alpha)
+	}
 
 	// compute h in canonical form
-	h1, h2, h3 := computeQuotientCanonical(pk, constraintsInd, constraintsOrdering, evaluationBlindedZDomainBigBitReversed, alpha)
+	h1, h2, h3 := computeQuotientCanonical(pk, constraintsInd, constraintsOrdering, evaluationBlindedZDomainBigBitReversed, constraintsLookup, alpha)
+	t.Phase("quotient", quotientStart)
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
 
 	// compute kzg commitments of h1, h2 and h3
+	phaseStart = time.Now()
 	if err := commitToQuotient(h1, h2, h3, proof, pk.Vk.KZGSRS); err != nil {
 		return nil, err
 	}
+	t.Phase("commit.H", phaseStart)
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
 
 	// derive zeta
 	zeta, err := deriveRandomness(&fs, "zeta", &proof.H[0], &proof.H[1], &proof.H[2])
@@ -275,6 +354,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	}()
 
 	// open blinded Z at zeta*z
+	phaseStart = time.Now()
 	var zetaShifted fr.Element
 	zetaShifted.Mul(&zeta, &pk.Vk.Generator)
 	proof.ZShiftedOpening, err = kzg.Open(
@@ -289,6 +369,24 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	// blinded z evaluated at u*zeta
 	bzuzeta := proof.ZShiftedOpening.ClaimedValue
 
+	// open the lookup Z, h1 and t at zeta*z
+	if lookup != nil {
+		proof.Lookup.ShiftedOpening, err = kzg.BatchOpenSinglePoint(
+			[][]fr.Element{lookup.z, lookup.h1, lookup.t},
+			[]kzg.Digest{proof.Lookup.Z, proof.Lookup.H1, lookup.tDigest},
+			zetaShifted,
+			hFunc,
+			pk.Vk.KZGSRS,
+		)
+		if err != nil {
+			return nil, err
+		}
+	}
+	t.Phase("open.Z", phaseStart)
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
+
 	var (
 		linearizedPolynomialCanonical []fr.Element
 		linearizedPolynomialDigest    curve.G1Affine
@@ -296,6 +394,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	)
 	chLpoly := make(chan struct{}, 1)
 
+	linearizeStart := time.Now()
 	go func() {
 		// compute the linearization polynomial r at zeta (goal: save committing separately to z, ql, qr, qm, qo, k)
 		wgZetaEvals.Wait()
@@ -311,61 +410,56 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 			blindedZCanonical,
 			pk,
 		)
+		if errLPoly = t.Err(); errLPoly != nil {
+			close(chLpoly)
+			return
+		}
 
 		// TODO this commitment is only necessary to derive the challenge, we should
 		// be able to avoid doing it and get the challenge in another way
 		linearizedPolynomialDigest, errLPoly = kzg.Commit(linearizedPolynomialCanonical, pk.Vk.KZGSRS)
+		t.Phase("linearize", linearizeStart)
 		close(chLpoly)
 	}()
 
-	// foldedHDigest = Comm(h1) + ζᵐ⁺²*Comm(h2) + ζ²⁽ᵐ⁺²⁾*Comm(h3)
-	var bZetaPowerm, bSize big.Int
-	bSize.SetUint64(pk.Domain[0].Cardinality + 2) // +2 because of the masking (h of degree 3(n+2)-1)
-	var zetaPowerm fr.Element
-	zetaPowerm.Exp(zeta, &bSize)
-	zetaPowerm.ToBigIntRegular(&bZetaPowerm)
-	foldedHDigest := proof.H[2]
-	foldedHDigest.ScalarMultiplication(&foldedHDigest, &bZetaPowerm)
-	foldedHDigest.Add(&foldedHDigest, &proof.H[1])                   // ζᵐ⁺²*Comm(h3)
-	foldedHDigest.ScalarMultiplication(&foldedHDigest, &bZetaPowerm) // ζ²⁽ᵐ⁺²⁾*Comm(h3) + ζᵐ⁺²*Comm(h2)
-	foldedHDigest.Add(&foldedHDigest, &proof.H[0])                   // ζ²⁽ᵐ⁺²⁾*Comm(h3) + ζᵐ⁺²*Comm(h2) + Comm(h1)
-
-	// foldedH = h1 + ζ*h2 + ζ²*h3
-	foldedH := h3
-	utils.Parallelize(len(foldedH), func(start, end int) {
-		for i := start; i < end; i++ {
-			foldedH[i].Mul(&foldedH[i], &zetaPowerm) // ζᵐ⁺²*h3
-			foldedH[i].Add(&foldedH[i], &h2[i])      // ζ^{m+2)*h3+h2
-			foldedH[i].Mul(&foldedH[i], &zetaPowerm) // ζ²⁽ᵐ⁺²⁾*h3+h2*ζᵐ⁺²
-			foldedH[i].Add(&foldedH[i], &h1[i])      // ζ^{2(m+2)*h3+ζᵐ⁺²*h2 + h1
-		}
-	})
+	foldedH, foldedHDigest := foldQuotient(h1, h2, h3, proof, zeta, &pk.Domain[0])
 
 	<-chLpoly
 	if errLPoly != nil {
 		return nil, errLPoly
 	}
+	if err := t.Err(); err != nil {
+		return nil, err
+	}
 
 	// Batch open the first list of polynomials
+	polynomials := [][]fr.Element{
+		foldedH,
+		linearizedPolynomialCanonical,
+		blindedLCanonical,
+		blindedRCanonical,
+		blindedOCanonical,
+		pk.S1Canonical,
+		pk.S2Canonical,
+	}
+	digests := []kzg.Digest{
+		foldedHDigest,
+		linearizedPolynomialDigest,
+		proof.LRO[0],
+		proof.LRO[1],
+		proof.LRO[2],
+		pk.Vk.S[0],
+		pk.Vk.S[1],
+	}
+	if lookup != nil {
+		lookupPolynomials, lookupDigests := lookup.polynomials(proof)
+		polynomials = append(polynomials, lookupPolynomials...)
+		digests = append(digests, lookupDigests...)
+	}
+	phaseStart = time.Now()
 	proof.BatchedProof, err = kzg.BatchOpenSinglePoint(
-		[][]fr.Element{
-			foldedH,
-			linearizedPolynomialCanonical,
-			blindedLCanonical,
-			blindedRCanonical,
-			blindedOCanonical,
-			pk.S1Canonical,
-			pk.S2Canonical,
-		},
-		[]kzg.Digest{
-			foldedHDigest,
-			linearizedPolynomialDigest,
-			proof.LRO[0],
-			proof.LRO[1],
-			proof.LRO[2],
-			pk.Vk.S[0],
-			pk.Vk.S[1],
-		},
+		polynomials,
+		digests,
 		zeta,
 		hFunc,
 		pk.Vk.KZGSRS,
@@ -376,6 +470,7 @@ func Prove(spr *cs.SparseR1CS, pk *ProvingKey, fullWitness bls12_381witness.Witn
 	if err != nil {
 		return nil, err
 	}
+	t.Phase("open.batch", phaseStart)
 
 	return proof, nil
 
@@ -444,42 +539,41 @@ func commitToQuotient(h1, h2, h3 []fr.Element, proof *Proof, srs *kzg.SRS) error
 }
 
 // computeBlindedLROCanonical l, r, o in canonical basis with blinding
-func computeBlindedLROCanonical(ll, lr, lo []fr.Element, domain *fft.Domain) (bcl, bcr, bco []fr.Element, err error) {
+func computeBlindedLROCanonical(ll, lr, lo []fr.Element, domain *fft.Domain, rnd io.Reader) (bcl, bcr, bco []fr.Element, err error) {
 
 	// note that bcl, bcr and bco reuses cl, cr and co memory
 	cl := make([]fr.Element, domain.Cardinality, domain.Cardinality+2)
 	cr := make([]fr.Element, domain.Cardinality, domain.Cardinality+2)
 	co := make([]fr.Element, domain.Cardinality, domain.Cardinality+2)
 
-	chDone := make(chan error, 2)
+	chDone := make(chan struct{}, 2)
 
 	go func() {
-		var err error
 		copy(cl, ll)
 		domain.FFTInverse(cl, fft.DIF)
 		fft.BitReverse(cl)
-		bcl, err = blindPoly(cl, domain.Cardinality, 1)
-		chDone <- err
+		chDone <- struct{}{}
 	}()
 	go func() {
-		var err error
 		copy(cr, lr)
 		domain.FFTInverse(cr, fft.DIF)
 		fft.BitReverse(cr)
-		bcr, err = blindPoly(cr, domain.Cardinality, 1)
-		chDone <- err
+		chDone <- struct{}{}
 	}()
 	copy(co, lo)
 	domain.FFTInverse(co, fft.DIF)
 	fft.BitReverse(co)
-	if bco, err = blindPoly(co, domain.Cardinality, 1); err != nil {
+	<-chDone
+	<-chDone
+
+	// the blinding polynomials are read from rnd in a fixed order
+	if bcl, err = blindPoly(cl, domain.Cardinality, 1, rnd); err != nil {
 		return
 	}
-	err = <-chDone
-	if err != nil {
+	if bcr, err = blindPoly(cr, domain.Cardinality, 1, rnd); err != nil {
 		return
 	}
-	err = <-chDone
+	bco, err = blindPoly(co, domain.Cardinality, 1, rnd)
 	return
 
 }
@@ -489,11 +583,12 @@ func computeBlindedLROCanonical(ll, lr, lo []fr.Element, domain *fft.Domain) (bc
 // * cp polynomial in canonical form
 // * rou root of unity, meaning the blinding factor is multiple of X**rou-1
 // * bo blinding order,  it's the degree of Q, where the blinding is Q(X)*(X**degree-1)
+// * rnd source of the coefficients of Q, crypto/rand if nil
 //
 // WARNING:
 // pre condition degree(cp) ⩽ rou + bo
 // pre condition cap(cp) ⩾ int(totalDegree + 1)
-func blindPoly(cp []fr.Element, rou, bo uint64) ([]fr.Element, error) {
+func blindPoly(cp []fr.Element, rou, bo uint64, rnd io.Reader) ([]fr.Element, error) {
 
 	// degree of the blinded polynomial is max(rou+order, cp.Degree)
 	totalDegree := rou + bo
@@ -504,7 +599,7 @@ func blindPoly(cp []fr.Element, rou, bo uint64) ([]fr.Element, error) {
 	// random polynomial
 	blindingPoly := make([]fr.Element, bo+1)
 	for i := uint64(0); i < bo+1; i++ {
-		if _, err := blindingPoly[i].SetRandom(); err != nil {
+		if err := setRandom(&blindingPoly[i], rnd); err != nil {
 			return nil, err
 		}
 	}
@@ -519,6 +614,20 @@ func blindPoly(cp []fr.Element, rou, bo uint64) ([]fr.Element, error) {
 
 }
 
+// setRandom sets x to a uniformly random element read from rnd, or from crypto/rand if rnd is nil
+func setRandom(x *fr.Element, rnd io.Reader) error {
+	if rnd == nil {
+		_, err := x.SetRandom()
+		return err
+	}
+	v, err := rand.Int(rnd, fr.Modulus())
+	if err != nil {
+		return err
+	}
+	x.SetBigInt(v)
+	return nil
+}
+
 // evaluateLROSmallDomain extracts the solution l, r, o, and returns it in lagrange form.
 // solution = [ public | secret | internal ]
 func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
@@ -537,12 +646,14 @@ func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.El
 		o[i] = s0
 	}
 	offset := spr.NbPublicVariables
-	for i := 0; i < len(spr.Constraints); i++ { // constraints
-		l[offset+i] = solution[spr.Constraints[i].L.WireID()]
-		r[offset+i] = solution[spr.Constraints[i].R.WireID()]
-		o[offset+i] = solution[spr.Constraints[i].O.WireID()]
+	nbConstraints := len(spr.Constraints) + spr.LazyCons.GetConstraintsAll()
+	for i := 0; i < nbConstraints; i++ { // constraints
+		c := spr.FetchConstraint(i)
+		l[offset+i] = solution[c.L.WireID()]
+		r[offset+i] = solution[c.R.WireID()]
+		o[offset+i] = solution[c.O.WireID()]
 	}
-	offset += len(spr.Constraints)
+	offset += nbConstraints
 
 	for i := 0; i < s-offset; i++ { // offset to reach 2**n constraints (where the id of l,r,o is 0, so we assign solution[0])
 		l[offset+i] = s0
@@ -562,8 +673,14 @@ func evaluateLROSmallDomain(spr *cs.SparseR1CS, pk *ProvingKey, solution []fr.El
 // * for i>0: Z(gⁱ) = Π_{k<i} -------------------------------------------------------
 //								     (l(g^k)+β*s1(g^k)+γ)*(r(g^k)+β*s2(g^k)+γ)*(o(g^k)+β*s3(\g^k)+γ)
 //
-//	* l, r, o are the solution in Lagrange basis, evaluated on the small domain
-func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, beta, gamma fr.Element) ([]fr.Element, error) {
+//   - Z(1)=1
+//     (l(g^k)+β*g^k+γ)*(r(g^k)+uβ*g^k+γ)*(o(g^k)+u²β*g^k+γ)
+//
+//   - for i>0: Z(gⁱ) = Π_{k<i} -------------------------------------------------------
+//     (l(g^k)+β*s1(g^k)+γ)*(r(g^k)+β*s2(g^k)+γ)*(o(g^k)+β*s3(\g^k)+γ)
+//
+//   - l, r, o are the solution in Lagrange basis, evaluated on the small domain
+func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, beta, gamma fr.Element, rnd io.Reader) ([]fr.Element, error) {
 
 	// note that z has more capacity has its memory is reused for blinded z later on
 	z := make([]fr.Element, pk.Domain[0].Cardinality, pk.Domain[0].Cardinality+3)
@@ -607,7 +724,7 @@ func computeBlindedZCanonical(l, r, o []fr.Element, pk *ProvingKey, beta, gamma
 	pk.Domain[0].FFTInverse(z, fft.DIF)
 	fft.BitReverse(z)
 
-	return blindPoly(z, pk.Domain[0].Cardinality, 2)
+	return blindPoly(z, pk.Domain[0].Cardinality, 2, rnd)
 
 }
 
@@ -760,8 +877,10 @@ func evaluateXnMinusOneDomainBigCoset(domainBig, domainSmall *fft.Domain) []fr.E
 //
 // ql(X)L(X)+qr(X)R(X)+qm(X)L(X)R(X)+qo(X)O(X)+k(X) + α.(z(μX)*g₁(X)*g₂(X)*g₃(X)-z(X)*f₁(X)*f₂(X)*f₃(X)) + α²*L₁(X)*(Z(X)-1)= h(X)Z(X)
 //
-// constraintInd, constraintOrdering are evaluated on the big domain (coset).
-func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReversed, evaluationConstraintOrderingBitReversed, evaluationBlindedZDomainBigBitReversed []fr.Element, alpha fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
+// constraintInd, constraintOrdering are evaluated on the big domain (coset). If the constraint system
+// has lookups, α³ times the lookup part of the numerator (see lookupChallenges.numerator) is added to the
+// left-hand side, evaluationLookupBitReversed is nil otherwise.
+func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReversed, evaluationConstraintOrderingBitReversed, evaluationBlindedZDomainBigBitReversed, evaluationLookupBitReversed []fr.Element, alpha fr.Element) ([]fr.Element, []fr.Element, []fr.Element) {
 
 	h := make([]fr.Element, pk.Domain[1].Cardinality)
 
@@ -770,11 +889,8 @@ func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReverse
 	evaluationXnMinusOneInverse = fr.BatchInvert(evaluationXnMinusOneInverse)
 
 	// computes L₁ (canonical form)
-	startsAtOne := make([]fr.Element, pk.Domain[1].Cardinality)
-	for i := 0; i < int(pk.Domain[0].Cardinality); i++ {
-		startsAtOne[i].Set(&pk.Domain[0].CardinalityInv)
-	}
-	pk.Domain[1].FFT(startsAtOne, fft.DIF, true)
+	startsAtOne := evaluateL1DomainBigBitReversed(pk)
+	alphaCube := lookupAlphaCube(alpha)
 
 	// ql(X)L(X)+qr(X)R(X)+qm(X)L(X)R(X)+qo(X)O(X)+k(X) + α.(z(μX)*g₁(X)*g₂(X)*g₃(X)-z(X)*f₁(X)*f₂(X)*f₃(X)) + α**2*L₁(X)(Z(X)-1)
 	// on a coset of the big domain
@@ -795,8 +911,12 @@ func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReverse
 			h[_i].Mul(&startsAtOne[_i], &alpha).Mul(&h[_i], &t).
 				Add(&h[_i], &evaluationConstraintOrderingBitReversed[_i]).
 				Mul(&h[_i], &alpha).
-				Add(&h[_i], &evaluationConstraintsIndBitReversed[_i]).
-				Mul(&h[_i], &evaluationXnMinusOneInverse[i%ratio])
+				Add(&h[_i], &evaluationConstraintsIndBitReversed[_i])
+			if evaluationLookupBitReversed != nil {
+				t.Mul(&evaluationLookupBitReversed[_i], &alphaCube)
+				h[_i].Add(&h[_i], &t)
+			}
+			h[_i].Mul(&h[_i], &evaluationXnMinusOneInverse[i%ratio])
 		}
 	})
 
@@ -826,49 +946,15 @@ func computeQuotientCanonical(pk *ProvingKey, evaluationConstraintsIndBitReverse
 // + l(ζ)*Ql(X) + l(ζ)r(ζ)*Qm(X) + r(ζ)*Qr(X) + o(ζ)*Qo(X) + Qk(X)
 func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta, zu fr.Element, blindedZCanonical []fr.Element, pk *ProvingKey) []fr.Element {
 
-	// first part: individual constraints
-	var rl fr.Element
-	rl.Mul(&rZeta, &lZeta)
-
-	// second part:
-	// Z(μζ)(l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*β*s3(X)-Z(X)(l(ζ)+β*id1(ζ)+γ)*(r(ζ)+β*id2(ζ)+γ)*(o(ζ)+β*id3(ζ)+γ)
-	var s1, s2 fr.Element
+	var s1Zeta, s2Zeta fr.Element
 	chS1 := make(chan struct{}, 1)
 	go func() {
-		s1 = eval(pk.S1Canonical, zeta)                      // s1(ζ)
-		s1.Mul(&s1, &beta).Add(&s1, &lZeta).Add(&s1, &gamma) // (l(ζ)+β*s1(ζ)+γ)
+		s1Zeta = eval(pk.S1Canonical, zeta) // s1(ζ)
 		close(chS1)
 	}()
-	tmp := eval(pk.S2Canonical, zeta)                        // s2(ζ)
-	tmp.Mul(&tmp, &beta).Add(&tmp, &rZeta).Add(&tmp, &gamma) // (r(ζ)+β*s2(ζ)+γ)
+	s2Zeta = eval(pk.S2Canonical, zeta) // s2(ζ)
 	<-chS1
-	s1.Mul(&s1, &tmp).Mul(&s1, &zu).Mul(&s1, &beta) // (l(ζ)+β*s1(β)+γ)*(r(ζ)+β*s2(β)+γ)*β*Z(μζ)
-
-	var uzeta, uuzeta fr.Element
-	uzeta.Mul(&zeta, &pk.Vk.CosetShift)
-	uuzeta.Mul(&uzeta, &pk.Vk.CosetShift)
-
-	s2.Mul(&beta, &zeta).Add(&s2, &lZeta).Add(&s2, &gamma)      // (l(ζ)+β*ζ+γ)
-	tmp.Mul(&beta, &uzeta).Add(&tmp, &rZeta).Add(&tmp, &gamma)  // (r(ζ)+β*u*ζ+γ)
-	s2.Mul(&s2, &tmp)                                           // (l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)
-	tmp.Mul(&beta, &uuzeta).Add(&tmp, &oZeta).Add(&tmp, &gamma) // (o(ζ)+β*u²*ζ+γ)
-	s2.Mul(&s2, &tmp)                                           // (l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
-	s2.Neg(&s2)                                                 // -(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
-
-	// third part L₁(ζ)*α²*Z
-	var lagrangeZeta, one, den, frNbElmt fr.Element
-	one.SetOne()
-	nbElmt := int64(pk.Domain[0].Cardinality)
-	lagrangeZeta.Set(&zeta).
-		Exp(lagrangeZeta, big.NewInt(nbElmt)).
-		Sub(&lagrangeZeta, &one)
-	frNbElmt.SetUint64(uint64(nbElmt))
-	den.Sub(&zeta, &one).
-		Inverse(&den)
-	lagrangeZeta.Mul(&lagrangeZeta, &den). // L₁ = (ζⁿ⁻¹)/(ζ-1)
-						Mul(&lagrangeZeta, &alpha).
-						Mul(&lagrangeZeta, &alpha).
-						Mul(&lagrangeZeta, &pk.Domain[0].CardinalityInv) // (1/n)*α²*L₁(ζ)
+	rl, s1, s2, lagrangeZeta := linearizedPolynomialFactors(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta, zu, s1Zeta, s2Zeta, pk)
 
 	linPol := make([]fr.Element, len(blindedZCanonical))
 	copy(linPol, blindedZCanonical)
@@ -911,3 +997,78 @@ func computeLinearizedPolynomial(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta,
 
 	return linPol
 }
+
+// foldQuotient returns h1 + ζᵐ⁺²*h2 + ζ²⁽ᵐ⁺²⁾*h3 and its commitment, computed from proof.H.
+// The memory of h3 is reused.
+func foldQuotient(h1, h2, h3 []fr.Element, proof *Proof, zeta fr.Element, domain *fft.Domain) ([]fr.Element, kzg.Digest) {
+	// foldedHDigest = Comm(h1) + ζᵐ⁺²*Comm(h2) + ζ²⁽ᵐ⁺²⁾*Comm(h3)
+	var bZetaPowerm, bSize big.Int
+	bSize.SetUint64(domain.Cardinality + 2) // +2 because of the masking (h of degree 3(n+2)-1)
+	var zetaPowerm fr.Element
+	zetaPowerm.Exp(zeta, &bSize)
+	zetaPowerm.ToBigIntRegular(&bZetaPowerm)
+	foldedHDigest := proof.H[2]
+	foldedHDigest.ScalarMultiplication(&foldedHDigest, &bZetaPowerm)
+	foldedHDigest.Add(&foldedHDigest, &proof.H[1])                   // ζᵐ⁺²*Comm(h3)
+	foldedHDigest.ScalarMultiplication(&foldedHDigest, &bZetaPowerm) // ζ²⁽ᵐ⁺²⁾*Comm(h3) + ζᵐ⁺²*Comm(h2)
+	foldedHDigest.Add(&foldedHDigest, &proof.H[0])                   // ζ²⁽ᵐ⁺²⁾*Comm(h3) + ζᵐ⁺²*Comm(h2) + Comm(h1)
+
+	// foldedH = h1 + ζ*h2 + ζ²*h3
+	foldedH := h3
+	utils.Parallelize(len(foldedH), func(start, end int) {
+		for i := start; i < end; i++ {
+			foldedH[i].Mul(&foldedH[i], &zetaPowerm) // ζᵐ⁺²*h3
+			foldedH[i].Add(&foldedH[i], &h2[i])      // ζ^{m+2)*h3+h2
+			foldedH[i].Mul(&foldedH[i], &zetaPowerm) // ζ²⁽ᵐ⁺²⁾*h3+h2*ζᵐ⁺²
+			foldedH[i].Add(&foldedH[i], &h1[i])      // ζ^{2(m+2)*h3+ζᵐ⁺²*h2 + h1
+		}
+	})
+
+	return foldedH, foldedHDigest
+}
+
+// linearizedPolynomialFactors returns the scalars of the linearized polynomial (see computeLinearizedPolynomial):
+// * rl = l(ζ)r(ζ), the factor of Qm
+// * s1 = (l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*β*Z(μζ), the factor of s3 (before α)
+// * s2 = -(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ), the factor of Z (before α)
+// * lagrangeZeta = (1/n)*α²*L₁(ζ), the factor of Z
+func linearizedPolynomialFactors(lZeta, rZeta, oZeta, alpha, beta, gamma, zeta, zu, s1Zeta, s2Zeta fr.Element, pk *ProvingKey) (rl, s1, s2, lagrangeZeta fr.Element) {
+
+	// first part: individual constraints
+	rl.Mul(&rZeta, &lZeta)
+
+	// second part:
+	// Z(μζ)(l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*β*s3(X)-Z(X)(l(ζ)+β*id1(ζ)+γ)*(r(ζ)+β*id2(ζ)+γ)*(o(ζ)+β*id3(ζ)+γ)
+	s1.Mul(&s1Zeta, &beta).Add(&s1, &lZeta).Add(&s1, &gamma) // (l(ζ)+β*s1(ζ)+γ)
+	var tmp fr.Element
+	tmp.Mul(&s2Zeta, &beta).Add(&tmp, &rZeta).Add(&tmp, &gamma) // (r(ζ)+β*s2(ζ)+γ)
+	s1.Mul(&s1, &tmp).Mul(&s1, &zu).Mul(&s1, &beta)             // (l(ζ)+β*s1(β)+γ)*(r(ζ)+β*s2(β)+γ)*β*Z(μζ)
+
+	var uzeta, uuzeta fr.Element
+	uzeta.Mul(&zeta, &pk.Vk.CosetShift)
+	uuzeta.Mul(&uzeta, &pk.Vk.CosetShift)
+
+	s2.Mul(&beta, &zeta).Add(&s2, &lZeta).Add(&s2, &gamma)      // (l(ζ)+β*ζ+γ)
+	tmp.Mul(&beta, &uzeta).Add(&tmp, &rZeta).Add(&tmp, &gamma)  // (r(ζ)+β*u*ζ+γ)
+	s2.Mul(&s2, &tmp)                                           // (l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)
+	tmp.Mul(&beta, &uuzeta).Add(&tmp, &oZeta).Add(&tmp, &gamma) // (o(ζ)+β*u²*ζ+γ)
+	s2.Mul(&s2, &tmp)                                           // (l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
+	s2.Neg(&s2)                                                 // -(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ)
+
+	// third part L₁(ζ)*α²*Z
+	var one, den, frNbElmt fr.Element
+	one.SetOne()
+	nbElmt := int64(pk.Domain[0].Cardinality)
+	lagrangeZeta.Set(&zeta).
+		Exp(lagrangeZeta, big.NewInt(nbElmt)).
+		Sub(&lagrangeZeta, &one)
+	frNbElmt.SetUint64(uint64(nbElmt))
+	den.Sub(&zeta, &one).
+		Inverse(&den)
+	lagrangeZeta.Mul(&lagrangeZeta, &den). // L₁ = (ζⁿ⁻¹)/(ζ-1)
+						Mul(&lagrangeZeta, &alpha).
+						Mul(&lagrangeZeta, &alpha).
+						Mul(&lagrangeZeta, &pk.Domain[0].CardinalityInv) // (1/n)*α²*L₁(ζ)
+
+	return
+}
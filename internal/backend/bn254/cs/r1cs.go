@@ -17,10 +17,10 @@
 package cs
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"github.com/consensys/gnark/frontend/cs"
 	"github.com/fxamacker/cbor/v2"
 	"io"
 	"math/big"
@@ -34,6 +34,7 @@ import (
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/witness"
 	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/frontend/cs"
 	"github.com/consensys/gnark/frontend/schema"
 	"github.com/consensys/gnark/internal/backend/ioutils"
 	"github.com/consensys/gnark/logger"
@@ -111,7 +112,7 @@ func (cs *R1CS) Solve(witness, a, b, c []fr.Element, opt backend.ProverConfig) (
 	// (or sooner, if a constraint is not satisfied)
 	defer solution.printLogs(opt.CircuitLogger, cs.Logs)
 
-	if err := cs.parallelSolve(a, b, c, &solution); err != nil {
+	if err := cs.parallelSolve(opt.Ctx, a, b, c, &solution); err != nil {
 		if unsatisfiedErr, ok := err.(*UnsatisfiedConstraintError); ok {
 			log.Err(errors.New("unsatisfied constraint")).Int("id", unsatisfiedErr.CID).Send()
 		} else {
@@ -131,7 +132,7 @@ func (cs *R1CS) Solve(witness, a, b, c []fr.Element, opt backend.ProverConfig) (
 	return solution.values, nil
 }
 
-func (cs *R1CS) parallelSolve(a, b, c []fr.Element, solution *solution) error {
+func (cs *R1CS) parallelSolve(ctx context.Context, a, b, c []fr.Element, solution *solution) error {
 	// minWorkPerCPU is the minimum target number of constraint a task should hold
 	// in other words, if a level has less than minWorkPerCPU, it will not be parallelized and executed
 	// sequentially without sync.
@@ -181,6 +182,10 @@ func (cs *R1CS) parallelSolve(a, b, c []fr.Element, solution *solution) error {
 
 	// for each level, we push the tasks
 	for _, level := range cs.Levels {
+		// stop between levels if the prover was cancelled, see backend.WithContext
+		if ctx != nil && ctx.Err() != nil {
+			return ctx.Err()
+		}
 
 		// max CPU to use
 		maxCPU := float64(len(level)) / minWorkPerCPU
@@ -565,6 +570,11 @@ func (cs *R1CS) GetLazyConstraints() [][]string {
 			line[1] = cs.vtoString(cc.V)
 			line[2] = ""
 			line[3] = fmt.Sprintf("@%d, ", cc.Loc)
+		case *compiled.LazyGadgetInputs:
+			line[0] = cs.vtoString(cc.Inputs)
+			line[1] = cc.Key
+			line[2] = fmt.Sprintf("v%d", cc.Start-cs.NbPublicVariables-cs.NbSecretVariables)
+			line[3] = fmt.Sprintf("@%d, ", cc.Loc)
 		}
 		r = append(r, line[:])
 	}
@@ -730,6 +740,9 @@ func (h *R1CS) inputsCBORTags() (cbor.TagSet, error) {
 	if err := tags.Add(defTagOpts, reflect.TypeOf(compiled.LazyMimcEncInputs{}), 25449); err != nil {
 		return nil, fmt.Errorf("new LE tag: %w", err)
 	}
+	if err := tags.Add(defTagOpts, reflect.TypeOf(compiled.LazyGadgetInputs{}), 25450); err != nil {
+		return nil, fmt.Errorf("new LE tag: %w", err)
+	}
 	return tags, nil
 }
 
@@ -754,6 +767,15 @@ func (cs *R1CS) WriteTo(w io.Writer) (int64, error) {
 	return _w.N, err
 }
 
+// WriteCircuitTo encodes R1CS as WriteTo without its debug information, which is dropped by ReadFrom,
+// so that a R1CS and its decoded copy have the same encoding. It is the encoding of the circuit hash of a dump.
+func (cs *R1CS) WriteCircuitTo(w io.Writer) (int64, error) {
+	_cs := *cs
+	_cs.DebugInfo = make([]compiled.LogEntry, 0)
+	_cs.MDebug = make(map[int]int, 0)
+	return _cs.WriteTo(w)
+}
+
 func (cs *R1CS) WriteConstraintsTo(w io.Writer) (int64, error) {
 	_w := ioutils.WriterCounter{W: w} // wraps writer to count the bytes written
 	enc, err := cbor.CoreDetEncOptions().EncMode()
@@ -17,19 +17,23 @@
 package groth16
 
 import (
-	"fmt"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
-	"os"
 
 	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 
 	"github.com/consensys/gnark/internal/backend/bn254/cs"
 
+	"crypto/rand"
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/internal/backend/dump"
+	"github.com/consensys/gnark/internal/backend/progress"
+	"io"
 	"math/big"
 	"math/bits"
+	"time"
 )
 
 // ProvingKey is used by a Groth16 prover to encode a proof of a statement
@@ -79,25 +83,22 @@ type VerifyingKey struct {
 	e curve.GT // not serialized
 }
 
-func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk *VerifyingKey) error {
-	// dump r1cs to file
-	cTFile, err := os.Create(fmt.Sprintf("%s.ccs.ct.save", session))
-	if err != nil {
-		return err
-	}
-	_, err = r1cs.WriteCTTo(cTFile)
-	_ = cTFile.Close()
-	if err != nil {
+// SetupWithDump runs Setup and writes the R1CS, the VerifyingKey and the segments of the ProvingKey
+// under the session prefix instead of returning them, see package dump for the layout of the files.
+//
+// If the setup is cancelled, the segments already written are left without a manifest.
+func SetupWithDump(r1cs *cs.R1CS, session string, opt backend.SetupConfig) error {
+	t := progress.New(opt.Ctx, opt.Progress, 7)
+	w := dump.NewWriter(session, r1cs.CurveID())
+
+	// dump r1cs to file, the coefficient table is written apart from the cbor encoded constraints
+	if err := w.Write(dump.CoeffTable, r1cs.WriteCTTo); err != nil {
 		return err
 	}
-	// remove CoefT from cbor
-	ccsFile, err := os.Create(fmt.Sprintf("%s.ccs.save", session))
-	if err != nil {
+	if err := w.Write(dump.ConstraintSystem, r1cs.WriteTo); err != nil {
 		return err
 	}
-	_, err = r1cs.WriteTo(ccsFile)
-	_ = ccsFile.Close()
-	if err != nil {
+	if err := w.HashCircuit(r1cs.WriteCircuitTo, r1cs.WriteCTTo); err != nil {
 		return err
 	}
 
@@ -110,10 +111,11 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 	nbPrivateWires := r1cs.NbSecretVariables + r1cs.NbInternalVariables
 
 	// Setting group for fft
+	start := time.Now()
 	domain := fft.NewDomain(uint64(len(r1cs.Constraints)))
 
 	// samples toxic waste
-	toxicWaste, err := sampleToxicWaste()
+	toxicWaste, err := sampleToxicWaste(opt.RandomSource)
 	if err != nil {
 		return err
 	}
@@ -214,6 +216,12 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 	B = B[:n]
 	pk.NbInfinityB = uint64(nbWires - n)
 
+	t.Phase("qap", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
 	// E part
 	{
 		g1Scalars := make([]fr.Element, 0, 3)
@@ -230,19 +238,11 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 		pk.G2.Beta = g2PointsAff[0]
 		pk.G2.Delta = g2PointsAff[1]
 
-		name := fmt.Sprintf("%s.pk.E.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		cnt, err := pk.WriteRawETo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		// set domain, its cardinality is part of the E segment
+		pk.Domain = *domain
+		if err := w.Write(dump.ProvingKeyE, pk.WriteRawETo); err != nil {
 			return err
 		}
-		fmt.Println("written ", cnt, "bytes for pk.E.save")
-		// set domain
-		pk.Domain = *domain
 
 		// vk
 		g1Scalars = make([]fr.Element, 0, nbPublicWires)
@@ -273,18 +273,15 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 			return err
 		}
 
-		name = fmt.Sprintf("%s.vk.save", session)
-		vkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		cnt, err = vk.WriteRawTo(vkFile)
-		_ = vkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.VerifyingKey, vk.WriteRawTo); err != nil {
 			return err
 		}
-		fmt.Println("written ", cnt, "bytes for vk.save")
 	}
+	t.Phase("pk.E", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// A part
 	{
@@ -294,18 +291,15 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
 		pk.G1.A = g1PointsAff
 
-		name := fmt.Sprintf("%s.pk.A.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyA, pk.WriteRawATo); err != nil {
 			return err
 		}
-		cnt, err := pk.WriteRawATo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
-			return err
-		}
-		fmt.Println("written ", cnt, "bytes for pk.A.save")
 	}
+	t.Phase("pk.A", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// B1 part
 	{
@@ -315,18 +309,15 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
 		pk.G1.B = g1PointsAff
 
-		name := fmt.Sprintf("%s.pk.B1.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		cnt, err := pk.WriteRawB1To(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyB1, pk.WriteRawB1To); err != nil {
 			return err
 		}
-		fmt.Println("written ", cnt, "bytes for pk.B1.save")
 	}
+	t.Phase("pk.B1", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// K part
 	{
@@ -336,18 +327,15 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
 		pk.G1.K = g1PointsAff
 
-		name := fmt.Sprintf("%s.pk.K.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyK, pk.WriteRawKTo); err != nil {
 			return err
 		}
-		cnt, err := pk.WriteRawKTo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
-			return err
-		}
-		fmt.Println("written ", cnt, "bytes for pk.K.save")
 	}
+	t.Phase("pk.K", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// Z part
 	{
@@ -358,18 +346,15 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 		pk.G1.Z = g1PointsAff
 		bitReverse(pk.G1.Z)
 
-		name := fmt.Sprintf("%s.pk.Z.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		cnt, err := pk.WriteRawZTo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyZ, pk.WriteRawZTo); err != nil {
 			return err
 		}
-		fmt.Println("written ", cnt, "bytes for pk.Z.save")
 	}
+	t.Phase("pk.Z", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// B2 part
 	{
@@ -379,41 +364,31 @@ func SetupWithDump(r1cs *cs.R1CS, session string) error { //, pk *ProvingKey, vk
 		g2PointsAff := curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
 		pk.G2.B = g2PointsAff
 
-		name := fmt.Sprintf("%s.pk.B2.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyB2, pk.WriteRawB2To); err != nil {
 			return err
 		}
-		cnt, err := pk.WriteRawB2To(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
-			return err
-		}
-		fmt.Println("written ", cnt, "bytes for pk.B2.save")
+	}
+	t.Phase("pk.B2", start)
+	if err := t.Err(); err != nil {
+		return err
 	}
 
-	return nil
+	return w.Close()
 }
 
-func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
-	// dump r1cs to file
-	cTFile, err := os.Create(fmt.Sprintf("%s.ccs.ct.save", session))
-	if err != nil {
-		return err
-	}
-	_, err = r1cs.WriteCTTo(cTFile)
-	_ = cTFile.Close()
-	if err != nil {
+// SetupLazyWithDump behaves as SetupWithDump on a lazified R1CS
+func SetupLazyWithDump(r1cs *cs.R1CS, session string, opt backend.SetupConfig) error {
+	t := progress.New(opt.Ctx, opt.Progress, 7)
+	w := dump.NewWriter(session, r1cs.CurveID())
+
+	// dump r1cs to file, the coefficient table is written apart from the cbor encoded constraints
+	if err := w.Write(dump.CoeffTable, r1cs.WriteCTTo); err != nil {
 		return err
 	}
-	// remove CoefT from cbor
-	ccsFile, err := os.Create(fmt.Sprintf("%s.ccs.save", session))
-	if err != nil {
+	if err := w.Write(dump.ConstraintSystem, r1cs.WriteTo); err != nil {
 		return err
 	}
-	_, err = r1cs.WriteTo(ccsFile)
-	_ = ccsFile.Close()
-	if err != nil {
+	if err := w.HashCircuit(r1cs.WriteCircuitTo, r1cs.WriteCTTo); err != nil {
 		return err
 	}
 
@@ -426,10 +401,11 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 	nbPrivateWires := r1cs.NbSecretVariables + r1cs.NbInternalVariables
 
 	// Setting group for fft
+	start := time.Now()
 	domain := fft.NewDomain(uint64(len(r1cs.Constraints) + r1cs.LazyCons.GetConstraintsAll()))
 
 	// samples toxic waste
-	toxicWaste, err := sampleToxicWaste()
+	toxicWaste, err := sampleToxicWaste(opt.RandomSource)
 	if err != nil {
 		return err
 	}
@@ -533,6 +509,12 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 	// set domain
 	pk.Domain = *domain
 
+	t.Phase("qap", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
 	// E part and VK
 	{
 		g1Scalars := make([]fr.Element, 0, 3)
@@ -549,14 +531,7 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 		pk.G2.Beta = g2PointsAff[0]
 		pk.G2.Delta = g2PointsAff[1]
 
-		name := fmt.Sprintf("%s.pk.E.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		_, err = pk.WriteRawETo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyE, pk.WriteRawETo); err != nil {
 			return err
 		}
 
@@ -589,17 +564,15 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 			return err
 		}
 
-		name = fmt.Sprintf("%s.vk.save", session)
-		vkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		_, err = vk.WriteRawTo(vkFile)
-		_ = vkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.VerifyingKey, vk.WriteRawTo); err != nil {
 			return err
 		}
 	}
+	t.Phase("pk.E", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// A part
 	{
@@ -609,17 +582,15 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
 		pk.G1.A = g1PointsAff
 
-		name := fmt.Sprintf("%s.pk.A.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		_, err = pk.WriteRawATo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyA, pk.WriteRawATo); err != nil {
 			return err
 		}
 	}
+	t.Phase("pk.A", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// B1 part
 	{
@@ -629,17 +600,15 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
 		pk.G1.B = g1PointsAff
 
-		name := fmt.Sprintf("%s.pk.B1.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		_, err = pk.WriteRawB1To(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyB1, pk.WriteRawB1To); err != nil {
 			return err
 		}
 	}
+	t.Phase("pk.B1", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// K part
 	{
@@ -649,17 +618,15 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 		g1PointsAff := curve.BatchScalarMultiplicationG1(&g1, g1Scalars)
 		pk.G1.K = g1PointsAff
 
-		name := fmt.Sprintf("%s.pk.K.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		_, err = pk.WriteRawKTo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyK, pk.WriteRawKTo); err != nil {
 			return err
 		}
 	}
+	t.Phase("pk.K", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// Z part
 	{
@@ -670,17 +637,15 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 		pk.G1.Z = g1PointsAff
 		bitReverse(pk.G1.Z)
 
-		name := fmt.Sprintf("%s.pk.Z.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		_, err = pk.WriteRawZTo(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyZ, pk.WriteRawZTo); err != nil {
 			return err
 		}
 	}
+	t.Phase("pk.Z", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
 
 	// B2 part
 	{
@@ -690,23 +655,20 @@ func SetupLazyWithDump(r1cs *cs.R1CS, session string) error {
 		g2PointsAff := curve.BatchScalarMultiplicationG2(&g2, g2Scalars)
 		pk.G2.B = g2PointsAff
 
-		name := fmt.Sprintf("%s.pk.B2.save", session)
-		pkFile, err := os.Create(name)
-		if err != nil {
-			return err
-		}
-		_, err = pk.WriteRawB2To(pkFile)
-		_ = pkFile.Close()
-		if err != nil {
+		if err := w.Write(dump.ProvingKeyB2, pk.WriteRawB2To); err != nil {
 			return err
 		}
 	}
+	t.Phase("pk.B2", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
 
-	return nil
+	return w.Close()
 }
 
 // Setup constructs the SRS
-func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
+func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey, opt backend.SetupConfig) error {
 	/*
 		Setup
 		-----
@@ -723,10 +685,12 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	nbPrivateWires := r1cs.NbSecretVariables + r1cs.NbInternalVariables
 
 	// Setting group for fft
+	t := progress.New(opt.Ctx, opt.Progress, 3)
+	start := time.Now()
 	domain := fft.NewDomain(uint64(len(r1cs.Constraints)))
 
 	// samples toxic waste
-	toxicWaste, err := sampleToxicWaste()
+	toxicWaste, err := sampleToxicWaste(opt.RandomSource)
 	if err != nil {
 		return err
 	}
@@ -827,6 +791,12 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	B = B[:n]
 	pk.NbInfinityB = uint64(nbWires - n)
 
+	t.Phase("qap", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
 	// compute our batch scalar multiplication with g1 elements
 	g1Scalars := make([]fr.Element, 0, (nbWires*3)+int(domain.Cardinality)+3)
 	g1Scalars = append(g1Scalars, toxicWaste.alphaReg, toxicWaste.betaReg, toxicWaste.deltaReg)
@@ -860,6 +830,12 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 
 	vk.G1.K = g1PointsAff[offset:]
 
+	t.Phase("pk.G1", start)
+	if err := t.Err(); err != nil {
+		return err
+	}
+	start = time.Now()
+
 	// ---------------------------------------------------------------------------------------------
 	// G2 scalars
 
@@ -901,6 +877,8 @@ func Setup(r1cs *cs.R1CS, pk *ProvingKey, vk *VerifyingKey) error {
 	// set domain
 	pk.Domain = *domain
 
+	t.Phase("pk.G2", start)
+
 	return nil
 }
 
@@ -1035,17 +1013,6 @@ func setupLazyABC(r1cs *cs.R1CS, domain *fft.Domain, toxicWaste toxicWaste) (A [
 		}
 	}
 
-	getWid := func(t compiled.Term, li compiled.LazyInputs, shift, j, loc int) int {
-		shiftI := shift
-		if li.IsInput(j, uint8(loc)) {
-			shiftI = 0
-		}
-		wID := t.WireID()
-		if wID != 0 {
-			wID += shiftI
-		}
-		return wID
-	}
 	// each constraint is in the form
 	// L * R == O
 	// L, R and O being linear expressions
@@ -1077,15 +1044,15 @@ func setupLazyABC(r1cs *cs.R1CS, domain *fft.Domain, toxicWaste toxicWaste) (A [
 			row := li.FetchLazy(j, &r1cs.R1CS, &r1cs.CoefT)
 
 			for _, t := range row.L {
-				wID := getWid(t, li, shift, j, 1)
+				wID := lazyWireID(t, li, shift, j, 1)
 				accumulate(&A[wID], t, &L)
 			}
 			for _, t := range row.R {
-				wID := getWid(t, li, shift, j, 2)
+				wID := lazyWireID(t, li, shift, j, 2)
 				accumulate(&B[wID], t, &L)
 			}
 			for _, t := range row.O {
-				wID := getWid(t, li, shift, j, 3)
+				wID := lazyWireID(t, li, shift, j, 3)
 				accumulate(&C[wID], t, &L)
 			}
 
@@ -1101,6 +1068,21 @@ func setupLazyABC(r1cs *cs.R1CS, domain *fft.Domain, toxicWaste toxicWaste) (A [
 
 }
 
+// lazyWireID returns the wire id of the term t of the j-th constraint of li, loc being 1, 2 or 3
+// for its L, R or O linear expression: the wires of a lazy constraint are the ones of the constraint
+// it was generated from, shifted by shift unless they are inputs
+func lazyWireID(t compiled.Term, li compiled.LazyInputs, shift, j, loc int) int {
+	shiftI := shift
+	if li.IsInput(j, uint8(loc)) {
+		shiftI = 0
+	}
+	wID := t.WireID()
+	if wID != 0 {
+		wID += shiftI
+	}
+	return wID
+}
+
 // toxicWaste toxic waste
 type toxicWaste struct {
 
@@ -1112,32 +1094,33 @@ type toxicWaste struct {
 	alphaReg, betaReg, gammaReg, deltaReg fr.Element
 }
 
-func sampleToxicWaste() (toxicWaste, error) {
+// sampleToxicWaste samples the toxic waste from rnd, or from crypto/rand if rnd is nil
+func sampleToxicWaste(rnd io.Reader) (toxicWaste, error) {
 
 	res := toxicWaste{}
 
 	for res.t.IsZero() {
-		if _, err := res.t.SetRandom(); err != nil {
+		if err := setRandom(&res.t, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.alpha.IsZero() {
-		if _, err := res.alpha.SetRandom(); err != nil {
+		if err := setRandom(&res.alpha, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.beta.IsZero() {
-		if _, err := res.beta.SetRandom(); err != nil {
+		if err := setRandom(&res.beta, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.gamma.IsZero() {
-		if _, err := res.gamma.SetRandom(); err != nil {
+		if err := setRandom(&res.gamma, rnd); err != nil {
 			return res, err
 		}
 	}
 	for res.delta.IsZero() {
-		if _, err := res.delta.SetRandom(); err != nil {
+		if err := setRandom(&res.delta, rnd); err != nil {
 			return res, err
 		}
 	}
@@ -1153,6 +1136,20 @@ func sampleToxicWaste() (toxicWaste, error) {
 	return res, nil
 }
 
+// setRandom sets x to a uniformly random element read from rnd, or from crypto/rand if rnd is nil
+func setRandom(x *fr.Element, rnd io.Reader) error {
+	if rnd == nil {
+		_, err := x.SetRandom()
+		return err
+	}
+	v, err := rand.Int(rnd, fr.Modulus())
+	if err != nil {
+		return err
+	}
+	x.SetBigInt(v)
+	return nil
+}
+
 // DummySetup fills a random ProvingKey
 // used for test or benchmarking purposes
 func DummySetup(r1cs *cs.R1CS, pk *ProvingKey) error {
@@ -1187,7 +1184,7 @@ func DummySetup(r1cs *cs.R1CS, pk *ProvingKey) error {
 	}
 
 	// samples toxic waste
-	toxicWaste, err := sampleToxicWaste()
+	toxicWaste, err := sampleToxicWaste(nil)
 	if err != nil {
 		return err
 	}
@@ -18,12 +18,15 @@ package plonk
 
 import (
 	"errors"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/fft"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
 	"github.com/consensys/gnark/internal/backend/bn254/cs"
 
 	kzgg "github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/frontend/compiled"
+	"github.com/consensys/gnark/internal/backend/dump"
 )
 
 // ProvingKey stores the data needed to generate a proof:
@@ -34,6 +37,7 @@ import (
 // with the list of public inputs.
 // * sigma_1, sigma_2, sigma_3 in both basis
 // * the copy constraint permutation
+// * qlookup, qtable and the lookup tables if the constraint system has lookups
 type ProvingKey struct {
 	// Verifying Key is embedded into the proving key (needed by Prove)
 	Vk *VerifyingKey
@@ -57,6 +61,13 @@ type ProvingKey struct {
 
 	// position -> permuted position (position in [0,3*sizeSystem-1])
 	Permutation []int64
+
+	// Qlookup, Qtable (in canonical basis), one and the table identifier on the lookup constraints.
+	// nil if the constraint system has no lookups.
+	Qlookup, Qtable []fr.Element
+
+	// Columns and table identifiers of the concatenated lookup tables, in Lagrange basis
+	LTable [4][]fr.Element
 }
 
 // VerifyingKey stores the data needed to verify a proof:
@@ -83,6 +94,9 @@ type VerifyingKey struct {
 	// Commitments to ql, qr, qm, qo prepended with as many zeroes (ones for l) as there are public inputs.
 	// In particular Qk is not complete.
 	Ql, Qr, Qm, Qo, Qk kzg.Digest
+
+	// Commitments proper to the lookup argument, nil if the constraint system has no lookups
+	Lookup *LookupVerifyingKey
 }
 
 // Setup sets proving and verifying keys
@@ -93,26 +107,9 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 	// The verifying key shares data with the proving key
 	pk.Vk = &vk
 
-	nbConstraints := len(spr.Constraints)
+	nbConstraints := len(spr.Constraints) + spr.LazyCons.GetConstraintsAll()
 
-	// fft domains
-	sizeSystem := uint64(nbConstraints + spr.NbPublicVariables) // spr.NbPublicVariables is for the placeholder constraints
-	pk.Domain[0] = *fft.NewDomain(sizeSystem)
-	pk.Vk.CosetShift.Set(&pk.Domain[0].FrMultiplicativeGen)
-
-	// h, the quotient polynomial is of degree 3(n+1)+2, so it's in a 3(n+2) dim vector space,
-	// the domain is the next power of 2 superior to 3(n+2). 4*domainNum is enough in all cases
-	// except when n<6.
-	if sizeSystem < 6 {
-		pk.Domain[1] = *fft.NewDomain(8 * sizeSystem)
-	} else {
-		pk.Domain[1] = *fft.NewDomain(4 * sizeSystem)
-	}
-
-	vk.Size = pk.Domain[0].Cardinality
-	vk.SizeInv.SetUint64(vk.Size).Inverse(&vk.SizeInv)
-	vk.Generator.Set(&pk.Domain[0].Generator)
-	vk.NbPublicVariables = uint64(spr.NbPublicVariables)
+	setupDomains(spr, &pk)
 
 	if err := pk.InitKZG(srs); err != nil {
 		return nil, nil, err
@@ -136,14 +133,15 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 	}
 	offset := spr.NbPublicVariables
 	for i := 0; i < nbConstraints; i++ { // constraints
-
-		pk.Ql[offset+i].Set(&spr.Coefficients[spr.Constraints[i].L.CoeffID()])
-		pk.Qr[offset+i].Set(&spr.Coefficients[spr.Constraints[i].R.CoeffID()])
-		pk.Qm[offset+i].Set(&spr.Coefficients[spr.Constraints[i].M[0].CoeffID()]).
-			Mul(&pk.Qm[offset+i], &spr.Coefficients[spr.Constraints[i].M[1].CoeffID()])
-		pk.Qo[offset+i].Set(&spr.Coefficients[spr.Constraints[i].O.CoeffID()])
-		pk.CQk[offset+i].Set(&spr.Coefficients[spr.Constraints[i].K])
-		pk.LQk[offset+i].Set(&spr.Coefficients[spr.Constraints[i].K])
+		c := spr.FetchConstraint(i)
+
+		pk.Ql[offset+i].Set(&spr.Coefficients[c.L.CoeffID()])
+		pk.Qr[offset+i].Set(&spr.Coefficients[c.R.CoeffID()])
+		pk.Qm[offset+i].Set(&spr.Coefficients[c.M[0].CoeffID()]).
+			Mul(&pk.Qm[offset+i], &spr.Coefficients[c.M[1].CoeffID()])
+		pk.Qo[offset+i].Set(&spr.Coefficients[c.O.CoeffID()])
+		pk.CQk[offset+i].Set(&spr.Coefficients[c.K])
+		pk.LQk[offset+i].Set(&spr.Coefficients[c.K])
 	}
 
 	pk.Domain[0].FFTInverse(pk.Ql, fft.DIF)
@@ -190,10 +188,179 @@ func Setup(spr *cs.SparseR1CS, srs *kzg.SRS) (*ProvingKey, *VerifyingKey, error)
 		return nil, nil, err
 	}
 
+	if len(spr.Tables) != 0 {
+		if err := setupLookup(spr, &pk); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	return &pk, &vk, nil
 
 }
 
+// setupDomains sets the fft domains of pk and the fields of pk.Vk derived from them
+func setupDomains(spr *cs.SparseR1CS, pk *ProvingKey) {
+	nbConstraints := len(spr.Constraints) + spr.LazyCons.GetConstraintsAll()
+
+	// fft domains
+	sizeSystem := uint64(nbConstraints + spr.NbPublicVariables) // spr.NbPublicVariables is for the placeholder constraints
+	if nbRows := uint64(spr.NbTableRows()); nbRows > sizeSystem {
+		sizeSystem = nbRows // the lookup tables are interpolated on the small domain
+	}
+	pk.Domain[0] = *fft.NewDomain(sizeSystem)
+	pk.Vk.CosetShift.Set(&pk.Domain[0].FrMultiplicativeGen)
+
+	// h, the quotient polynomial is of degree 3(n+1)+2, so it's in a 3(n+2) dim vector space,
+	// the domain is the next power of 2 superior to 3(n+2). 4*domainNum is enough in all cases
+	// except when n<6.
+	if sizeSystem < 6 {
+		pk.Domain[1] = *fft.NewDomain(8 * sizeSystem)
+	} else {
+		pk.Domain[1] = *fft.NewDomain(4 * sizeSystem)
+	}
+
+	pk.Vk.Size = pk.Domain[0].Cardinality
+	pk.Vk.SizeInv.SetUint64(pk.Vk.Size).Inverse(&pk.Vk.SizeInv)
+	pk.Vk.Generator.Set(&pk.Domain[0].Generator)
+	pk.Vk.NbPublicVariables = uint64(spr.NbPublicVariables)
+}
+
+// SetupWithDump runs Setup and writes the SparseR1CS, the VerifyingKey and each polynomial of the
+// ProvingKey under the session prefix instead of returning them, see package dump for the layout of
+// the files. The polynomials are computed, committed and written one at a time.
+func SetupWithDump(spr *cs.SparseR1CS, srs *kzg.SRS, session string) error {
+	if len(spr.Tables) != 0 {
+		return errors.New("lookup tables are not supported")
+	}
+	w := dump.NewWriter(session, spr.CurveID())
+	if err := w.Write(dump.ConstraintSystem, spr.WriteTo); err != nil {
+		return err
+	}
+	if err := w.HashCircuit(spr.WriteCircuitTo, nil); err != nil {
+		return err
+	}
+
+	var pk ProvingKey
+	var vk VerifyingKey
+	pk.Vk = &vk
+
+	setupDomains(spr, &pk)
+	if err := pk.InitKZG(srs); err != nil {
+		return err
+	}
+	if err := w.Write(dump.ProvingKeyDomain, pk.writeDomainsTo); err != nil {
+		return err
+	}
+
+	// selectors, placeholders included (see Setup)
+	var minusOne fr.Element
+	minusOne.SetOne().Neg(&minusOne)
+	for _, s := range []struct {
+		name        string
+		placeholder fr.Element
+		q           func(c compiled.SparseR1C) fr.Element
+		commitment  *kzg.Digest // nil for LQk, which stays in Lagrange form
+	}{
+		{dump.ProvingKeyQl, minusOne, func(c compiled.SparseR1C) fr.Element { return spr.Coefficients[c.L.CoeffID()] }, &vk.Ql},
+		{dump.ProvingKeyQr, fr.Element{}, func(c compiled.SparseR1C) fr.Element { return spr.Coefficients[c.R.CoeffID()] }, &vk.Qr},
+		{dump.ProvingKeyQm, fr.Element{}, func(c compiled.SparseR1C) fr.Element {
+			var qm fr.Element
+			return *qm.Mul(&spr.Coefficients[c.M[0].CoeffID()], &spr.Coefficients[c.M[1].CoeffID()])
+		}, &vk.Qm},
+		{dump.ProvingKeyQo, fr.Element{}, func(c compiled.SparseR1C) fr.Element { return spr.Coefficients[c.O.CoeffID()] }, &vk.Qo},
+		{dump.ProvingKeyCQk, fr.Element{}, func(c compiled.SparseR1C) fr.Element { return spr.Coefficients[c.K] }, &vk.Qk},
+		{dump.ProvingKeyLQk, fr.Element{}, func(c compiled.SparseR1C) fr.Element { return spr.Coefficients[c.K] }, nil},
+	} {
+		q := setupSelector(spr, &pk, s.placeholder, s.q)
+		if s.commitment != nil {
+			pk.Domain[0].FFTInverse(q, fft.DIF)
+			fft.BitReverse(q)
+			var err error
+			if *s.commitment, err = kzg.Commit(q, vk.KZGSRS); err != nil {
+				return err
+			}
+		}
+		if err := w.Write(s.name, writeElements(q)); err != nil {
+			return err
+		}
+	}
+
+	// permutation
+	buildPermutation(spr, &pk)
+	if err := w.Write(dump.ProvingKeyPermutation, pk.writePermutationTo); err != nil {
+		return err
+	}
+	evaluationIDSmallDomain := getIDSmallDomain(&pk.Domain[0])
+	for i, s := range []struct {
+		canonical, bigDomain string
+	}{
+		{dump.ProvingKeyS1, dump.ProvingKeyS1BigDomain},
+		{dump.ProvingKeyS2, dump.ProvingKeyS2BigDomain},
+		{dump.ProvingKeyS3, dump.ProvingKeyS3BigDomain},
+	} {
+		sCanonical := permutationPolynomialCanonical(&pk, evaluationIDSmallDomain, i)
+		var err error
+		if vk.S[i], err = kzg.Commit(sCanonical, vk.KZGSRS); err != nil {
+			return err
+		}
+		if err := w.Write(s.canonical, writeElements(sCanonical)); err != nil {
+			return err
+		}
+		if err := w.Write(s.bigDomain, writeElements(evaluateDomainBigBitReversed(sCanonical, &pk.Domain[1]))); err != nil {
+			return err
+		}
+	}
+	pk.Permutation = nil
+
+	if err := w.Write(dump.VerifyingKey, vk.WriteTo); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// setupSelector returns the selector q of the constraints of spr in Lagrange form, prepended
+// with placeholder for each public input
+func setupSelector(spr *cs.SparseR1CS, pk *ProvingKey, placeholder fr.Element, q func(c compiled.SparseR1C) fr.Element) []fr.Element {
+	res := make([]fr.Element, pk.Domain[0].Cardinality)
+	for i := 0; i < spr.NbPublicVariables; i++ {
+		res[i] = placeholder
+	}
+	offset := spr.NbPublicVariables
+	nbConstraints := len(spr.Constraints) + spr.LazyCons.GetConstraintsAll()
+	for i := 0; i < nbConstraints; i++ {
+		res[offset+i] = q(spr.FetchConstraint(i))
+	}
+	return res
+}
+
+// ReadSegmentProveKey reads the VerifyingKey and the domains of a ProvingKey dumped by SetupWithDump
+// for spr under session, the polynomials are left on disk for ProveRoll.
+//
+// As the SRS isn't part of the dump, InitKZG must be called on the result before proving.
+func ReadSegmentProveKey(spr *cs.SparseR1CS, session string) (*ProvingKey, error) {
+	m, err := dump.ReadManifest(session)
+	if err != nil {
+		return nil, err
+	}
+	if err := m.CheckCurve(curve.ID); err != nil {
+		return nil, err
+	}
+	if err := m.CheckCircuit(spr.WriteCircuitTo, nil); err != nil {
+		return nil, err
+	}
+
+	pk := &ProvingKey{Vk: &VerifyingKey{}}
+	if err := m.ReadSegment(dump.VerifyingKey, pk.Vk.ReadFrom); err != nil {
+		return nil, err
+	}
+	if err := m.ReadSegment(dump.ProvingKeyDomain, pk.readDomainsFrom); err != nil {
+		return nil, err
+	}
+	// the coset shift isn't part of the encoding of the VerifyingKey
+	pk.Vk.CosetShift.Set(&pk.Domain[0].FrMultiplicativeGen)
+	return pk, nil
+}
+
 // buildPermutation builds the Permutation associated with a circuit.
 //
 // The permutation s is composed of cycles of maximum length such that
@@ -224,10 +391,12 @@ func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
 	}
 
 	offset := spr.NbPublicVariables
-	for i := 0; i < len(spr.Constraints); i++ { // IDs of LRO associated to constraints
-		lro[offset+i] = spr.Constraints[i].L.WireID()
-		lro[sizeSolution+offset+i] = spr.Constraints[i].R.WireID()
-		lro[2*sizeSolution+offset+i] = spr.Constraints[i].O.WireID()
+	nbConstraints := len(spr.Constraints) + spr.LazyCons.GetConstraintsAll()
+	for i := 0; i < nbConstraints; i++ { // IDs of LRO associated to constraints
+		c := spr.FetchConstraint(i)
+		lro[offset+i] = c.L.WireID()
+		lro[sizeSolution+offset+i] = c.R.WireID()
+		lro[2*sizeSolution+offset+i] = c.O.WireID()
 	}
 
 	// init cycle:
@@ -265,28 +434,13 @@ func buildPermutation(spr *cs.SparseR1CS, pk *ProvingKey) {
 // 		s1 (LDE)                s2 (LDE)                          s3 (LDE)
 func ccomputePermutationPolynomials(pk *ProvingKey) {
 
-	nbElmts := int(pk.Domain[0].Cardinality)
-
 	// Lagrange form of ID
 	evaluationIDSmallDomain := getIDSmallDomain(&pk.Domain[0])
 
-	// Lagrange form of S1, S2, S3
-	pk.S1Canonical = make([]fr.Element, nbElmts)
-	pk.S2Canonical = make([]fr.Element, nbElmts)
-	pk.S3Canonical = make([]fr.Element, nbElmts)
-	for i := 0; i < nbElmts; i++ {
-		pk.S1Canonical[i].Set(&evaluationIDSmallDomain[pk.Permutation[i]])
-		pk.S2Canonical[i].Set(&evaluationIDSmallDomain[pk.Permutation[nbElmts+i]])
-		pk.S3Canonical[i].Set(&evaluationIDSmallDomain[pk.Permutation[2*nbElmts+i]])
-	}
-
 	// Canonical form of S1, S2, S3
-	pk.Domain[0].FFTInverse(pk.S1Canonical, fft.DIF)
-	pk.Domain[0].FFTInverse(pk.S2Canonical, fft.DIF)
-	pk.Domain[0].FFTInverse(pk.S3Canonical, fft.DIF)
-	fft.BitReverse(pk.S1Canonical)
-	fft.BitReverse(pk.S2Canonical)
-	fft.BitReverse(pk.S3Canonical)
+	pk.S1Canonical = permutationPolynomialCanonical(pk, evaluationIDSmallDomain, 0)
+	pk.S2Canonical = permutationPolynomialCanonical(pk, evaluationIDSmallDomain, 1)
+	pk.S3Canonical = permutationPolynomialCanonical(pk, evaluationIDSmallDomain, 2)
 
 	// evaluation of permutation on the big domain
 	pk.EvaluationPermutationBigDomainBitReversed = make([]fr.Element, 3*pk.Domain[1].Cardinality)
@@ -299,6 +453,18 @@ func ccomputePermutationPolynomials(pk *ProvingKey) {
 
 }
 
+// permutationPolynomialCanonical returns the canonical form of s1, s2 or s3 for i = 0, 1 or 2
+func permutationPolynomialCanonical(pk *ProvingKey, evaluationIDSmallDomain []fr.Element, i int) []fr.Element {
+	nbElmts := int(pk.Domain[0].Cardinality)
+	res := make([]fr.Element, nbElmts)
+	for j := 0; j < nbElmts; j++ {
+		res[j].Set(&evaluationIDSmallDomain[pk.Permutation[i*nbElmts+j]])
+	}
+	pk.Domain[0].FFTInverse(res, fft.DIF)
+	fft.BitReverse(res)
+	return res
+}
+
 // getIDSmallDomain returns the Lagrange form of ID on the small domain
 func getIDSmallDomain(domain *fft.Domain) []fr.Element {
 
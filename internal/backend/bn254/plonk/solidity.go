@@ -0,0 +1,311 @@
+package plonk
+
+// solidityTemplate is the verifier contract written by VerifyingKey.ExportSolidity, it follows Verify:
+// the challenges are derived with the same sha256 transcript, and the two KZG opening proofs are
+// batched with a random linear combination derived from the proof itself.
+// this is an experimental feature and gnark solidity generator as not been thoroughly tested
+const solidityTemplate = `
+// SPDX-License-Identifier: Apache-2.0
+
+// Code generated by gnark DO NOT EDIT
+
+pragma solidity ^0.8.0;
+
+contract PlonkVerifier {
+
+    uint256 constant R_MOD = 21888242871839275222246405745257275088548364400416034343698204186575808495617;
+    uint256 constant P_MOD = 21888242871839275222246405745257275088696311157297823662689037894645226208583;
+
+    uint256 constant VK_SIZE = {{.Size}};
+    uint256 constant VK_INV_SIZE = {{.SizeInv.String}};
+    uint256 constant VK_OMEGA = {{.Generator.String}};
+    uint256 constant VK_COSET_SHIFT = {{.CosetShift.String}};
+    uint256 constant VK_NB_PUBLIC_INPUTS = {{.NbPublicVariables}};
+
+    uint256 constant VK_S1_X = {{(index .S 0).X.String}};
+    uint256 constant VK_S1_Y = {{(index .S 0).Y.String}};
+    uint256 constant VK_S2_X = {{(index .S 1).X.String}};
+    uint256 constant VK_S2_Y = {{(index .S 1).Y.String}};
+    uint256 constant VK_S3_X = {{(index .S 2).X.String}};
+    uint256 constant VK_S3_Y = {{(index .S 2).Y.String}};
+    uint256 constant VK_QL_X = {{.Ql.X.String}};
+    uint256 constant VK_QL_Y = {{.Ql.Y.String}};
+    uint256 constant VK_QR_X = {{.Qr.X.String}};
+    uint256 constant VK_QR_Y = {{.Qr.Y.String}};
+    uint256 constant VK_QM_X = {{.Qm.X.String}};
+    uint256 constant VK_QM_Y = {{.Qm.Y.String}};
+    uint256 constant VK_QO_X = {{.Qo.X.String}};
+    uint256 constant VK_QO_Y = {{.Qo.Y.String}};
+    uint256 constant VK_QK_X = {{.Qk.X.String}};
+    uint256 constant VK_QK_Y = {{.Qk.Y.String}};
+
+    // KZG SRS: [1]₁, [1]₂ and [α]₂, the coordinates of G2 points are in the order of the pairing precompile
+    uint256 constant SRS_G1_X = {{(index .KZGSRS.G1 0).X.String}};
+    uint256 constant SRS_G1_Y = {{(index .KZGSRS.G1 0).Y.String}};
+    uint256 constant SRS_G2_X_0 = {{(index .KZGSRS.G2 0).X.A1.String}};
+    uint256 constant SRS_G2_X_1 = {{(index .KZGSRS.G2 0).X.A0.String}};
+    uint256 constant SRS_G2_Y_0 = {{(index .KZGSRS.G2 0).Y.A1.String}};
+    uint256 constant SRS_G2_Y_1 = {{(index .KZGSRS.G2 0).Y.A0.String}};
+    uint256 constant SRS_ALPHA_G2_X_0 = {{(index .KZGSRS.G2 1).X.A1.String}};
+    uint256 constant SRS_ALPHA_G2_X_1 = {{(index .KZGSRS.G2 1).X.A0.String}};
+    uint256 constant SRS_ALPHA_G2_Y_0 = {{(index .KZGSRS.G2 1).Y.A1.String}};
+    uint256 constant SRS_ALPHA_G2_Y_1 = {{(index .KZGSRS.G2 1).Y.A0.String}};
+
+    // layout of the proof, in 32-byte words, see Proof.MarshalSolidity
+    uint256 constant PROOF_L = 0;
+    uint256 constant PROOF_R = 2;
+    uint256 constant PROOF_O = 4;
+    uint256 constant PROOF_Z = 6;
+    uint256 constant PROOF_H_0 = 8;
+    uint256 constant PROOF_H_1 = 10;
+    uint256 constant PROOF_H_2 = 12;
+    uint256 constant PROOF_BATCHED_H = 14;
+    uint256 constant PROOF_QUOTIENT_ZETA = 16;
+    uint256 constant PROOF_LINEARIZED_ZETA = 17;
+    uint256 constant PROOF_L_ZETA = 18;
+    uint256 constant PROOF_R_ZETA = 19;
+    uint256 constant PROOF_O_ZETA = 20;
+    uint256 constant PROOF_S1_ZETA = 21;
+    uint256 constant PROOF_S2_ZETA = 22;
+    uint256 constant PROOF_Z_SHIFTED_H = 23;
+    uint256 constant PROOF_Z_SHIFTED_ZETA = 25;
+    uint256 constant PROOF_NB_WORDS = 26;
+
+    struct State {
+        // challenges
+        uint256 gamma;
+        uint256 beta;
+        uint256 alpha;
+        uint256 zeta;
+
+        // ζⁿ, L₁(ζ), PI(ζ) and α²L₁(ζ)
+        uint256 zetaPowerN;
+        uint256 lagrangeOne;
+        uint256 pi;
+        uint256 alphaSquareLagrange;
+    }
+
+    // Verify returns true if proof, encoded by Proof.MarshalSolidity, is valid for the public inputs
+    function Verify(bytes calldata proof, uint256[] calldata public_inputs) public view returns (bool) {
+        require(proof.length == PROOF_NB_WORDS * 0x20, "wrong proof size");
+        require(public_inputs.length == VK_NB_PUBLIC_INPUTS, "wrong number of public inputs");
+        for (uint256 i = 0; i < public_inputs.length; i++) {
+            require(public_inputs[i] < R_MOD, "public input not reduced");
+        }
+
+        uint256[PROOF_NB_WORDS] memory p;
+        assembly {
+            calldatacopy(p, proof.offset, mul(PROOF_NB_WORDS, 0x20))
+        }
+        for (uint256 i = PROOF_QUOTIENT_ZETA; i <= PROOF_S2_ZETA; i++) {
+            require(p[i] < R_MOD, "claimed value not reduced");
+        }
+        require(p[PROOF_Z_SHIFTED_ZETA] < R_MOD, "claimed value not reduced");
+
+        State memory s;
+        deriveChallenges(p, public_inputs, s);
+        computePublicInputs(public_inputs, s);
+        if (!checkQuotient(p, s)) {
+            return false;
+        }
+
+        (uint256[2] memory digest, uint256 eval) = foldDigests(p, s);
+        return batchVerify(p, s, digest, eval);
+    }
+
+    // deriveChallenges derives γ, β, α and ζ as the fiat-shamir transcript of Verify
+    function deriveChallenges(uint256[PROOF_NB_WORDS] memory p, uint256[] calldata public_inputs, State memory s) internal view {
+        bytes memory buf = abi.encodePacked("gamma", VK_S1_X, VK_S1_Y, VK_S2_X, VK_S2_Y, VK_S3_X, VK_S3_Y);
+        buf = abi.encodePacked(buf, VK_QL_X, VK_QL_Y, VK_QR_X, VK_QR_Y, VK_QM_X, VK_QM_Y);
+        buf = abi.encodePacked(buf, VK_QO_X, VK_QO_Y, VK_QK_X, VK_QK_Y, public_inputs);
+        bytes32 gamma = sha256(buf);
+        bytes32 beta = sha256(abi.encodePacked("beta", gamma));
+        bytes32 alpha = sha256(abi.encodePacked("alpha", beta, p[PROOF_Z], p[PROOF_Z + 1]));
+        bytes32 zeta = sha256(abi.encodePacked("zeta", alpha, p[PROOF_H_0], p[PROOF_H_0 + 1],
+            p[PROOF_H_1], p[PROOF_H_1 + 1], p[PROOF_H_2], p[PROOF_H_2 + 1]));
+
+        s.gamma = uint256(gamma) % R_MOD;
+        s.beta = uint256(beta) % R_MOD;
+        s.alpha = uint256(alpha) % R_MOD;
+        s.zeta = uint256(zeta) % R_MOD;
+    }
+
+    // computePublicInputs computes PI(ζ) = ∑ᵢLᵢ(ζ)wᵢ and L₁(ζ)
+    function computePublicInputs(uint256[] calldata public_inputs, State memory s) internal view {
+        s.zetaPowerN = expMod(s.zeta, VK_SIZE);
+
+        // L₁(ζ) = (ζⁿ-1)/(n(ζ-1)), then Lᵢ₊₁(ζ) = ωLᵢ(ζ)(ζ-ωⁱ)/(ζ-ωⁱ⁺¹)
+        uint256 den = addmod(s.zeta, R_MOD - 1, R_MOD);
+        uint256 lagrange = mulmod(addmod(s.zetaPowerN, R_MOD - 1, R_MOD), VK_INV_SIZE, R_MOD);
+        lagrange = mulmod(lagrange, inverse(den), R_MOD);
+        s.lagrangeOne = lagrange;
+
+        uint256 acc = 1;
+        uint256 pi = 0;
+        for (uint256 i = 0; i < public_inputs.length; i++) {
+            pi = addmod(pi, mulmod(lagrange, public_inputs[i], R_MOD), R_MOD);
+            lagrange = mulmod(mulmod(lagrange, VK_OMEGA, R_MOD), den, R_MOD);
+            acc = mulmod(acc, VK_OMEGA, R_MOD);
+            den = addmod(s.zeta, R_MOD - acc, R_MOD);
+            lagrange = mulmod(lagrange, inverse(den), R_MOD);
+        }
+        s.pi = pi;
+        s.alphaSquareLagrange = mulmod(mulmod(s.lagrangeOne, s.alpha, R_MOD), s.alpha, R_MOD);
+    }
+
+    // checkQuotient checks that the claimed H(ζ) is
+    // (linearizedpolynomial(ζ) + PI(ζ) + α·Z(μζ)(l(ζ)+β·s1(ζ)+γ)(r(ζ)+β·s2(ζ)+γ)(o(ζ)+γ) - α²L₁(ζ)) / (ζⁿ-1)
+    function checkQuotient(uint256[PROOF_NB_WORDS] memory p, State memory s) internal view returns (bool) {
+        uint256 t = addmod(addmod(mulmod(p[PROOF_S1_ZETA], s.beta, R_MOD), p[PROOF_L_ZETA], R_MOD), s.gamma, R_MOD);
+        t = mulmod(t, addmod(addmod(mulmod(p[PROOF_S2_ZETA], s.beta, R_MOD), p[PROOF_R_ZETA], R_MOD), s.gamma, R_MOD), R_MOD);
+        t = mulmod(t, addmod(p[PROOF_O_ZETA], s.gamma, R_MOD), R_MOD);
+        t = mulmod(mulmod(t, s.alpha, R_MOD), p[PROOF_Z_SHIFTED_ZETA], R_MOD);
+
+        uint256 res = addmod(p[PROOF_LINEARIZED_ZETA], s.pi, R_MOD);
+        res = addmod(res, t, R_MOD);
+        res = addmod(res, R_MOD - s.alphaSquareLagrange, R_MOD);
+        res = mulmod(res, inverse(addmod(s.zetaPowerN, R_MOD - 1, R_MOD)), R_MOD);
+        return res == p[PROOF_QUOTIENT_ZETA];
+    }
+
+    // foldDigests returns the digest and claimed value of the folded batch opening proof at ζ, as kzg.FoldProof
+    function foldDigests(uint256[PROOF_NB_WORDS] memory p, State memory s) internal view returns (uint256[2] memory digest, uint256 eval) {
+        uint256[2] memory foldedH = foldedHDigest(p, s);
+        uint256[2] memory linearized = linearizedDigest(p, s);
+
+        bytes memory buf = abi.encodePacked("gamma", s.zeta, foldedH[0], foldedH[1], linearized[0], linearized[1]);
+        buf = abi.encodePacked(buf, p[PROOF_L], p[PROOF_L + 1], p[PROOF_R], p[PROOF_R + 1], p[PROOF_O], p[PROOF_O + 1]);
+        buf = abi.encodePacked(buf, VK_S1_X, VK_S1_Y, VK_S2_X, VK_S2_Y);
+        uint256 gamma = uint256(sha256(buf)) % R_MOD;
+
+        // ∑ᵢγⁱDᵢ and ∑ᵢγⁱfᵢ(ζ) over [H, linearized polynomial, L, R, O, S1, S2]
+        digest = foldedH;
+        eval = p[PROOF_QUOTIENT_ZETA];
+        uint256 gammaI = gamma;
+        digest = ecAdd(digest, ecMul(linearized, gammaI));
+        eval = addmod(eval, mulmod(p[PROOF_LINEARIZED_ZETA], gammaI, R_MOD), R_MOD);
+        for (uint256 i = 0; i < 3; i++) {
+            gammaI = mulmod(gammaI, gamma, R_MOD);
+            digest = ecAdd(digest, ecMul([p[PROOF_L + 2 * i], p[PROOF_L + 2 * i + 1]], gammaI));
+            eval = addmod(eval, mulmod(p[PROOF_L_ZETA + i], gammaI, R_MOD), R_MOD);
+        }
+        gammaI = mulmod(gammaI, gamma, R_MOD);
+        digest = ecAdd(digest, ecMul([VK_S1_X, VK_S1_Y], gammaI));
+        eval = addmod(eval, mulmod(p[PROOF_S1_ZETA], gammaI, R_MOD), R_MOD);
+        gammaI = mulmod(gammaI, gamma, R_MOD);
+        digest = ecAdd(digest, ecMul([VK_S2_X, VK_S2_Y], gammaI));
+        eval = addmod(eval, mulmod(p[PROOF_S2_ZETA], gammaI, R_MOD), R_MOD);
+    }
+
+    // foldedHDigest returns Comm(h₁) + ζⁿ⁺²Comm(h₂) + ζ²⁽ⁿ⁺²⁾Comm(h₃)
+    function foldedHDigest(uint256[PROOF_NB_WORDS] memory p, State memory s) internal view returns (uint256[2] memory res) {
+        uint256 zetaNPlusTwo = mulmod(mulmod(s.zetaPowerN, s.zeta, R_MOD), s.zeta, R_MOD);
+        res = ecMul([p[PROOF_H_2], p[PROOF_H_2 + 1]], zetaNPlusTwo);
+        res = ecAdd(res, [p[PROOF_H_1], p[PROOF_H_1 + 1]]);
+        res = ecMul(res, zetaNPlusTwo);
+        res = ecAdd(res, [p[PROOF_H_0], p[PROOF_H_0 + 1]]);
+    }
+
+    // linearizedDigest returns the commitment to the linearized polynomial
+    // l(ζ)Ql + r(ζ)Qr + r(ζ)l(ζ)Qm + o(ζ)Qo + Qk
+    // + αZ(μζ)(l(ζ)+β·s1(ζ)+γ)(r(ζ)+β·s2(ζ)+γ)β·S3
+    // - α(l(ζ)+βζ+γ)(r(ζ)+βμζ+γ)(o(ζ)+βμ²ζ+γ)·Z + α²L₁(ζ)·Z
+    function linearizedDigest(uint256[PROOF_NB_WORDS] memory p, State memory s) internal view returns (uint256[2] memory res) {
+        uint256 l = p[PROOF_L_ZETA];
+        uint256 r = p[PROOF_R_ZETA];
+        uint256 o = p[PROOF_O_ZETA];
+
+        res = ecMul([VK_QL_X, VK_QL_Y], l);
+        res = ecAdd(res, ecMul([VK_QR_X, VK_QR_Y], r));
+        res = ecAdd(res, ecMul([VK_QM_X, VK_QM_Y], mulmod(l, r, R_MOD)));
+        res = ecAdd(res, ecMul([VK_QO_X, VK_QO_Y], o));
+        res = ecAdd(res, [VK_QK_X, VK_QK_Y]);
+
+        uint256 coeff = mulmod(p[PROOF_Z_SHIFTED_ZETA], s.beta, R_MOD);
+        coeff = mulmod(coeff, addmod(addmod(mulmod(s.beta, p[PROOF_S1_ZETA], R_MOD), l, R_MOD), s.gamma, R_MOD), R_MOD);
+        coeff = mulmod(coeff, addmod(addmod(mulmod(s.beta, p[PROOF_S2_ZETA], R_MOD), r, R_MOD), s.gamma, R_MOD), R_MOD);
+        coeff = mulmod(coeff, s.alpha, R_MOD);
+        res = ecAdd(res, ecMul([VK_S3_X, VK_S3_Y], coeff));
+
+        uint256 betaZeta = mulmod(s.beta, s.zeta, R_MOD);
+        coeff = addmod(addmod(betaZeta, l, R_MOD), s.gamma, R_MOD);
+        betaZeta = mulmod(betaZeta, VK_COSET_SHIFT, R_MOD);
+        coeff = mulmod(coeff, addmod(addmod(betaZeta, r, R_MOD), s.gamma, R_MOD), R_MOD);
+        betaZeta = mulmod(betaZeta, VK_COSET_SHIFT, R_MOD);
+        coeff = mulmod(coeff, addmod(addmod(betaZeta, o, R_MOD), s.gamma, R_MOD), R_MOD);
+        coeff = mulmod(R_MOD - coeff, s.alpha, R_MOD);
+        coeff = addmod(coeff, s.alphaSquareLagrange, R_MOD);
+        res = ecAdd(res, ecMul([p[PROOF_Z], p[PROOF_Z + 1]], coeff));
+    }
+
+    // batchVerify checks the folded opening proof at ζ and the opening proof of Z at μζ with a single pairing:
+    // e(∑ᵢλᵢ(Dᵢ - [fᵢ]₁ + pᵢHᵢ), [1]₂)·e(-∑ᵢλᵢHᵢ, [α]₂) = 1, λ₀ = 1 and λ₁ derived from the proof
+    function batchVerify(uint256[PROOF_NB_WORDS] memory p, State memory s, uint256[2] memory digest, uint256 eval) internal view returns (bool) {
+        uint256 lambda = uint256(sha256(abi.encodePacked(s.zeta, digest[0], digest[1], eval, p))) % R_MOD;
+        uint256[2] memory h = [p[PROOF_BATCHED_H], p[PROOF_BATCHED_H + 1]];
+        uint256[2] memory hShifted = [p[PROOF_Z_SHIFTED_H], p[PROOF_Z_SHIFTED_H + 1]];
+
+        uint256[2] memory quotients = ecAdd(h, ecMul(hShifted, lambda));
+
+        eval = addmod(eval, mulmod(lambda, p[PROOF_Z_SHIFTED_ZETA], R_MOD), R_MOD);
+        digest = ecAdd(digest, ecMul([p[PROOF_Z], p[PROOF_Z + 1]], lambda));
+        digest = ecAdd(digest, ecNeg(ecMul([SRS_G1_X, SRS_G1_Y], eval)));
+        digest = ecAdd(digest, ecMul(h, s.zeta));
+        digest = ecAdd(digest, ecMul(hShifted, mulmod(mulmod(lambda, s.zeta, R_MOD), VK_OMEGA, R_MOD)));
+
+        quotients = ecNeg(quotients);
+        uint256[12] memory input = [
+            digest[0], digest[1], SRS_G2_X_0, SRS_G2_X_1, SRS_G2_Y_0, SRS_G2_Y_1,
+            quotients[0], quotients[1], SRS_ALPHA_G2_X_0, SRS_ALPHA_G2_X_1, SRS_ALPHA_G2_Y_0, SRS_ALPHA_G2_Y_1
+        ];
+        uint256[1] memory out;
+        bool success;
+        assembly {
+            success := staticcall(gas(), 8, input, 0x180, out, 0x20)
+        }
+        require(success, "pairing failed");
+        return out[0] == 1;
+    }
+
+    function ecAdd(uint256[2] memory a, uint256[2] memory b) internal view returns (uint256[2] memory res) {
+        uint256[4] memory input = [a[0], a[1], b[0], b[1]];
+        bool success;
+        assembly {
+            success := staticcall(gas(), 6, input, 0x80, res, 0x40)
+        }
+        require(success, "ecAdd failed");
+    }
+
+    function ecMul(uint256[2] memory a, uint256 s) internal view returns (uint256[2] memory res) {
+        uint256[3] memory input = [a[0], a[1], s];
+        bool success;
+        assembly {
+            success := staticcall(gas(), 7, input, 0x60, res, 0x40)
+        }
+        require(success, "ecMul failed");
+    }
+
+    function ecNeg(uint256[2] memory a) internal pure returns (uint256[2] memory) {
+        if (a[0] == 0 && a[1] == 0) {
+            return a;
+        }
+        return [a[0], P_MOD - a[1]];
+    }
+
+    function expMod(uint256 base, uint256 e) internal view returns (uint256) {
+        uint256[6] memory input = [uint256(0x20), 0x20, 0x20, base, e, R_MOD];
+        uint256[1] memory out;
+        bool success;
+        assembly {
+            success := staticcall(gas(), 5, input, 0xc0, out, 0x20)
+        }
+        require(success, "expMod failed");
+        return out[0];
+    }
+
+    function inverse(uint256 a) internal view returns (uint256) {
+        require(a != 0, "inverse of zero");
+        return expMod(a, R_MOD - 2);
+    }
+}
+`
@@ -0,0 +1,219 @@
+package plonk_test
+
+//go:generate go test -run TestSolidityVerifier -update
+
+import (
+	"bytes"
+	"flag"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	curve "github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/internal/backend/bn254/cs"
+	bn254plonk "github.com/consensys/gnark/internal/backend/bn254/plonk"
+	bn254witness "github.com/consensys/gnark/internal/backend/bn254/witness"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+)
+
+type solidityCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+func (circuit *solidityCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(circuit.Y, api.Add(x3, circuit.X, 5))
+	api.AssertIsEqual(circuit.Z, api.Mul(circuit.Y, circuit.X))
+	return nil
+}
+
+func solidityProof(t *testing.T) (*bn254plonk.Proof, *bn254plonk.VerifyingKey, bn254witness.Witness) {
+	ccs, err := frontend.Compile(curve.ID, scs.NewBuilder, &solidityCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assignment := solidityCircuit{X: 3, Y: 35, Z: 105}
+	var fullWitness, publicWitness bn254witness.Witness
+	if _, err := fullWitness.FromAssignment(&assignment, tVariable, false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := publicWitness.FromAssignment(&assignment, tVariable, true); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, nbPublic := ccs.GetNbVariables()
+	srs, err := kzg.NewSRS(ecc.NextPowerOfTwo(uint64(ccs.GetNbConstraints()+nbPublic))+3, big.NewInt(42))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := bn254plonk.Setup(ccs.(*cs.SparseR1CS), srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := bn254plonk.Prove(ccs.(*cs.SparseR1CS), pk, fullWitness, backend.ProverConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := bn254plonk.Verify(proof, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+	return proof, vk, publicWitness
+}
+
+func TestMarshalSolidity(t *testing.T) {
+	proof, _, _ := solidityProof(t)
+	calldata := proof.MarshalSolidity()
+	if len(calldata) != 26*fr.Bytes {
+		t.Fatalf("calldata has %d bytes, expected %d", len(calldata), 26*fr.Bytes)
+	}
+
+	// read the words back in the order of the contract
+	var offset int
+	nextPoint := func() curve.G1Affine {
+		var p curve.G1Affine
+		p.X.SetBytes(calldata[offset : offset+fr.Bytes])
+		p.Y.SetBytes(calldata[offset+fr.Bytes : offset+2*fr.Bytes])
+		offset += 2 * fr.Bytes
+		return p
+	}
+	nextScalar := func() fr.Element {
+		var s fr.Element
+		s.SetBytes(calldata[offset : offset+fr.Bytes])
+		offset += fr.Bytes
+		return s
+	}
+
+	var decoded bn254plonk.Proof
+	for i := range decoded.LRO {
+		decoded.LRO[i] = nextPoint()
+	}
+	decoded.Z = nextPoint()
+	for i := range decoded.H {
+		decoded.H[i] = nextPoint()
+	}
+	decoded.BatchedProof.H = nextPoint()
+	decoded.BatchedProof.ClaimedValues = make([]fr.Element, len(proof.BatchedProof.ClaimedValues))
+	for i := range decoded.BatchedProof.ClaimedValues {
+		decoded.BatchedProof.ClaimedValues[i] = nextScalar()
+	}
+	decoded.ZShiftedOpening.H = nextPoint()
+	decoded.ZShiftedOpening.ClaimedValue = nextScalar()
+
+	if !reflect.DeepEqual(proof, &decoded) {
+		t.Fatal("calldata doesn't match the proof")
+	}
+}
+
+const solidityABI = `[{"inputs":[{"internalType":"bytes","name":"proof","type":"bytes"},{"internalType":"uint256[]","name":"public_inputs","type":"uint256[]"}],"name":"Verify","outputs":[{"internalType":"bool","name":"","type":"bool"}],"stateMutability":"view","type":"function"}]`
+
+var update = flag.Bool("update", false, "regenerate the contract fixtures of testdata, compiling them with solc")
+
+// TestSolidityVerifier checks the exported contract against testdata/PlonkVerifier.sol,
+// and runs the bytecode compiled from it, testdata/PlonkVerifier.bin, in the EVM.
+// Both are regenerated with go generate, which needs solc.
+func TestSolidityVerifier(t *testing.T) {
+	proof, vk, publicWitness := solidityProof(t)
+
+	var contract bytes.Buffer
+	if err := vk.ExportSolidity(&contract); err != nil {
+		t.Fatal(err)
+	}
+	solPath := filepath.Join("testdata", "PlonkVerifier.sol")
+	binPath := filepath.Join("testdata", "PlonkVerifier.bin")
+	if *update {
+		updateSolidityFixtures(t, contract.Bytes(), solPath, binPath)
+	}
+	golden, err := os.ReadFile(solPath)
+	if err != nil {
+		t.Fatalf("%v, run go generate", err)
+	}
+	if !bytes.Equal(golden, contract.Bytes()) {
+		t.Fatalf("the exported contract doesn't match %s, run go generate", solPath)
+	}
+	bin, err := os.ReadFile(binPath)
+	if os.IsNotExist(err) {
+		t.Skipf("%s not found, run go generate with solc installed", binPath)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &runtime.Config{GasLimit: 10_000_000}
+	_, address, _, err := runtime.Create(common.FromHex(strings.TrimSpace(string(bin))), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verifier, err := abi.JSON(strings.NewReader(solidityABI))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	verify := func(calldata []byte, publicInputs []*big.Int) bool {
+		input, err := verifier.Pack("Verify", calldata, publicInputs)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, _, err := runtime.Call(address, input, cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := verifier.Unpack("Verify", ret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res[0].(bool)
+	}
+
+	publicInputs := make([]*big.Int, len(publicWitness))
+	for i := range publicWitness {
+		publicInputs[i] = new(big.Int)
+		publicWitness[i].ToBigIntRegular(publicInputs[i])
+	}
+	if !verify(proof.MarshalSolidity(), publicInputs) {
+		t.Fatal("valid proof rejected")
+	}
+
+	publicInputs[0] = new(big.Int).Add(publicInputs[0], big.NewInt(1))
+	if verify(proof.MarshalSolidity(), publicInputs) {
+		t.Fatal("proof accepted with wrong public inputs")
+	}
+}
+
+// updateSolidityFixtures writes the contract to solPath and its bytecode, compiled with solc, to binPath
+func updateSolidityFixtures(t *testing.T, contract []byte, solPath, binPath string) {
+	solc, err := exec.LookPath("solc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(solPath), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(solPath, contract, 0644); err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	if out, err := exec.Command(solc, "--optimize", "--bin", "-o", dir, solPath).CombinedOutput(); err != nil {
+		t.Fatal(string(out))
+	}
+	bin, err := os.ReadFile(filepath.Join(dir, "PlonkVerifier.bin"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(binPath, bin, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
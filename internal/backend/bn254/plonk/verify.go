@@ -19,9 +19,13 @@ package plonk
 import (
 	"crypto/sha256"
 	"errors"
+	"fmt"
+	"io"
 	"math/big"
 	"time"
 
+	"text/template"
+
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr/kzg"
@@ -32,32 +36,106 @@ import (
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/fiat-shamir"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/logger"
 )
 
 var (
 	errWrongClaimedQuotient = errors.New("claimed quotient is not as expected")
+	errLookupMismatch       = errors.New("the lookup argument of the proof doesn't match the verifying key")
 )
 
 func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness) error {
 	log := logger.Logger().With().Str("curve", "bls12_377").Str("backend", "plonk").Logger()
 	start := time.Now()
 
+	digests, openings, points, err := verifyClaims(proof, vk, publicWitness)
+	if err != nil {
+		return err
+	}
+	err = kzg.BatchVerifyMultiPoints(digests,
+		openings,
+		points,
+		vk.KZGSRS,
+	)
+
+	log.Debug().Dur("took", time.Since(start)).Msg("verifier done")
+
+	return err
+}
+
+// BatchVerify verifies proofs with given VerifyingKey and publicWitnesses. The checks which don't involve
+// pairings are done for each proof, then the KZG openings of all the proofs are checked with a single
+// multi-pairing, combined with random coefficients.
+// If the combined check fails, the proofs are verified one by one and a *backend.BatchVerifyError
+// points to the first invalid one.
+func BatchVerify(proofs []*Proof, vk *VerifyingKey, publicWitnesses []bn254witness.Witness) error {
+	if len(proofs) != len(publicWitnesses) {
+		return fmt.Errorf("got %d proofs for %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+	if len(proofs) == 0 {
+		return nil
+	}
+	log := logger.Logger().With().Str("curve", curve.ID.String()).Str("backend", "plonk").Int("nbProofs", len(proofs)).Logger()
+	start := time.Now()
+
+	var digests []kzg.Digest
+	var openings []kzg.OpeningProof
+	var points []fr.Element
+	for i := range proofs {
+		_digests, _openings, _points, err := verifyClaims(proofs[i], vk, publicWitnesses[i])
+		if err != nil {
+			return &backend.BatchVerifyError{Index: i, Err: err}
+		}
+		digests = append(digests, _digests...)
+		openings = append(openings, _openings...)
+		points = append(points, _points...)
+	}
+	if err := kzg.BatchVerifyMultiPoints(digests, openings, points, vk.KZGSRS); err == nil {
+		log.Debug().Dur("took", time.Since(start)).Msg("batch verifier done")
+		return nil
+	}
+
+	// find the invalid proof
+	for i := range proofs {
+		if err := Verify(proofs[i], vk, publicWitnesses[i]); err != nil {
+			return &backend.BatchVerifyError{Index: i, Err: err}
+		}
+	}
+	return kzg.ErrVerifyOpeningProof
+}
+
+// verifyClaims checks the claimed values of proof and returns the folded KZG openings which remain
+// to be checked, with the points at which they are opened
+func verifyClaims(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness) ([]kzg.Digest, []kzg.OpeningProof, []fr.Element, error) {
+	nbOpenings := 7
+	if vk.Lookup != nil {
+		nbOpenings += 7
+		if proof.Lookup == nil || len(proof.Lookup.ShiftedOpening.ClaimedValues) != 3 {
+			return nil, nil, nil, errLookupMismatch
+		}
+	} else if proof.Lookup != nil {
+		return nil, nil, nil, errLookupMismatch
+	}
+	if len(proof.BatchedProof.ClaimedValues) != nbOpenings {
+		return nil, nil, nil, errLookupMismatch
+	}
+
 	// pick a hash function to derive the challenge (the same as in the prover)
 	hFunc := sha256.New()
 
 	// transcript to derive the challenge
-	fs := fiatshamir.NewTranscript(hFunc, "gamma", "beta", "alpha", "zeta")
+	fs := fiatshamir.NewTranscript(hFunc, challenges(vk)...)
 
 	// The first challenge is derived using the public data: the commitments to the permutation,
 	// the coefficients of the circuit, and the public inputs.
 	// derive gamma from the Comm(blinded cl), Comm(blinded cr), Comm(blinded co)
 	if err := bindPublicData(&fs, "gamma", *vk, publicWitness); err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 	bgamma, err := fs.ComputeChallenge("gamma")
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 	var gamma fr.Element
 	gamma.SetBytes(bgamma)
@@ -65,19 +143,42 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness)
 	// derive beta from Comm(l), Comm(r), Comm(o)
 	beta, err := deriveRandomness(&fs, "beta")
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
-	// derive alpha from Comm(l), Comm(r), Comm(o), Com(Z)
-	alpha, err := deriveRandomness(&fs, "alpha", &proof.Z)
+	// derive the challenges of the lookup argument, eta from Comm(l), Comm(r), Comm(o),
+	// delta from Comm(f), Comm(h₁), Comm(h₂)
+	var lookup lookupChallenges
+	if vk.Lookup != nil {
+		eta, err := deriveRandomness(&fs, "eta", &proof.LRO[0], &proof.LRO[1], &proof.LRO[2])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		delta, err := deriveRandomness(&fs, "delta", &proof.Lookup.F, &proof.Lookup.H1, &proof.Lookup.H2)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		epsilon, err := deriveRandomness(&fs, "epsilon")
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		lookup = newLookupChallenges(eta, delta, epsilon, fr.Element{})
+	}
+
+	// derive alpha from Comm(l), Comm(r), Comm(o), Com(Z) (and the Com(Z) of the lookup argument)
+	alphaPoints := []*curve.G1Affine{&proof.Z}
+	if vk.Lookup != nil {
+		alphaPoints = append(alphaPoints, &proof.Lookup.Z)
+	}
+	alpha, err := deriveRandomness(&fs, "alpha", alphaPoints...)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// derive zeta, the point of evaluation
 	zeta, err := deriveRandomness(&fs, "zeta", &proof.H[0], &proof.H[1], &proof.H[2])
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// evaluation of Z=Xⁿ⁻¹ at ζ
@@ -142,6 +243,20 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness)
 		Add(&linearizedPolynomialZeta, &_s1).                // linearizedpolynomial+pi(zeta)+α*(Z(μζ))*(l(ζ)+s1(ζ)+γ)*(r(ζ)+s2(ζ)+γ)*(o(ζ)+γ)
 		Sub(&linearizedPolynomialZeta, &alphaSquareLagrange) // linearizedpolynomial+pi(zeta)+α*(Z(μζ))*(l(ζ)+s1(ζ)+γ)*(r(ζ)+s2(ζ)+γ)*(o(ζ)+γ)-α²*L₁(ζ)
 
+	// + α³*(lookup part of the numerator at ζ)
+	var lookupDigests, lookupShiftedDigests []kzg.Digest
+	if vk.Lookup != nil {
+		lookup.alpha = alpha
+		var numerator fr.Element
+		numerator, lookupDigests, lookupShiftedDigests, err = lookupZeta(vk, proof, &lookup, l, r, o, lagrangeOne, proof.BatchedProof.ClaimedValues[7:])
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		alphaCube := lookupAlphaCube(alpha)
+		numerator.Mul(&numerator, &alphaCube)
+		linearizedPolynomialZeta.Add(&linearizedPolynomialZeta, &numerator)
+	}
+
 	// Compute H(ζ) using the previous result: H(ζ) = prev_result/(ζⁿ-1)
 	var zetaPowerMMinusOne fr.Element
 	zetaPowerMMinusOne.Sub(&zetaPowerM, &one)
@@ -149,7 +264,7 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness)
 
 	// check that H(ζ) is as claimed
 	if !claimedQuotient.Equal(&linearizedPolynomialZeta) {
-		return errWrongClaimedQuotient
+		return nil, nil, nil, errWrongClaimedQuotient
 	}
 
 	// compute the folded commitment to H: Comm(h₁) + ζᵐ⁺²*Comm(h₂) + ζ²⁽ᵐ⁺²⁾*Comm(h₃)
@@ -202,11 +317,11 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness)
 		_s1, _s2, // second & third part
 	}
 	if _, err := linearizedPolynomialDigest.MultiExp(points, scalars, ecc.MultiExpConfig{ScalarsMont: true}); err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
 	// Fold the first proof
-	foldedProof, foldedDigest, err := kzg.FoldProof([]kzg.Digest{
+	foldedProof, foldedDigest, err := kzg.FoldProof(append([]kzg.Digest{
 		foldedH,
 		linearizedPolynomialDigest,
 		proof.LRO[0],
@@ -214,36 +329,32 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bn254witness.Witness)
 		proof.LRO[2],
 		vk.S[0],
 		vk.S[1],
-	},
+	}, lookupDigests...),
 		&proof.BatchedProof,
 		zeta,
 		hFunc,
 	)
 	if err != nil {
-		return err
+		return nil, nil, nil, err
 	}
 
-	// Batch verify
+	// the openings of the folded proof at ζ, of Z at ζω, and of the lookup argument at ζω
 	var shiftedZeta fr.Element
 	shiftedZeta.Mul(&zeta, &vk.Generator)
-	err = kzg.BatchVerifyMultiPoints([]kzg.Digest{
-		foldedDigest,
-		proof.Z,
-	},
-		[]kzg.OpeningProof{
-			foldedProof,
-			proof.ZShiftedOpening,
-		},
-		[]fr.Element{
-			zeta,
-			shiftedZeta,
-		},
-		vk.KZGSRS,
-	)
-
-	log.Debug().Dur("took", time.Since(start)).Msg("verifier done")
+	digests := []kzg.Digest{foldedDigest, proof.Z}
+	openings := []kzg.OpeningProof{foldedProof, proof.ZShiftedOpening}
+	zetas := []fr.Element{zeta, shiftedZeta}
+	if vk.Lookup != nil {
+		foldedShiftedProof, foldedShiftedDigest, err := kzg.FoldProof(lookupShiftedDigests, &proof.Lookup.ShiftedOpening, shiftedZeta, hFunc)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		digests = append(digests, foldedShiftedDigest)
+		openings = append(openings, foldedShiftedProof)
+		zetas = append(zetas, shiftedZeta)
+	}
 
-	return err
+	return digests, openings, zetas, nil
 }
 
 func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk VerifyingKey, publicInputs []fr.Element) error {
@@ -276,6 +387,15 @@ func bindPublicData(fs *fiatshamir.Transcript, challenge string, vk VerifyingKey
 		return err
 	}
 
+	// lookup selectors and tables
+	if vk.Lookup != nil {
+		for _, d := range append([]kzg.Digest{vk.Lookup.Qlookup, vk.Lookup.Qtable}, vk.Lookup.T[:]...) {
+			if err := fs.Bind(challenge, d.Marshal()); err != nil {
+				return err
+			}
+		}
+	}
+
 	// public inputs
 	for i := 0; i < len(publicInputs); i++ {
 		if err := fs.Bind(challenge, publicInputs[i].Marshal()); err != nil {
@@ -306,3 +426,45 @@ func deriveRandomness(fs *fiatshamir.Transcript, challenge string, points ...*cu
 	r.SetBytes(b)
 	return r, nil
 }
+
+// ExportSolidity writes a solidity Verifier contract on provided writer.
+// The contract checks proofs encoded by Proof.MarshalSolidity against the public inputs, as Verify.
+// this is an experimental feature and gnark solidity generator as not been thoroughly tested
+func (vk *VerifyingKey) ExportSolidity(w io.Writer) error {
+	if vk.Lookup != nil {
+		return errors.New("lookup tables are not supported by the solidity verifier")
+	}
+	tmpl, err := template.New("").Parse(solidityTemplate)
+	if err != nil {
+		return err
+	}
+
+	// execute template
+	return tmpl.Execute(w, vk)
+}
+
+// MarshalSolidity returns the proof as expected by the contract written by VerifyingKey.ExportSolidity:
+// 32-byte big-endian words, the coordinates of LRO, Z, H and of the batched opening proof, its claimed
+// values, then the opening proof of Z at ζω and its claimed value.
+func (proof *Proof) MarshalSolidity() []byte {
+	res := make([]byte, 0, 26*fr.Bytes)
+
+	points := []*curve.G1Affine{
+		&proof.LRO[0], &proof.LRO[1], &proof.LRO[2], &proof.Z,
+		&proof.H[0], &proof.H[1], &proof.H[2], &proof.BatchedProof.H,
+	}
+	for _, p := range points {
+		buf := p.RawBytes()
+		res = append(res, buf[:]...)
+	}
+	for i := range proof.BatchedProof.ClaimedValues {
+		buf := proof.BatchedProof.ClaimedValues[i].Bytes()
+		res = append(res, buf[:]...)
+	}
+	buf := proof.ZShiftedOpening.H.RawBytes()
+	res = append(res, buf[:]...)
+	v := proof.ZShiftedOpening.ClaimedValue.Bytes()
+	res = append(res, v[:]...)
+
+	return res
+}
@@ -88,7 +88,7 @@ func BenchmarkSetup(b *testing.B) {
 
 	b.Run("setup", func(b *testing.B) {
 		for i := 0; i < b.N; i++ {
-			bw6_633groth16.Setup(r1cs.(*cs.R1CS), &pk, &vk)
+			bw6_633groth16.Setup(r1cs.(*cs.R1CS), &pk, &vk, backend.SetupConfig{})
 		}
 	})
 }
@@ -127,7 +127,7 @@ func BenchmarkVerifier(b *testing.B) {
 
 	var pk bw6_633groth16.ProvingKey
 	var vk bw6_633groth16.VerifyingKey
-	bw6_633groth16.Setup(r1cs.(*cs.R1CS), &pk, &vk)
+	bw6_633groth16.Setup(r1cs.(*cs.R1CS), &pk, &vk, backend.SetupConfig{})
 	proof, err := bw6_633groth16.Prove(r1cs.(*cs.R1CS), &pk, fullWitness, backend.ProverConfig{})
 	if err != nil {
 		panic(err)
@@ -151,7 +151,7 @@ func BenchmarkProofSerialization(b *testing.B) {
 
 	var pk bw6_633groth16.ProvingKey
 	var vk bw6_633groth16.VerifyingKey
-	bw6_633groth16.Setup(r1cs.(*cs.R1CS), &pk, &vk)
+	bw6_633groth16.Setup(r1cs.(*cs.R1CS), &pk, &vk, backend.SetupConfig{})
 	proof, err := bw6_633groth16.Prove(r1cs.(*cs.R1CS), &pk, fullWitness, backend.ProverConfig{})
 	if err != nil {
 		panic(err)
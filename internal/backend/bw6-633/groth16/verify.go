@@ -19,6 +19,8 @@ package groth16
 import (
 	"github.com/consensys/gnark-crypto/ecc"
 
+	"github.com/consensys/gnark-crypto/ecc/bw6-633/fr"
+
 	curve "github.com/consensys/gnark-crypto/ecc/bw6-633"
 
 	"errors"
@@ -27,7 +29,10 @@ import (
 	"io"
 	"time"
 
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/internal/utils"
 	"github.com/consensys/gnark/logger"
+	"math/big"
 )
 
 var (
@@ -88,6 +93,100 @@ func Verify(proof *Proof, vk *VerifyingKey, publicWitness bw6_633witness.Witness
 	return nil
 }
 
+// BatchVerify verifies proofs with given VerifyingKey and publicWitnesses in a single multi-pairing.
+// The verification equations are combined with random coefficients ρᵢ:
+//
+//	Π e(ρᵢ·Arᵢ, Bsᵢ) · e(Σ ρᵢ·Krsᵢ, -[δ]₂) · e(Σ ρᵢ·PIᵢ, -[γ]₂) = e([α]₁, [β]₂)^(Σ ρᵢ)
+//
+// If the combined check fails, the proofs are verified one by one and a *backend.BatchVerifyError
+// points to the first invalid one.
+func BatchVerify(proofs []*Proof, vk *VerifyingKey, publicWitnesses []bw6_633witness.Witness) error {
+	if len(proofs) != len(publicWitnesses) {
+		return fmt.Errorf("got %d proofs for %d public witnesses", len(proofs), len(publicWitnesses))
+	}
+	if len(proofs) == 0 {
+		return nil
+	}
+	log := logger.Logger().With().Str("curve", vk.CurveID().String()).Str("backend", "groth16").Int("nbProofs", len(proofs)).Logger()
+	start := time.Now()
+
+	for i := range proofs {
+		if len(publicWitnesses[i]) != (len(vk.G1.K) - 1) {
+			return &backend.BatchVerifyError{Index: i, Err: fmt.Errorf("invalid witness size, got %d, expected %d (public - ONE_WIRE)", len(publicWitnesses[i]), len(vk.G1.K)-1)}
+		}
+		if !proofs[i].isValid() {
+			return &backend.BatchVerifyError{Index: i, Err: errCorrectSubgroupCheckFailed}
+		}
+	}
+
+	rho := make([]fr.Element, len(proofs))
+	for i := range rho {
+		if _, err := rho[i].SetRandom(); err != nil {
+			return err
+		}
+	}
+
+	// ρᵢ·Arᵢ, paired with Bsᵢ
+	P := make([]curve.G1Affine, len(proofs), len(proofs)+2)
+	Q := make([]curve.G2Affine, len(proofs), len(proofs)+2)
+	utils.Parallelize(len(proofs), func(start, end int) {
+		var rhoBig big.Int
+		for i := start; i < end; i++ {
+			rho[i].ToBigIntRegular(&rhoBig)
+			P[i].ScalarMultiplication(&proofs[i].Ar, &rhoBig)
+			Q[i] = proofs[i].Bs
+		}
+	})
+
+	// Σ ρᵢ·Krsᵢ, paired with -[δ]₂
+	krs := make([]curve.G1Affine, len(proofs))
+	for i := range proofs {
+		krs[i] = proofs[i].Krs
+	}
+	var krsSum curve.G1Affine
+	if _, err := krsSum.MultiExp(krs, rho, ecc.MultiExpConfig{ScalarsMont: true}); err != nil {
+		return err
+	}
+
+	// Σ ρᵢ·PIᵢ = (Σ ρᵢ)·[Kvk(t)]₀ + Σⱼ (Σᵢ ρᵢ·xᵢⱼ)·[Kvk(t)]ⱼ, paired with -[γ]₂
+	scalars := make([]fr.Element, len(vk.G1.K))
+	var t fr.Element
+	for i := range proofs {
+		scalars[0].Add(&scalars[0], &rho[i])
+		for j := range publicWitnesses[i] {
+			scalars[j+1].Add(&scalars[j+1], t.Mul(&rho[i], &publicWitnesses[i][j]))
+		}
+	}
+	var kSum curve.G1Affine
+	if _, err := kSum.MultiExp(vk.G1.K, scalars, ecc.MultiExpConfig{ScalarsMont: true}); err != nil {
+		return err
+	}
+
+	P = append(P, krsSum, kSum)
+	Q = append(Q, vk.G2.deltaNeg, vk.G2.gammaNeg)
+	right, err := curve.Pair(P, Q)
+	if err != nil {
+		return err
+	}
+
+	var rhoSum big.Int
+	scalars[0].ToBigIntRegular(&rhoSum)
+	var left curve.GT
+	left.Exp(&vk.e, rhoSum)
+	if left.Equal(&right) {
+		log.Debug().Dur("took", time.Since(start)).Msg("batch verifier done")
+		return nil
+	}
+
+	// find the invalid proof
+	for i := range proofs {
+		if err := Verify(proofs[i], vk, publicWitnesses[i]); err != nil {
+			return &backend.BatchVerifyError{Index: i, Err: err}
+		}
+	}
+	return errPairingCheckFailed
+}
+
 // ExportSolidity not implemented for BW6-633
 func (vk *VerifyingKey) ExportSolidity(w io.Writer) error {
 	return errors.New("not implemented")
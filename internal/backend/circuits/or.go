@@ -75,4 +75,25 @@ func init() {
 	}
 
 	addNewEntry("or", &orCircuit{}, good, bad, gnark.Curves())
+
+	goodConstant := []frontend.Circuit{
+		&orConstantCircuit{Op: 0, Res1: 1, Res0: 0},
+		&orConstantCircuit{Op: 1, Res1: 1, Res0: 1},
+	}
+	badConstant := []frontend.Circuit{
+		&orConstantCircuit{Op: 0, Res1: 0, Res0: 0},
+		&orConstantCircuit{Op: 1, Res1: 0, Res0: 1},
+	}
+	addNewEntry("or_constant", &orConstantCircuit{}, goodConstant, badConstant, gnark.Curves())
+}
+
+// orConstantCircuit checks the case where an operand is a constant
+type orConstantCircuit struct {
+	Op, Res1, Res0 frontend.Variable
+}
+
+func (circuit *orConstantCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Or(circuit.Op, 1), circuit.Res1)
+	api.AssertIsEqual(api.Or(0, circuit.Op), circuit.Res0)
+	return nil
 }
@@ -75,4 +75,25 @@ func init() {
 	}
 
 	addNewEntry("xor", &xorCircuit{}, good, bad, gnark.Curves())
+
+	goodConstant := []frontend.Circuit{
+		&xorConstantCircuit{Op: 0, Res1: 1, Res0: 0},
+		&xorConstantCircuit{Op: 1, Res1: 0, Res0: 1},
+	}
+	badConstant := []frontend.Circuit{
+		&xorConstantCircuit{Op: 0, Res1: 0, Res0: 0},
+		&xorConstantCircuit{Op: 1, Res1: 1, Res0: 1},
+	}
+	addNewEntry("xor_constant", &xorConstantCircuit{}, goodConstant, badConstant, gnark.Curves())
+}
+
+// xorConstantCircuit checks the case where an operand is a constant
+type xorConstantCircuit struct {
+	Op, Res1, Res0 frontend.Variable
+}
+
+func (circuit *xorConstantCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Xor(circuit.Op, 1), circuit.Res1)
+	api.AssertIsEqual(api.Xor(0, circuit.Op), circuit.Res0)
+	return nil
 }
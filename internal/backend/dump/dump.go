@@ -0,0 +1,301 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dump implements the on-disk layout of a Groth16 or PLONK setup split in segments.
+//
+// A dump written under a session prefix is made of one file per segment, named
+// <session>.<segment>.save, and of a manifest <session>.manifest.json recording the curve,
+// a digest of the constraint system and the size and sha256 checksum of each segment.
+// Segments are checked against the manifest as they are read, so that a truncated or
+// mismatched file is reported instead of being decoded.
+package dump
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/logger"
+)
+
+// Version of the manifest format
+const Version = 2
+
+// Segments of a dump
+const (
+	ConstraintSystem = "ccs"
+	CoeffTable       = "ccs.ct"
+	VerifyingKey     = "vk"
+	ProvingKeyE      = "pk.E"
+	ProvingKeyA      = "pk.A"
+	ProvingKeyB1     = "pk.B1"
+	ProvingKeyB2     = "pk.B2"
+	ProvingKeyZ      = "pk.Z"
+	ProvingKeyK      = "pk.K"
+)
+
+// Segments of a PLONK dump, in addition to ConstraintSystem and VerifyingKey.
+// Each polynomial of the ProvingKey is written in its own segment, the permutation
+// evaluated on the big domain is split in one segment per sigma.
+const (
+	ProvingKeyDomain      = "pk.Domain"
+	ProvingKeyQl          = "pk.Ql"
+	ProvingKeyQr          = "pk.Qr"
+	ProvingKeyQm          = "pk.Qm"
+	ProvingKeyQo          = "pk.Qo"
+	ProvingKeyCQk         = "pk.CQk"
+	ProvingKeyLQk         = "pk.LQk"
+	ProvingKeyS1          = "pk.S1"
+	ProvingKeyS2          = "pk.S2"
+	ProvingKeyS3          = "pk.S3"
+	ProvingKeyS1BigDomain = "pk.S1Big"
+	ProvingKeyS2BigDomain = "pk.S2Big"
+	ProvingKeyS3BigDomain = "pk.S3Big"
+	ProvingKeyPermutation = "pk.Permutation"
+)
+
+// Segment describes a file of a dump
+type Segment struct {
+	Name     string `json:"name"`
+	File     string `json:"file"` // relative to the directory of the manifest
+	Size     int64  `json:"size"`
+	Checksum string `json:"sha256"`
+}
+
+// Manifest describes the segments of a dump
+type Manifest struct {
+	Version int    `json:"version"`
+	Curve   string `json:"curve"`
+
+	// CircuitHash is the sha256 of the constraint system, encoded without its debug information,
+	// followed by its coefficient table for a R1CS, see Writer.HashCircuit
+	CircuitHash string    `json:"circuitHash"`
+	Segments    []Segment `json:"segments"`
+
+	session string
+}
+
+// manifestPath returns the path of the manifest of the dump written under session
+func manifestPath(session string) string {
+	return session + ".manifest.json"
+}
+
+func (m *Manifest) segment(name string) (*Segment, error) {
+	for i := range m.Segments {
+		if m.Segments[i].Name == name {
+			return &m.Segments[i], nil
+		}
+	}
+	return nil, fmt.Errorf("dump %s: no segment %s in manifest", m.session, name)
+}
+
+// CheckCircuit returns an error if the constraint system encoded by writeCS and writeCT isn't
+// the one the dump was written for, they must be the functions given to Writer.HashCircuit.
+func (m *Manifest) CheckCircuit(writeCS, writeCT func(io.Writer) (int64, error)) error {
+	circuitHash, err := circuitHash(writeCS, writeCT)
+	if err != nil {
+		return fmt.Errorf("dump %s: %w", m.session, err)
+	}
+	if circuitHash != m.CircuitHash {
+		return fmt.Errorf("dump %s: written for another constraint system", m.session)
+	}
+	return nil
+}
+
+// circuitHash returns the sha256 of the encodings of writeCS then writeCT, the latter may be nil
+func circuitHash(writeCS, writeCT func(io.Writer) (int64, error)) (string, error) {
+	h := sha256.New()
+	if _, err := writeCS(h); err != nil {
+		return "", err
+	}
+	if writeCT != nil {
+		if _, err := writeCT(h); err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Writer creates the segments of a dump and records them in its manifest
+type Writer struct {
+	manifest Manifest
+}
+
+// NewWriter returns a Writer for a dump of a setup over curveID under the session prefix
+func NewWriter(session string, curveID ecc.ID) *Writer {
+	return &Writer{
+		manifest: Manifest{
+			Version: Version,
+			Curve:   curveID.String(),
+			session: session,
+		},
+	}
+}
+
+// Write creates the segment name and fills it with write
+func (w *Writer) Write(name string, write func(io.Writer) (int64, error)) error {
+	if _, err := w.manifest.segment(name); err == nil {
+		return fmt.Errorf("dump %s: segment %s written twice", w.manifest.session, name)
+	}
+	path := fmt.Sprintf("%s.%s.save", w.manifest.session, name)
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	h := sha256.New()
+	n, err := write(io.MultiWriter(f, h))
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err != nil {
+		return fmt.Errorf("dump %s: segment %s: %w", w.manifest.session, name, err)
+	}
+
+	w.manifest.Segments = append(w.manifest.Segments, Segment{
+		Name:     name,
+		File:     filepath.Base(path),
+		Size:     n,
+		Checksum: hex.EncodeToString(h.Sum(nil)),
+	})
+	log := logger.Logger()
+	log.Debug().Str("segment", name).Int64("size", n).Msg("dump segment written")
+	return nil
+}
+
+// HashCircuit records the digest of the constraint system the dump is written for in its manifest.
+//
+// writeCS must encode the constraint system without its debug information, which isn't part of
+// the circuit and is dropped when decoding, writeCT encodes the coefficient table of a R1CS and is
+// nil for a SparseR1CS.
+func (w *Writer) HashCircuit(writeCS, writeCT func(io.Writer) (int64, error)) (err error) {
+	if w.manifest.CircuitHash, err = circuitHash(writeCS, writeCT); err != nil {
+		return fmt.Errorf("dump %s: %w", w.manifest.session, err)
+	}
+	return nil
+}
+
+// Close writes the manifest of the dump, it must be called once all the segments are written
+func (w *Writer) Close() error {
+	if w.manifest.CircuitHash == "" {
+		return fmt.Errorf("dump %s: the circuit isn't hashed", w.manifest.session)
+	}
+	data, err := json.MarshalIndent(&w.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(manifestPath(w.manifest.session), data, 0o644)
+}
+
+// ReadManifest reads the manifest of the dump written under the session prefix
+func ReadManifest(session string) (*Manifest, error) {
+	data, err := os.ReadFile(manifestPath(session))
+	if err != nil {
+		return nil, fmt.Errorf("dump %s: %w", session, err)
+	}
+	m := &Manifest{session: session}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("dump %s: invalid manifest: %w", session, err)
+	}
+	if m.Version != Version {
+		return nil, fmt.Errorf("dump %s: unsupported manifest version %d (expected %d)", session, m.Version, Version)
+	}
+	return m, nil
+}
+
+// CheckCurve returns an error if the dump wasn't written for curveID
+func (m *Manifest) CheckCurve(curveID ecc.ID) error {
+	if m.Curve != curveID.String() {
+		return fmt.Errorf("dump %s: written for curve %s, not %s", m.session, m.Curve, curveID)
+	}
+	return nil
+}
+
+// ReadSegment opens the segment name and decodes it with read.
+//
+// The size of the file is checked before decoding, and its checksum once read returns,
+// an error is returned if either doesn't match the manifest or if read didn't consume the
+// whole segment. If read returns the error of a done context, the rest of the segment isn't
+// read to check its checksum.
+func (m *Manifest) ReadSegment(name string, read func(io.Reader) (int64, error)) error {
+	s, err := m.segment(name)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(filepath.Join(filepath.Dir(m.session), s.File))
+	if err != nil {
+		return fmt.Errorf("dump %s: segment %s: %w", m.session, name, err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("dump %s: segment %s: %w", m.session, name, err)
+	}
+	if info.Size() < s.Size {
+		return fmt.Errorf("dump %s: segment %s is truncated: %d bytes, expected %d", m.session, name, info.Size(), s.Size)
+	}
+	if info.Size() != s.Size {
+		return fmt.Errorf("dump %s: segment %s has %d bytes, expected %d", m.session, name, info.Size(), s.Size)
+	}
+
+	h := sha256.New()
+	n, readErr := read(io.TeeReader(f, h))
+	if errors.Is(readErr, context.Canceled) || errors.Is(readErr, context.DeadlineExceeded) {
+		// the caller stopped, don't drain the segment
+		return fmt.Errorf("dump %s: segment %s: %w", m.session, name, readErr)
+	}
+
+	// a decoding error is most likely due to a corrupted segment, check it first
+	if err := m.checkSum(s, h, f); err != nil {
+		return err
+	}
+	if readErr != nil {
+		return fmt.Errorf("dump %s: segment %s: %w", m.session, name, readErr)
+	}
+	if n != s.Size {
+		return fmt.Errorf("dump %s: segment %s: decoded %d bytes, expected %d", m.session, name, n, s.Size)
+	}
+	return nil
+}
+
+// checkSum drains r in h and compares the result with the checksum of s
+func (m *Manifest) checkSum(s *Segment, h hash.Hash, r io.Reader) error {
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("dump %s: segment %s: %w", m.session, s.Name, err)
+	}
+	if hex.EncodeToString(h.Sum(nil)) != s.Checksum {
+		return fmt.Errorf("dump %s: segment %s: checksum mismatch", m.session, s.Name)
+	}
+	return nil
+}
+
+// Verify checks the size and checksum of all the segments of m
+func (m *Manifest) Verify() error {
+	for _, s := range m.Segments {
+		if err := m.ReadSegment(s.Name, func(r io.Reader) (int64, error) {
+			return io.Copy(io.Discard, r)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 ConsenSys AG
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package progress tracks the phases of the provers and setups, reporting them to the
+// backend.ProgressFunc of their options and checking their context between phases.
+package progress
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/consensys/gnark/backend"
+)
+
+// Tracker reports the phases of a prover or a setup
+type Tracker struct {
+	ctx    context.Context
+	report backend.ProgressFunc
+	start  time.Time
+	total  int
+
+	lock sync.Mutex
+	done int
+}
+
+// New returns a Tracker of total phases starting now. ctx and report may be nil.
+func New(ctx context.Context, report backend.ProgressFunc, total int) *Tracker {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return &Tracker{ctx: ctx, report: report, start: time.Now(), total: total}
+}
+
+// Phase reports the end of the phase started at start, it is safe for concurrent use
+func (t *Tracker) Phase(phase string, start time.Time) {
+	if t.report != nil {
+		now := time.Now()
+		t.lock.Lock()
+		t.done++
+		t.report(backend.Progress{
+			Phase:   phase,
+			Took:    now.Sub(start),
+			Elapsed: now.Sub(t.start),
+			Done:    t.done,
+			Total:   t.total,
+		})
+		t.lock.Unlock()
+	}
+}
+
+// Err returns the error of the context, nil if it isn't done
+func (t *Tracker) Err() error {
+	return t.ctx.Err()
+}
+
+// Wait returns the first error received on ch, or the error of the context if it's done first
+func (t *Tracker) Wait(ch <-chan error) error {
+	select {
+	case err := <-ch:
+		return err
+	case <-t.ctx.Done():
+		return t.ctx.Err()
+	}
+}
+
+// Done returns a channel closed when the context is done, see context.Context.Done
+func (t *Tracker) Done() <-chan struct{} {
+	return t.ctx.Done()
+}
@@ -105,10 +105,16 @@ func main() {
 			entries = []bavard.Entry{
 				{File: filepath.Join(groth16Dir, "verify.go"), Templates: []string{"groth16/groth16.verify.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "prove.go"), Templates: []string{"groth16/groth16.prove.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "stream.go"), Templates: []string{"groth16/groth16.stream.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "mpc.go"), Templates: []string{"groth16/groth16.mpc.go.tmpl", importCurve}},
+				{File: filepath.Join(groth16Dir, "lazy.go"), Templates: []string{"groth16/groth16.lazy.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "setup.go"), Templates: []string{"groth16/groth16.setup.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal.go"), Templates: []string{"groth16/groth16.marshal.go.tmpl", importCurve}},
 				{File: filepath.Join(groth16Dir, "marshal_test.go"), Templates: []string{"groth16/tests/groth16.marshal.go.tmpl", importCurve}},
 			}
+			if d.Curve == "BN254" || d.Curve == "BLS12-381" {
+				entries = append(entries, bavard.Entry{File: filepath.Join(groth16Dir, "aggregate.go"), Templates: []string{"groth16/groth16.aggregate.go.tmpl", importCurve}})
+			}
 			if err := bgen.Generate(d, "groth16", "./template/zkpschemes/", entries...); err != nil {
 				panic(err) // TODO handle
 			}
@@ -126,6 +132,8 @@ func main() {
 				{File: filepath.Join(plonkDir, "prove.go"), Templates: []string{"plonk/plonk.prove.go.tmpl", importCurve}},
 				{File: filepath.Join(plonkDir, "setup.go"), Templates: []string{"plonk/plonk.setup.go.tmpl", importCurve}},
 				{File: filepath.Join(plonkDir, "marshal.go"), Templates: []string{"plonk/plonk.marshal.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkDir, "roll.go"), Templates: []string{"plonk/plonk.roll.go.tmpl", importCurve}},
+				{File: filepath.Join(plonkDir, "lookup.go"), Templates: []string{"plonk/plonk.lookup.go.tmpl", importCurve}},
 				{File: filepath.Join(plonkDir, "marshal_test.go"), Templates: []string{"plonk/tests/marshal.go.tmpl", importCurve}},
 			}
 			if err := bgen.Generate(d, "plonk", "./template/zkpschemes/", entries...); err != nil {
@@ -0,0 +1,75 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package smt provides ZKP-circuit functions to verify membership, non-membership
+// and update proofs in a sparse Merkle tree, and a native tree producing their witnesses.
+//
+// The tree has a fixed depth d and 2ᵈ leaves indexed by their key. A leaf is a
+// field element, 0 being the value of the empty leaves, and an internal node is
+// the hash of its left and right children. A proof is the list of the siblings of
+// the nodes on the path from the leaf to the root, the sibling of the leaf first.
+// The bit i of the key is 1 if the node at height i on that path is a right child.
+package smt
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+)
+
+// ComputeRoot returns the root of the tree whose leaf at key is leaf, given the
+// siblings of its proof. key must be less than 2^len(siblings).
+func ComputeRoot(api frontend.API, h hash.Hash, key, leaf frontend.Variable, siblings []frontend.Variable) frontend.Variable {
+	return computeRoot(api, h, api.ToBinary(key, len(siblings)), leaf, siblings)
+}
+
+// computeRoot hashes node with its siblings up to the root, path being the bits of the key
+func computeRoot(api frontend.API, h hash.Hash, path []frontend.Variable, node frontend.Variable, siblings []frontend.Variable) frontend.Variable {
+	for i := range siblings {
+		// if path[i] is 1 the node is the right child
+		left := api.Select(path[i], siblings[i], node)
+		right := api.Sub(api.Add(node, siblings[i]), left)
+		h.Reset()
+		h.Write(left, right)
+		node = h.Sum()
+	}
+	return node
+}
+
+// VerifyMembership checks that leaf is the leaf at key in the tree of the given root.
+func VerifyMembership(api frontend.API, h hash.Hash, root, key, leaf frontend.Variable, siblings []frontend.Variable) {
+	api.AssertIsEqual(root, ComputeRoot(api, h, key, leaf, siblings))
+}
+
+// VerifyNonMembership checks that the leaf at key is empty in the tree of the given root.
+func VerifyNonMembership(api frontend.API, h hash.Hash, root, key frontend.Variable, siblings []frontend.Variable) {
+	VerifyMembership(api, h, root, key, 0, siblings)
+}
+
+// VerifyUpdate checks that replacing the leaf oldLeaf at key by newLeaf turns the
+// tree of root oldRoot into the tree of root newRoot. The siblings are the same
+// in both trees.
+func VerifyUpdate(api frontend.API, h hash.Hash, oldRoot, newRoot, key, oldLeaf, newLeaf frontend.Variable, siblings []frontend.Variable) {
+	path := api.ToBinary(key, len(siblings))
+	api.AssertIsEqual(oldRoot, computeRoot(api, h, path, oldLeaf, siblings))
+	api.AssertIsEqual(newRoot, computeRoot(api, h, path, newLeaf, siblings))
+}
+
+// VerifyInsert checks that setting the empty leaf at key to the non-empty leaf
+// turns the tree of root oldRoot into the tree of root newRoot.
+func VerifyInsert(api frontend.API, h hash.Hash, oldRoot, newRoot, key, leaf frontend.Variable, siblings []frontend.Variable) {
+	api.AssertIsDifferent(leaf, 0)
+	VerifyUpdate(api, h, oldRoot, newRoot, key, 0, leaf, siblings)
+}
@@ -0,0 +1,300 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smt
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	bn254 "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/hash/poseidon"
+	"github.com/consensys/gnark/test"
+)
+
+const testDepth = 16
+
+// newTestTree returns a tree with a few random leaves
+func newTestTree(t *testing.T, keys ...uint64) *Tree {
+	tree, err := NewTree(bn254.NewMiMC(), testDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		if err := tree.Set(key, randomLeaf(t)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return tree
+}
+
+func randomLeaf(t *testing.T) []byte {
+	var leaf fr.Element
+	if _, err := leaf.SetRandom(); err != nil {
+		t.Fatal(err)
+	}
+	b := leaf.Bytes()
+	return b[:]
+}
+
+func toVariables(s [][]byte) []frontend.Variable {
+	res := make([]frontend.Variable, len(s))
+	for i := range s {
+		res[i] = s[i]
+	}
+	return res
+}
+
+func TestTree(t *testing.T) {
+	empty := newTestTree(t)
+	tree := newTestTree(t, 0, 1, 42, 1<<testDepth-1)
+
+	for _, key := range []uint64{0, 1, 2, 42, 1<<testDepth - 1} {
+		leaf, err := tree.Get(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		siblings, err := tree.Prove(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyProof(bn254.NewMiMC(), tree.Root(), key, leaf, siblings); err != nil || !ok {
+			t.Fatalf("proof of key %d rejected", key)
+		}
+		if ok, _ := VerifyProof(bn254.NewMiMC(), tree.Root(), key^4, leaf, siblings); ok {
+			t.Fatalf("proof of key %d accepted for another key", key)
+		}
+	}
+
+	// removing the leaves gives back the empty tree
+	for _, key := range []uint64{0, 1, 42, 1<<testDepth - 1} {
+		if err := tree.Set(key, make([]byte, fr.Bytes)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(tree.Root(), empty.Root()) {
+		t.Fatal("root of the emptied tree differs from the empty root")
+	}
+	for i := range tree.nodes {
+		if len(tree.nodes[i]) != 0 {
+			t.Fatalf("%d nodes left at height %d", len(tree.nodes[i]), i)
+		}
+	}
+
+	if err := tree.Set(1<<testDepth, randomLeaf(t)); err == nil {
+		t.Fatal("out of range key accepted")
+	}
+	if err := tree.Set(0, []byte{1}); err == nil {
+		t.Fatal("short leaf accepted")
+	}
+}
+
+type membershipCircuit struct {
+	Root       frontend.Variable `gnark:",public"`
+	Key, Leaf  frontend.Variable
+	Siblings   []frontend.Variable
+	AbsentKey  frontend.Variable
+	AbsentPath []frontend.Variable
+}
+
+func (circuit *membershipCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	VerifyMembership(api, &h, circuit.Root, circuit.Key, circuit.Leaf, circuit.Siblings)
+	VerifyNonMembership(api, &h, circuit.Root, circuit.AbsentKey, circuit.AbsentPath)
+	return nil
+}
+
+func TestMembership(t *testing.T) {
+	tree := newTestTree(t, 3, 7, 1000)
+
+	leaf, _ := tree.Get(7)
+	siblings, _ := tree.Prove(7)
+	absent, _ := tree.Prove(8)
+
+	circuit := membershipCircuit{
+		Siblings:   make([]frontend.Variable, testDepth),
+		AbsentPath: make([]frontend.Variable, testDepth),
+	}
+	witness := membershipCircuit{
+		Root:       tree.Root(),
+		Key:        7,
+		Leaf:       leaf,
+		Siblings:   toVariables(siblings),
+		AbsentKey:  8,
+		AbsentPath: toVariables(absent),
+	}
+	assert := test.NewAssert(t)
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	// the leaf at 7 is not empty
+	wrong := witness
+	wrong.AbsentKey, wrong.AbsentPath = 7, witness.Siblings
+	if err := test.IsSolved(&circuit, &wrong, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("non-membership accepted for a present key")
+	}
+
+	wrong = witness
+	wrong.Leaf = randomLeaf(t)
+	if err := test.IsSolved(&circuit, &wrong, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("membership accepted for a wrong leaf")
+	}
+}
+
+type poseidonMembershipCircuit struct {
+	Root      frontend.Variable `gnark:",public"`
+	Key, Leaf frontend.Variable
+	Siblings  []frontend.Variable
+}
+
+func (circuit *poseidonMembershipCircuit) Define(api frontend.API) error {
+	params, err := poseidon.DefaultParameters(api.Compiler().Curve(), 3)
+	if err != nil {
+		return err
+	}
+	h, err := poseidon.NewHasher(api, params)
+	if err != nil {
+		return err
+	}
+	VerifyMembership(api, &h, circuit.Root, circuit.Key, circuit.Leaf, circuit.Siblings)
+	return nil
+}
+
+func TestMembershipPoseidon(t *testing.T) {
+	params, err := poseidon.DefaultParameters(ecc.BN254, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := NewTree(poseidon.NewNativeHasher(params), testDepth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf := randomLeaf(t)
+	if err := tree.Set(5, leaf); err != nil {
+		t.Fatal(err)
+	}
+	siblings, _ := tree.Prove(5)
+
+	circuit := poseidonMembershipCircuit{Siblings: make([]frontend.Variable, testDepth)}
+	witness := poseidonMembershipCircuit{Root: tree.Root(), Key: 5, Leaf: leaf, Siblings: toVariables(siblings)}
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+	witness.Key = 4
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("membership accepted for another key")
+	}
+}
+
+type updateCircuit struct {
+	OldRoot, NewRoot frontend.Variable `gnark:",public"`
+	Key              frontend.Variable
+	OldLeaf, NewLeaf frontend.Variable
+	Siblings         []frontend.Variable
+}
+
+func (circuit *updateCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	VerifyUpdate(api, &h, circuit.OldRoot, circuit.NewRoot, circuit.Key, circuit.OldLeaf, circuit.NewLeaf, circuit.Siblings)
+	return nil
+}
+
+type insertCircuit struct {
+	OldRoot, NewRoot frontend.Variable `gnark:",public"`
+	Key, Leaf        frontend.Variable
+	Siblings         []frontend.Variable
+}
+
+func (circuit *insertCircuit) Define(api frontend.API) error {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	VerifyInsert(api, &h, circuit.OldRoot, circuit.NewRoot, circuit.Key, circuit.Leaf, circuit.Siblings)
+	return nil
+}
+
+// update sets the leaf at key and returns the roots, the old leaf and the siblings
+func update(t *testing.T, tree *Tree, key uint64, leaf []byte) (oldRoot, newRoot, oldLeaf []byte, siblings []frontend.Variable) {
+	oldRoot = tree.Root()
+	oldLeaf, _ = tree.Get(key)
+	_siblings, err := tree.Prove(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Set(key, leaf); err != nil {
+		t.Fatal(err)
+	}
+	return oldRoot, tree.Root(), oldLeaf, toVariables(_siblings)
+}
+
+func TestUpdate(t *testing.T) {
+	tree := newTestTree(t, 3, 7, 1000)
+	circuit := updateCircuit{Siblings: make([]frontend.Variable, testDepth)}
+
+	var witness updateCircuit
+	newLeaf := randomLeaf(t)
+	witness.OldRoot, witness.NewRoot, witness.OldLeaf, witness.Siblings = update(t, tree, 7, newLeaf)
+	witness.Key, witness.NewLeaf = 7, newLeaf
+
+	assert := test.NewAssert(t)
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	wrong := witness
+	wrong.Key = 6
+	if err := test.IsSolved(&circuit, &wrong, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("update accepted for another key")
+	}
+
+	// deletion is an update to the empty leaf
+	var deletion updateCircuit
+	deletion.OldRoot, deletion.NewRoot, deletion.OldLeaf, deletion.Siblings = update(t, tree, 3, make([]byte, fr.Bytes))
+	deletion.Key, deletion.NewLeaf = 3, 0
+	if err := test.IsSolved(&circuit, &deletion, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestInsert(t *testing.T) {
+	tree := newTestTree(t, 3, 7, 1000)
+	circuit := insertCircuit{Siblings: make([]frontend.Variable, testDepth)}
+
+	var witness insertCircuit
+	leaf := randomLeaf(t)
+	witness.OldRoot, witness.NewRoot, _, witness.Siblings = update(t, tree, 1001, leaf)
+	witness.Key, witness.Leaf = 1001, leaf
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	// the leaf at 1000 is not empty
+	var wrong insertCircuit
+	wrong.OldRoot, wrong.NewRoot, _, wrong.Siblings = update(t, tree, 1000, leaf)
+	wrong.Key, wrong.Leaf = 1000, leaf
+	if err := test.IsSolved(&circuit, &wrong, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("insertion accepted over a present leaf")
+	}
+}
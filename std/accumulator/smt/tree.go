@@ -0,0 +1,184 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// Tree is a sparse Merkle tree stored in memory. Its leaves and nodes are the
+// big-endian encodings of field elements on h.Size() bytes, they can be
+// assigned as they are to the frontend.Variable of the circuit functions.
+//
+// h must be the native counterpart of the hash used in the circuit, for
+// instance the MiMC or Poseidon hash of the scalar field of the curve.
+type Tree struct {
+	h     hash.Hash
+	depth int
+
+	// empty[i] is the root of an empty subtree of height i
+	empty [][]byte
+
+	// nodes[i] holds the non-empty nodes at height i, by index
+	nodes []map[uint64][]byte
+}
+
+var errInvalidLeaf = errors.New("smt: leaves must be encoded on the size of the hash")
+
+// NewTree returns an empty tree of the given depth, at most 64, hashing with h.
+func NewTree(h hash.Hash, depth int) (*Tree, error) {
+	if depth < 1 || depth > 64 {
+		return nil, fmt.Errorf("smt: invalid depth %d", depth)
+	}
+	t := &Tree{
+		h:     h,
+		depth: depth,
+		empty: make([][]byte, depth+1),
+		nodes: make([]map[uint64][]byte, depth+1),
+	}
+	t.empty[0] = make([]byte, h.Size())
+	for i := 0; i < depth; i++ {
+		var err error
+		if t.empty[i+1], err = hashNodes(h, t.empty[i], t.empty[i]); err != nil {
+			return nil, err
+		}
+	}
+	for i := range t.nodes {
+		t.nodes[i] = make(map[uint64][]byte)
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree, which is the number of siblings in a proof.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() []byte {
+	return t.node(t.depth, 0)
+}
+
+// Get returns the leaf at key.
+func (t *Tree) Get(key uint64) ([]byte, error) {
+	if err := t.checkKey(key); err != nil {
+		return nil, err
+	}
+	return t.node(0, key), nil
+}
+
+// Set sets the leaf at key, an all-zero leaf removing it from the tree.
+func (t *Tree) Set(key uint64, leaf []byte) error {
+	if err := t.checkKey(key); err != nil {
+		return err
+	}
+	if len(leaf) != t.h.Size() {
+		return errInvalidLeaf
+	}
+
+	node := append([]byte(nil), leaf...)
+	for i := 0; i < t.depth; i++ {
+		t.setNode(i, key, node)
+		var err error
+		if key&1 == 0 {
+			node, err = hashNodes(t.h, node, t.node(i, key^1))
+		} else {
+			node, err = hashNodes(t.h, t.node(i, key^1), node)
+		}
+		if err != nil {
+			return err
+		}
+		key >>= 1
+	}
+	t.setNode(t.depth, 0, node)
+	return nil
+}
+
+// Prove returns the siblings of the nodes on the path from the leaf at key to
+// the root, the sibling of the leaf first. They prove the membership of the
+// leaf, its non-membership if it is empty, and the update of the leaf.
+func (t *Tree) Prove(key uint64) ([][]byte, error) {
+	if err := t.checkKey(key); err != nil {
+		return nil, err
+	}
+	siblings := make([][]byte, t.depth)
+	for i := range siblings {
+		siblings[i] = t.node(i, key^1)
+		key >>= 1
+	}
+	return siblings, nil
+}
+
+// VerifyProof returns true if leaf is the leaf at key in the tree of the given
+// root, siblings being returned by Tree.Prove.
+func VerifyProof(h hash.Hash, root []byte, key uint64, leaf []byte, siblings [][]byte) (bool, error) {
+	if key>>len(siblings) != 0 {
+		return false, nil
+	}
+	node := leaf
+	for i := range siblings {
+		var err error
+		if key&1 == 0 {
+			node, err = hashNodes(h, node, siblings[i])
+		} else {
+			node, err = hashNodes(h, siblings[i], node)
+		}
+		if err != nil {
+			return false, err
+		}
+		key >>= 1
+	}
+	return bytes.Equal(node, root), nil
+}
+
+func (t *Tree) checkKey(key uint64) error {
+	if key>>t.depth != 0 {
+		return fmt.Errorf("smt: key %d out of range for depth %d", key, t.depth)
+	}
+	return nil
+}
+
+// node returns a copy of the node at height i and index key
+func (t *Tree) node(i int, key uint64) []byte {
+	if n, ok := t.nodes[i][key]; ok {
+		return append([]byte(nil), n...)
+	}
+	return append([]byte(nil), t.empty[i]...)
+}
+
+func (t *Tree) setNode(i int, key uint64, node []byte) {
+	if bytes.Equal(node, t.empty[i]) {
+		delete(t.nodes[i], key)
+	} else {
+		t.nodes[i][key] = node
+	}
+}
+
+// hashNodes returns the hash of the internal node of children left and right
+func hashNodes(h hash.Hash, left, right []byte) ([]byte, error) {
+	h.Reset()
+	if _, err := h.Write(left); err != nil {
+		return nil, err
+	}
+	if _, err := h.Write(right); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
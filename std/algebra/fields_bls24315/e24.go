@@ -93,7 +93,7 @@ func (e *E24) SetOne(api frontend.API) *E24 {
 	e.D0.C0.B1.A1 = 0
 	e.D0.C1.B0.A0 = 0
 	e.D0.C1.B0.A1 = 0
-	e.D0.C1.B1.A0 = 1
+	e.D0.C1.B1.A0 = 0
 	e.D0.C1.B1.A1 = 0
 	e.D0.C2.B0.A0 = 0
 	e.D0.C2.B0.A1 = 0
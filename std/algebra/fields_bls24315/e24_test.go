@@ -123,6 +123,33 @@ func TestMulFp24(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_633))
 }
 
+type fp24One struct {
+	A E24
+}
+
+func (circuit *fp24One) Define(api frontend.API) error {
+	var one, expected E24
+	one.SetOne(api)
+	expected.Mul(api, circuit.A, one)
+	expected.MustBeEqual(api, circuit.A)
+	return nil
+}
+
+func TestOneFp24(t *testing.T) {
+
+	var circuit, witness fp24One
+
+	// witness values
+	var a bls24315.E24
+	a.SetRandom()
+
+	witness.A.Assign(&a)
+
+	// cs values
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_633))
+}
+
 type fp24Square struct {
 	A E24
 	B E24 `gnark:",public"`
@@ -229,6 +229,7 @@ var DecomposeScalar = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) erro
 
 func init() {
 	hint.Register(DecomposeScalar)
+	hint.Register(DecomposeScalarSigned)
 }
 
 // varScalarMul sets P = [s] Q and returns P.
@@ -393,6 +394,148 @@ func (P *G1Affine) constScalarMul(api frontend.API, Q G1Affine, s *big.Int) *G1A
 	return P
 }
 
+// MultiScalarMul sets P = ∑ᵢ [sᵢ] Qᵢ and returns P. Q and s must be non-empty
+// and of the same length.
+//
+// The doublings are shared by all the points: every scalar is written with
+// signed binary digits in {-1, 1}, so that at each step either Qᵢ or -Qᵢ is
+// added to the accumulator. The accumulator starts from a fixed point with no
+// known relation to the inputs, which keeps the incomplete addition formulas
+// away from their exceptional cases, and its contribution is removed at the
+// end. The points with the constant scalar 1 are added with that correction.
+//
+// The scalars must be less than 2²⁵³ (which is the case of the scalars of the
+// inner curve), the points must not be known multiples of one another and the
+// result must not be the point at infinity.
+func (P *G1Affine) MultiScalarMul(api frontend.API, Q []G1Affine, s []frontend.Variable) *G1Affine {
+	if len(Q) == 0 || len(Q) != len(s) {
+		panic("invalid number of points or scalars")
+	}
+	cc := innerCurve(api.Compiler().Curve())
+
+	// split the points whose scalar is 1 from the others
+	var ones, points []G1Affine
+	var scalars []frontend.Variable
+	for i := range s {
+		if n, ok := api.Compiler().ConstantValue(s[i]); ok && n.IsInt64() && n.Int64() == 1 {
+			ones = append(ones, Q[i])
+		} else {
+			points = append(points, Q[i])
+			scalars = append(scalars, s[i])
+		}
+	}
+	if len(points) == 0 {
+		Acc := ones[0]
+		for i := 1; i < len(ones); i++ {
+			Acc.AddAssign(api, ones[i])
+		}
+		P.X, P.Y = Acc.X, Acc.Y
+		return P
+	}
+
+	// the scalar sᵢ has nbDigits digits, the top one is always 1.
+	nbDigits := cc.fr.BitLen() + 1
+	digits := make([][]frontend.Variable, len(scalars))
+	for i := range scalars {
+		digits[i] = signedDigits(api, cc, scalars[i], nbDigits)
+	}
+
+	// only y coordinate differs for negation, select on that instead.
+	negY := make([]frontend.Variable, len(points))
+	for i := range points {
+		negY[i] = api.Neg(points[i].Y)
+	}
+	selectQ := func(i, j int) G1Affine {
+		return G1Affine{X: points[i].X, Y: api.Select(digits[i][j], points[i].Y, negY[i])}
+	}
+
+	// Acc = G + ∑ᵢ Qᵢ
+	g, gn := msmOffset(nbDigits - 1)
+	var Acc, B G1Affine
+	Acc.Assign(&g)
+	for i := range points {
+		Acc.AddAssign(api, points[i])
+	}
+
+	// Acc = [2] Acc ± Q₀ ± Q₁ ...
+	for j := nbDigits - 2; j >= 0; j-- {
+		B = selectQ(0, j)
+		Acc.DoubleAndAdd(api, &Acc, &B)
+		for i := 1; i < len(points); i++ {
+			Acc.AddAssign(api, selectQ(i, j))
+		}
+	}
+
+	// remove [2ⁿ⁻¹] G and add the points with the scalar 1
+	gn.Neg(&gn)
+	B.Assign(&gn)
+	for i := range ones {
+		B.AddAssign(api, ones[i])
+	}
+	Acc.AddAssign(api, B)
+
+	P.X, P.Y = Acc.X, Acc.Y
+
+	return P
+}
+
+// msmOffset returns the initial point G of the accumulator in MultiScalarMul
+// and [2ⁿ] G.
+func msmOffset(n int) (g, gn bls12377.G1Affine) {
+	g, err := bls12377.HashToCurveG1Svdw([]byte("MultiScalarMul offset"), []byte("gnark"))
+	if err != nil {
+		panic(err)
+	}
+	var e big.Int
+	e.Lsh(big.NewInt(1), uint(n))
+	gn.ScalarMultiplication(&g, &e)
+	return g, gn
+}
+
+// signedDigits returns b such that s = 2ⁿ⁻¹ + ∑ⱼ (2bⱼ - 1) 2ʲ mod r where n
+// is nbDigits. If s is constant, then b is constant.
+func signedDigits(api frontend.API, cc *innerConfig, s frontend.Variable, nbDigits int) []frontend.Variable {
+	if n, ok := api.Compiler().ConstantValue(s); ok {
+		res := make([]*big.Int, 2)
+		res[0], res[1] = new(big.Int), new(big.Int)
+		if err := DecomposeScalarSigned(api.Compiler().Curve(), []*big.Int{n.Mod(n, cc.fr)}, res); err != nil {
+			panic(err)
+		}
+		b := make([]frontend.Variable, nbDigits-1)
+		for j := range b {
+			b[j] = res[0].Bit(j)
+		}
+		return b
+	}
+
+	// the hint returns t and k such that s + k*r = 2t + 1, k being a bit. We
+	// then have ∑ⱼ (2bⱼ - 1) 2ʲ = 2t + 1 - 2ⁿ⁻¹ for the bits of t.
+	sd, err := api.Compiler().NewHint(DecomposeScalarSigned, 2, s)
+	if err != nil {
+		// err is non-nil only for invalid number of inputs
+		panic(err)
+	}
+	t, k := sd[0], sd[1]
+	api.AssertIsBoolean(k)
+	api.AssertIsEqual(api.Add(s, api.Mul(k, cc.fr)), api.Add(api.Mul(t, 2), 1))
+	return api.ToBinary(t, nbDigits-1)
+}
+
+// DecomposeScalarSigned returns t and k such that s + k*r = 2t + 1 for the
+// scalar s, where k is 0 when s is odd and 1 otherwise.
+var DecomposeScalarSigned = func(curve ecc.ID, inputs []*big.Int, res []*big.Int) error {
+	cc := innerCurve(curve)
+	res[0].Set(inputs[0])
+	res[1].SetUint64(0)
+	if res[0].Bit(0) == 0 {
+		res[0].Add(res[0], cc.fr)
+		res[1].SetUint64(1)
+	}
+	res[0].Rsh(res[0], 1)
+
+	return nil
+}
+
 // Assign a value to self (witness assignment)
 func (p *G1Jac) Assign(p1 *bls12377.G1Jac) {
 	p.X = (fr.Element)(p1.X)
@@ -368,6 +368,61 @@ func TestScalarMulG1(t *testing.T) {
 	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
 }
 
+type g1MultiScalarMul struct {
+	A    [3]G1Affine
+	C    G1Affine `gnark:",public"`
+	Rvar [3]frontend.Variable
+	Rcon [3]fr.Element
+}
+
+func (circuit *g1MultiScalarMul) Define(api frontend.API) error {
+	var expected, expected2 G1Affine
+	expected.MultiScalarMul(api, circuit.A[:], circuit.Rvar[:])
+	expected.MustBeEqual(api, circuit.C)
+	rcon := make([]frontend.Variable, len(circuit.Rcon))
+	for i := range circuit.Rcon {
+		rcon[i] = circuit.Rcon[i]
+	}
+	expected2.MultiScalarMul(api, circuit.A[:], rcon)
+	expected2.MustBeEqual(api, circuit.C)
+	return nil
+}
+
+func TestMultiScalarMulG1(t *testing.T) {
+	assert := test.NewAssert(t)
+
+	// the scalars are random, 0 (even) and r-1 (even, the largest one)
+	var r [3]fr.Element
+	r[0].SetRandom()
+	r[2].SetOne()
+	r[2].Neg(&r[2])
+
+	// sample random points and compute the result
+	var a [3]bls12377.G1Affine
+	var _c bls12377.G1Jac
+	var circuit, witness g1MultiScalarMul
+	for i := range a {
+		_a := randomPointG1()
+		a[i].FromJacobian(&_a)
+		witness.A[i].Assign(&a[i])
+		br := r[i].ToBigIntRegular(new(big.Int))
+		witness.Rvar[i] = br
+		circuit.Rcon[i] = r[i]
+
+		_a.ScalarMultiplication(&_a, br)
+		_c.AddAssign(&_a)
+	}
+	var c bls12377.G1Affine
+	c.FromJacobian(&_c)
+	witness.C.Assign(&c)
+
+	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+
+	// a wrong scalar
+	witness.Rvar[1] = 1
+	assert.SolvingFailed(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+}
+
 func randomPointG1() bls12377.G1Jac {
 
 	p1, _, _, _ := bls12377.Generators()
@@ -439,3 +494,26 @@ func BenchmarkVarScalarMulG1(b *testing.B) {
 	b.Log("plonk", ccsBench.GetNbConstraints())
 
 }
+
+func BenchmarkMultiScalarMulG1(b *testing.B) {
+	var c g1MultiScalarMul
+	b.Run("groth16", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ccsBench, _ = frontend.Compile(ecc.BW6_761, r1cs.NewBuilder, &c)
+		}
+
+	})
+	b.Log("groth16", ccsBench.GetNbConstraints())
+	b.Run("plonk", func(b *testing.B) {
+		var err error
+		for i := 0; i < b.N; i++ {
+			ccsBench, err = frontend.Compile(ecc.BW6_761, scs.NewBuilder, &c)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+
+	})
+	b.Log("plonk", ccsBench.GetNbConstraints())
+
+}
@@ -0,0 +1,93 @@
+package weierstrass
+
+import (
+	"crypto/sha256"
+	"math/big"
+)
+
+// CurveParams describes a short Weierstrass curve y² = x³ + A·x + B and its base point (Gx, Gy)
+type CurveParams struct {
+	A, B   *big.Int
+	Gx, Gy *big.Int
+}
+
+// GetSecp256k1Params returns the parameters of the secp256k1 curve used by Bitcoin and Ethereum.
+// Its base field is emulated.Secp256k1 and its scalar field emulated.Secp256k1Fr.
+func GetSecp256k1Params() CurveParams {
+	gx, _ := new(big.Int).SetString("79be667ef9dcbbac55a06295ce870b07029bfcdb2dce28d959f2815b16f81798", 16)
+	gy, _ := new(big.Int).SetString("483ada7726a3c4655da4fbfc0e1108a8fd17b448a68554199c47d08ffb10d4b8", 16)
+	return CurveParams{
+		A:  big.NewInt(0),
+		B:  big.NewInt(7),
+		Gx: gx,
+		Gy: gy,
+	}
+}
+
+// GetP256Params returns the parameters of the NIST P-256 curve.
+// Its base field is emulated.P256Fp and its scalar field emulated.P256Fr.
+func GetP256Params() CurveParams {
+	a, _ := new(big.Int).SetString("ffffffff00000001000000000000000000000000fffffffffffffffffffffffc", 16)
+	b, _ := new(big.Int).SetString("5ac635d8aa3a93e7b3ebbd55769886bc651d06b0cc53b0f63bce3c3e27d2604b", 16)
+	gx, _ := new(big.Int).SetString("6b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296", 16)
+	gy, _ := new(big.Int).SetString("4fe342e2fe1a7f9b8ee7eb4a7c0f9e162bce33576b315ececbb6406837bf51f5", 16)
+	return CurveParams{
+		A:  a,
+		B:  b,
+		Gx: gx,
+		Gy: gy,
+	}
+}
+
+// nativePoint is an affine point computed outside of the circuit
+type nativePoint struct {
+	x, y *big.Int
+}
+
+// offsetPoint returns a point of the curve whose discrete logarithm is unknown: its abscissa is the
+// first sha256("gnark/std/algebra/weierstrass" ‖ counter) mod p for which the curve has a point
+func offsetPoint(params CurveParams, p *big.Int) nativePoint {
+	for counter := byte(0); ; counter++ {
+		digest := sha256.Sum256(append([]byte("gnark/std/algebra/weierstrass"), counter))
+		x := new(big.Int).SetBytes(digest[:])
+		x.Mod(x, p)
+		y := new(big.Int).ModSqrt(curveEquation(params, x, p), p)
+		if y != nil && y.Sign() != 0 {
+			return nativePoint{x: x, y: y}
+		}
+	}
+}
+
+// curveEquation returns x³ + A·x + B mod p
+func curveEquation(params CurveParams, x, p *big.Int) *big.Int {
+	res := new(big.Int).Mul(x, x)
+	res.Add(res, params.A).Mul(res, x).Add(res, params.B)
+	return res.Mod(res, p)
+}
+
+// nativeAdd returns q + r, they must not be equal or opposite
+func nativeAdd(q, r nativePoint, p *big.Int) nativePoint {
+	lambda := new(big.Int).Sub(r.x, q.x)
+	lambda.ModInverse(lambda.Mod(lambda, p), p)
+	lambda.Mul(lambda, new(big.Int).Sub(r.y, q.y)).Mod(lambda, p)
+	return nativeChord(q, r.x, lambda, p)
+}
+
+// nativeDouble returns 2q, q must not be of order 2
+func nativeDouble(params CurveParams, q nativePoint, p *big.Int) nativePoint {
+	lambda := new(big.Int).Lsh(q.y, 1)
+	lambda.ModInverse(lambda.Mod(lambda, p), p)
+	num := new(big.Int).Mul(q.x, q.x)
+	num.Mul(num, big.NewInt(3)).Add(num, params.A)
+	lambda.Mul(lambda, num).Mod(lambda, p)
+	return nativeChord(q, q.x, lambda, p)
+}
+
+// nativeChord returns the third point of the line of slope lambda through q and the point of abscissa x, negated
+func nativeChord(q nativePoint, x, lambda, p *big.Int) nativePoint {
+	x3 := new(big.Int).Mul(lambda, lambda)
+	x3.Sub(x3, q.x).Sub(x3, x).Mod(x3, p)
+	y3 := new(big.Int).Sub(q.x, x3)
+	y3.Mul(y3, lambda).Sub(y3, q.y).Mod(y3, p)
+	return nativePoint{x: x3, y: y3}
+}
@@ -0,0 +1,194 @@
+// Package weierstrass implements the arithmetic of short Weierstrass curves whose base and scalar fields
+// are emulated, see std/math/emulated. It is meant for curves unrelated to the native field, like
+// secp256k1 or P-256.
+//
+// The points are in affine coordinates and the formulas are incomplete: the point at infinity can't be
+// represented, and adding a point to itself or to its opposite makes the proof fail.
+package weierstrass
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// AffinePoint is a point (X, Y) of a curve whose base field is Base
+type AffinePoint[Base emulated.FieldParams] struct {
+	X, Y emulated.Element[Base]
+}
+
+// Curve performs the arithmetic of the curve described by CurveParams, of base field Base and scalar
+// field Scalar, in a circuit
+type Curve[Base, Scalar emulated.FieldParams] struct {
+	api       frontend.API
+	baseApi   *emulated.Field[Base]
+	scalarApi *emulated.Field[Scalar]
+
+	a, b *emulated.Element[Base]
+	addA bool
+	g    AffinePoint[Base]
+
+	// the scalar multiplications start from offset, so that the accumulator is never the point at
+	// infinity, and subtract [2^n]offset at the end, n being the number of bits of the scalars
+	offset, offsetEnd AffinePoint[Base]
+}
+
+// New returns a Curve performing the arithmetic of the curve params with the native api
+func New[Base, Scalar emulated.FieldParams](api frontend.API, params CurveParams) (*Curve[Base, Scalar], error) {
+	baseApi, err := emulated.NewField[Base](api)
+	if err != nil {
+		return nil, fmt.Errorf("base field: %w", err)
+	}
+	scalarApi, err := emulated.NewField[Scalar](api)
+	if err != nil {
+		return nil, fmt.Errorf("scalar field: %w", err)
+	}
+
+	var fp Base
+	var fr Scalar
+	p := fp.Modulus()
+	g := nativePoint{x: params.Gx, y: params.Gy}
+	if curveEquation(params, g.x, p).Cmp(new(big.Int).Mod(new(big.Int).Mul(g.y, g.y), p)) != 0 {
+		return nil, fmt.Errorf("weierstrass: base point isn't on the curve")
+	}
+	offset := offsetPoint(params, p)
+	offsetEnd := offset
+	for i := uint(0); i < fr.NbLimbs()*fr.BitsPerLimb(); i++ {
+		offsetEnd = nativeDouble(params, offsetEnd, p)
+	}
+	offsetEnd.y.Sub(p, offsetEnd.y)
+
+	a, b := emulated.NewElement[Base](params.A), emulated.NewElement[Base](params.B)
+	return &Curve[Base, Scalar]{
+		api:       api,
+		baseApi:   baseApi,
+		scalarApi: scalarApi,
+		a:         &a,
+		b:         &b,
+		addA:      params.A.Sign() != 0,
+		g:         newConstantPoint[Base](g),
+		offset:    newConstantPoint[Base](offset),
+		offsetEnd: newConstantPoint[Base](offsetEnd),
+	}, nil
+}
+
+func newConstantPoint[Base emulated.FieldParams](q nativePoint) AffinePoint[Base] {
+	return AffinePoint[Base]{X: emulated.NewElement[Base](q.x), Y: emulated.NewElement[Base](q.y)}
+}
+
+// BaseField returns the arithmetic of the base field used by c
+func (c *Curve[Base, Scalar]) BaseField() *emulated.Field[Base] {
+	return c.baseApi
+}
+
+// ScalarField returns the arithmetic of the scalar field used by c
+func (c *Curve[Base, Scalar]) ScalarField() *emulated.Field[Scalar] {
+	return c.scalarApi
+}
+
+// Generator returns the base point of the curve
+func (c *Curve[Base, Scalar]) Generator() *AffinePoint[Base] {
+	g := c.g
+	return &g
+}
+
+// Neg returns -p
+func (c *Curve[Base, Scalar]) Neg(p *AffinePoint[Base]) *AffinePoint[Base] {
+	return &AffinePoint[Base]{X: p.X, Y: *c.baseApi.Neg(&p.Y)}
+}
+
+// AssertIsEqual fails if p != q
+func (c *Curve[Base, Scalar]) AssertIsEqual(p, q *AffinePoint[Base]) {
+	c.baseApi.AssertIsEqual(&p.X, &q.X)
+	c.baseApi.AssertIsEqual(&p.Y, &q.Y)
+}
+
+// AssertIsOnCurve fails if p isn't on the curve
+func (c *Curve[Base, Scalar]) AssertIsOnCurve(p *AffinePoint[Base]) {
+	c.baseApi.AssertIsEqual(c.baseApi.Mul(&p.Y, &p.Y), c.equation(&p.X))
+}
+
+// Lift returns the point of abscissa x whose ordinate has the parity given by the boolean parity,
+// the proof fails if there is none
+func (c *Curve[Base, Scalar]) Lift(x *emulated.Element[Base], parity frontend.Variable) *AffinePoint[Base] {
+	y := c.baseApi.Sqrt(c.equation(x))
+	// the opposite of y has the other parity, y isn't zero since the curve has no point of order 2
+	flip := c.api.Xor(c.baseApi.ToCanonicalBits(y)[0], parity)
+	return &AffinePoint[Base]{X: *x, Y: *c.baseApi.Select(flip, c.baseApi.Neg(y), y)}
+}
+
+// equation returns x³ + a·x + b
+func (c *Curve[Base, Scalar]) equation(x *emulated.Element[Base]) *emulated.Element[Base] {
+	res := c.baseApi.Mul(x, x)
+	if c.addA {
+		res = c.baseApi.Add(res, c.a)
+	}
+	return c.baseApi.Add(c.baseApi.Mul(res, x), c.b)
+}
+
+// Add returns p + q, the proof fails if p = ±q
+func (c *Curve[Base, Scalar]) Add(p, q *AffinePoint[Base]) *AffinePoint[Base] {
+	// λ = (qy - py) / (qx - px), the inverse checks that qx != px so that λ is defined by the points
+	lambda := c.baseApi.Mul(c.baseApi.Sub(&q.Y, &p.Y), c.baseApi.Inverse(c.baseApi.Sub(&q.X, &p.X)))
+	return c.chord(p, &q.X, lambda)
+}
+
+// Double returns 2p, p must be on the curve
+func (c *Curve[Base, Scalar]) Double(p *AffinePoint[Base]) *AffinePoint[Base] {
+	// λ = (3px² + a) / 2py, py can't be zero since the curve has no point of order 2
+	num := c.baseApi.Mul(&p.X, &p.X)
+	num = c.baseApi.Add(c.baseApi.Add(num, num), num)
+	if c.addA {
+		num = c.baseApi.Add(num, c.a)
+	}
+	lambda := c.baseApi.Div(num, c.baseApi.Add(&p.Y, &p.Y))
+	return c.chord(p, &p.X, lambda)
+}
+
+// chord returns the opposite of the third point of the line of slope lambda through p and the point of abscissa x
+func (c *Curve[Base, Scalar]) chord(p *AffinePoint[Base], x, lambda *emulated.Element[Base]) *AffinePoint[Base] {
+	x3 := c.baseApi.Sub(c.baseApi.Sub(c.baseApi.Mul(lambda, lambda), &p.X), x)
+	y3 := c.baseApi.Sub(c.baseApi.Mul(lambda, c.baseApi.Sub(&p.X, x3)), &p.Y)
+	return &AffinePoint[Base]{X: *x3, Y: *y3}
+}
+
+// Select returns p if sel is true and q otherwise, sel must be boolean
+func (c *Curve[Base, Scalar]) Select(sel frontend.Variable, p, q *AffinePoint[Base]) *AffinePoint[Base] {
+	return &AffinePoint[Base]{
+		X: *c.baseApi.Select(sel, &p.X, &q.X),
+		Y: *c.baseApi.Select(sel, &p.Y, &q.Y),
+	}
+}
+
+// ScalarMul returns [s]p. p must be on the curve and [s]p must not be the point at infinity.
+func (c *Curve[Base, Scalar]) ScalarMul(p *AffinePoint[Base], s *emulated.Element[Scalar]) *AffinePoint[Base] {
+	bits := c.scalarApi.ToBits(s)
+	acc := &c.offset
+	for i := len(bits) - 1; i >= 0; i-- {
+		acc = c.Double(acc)
+		acc = c.Select(bits[i], c.Add(acc, p), acc)
+	}
+	return c.Add(acc, &c.offsetEnd)
+}
+
+// ScalarMulBase returns [s]G where G is the base point of the curve
+func (c *Curve[Base, Scalar]) ScalarMulBase(s *emulated.Element[Scalar]) *AffinePoint[Base] {
+	return c.ScalarMul(&c.g, s)
+}
+
+// JointScalarMul returns [s]p + [t]q with a single sequence of doublings. p and q must be on the curve,
+// p != ±q, and the result must not be the point at infinity.
+func (c *Curve[Base, Scalar]) JointScalarMul(p, q *AffinePoint[Base], s, t *emulated.Element[Scalar]) *AffinePoint[Base] {
+	sBits, tBits := c.scalarApi.ToBits(s), c.scalarApi.ToBits(t)
+	pq := c.Add(p, q)
+	acc := &c.offset
+	for i := len(sBits) - 1; i >= 0; i-- {
+		acc = c.Double(acc)
+		// p, q or p + q, only added if one of the bits is set
+		addend := c.Select(sBits[i], c.Select(tBits[i], pq, p), q)
+		acc = c.Select(c.api.Or(sBits[i], tBits[i]), c.Add(acc, addend), acc)
+	}
+	return c.Add(acc, &c.offsetEnd)
+}
@@ -0,0 +1,98 @@
+package weierstrass
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/test"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func TestParams(t *testing.T) {
+	check := func(name string, params CurveParams, curve elliptic.Curve, p, n *big.Int) {
+		cp := curve.Params()
+		if cp.P.Cmp(p) != 0 || cp.N.Cmp(n) != 0 || cp.Gx.Cmp(params.Gx) != 0 || cp.Gy.Cmp(params.Gy) != 0 {
+			t.Fatalf("%s: parameters don't match the reference implementation", name)
+		}
+		if cp.B.Cmp(params.B) != 0 {
+			t.Fatalf("%s: wrong b", name)
+		}
+	}
+	check("secp256k1", GetSecp256k1Params(), crypto.S256(), emulated.Secp256k1{}.Modulus(), emulated.Secp256k1Fr{}.Modulus())
+	check("P-256", GetP256Params(), elliptic.P256(), emulated.P256Fp{}.Modulus(), emulated.P256Fr{}.Modulus())
+}
+
+type scalarMulCircuit[Base, Scalar emulated.FieldParams] struct {
+	params CurveParams
+
+	P, Q     AffinePoint[Base]
+	S, T     emulated.Element[Scalar]
+	SP, SPTQ AffinePoint[Base]
+}
+
+func (c *scalarMulCircuit[Base, Scalar]) Define(api frontend.API) error {
+	curve, err := New[Base, Scalar](api, c.params)
+	if err != nil {
+		return err
+	}
+	curve.AssertIsOnCurve(&c.P)
+	curve.AssertIsEqual(curve.ScalarMul(&c.P, &c.S), &c.SP)
+	curve.AssertIsEqual(curve.JointScalarMul(&c.P, &c.Q, &c.S, &c.T), &c.SPTQ)
+	return nil
+}
+
+func newPoint[Base emulated.FieldParams](x, y interface{}) AffinePoint[Base] {
+	return AffinePoint[Base]{X: emulated.NewElement[Base](x), Y: emulated.NewElement[Base](y)}
+}
+
+func testScalarMul[Base, Scalar emulated.FieldParams](t *testing.T, params CurveParams, curve elliptic.Curve) {
+	var fr Scalar
+	s, err := rand.Int(rand.Reader, fr.Modulus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt, err := rand.Int(rand.Reader, fr.Modulus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	px, py := curve.ScalarBaseMult(big.NewInt(42).Bytes())
+	qx, qy := curve.ScalarBaseMult(big.NewInt(1337).Bytes())
+	spx, spy := curve.ScalarMult(px, py, s.Bytes())
+	tqx, tqy := curve.ScalarMult(qx, qy, tt.Bytes())
+	rx, ry := curve.Add(spx, spy, tqx, tqy)
+
+	circuit := scalarMulCircuit[Base, Scalar]{
+		params: params,
+		P:      newPoint[Base](nil, nil), Q: newPoint[Base](nil, nil),
+		S: emulated.NewElement[Scalar](nil), T: emulated.NewElement[Scalar](nil),
+		SP: newPoint[Base](nil, nil), SPTQ: newPoint[Base](nil, nil),
+	}
+	witness := scalarMulCircuit[Base, Scalar]{
+		params: params,
+		P:      newPoint[Base](px, py), Q: newPoint[Base](qx, qy),
+		S: emulated.NewElement[Scalar](s), T: emulated.NewElement[Scalar](tt),
+		SP: newPoint[Base](spx, spy), SPTQ: newPoint[Base](rx, ry),
+	}
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	witness.SP = newPoint[Base](tqx, tqy)
+	if err := test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("wrong scalar multiplication accepted")
+	}
+}
+
+func TestScalarMul(t *testing.T) {
+	testScalarMul[emulated.Secp256k1, emulated.Secp256k1Fr](t, GetSecp256k1Params(), crypto.S256())
+	if testing.Short() {
+		return
+	}
+	testScalarMul[emulated.P256Fp, emulated.P256Fr](t, GetP256Params(), elliptic.P256())
+}
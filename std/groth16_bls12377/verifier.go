@@ -18,7 +18,12 @@ limitations under the License.
 package groth16_bls12377
 
 import (
+	"fmt"
+
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
+	groth16_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/groth16"
 	"github.com/consensys/gnark/std/algebra/fields_bls12377"
 	"github.com/consensys/gnark/std/algebra/sw_bls12377"
 )
@@ -29,7 +34,29 @@ type Proof struct {
 	Bs      sw_bls12377.G2Affine // πB in https://eprint.iacr.org/2020/278.pdf
 }
 
+// NewProof returns the witness assignment of proof, a BLS12_377 proof returned by groth16.Prove
+func NewProof(proof groth16.Proof) (Proof, error) {
+	_proof, ok := proof.(*groth16_bls12377.Proof)
+	if !ok {
+		return Proof{}, fmt.Errorf("groth16_bls12377: expected a BLS12_377 proof, got %T", proof)
+	}
+	var res Proof
+	res.Ar.Assign(&_proof.Ar)
+	res.Krs.Assign(&_proof.Krs)
+	res.Bs.Assign(&_proof.Bs)
+	return res, nil
+}
+
 // VerifyingKey represents the groth16 verifying key in a r1cs
+//
+// If it is stored in an unexported field of the circuit, the key is a
+// parameter of the circuit and the compiled circuit verifies the proofs of a
+// single inner circuit. If it is stored in an exported field, the key is part
+// of the witness and the compiled circuit verifies the proofs of any inner
+// circuit with the same number of public inputs. The outer circuit must then
+// bind the key to something the verifier trusts (for instance by checking
+// its hash against a public input), as Verify does not check that its points
+// are on the curve and uses incomplete addition formulas.
 type VerifyingKey struct {
 
 	// e(α, β)
@@ -44,30 +71,57 @@ type VerifyingKey struct {
 	G1 []sw_bls12377.G1Affine // The indexes correspond to the public wires
 }
 
-// Verify implements the verification function of groth16.
-// pubInputNames should what r1cs.PublicInputs() outputs for the inner r1cs.
-// It creates public circuits input, corresponding to the pubInputNames slice.
-// Notations and naming are from https://eprint.iacr.org/2020/278.
-func Verify(api frontend.API, innerVk VerifyingKey, innerProof Proof, innerPubInputs []frontend.Variable) {
+// NewVerifyingKey returns the assignment of vk, a BLS12_377 verifying key returned by groth16.Setup
+func NewVerifyingKey(vk groth16.VerifyingKey) (VerifyingKey, error) {
+	_vk, ok := vk.(*groth16_bls12377.VerifyingKey)
+	if !ok {
+		return VerifyingKey{}, fmt.Errorf("groth16_bls12377: expected a BLS12_377 verifying key, got %T", vk)
+	}
+	if len(_vk.G1.K) == 0 {
+		return VerifyingKey{}, fmt.Errorf("groth16_bls12377: the verifying key has no [Kvk]1 points")
+	}
 
-	// compute psi0 using a sequence of multiexponentiations
-	// TODO maybe implement the bucket method with c=1 when there's a large input set
-	var psi0, tmp sw_bls12377.G1Affine
+	var res VerifyingKey
+	e, err := bls12377.Pair([]bls12377.G1Affine{_vk.G1.Alpha}, []bls12377.G2Affine{_vk.G2.Beta})
+	if err != nil {
+		return VerifyingKey{}, err
+	}
+	res.E.Assign(&e)
 
-	if len(innerVk.G1) == 0 {
-		panic("innver verifying key needs at least one point; VerifyingKey.G1 must be initialized before compiling circuit")
+	var deltaNeg, gammaNeg bls12377.G2Affine
+	deltaNeg.Neg(&_vk.G2.Delta)
+	gammaNeg.Neg(&_vk.G2.Gamma)
+	res.G2.DeltaNeg.Assign(&deltaNeg)
+	res.G2.GammaNeg.Assign(&gammaNeg)
+
+	res.G1 = make([]sw_bls12377.G1Affine, len(_vk.G1.K))
+	for i := range _vk.G1.K {
+		res.G1[i].Assign(&_vk.G1.K[i])
 	}
+	return res, nil
+}
 
-	// assign the initial psi0 to the part of the public key corresponding to one_wire
-	// note this assumes ONE_WIRE is at position 0
-	psi0.X = innerVk.G1[0].X
-	psi0.Y = innerVk.G1[0].Y
+// NewPlaceholderVerifyingKey returns a verifying key to be used in the circuit
+// definition when the key is part of the witness, for inner circuits with
+// nbPublicInputs public inputs.
+func NewPlaceholderVerifyingKey(nbPublicInputs int) VerifyingKey {
+	return VerifyingKey{G1: make([]sw_bls12377.G1Affine, nbPublicInputs+1)}
+}
 
-	for k, v := range innerPubInputs {
-		tmp.ScalarMul(api, innerVk.G1[k+1], v)
-		psi0.AddAssign(api, tmp)
+// Verify implements the verification function of groth16.
+// innerPubInputs are the public inputs of the inner circuit, in the order
+// of the inner witness, without the constant one wire.
+// Notations and naming are from https://eprint.iacr.org/2020/278.
+func Verify(api frontend.API, innerVk VerifyingKey, innerProof Proof, innerPubInputs []frontend.Variable) error {
+	if len(innerVk.G1) != len(innerPubInputs)+1 {
+		return fmt.Errorf("groth16_bls12377: the verifying key has %d points for %d public inputs, expected %d", len(innerVk.G1), len(innerPubInputs), len(innerPubInputs)+1)
 	}
 
+	// compute psi0 = [Kvk]1[0] + ∑ᵢ [xᵢ] [Kvk]1[i+1]
+	// note this assumes ONE_WIRE is at position 0
+	var psi0 sw_bls12377.G1Affine
+	psi0.MultiScalarMul(api, innerVk.G1, append([]frontend.Variable{1}, innerPubInputs...))
+
 	// e(psi0, -gamma)*e(-πC, -δ)*e(πA, πB)
 	resMillerLoop := sw_bls12377.TripleMillerLoop(api, [3]sw_bls12377.G1Affine{psi0, innerProof.Krs, innerProof.Ar}, [3]sw_bls12377.G2Affine{innerVk.G2.GammaNeg, innerVk.G2.DeltaNeg, innerProof.Bs})
 
@@ -77,4 +131,5 @@ func Verify(api frontend.API, innerVk VerifyingKey, innerProof Proof, innerPubIn
 	// vk.E must be equal to resPairing
 	innerVk.E.MustBeEqual(api, resPairing)
 
+	return nil
 }
@@ -17,18 +17,16 @@ limitations under the License.
 package groth16_bls12377
 
 import (
-	"reflect"
+	"math/big"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
-	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark-crypto/hash"
 	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/frontend/cs/r1cs"
-	backend_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/cs"
-	groth16_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/groth16"
-	"github.com/consensys/gnark/internal/backend/bls12-377/witness"
-	"github.com/consensys/gnark/std/algebra/sw_bls12377"
 	"github.com/consensys/gnark/std/hash/mimc"
 	"github.com/consensys/gnark/test"
 )
@@ -36,158 +34,320 @@ import (
 //--------------------------------------------------------------------
 // utils
 
-const preimage string = "4992816046196248432836492760315135318126925090839638585255611512962528270024"
-const publicHash string = "4458332240632096997117977163518118563548842578509780924154021342053538349576"
-
-type mimcCircuit struct {
-	Data frontend.Variable
-	Hash frontend.Variable `gnark:",public"`
+// polyCircuit checks that Y = X³ + X + 5 and Z = X * W
+type polyCircuit struct {
+	X       frontend.Variable
+	Y, Z, W frontend.Variable `gnark:",public"`
 }
 
-func (circuit *mimcCircuit) Define(api frontend.API) error {
-	mimc, err := mimc.NewMiMC(api)
-	if err != nil {
-		return err
-	}
-	mimc.Write(circuit.Data)
-	result := mimc.Sum()
-	api.AssertIsEqual(result, circuit.Hash)
+func (circuit *polyCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(circuit.Y, api.Add(x3, circuit.X, 5))
+	api.AssertIsEqual(circuit.Z, api.Mul(circuit.X, circuit.W))
 	return nil
 }
 
-// Prepare the data for the inner proof.
-// Returns the public inputs string of the inner proof
-func generateBls12377InnerProof(t *testing.T, vk *groth16_bls12377.VerifyingKey, proof *groth16_bls12377.Proof) {
+// squareCircuit checks that Y = X², Z = X + W and W != 0
+type squareCircuit struct {
+	X       frontend.Variable
+	Y, Z, W frontend.Variable `gnark:",public"`
+}
+
+func (circuit *squareCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(circuit.Y, api.Mul(circuit.X, circuit.X))
+	api.AssertIsEqual(circuit.Z, api.Add(circuit.X, circuit.W))
+	api.AssertIsDifferent(circuit.W, 0)
+	return nil
+}
 
-	// create a mock cs: knowing the preimage of a hash using mimc
-	var circuit, w mimcCircuit
-	r1cs, err := frontend.Compile(ecc.BLS12_377, r1cs.NewBuilder, &circuit)
+// generateInnerProofs returns the BLS12_377 verifying key of circuit and a
+// proof for each of the assignments, after checking it natively
+func generateInnerProofs(t testing.TB, circuit frontend.Circuit, assignments ...frontend.Circuit) (groth16.VerifyingKey, []groth16.Proof) {
+	ccs, err := frontend.Compile(ecc.BLS12_377, r1cs.NewBuilder, circuit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	w.Data = preimage
-	w.Hash = publicHash
+	proofs := make([]groth16.Proof, len(assignments))
+	for i := range assignments {
+		w, err := frontend.NewWitness(assignments[i], ecc.BLS12_377)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proofs[i], err = groth16.Prove(ccs, pk, w); err != nil {
+			t.Fatal(err)
+		}
+
+		// before returning verifies that the proof passes on bls12377
+		publicWitness, err := w.Public()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := groth16.Verify(proofs[i], vk, publicWitness); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return vk, proofs
+}
+
+// verifierCircuit verifies the proofs of a single inner circuit
+type verifierCircuit struct {
+	InnerProof Proof
+	Y, Z, W    frontend.Variable `gnark:",public"`
+
+	innerVk VerifyingKey
+}
 
-	correctAssignment := witness.Witness{}
+func (circuit *verifierCircuit) Define(api frontend.API) error {
+	return Verify(api, circuit.innerVk, circuit.InnerProof, []frontend.Variable{circuit.Y, circuit.Z, circuit.W})
+}
 
-	_, err = correctAssignment.FromAssignment(&w, tVariable, false)
+func TestVerifier(t *testing.T) {
+	innerVk, innerProofs := generateInnerProofs(t, &polyCircuit{},
+		&polyCircuit{X: 3, Y: 35, Z: 21, W: 7},
+		&polyCircuit{X: 3, Y: 35, Z: 0, W: 0},
+	)
+	vk, err := NewVerifyingKey(innerVk)
 	if err != nil {
 		t.Fatal(err)
 	}
-
-	// generate the data to return for the bls12377 proof
-	var pk groth16_bls12377.ProvingKey
-	groth16_bls12377.Setup(r1cs.(*backend_bls12377.R1CS), &pk, vk)
-	_proof, err := groth16_bls12377.Prove(r1cs.(*backend_bls12377.R1CS), &pk, correctAssignment, backend.ProverConfig{})
+	proof, err := NewProof(innerProofs[0])
 	if err != nil {
 		t.Fatal(err)
 	}
-	proof.Ar = _proof.Ar
-	proof.Bs = _proof.Bs
-	proof.Krs = _proof.Krs
-
-	correctAssignmentPublic := witness.Witness{}
-	_, err = correctAssignmentPublic.FromAssignment(&w, tVariable, true)
+	proofZero, err := NewProof(innerProofs[1])
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	// before returning verifies that the proof passes on bls12377
-	if err := groth16_bls12377.Verify(proof, vk, correctAssignmentPublic); err != nil {
+	circuit := verifierCircuit{innerVk: vk}
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&circuit, &verifierCircuit{InnerProof: proof, Y: 35, Z: 21, W: 7}, test.WithCurves(ecc.BW6_761), test.WithBackends(backend.GROTH16))
+
+	if err := test.IsSolved(&circuit, &verifierCircuit{InnerProof: proofZero, Y: 35, Z: 0, W: 0}, ecc.BW6_761, backend.UNKNOWN); err != nil {
 		t.Fatal(err)
 	}
+	if err := test.IsSolved(&circuit, &verifierCircuit{InnerProof: proof, Y: 35, Z: 21, W: 8}, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("proof accepted for other public inputs")
+	}
+}
 
+// nbAccounts is the number of accounts of transferCircuit, the leaves of its Merkle tree
+const nbAccounts = 4
+
+// transferCircuit is a rollup-like circuit: it checks a transfer of Amount from
+// the account Sender to the account Receiver, the accounts being the leaves of a
+// MiMC Merkle tree whose roots before and after the transfer are public
+type transferCircuit struct {
+	Nonces, Balances      [nbAccounts]frontend.Variable
+	Sender, Receiver      frontend.Variable
+	Amount                frontend.Variable
+	RootBefore, RootAfter frontend.Variable `gnark:",public"`
 }
 
-type verifierCircuit struct {
-	InnerProof Proof
-	InnerVk    VerifyingKey
-	Hash       frontend.Variable
+// merkleRoot returns the root of the tree of the accounts, a leaf is the hash
+// of the nonce and of the balance of an account
+func merkleRoot(api frontend.API, nonces, balances []frontend.Variable) (frontend.Variable, error) {
+	h, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	nodes := make([]frontend.Variable, len(nonces))
+	for i := range nodes {
+		h.Reset()
+		h.Write(nonces[i], balances[i])
+		nodes[i] = h.Sum()
+	}
+	for len(nodes) > 1 {
+		for i := 0; i < len(nodes)/2; i++ {
+			h.Reset()
+			h.Write(nodes[2*i], nodes[2*i+1])
+			nodes[i] = h.Sum()
+		}
+		nodes = nodes[:len(nodes)/2]
+	}
+	return nodes[0], nil
 }
 
-func (circuit *verifierCircuit) Define(api frontend.API) error {
-	// create the verifier cs
-	Verify(api, circuit.InnerVk, circuit.InnerProof, []frontend.Variable{circuit.Hash})
+func (circuit *transferCircuit) Define(api frontend.API) error {
+	root, err := merkleRoot(api, circuit.Nonces[:], circuit.Balances[:])
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(root, circuit.RootBefore)
+	api.AssertIsDifferent(circuit.Sender, circuit.Receiver)
+
+	var nonces, balances [nbAccounts]frontend.Variable
+	var nbSenders, nbReceivers frontend.Variable = 0, 0
+	for i := range balances {
+		isSender := api.IsZero(api.Sub(circuit.Sender, i))
+		isReceiver := api.IsZero(api.Sub(circuit.Receiver, i))
+		nbSenders = api.Add(nbSenders, isSender)
+		nbReceivers = api.Add(nbReceivers, isReceiver)
+
+		nonces[i] = api.Add(circuit.Nonces[i], isSender)
+		balances[i] = api.Add(circuit.Balances[i], api.Mul(api.Sub(isReceiver, isSender), circuit.Amount))
+		// the balances don't underflow
+		api.ToBinary(balances[i], 64)
+	}
+	api.AssertIsEqual(nbSenders, 1)
+	api.AssertIsEqual(nbReceivers, 1)
 
+	root, err = merkleRoot(api, nonces[:], balances[:])
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(root, circuit.RootAfter)
 	return nil
 }
 
-func TestVerifier(t *testing.T) {
+// nativeMerkleRoot computes the root of transferCircuit out of the circuit, the
+// balances are reduced modulo r
+func nativeMerkleRoot(nonces []uint64, balances []int64) *big.Int {
+	hashOf := func(a, b fr.Element) (res fr.Element) {
+		h := hash.MIMC_BLS12_377.New()
+		ba, bb := a.Bytes(), b.Bytes()
+		h.Write(ba[:])
+		h.Write(bb[:])
+		res.SetBytes(h.Sum(nil))
+		return
+	}
+	nodes := make([]fr.Element, len(nonces))
+	for i := range nodes {
+		var nonce, balance fr.Element
+		nonce.SetUint64(nonces[i])
+		balance.SetInt64(balances[i])
+		nodes[i] = hashOf(nonce, balance)
+	}
+	for len(nodes) > 1 {
+		for i := 0; i < len(nodes)/2; i++ {
+			nodes[i] = hashOf(nodes[2*i], nodes[2*i+1])
+		}
+		nodes = nodes[:len(nodes)/2]
+	}
+	return nodes[0].ToBigIntRegular(new(big.Int))
+}
 
-	// get the data
-	var innerVk groth16_bls12377.VerifyingKey
-	var innerProof groth16_bls12377.Proof
-	generateBls12377InnerProof(t, &innerVk, &innerProof) // get public inputs of the inner proof
+// rollupVerifierCircuit verifies the proofs of transferCircuit
+type rollupVerifierCircuit struct {
+	InnerProof            Proof
+	RootBefore, RootAfter frontend.Variable `gnark:",public"`
 
-	// create an empty cs
-	var circuit verifierCircuit
-	circuit.InnerVk.G1 = make([]sw_bls12377.G1Affine, len(innerVk.G1.K))
-
-	// create assignment, the private part consists of the proof,
-	// the public part is exactly the public part of the inner proof,
-	// up to the renaming of the inner ONE_WIRE to not conflict with the one wire of the outer proof.
-	var witness verifierCircuit
-	witness.InnerProof.Ar.Assign(&innerProof.Ar)
-	witness.InnerProof.Krs.Assign(&innerProof.Krs)
-	witness.InnerProof.Bs.Assign(&innerProof.Bs)
-
-	// compute vk.e
-	e, err := bls12377.Pair([]bls12377.G1Affine{innerVk.G1.Alpha}, []bls12377.G2Affine{innerVk.G2.Beta})
+	innerVk VerifyingKey
+}
+
+func (circuit *rollupVerifierCircuit) Define(api frontend.API) error {
+	return Verify(api, circuit.innerVk, circuit.InnerProof, []frontend.Variable{circuit.RootBefore, circuit.RootAfter})
+}
+
+func TestRollupVerifier(t *testing.T) {
+	nonces := []uint64{0, 3, 1, 0}
+	balances := []int64{100, 20, 0, 50}
+	rootBefore := nativeMerkleRoot(nonces, balances)
+	// account 1 sends 15 to account 3
+	rootAfter := nativeMerkleRoot([]uint64{0, 4, 1, 0}, []int64{100, 5, 0, 65})
+
+	var transfer transferCircuit
+	for i := range transfer.Nonces {
+		transfer.Nonces[i] = nonces[i]
+		transfer.Balances[i] = balances[i]
+	}
+	transfer.Sender, transfer.Receiver, transfer.Amount = 1, 3, 15
+	transfer.RootBefore, transfer.RootAfter = rootBefore, rootAfter
+
+	// the transfer of more than the balance of the sender is rejected
+	overdraft := transfer
+	overdraft.Amount = 30
+	overdraft.RootAfter = nativeMerkleRoot([]uint64{0, 4, 1, 0}, []int64{100, -10, 0, 80})
+	if err := test.IsSolved(&transferCircuit{}, &overdraft, ecc.BLS12_377, backend.UNKNOWN); err == nil {
+		t.Fatal("transfer of more than the balance accepted")
+	}
+
+	innerVk, innerProofs := generateInnerProofs(t, &transferCircuit{}, &transfer)
+	vk, err := NewVerifyingKey(innerVk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := NewProof(innerProofs[0])
 	if err != nil {
 		t.Fatal(err)
 	}
-	witness.InnerVk.E.Assign(&e)
 
-	witness.InnerVk.G1 = make([]sw_bls12377.G1Affine, len(innerVk.G1.K))
-	for i, vkg := range innerVk.G1.K {
-		witness.InnerVk.G1[i].Assign(&vkg)
+	circuit := rollupVerifierCircuit{innerVk: vk}
+	if err := test.IsSolved(&circuit, &rollupVerifierCircuit{InnerProof: proof, RootBefore: rootBefore, RootAfter: rootAfter}, ecc.BW6_761, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
 	}
-	var deltaNeg, gammaNeg bls12377.G2Affine
-	deltaNeg.Neg(&innerVk.G2.Delta)
-	gammaNeg.Neg(&innerVk.G2.Gamma)
-	witness.InnerVk.G2.DeltaNeg.Assign(&deltaNeg)
-	witness.InnerVk.G2.GammaNeg.Assign(&gammaNeg)
-	//witness.Hash = publicHash
-	witness.Hash = publicHash
-
-	// verifies the cs
-	assert := test.NewAssert(t)
+	if err := test.IsSolved(&circuit, &rollupVerifierCircuit{InnerProof: proof, RootBefore: rootBefore, RootAfter: rootBefore}, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("proof accepted for another root")
+	}
+}
 
-	assert.SolvingSucceeded(&circuit, &witness, test.WithCurves(ecc.BW6_761))
+// universalVerifierCircuit verifies the proofs of any inner circuit with
+// three public inputs
+type universalVerifierCircuit struct {
+	InnerProof Proof
+	InnerVk    VerifyingKey
+	Y, Z, W    frontend.Variable `gnark:",public"`
+}
 
-	/* comment from here */
+func (circuit *universalVerifierCircuit) Define(api frontend.API) error {
+	return Verify(api, circuit.InnerVk, circuit.InnerProof, []frontend.Variable{circuit.Y, circuit.Z, circuit.W})
+}
 
-	// TODO uncommenting the lines below yield incredibly long testing time (due to the setup)
-	// generate groth16 instance on bw6761 (setup, prove, verify)
-	// var vk groth16_bw6761.VerifyingKey
-	// var pk groth16_bw6761.ProvingKey
+func TestUniversalVerifier(t *testing.T) {
+	polyVk, polyProofs := generateInnerProofs(t, &polyCircuit{}, &polyCircuit{X: 3, Y: 35, Z: 21, W: 7})
+	squareVk, squareProofs := generateInnerProofs(t, &squareCircuit{}, &squareCircuit{X: 3, Y: 9, Z: 10, W: 7})
+
+	var vks [2]VerifyingKey
+	var proofs [2]Proof
+	var err error
+	for i, vk := range []groth16.VerifyingKey{polyVk, squareVk} {
+		if vks[i], err = NewVerifyingKey(vk); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i, proof := range []groth16.Proof{polyProofs[0], squareProofs[0]} {
+		if proofs[i], err = NewProof(proof); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-	// groth16_bw6761.Setup(&r1cs, &pk, &vk)
-	// proof, err := groth16_bw6761.Prove(&r1cs, &pk, correctAssignment)
-	// if err != nil {
-	// 	t.Fatal(err)
-	// }
+	circuit := universalVerifierCircuit{InnerVk: NewPlaceholderVerifyingKey(3)}
+	poly := universalVerifierCircuit{InnerProof: proofs[0], InnerVk: vks[0], Y: 35, Z: 21, W: 7}
+	square := universalVerifierCircuit{InnerProof: proofs[1], InnerVk: vks[1], Y: 9, Z: 10, W: 7}
+	for _, w := range []*universalVerifierCircuit{&poly, &square} {
+		if err := test.IsSolved(&circuit, w, ecc.BW6_761, backend.UNKNOWN); err != nil {
+			t.Fatal(err)
+		}
+	}
 
-	// res, err := groth16_bw6761.Verify(proof, &vk, correctAssignment)
-	// if err != nil {
-	// 	t.Fatal(err)
-	// }
-	// if !res {
-	// 	t.Fatal("correct proof should pass")
-	// }
+	mixed := universalVerifierCircuit{InnerProof: proofs[1], InnerVk: vks[0], Y: 9, Z: 10, W: 7}
+	if err := test.IsSolved(&circuit, &mixed, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("proof accepted for the verifying key of another circuit")
+	}
+}
 
+func TestVerifyInvalidKey(t *testing.T) {
+	circuit := universalVerifierCircuit{InnerVk: NewPlaceholderVerifyingKey(2)}
+	if _, err := frontend.Compile(ecc.BW6_761, r1cs.NewBuilder, &circuit); err == nil {
+		t.Fatal("verifying key with the wrong number of points accepted")
+	}
 }
 
 func BenchmarkCompile(b *testing.B) {
 	// get the data
-	var innerVk groth16_bls12377.VerifyingKey
-	var innerProof groth16_bls12377.Proof
-	generateBls12377InnerProof(nil, &innerVk, &innerProof) // get public inputs of the inner proof
+	innerVk, _ := generateInnerProofs(b, &polyCircuit{})
+	vk, err := NewVerifyingKey(innerVk)
+	if err != nil {
+		b.Fatal(err)
+	}
 
 	// create an empty cs
-	var circuit verifierCircuit
-	circuit.InnerVk.G1 = make([]sw_bls12377.G1Affine, len(innerVk.G1.K))
+	circuit := verifierCircuit{innerVk: vk}
 
 	var ccs frontend.CompiledConstraintSystem
 	b.ResetTimer()
@@ -250,9 +410,3 @@ func BenchmarkCompile(b *testing.B) {
 // 	}
 
 // }
-
-var tVariable reflect.Type
-
-func init() {
-	tVariable = reflect.ValueOf(struct{ A frontend.Variable }{}).FieldByName("A").Type()
-}
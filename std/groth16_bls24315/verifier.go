@@ -18,6 +18,8 @@ limitations under the License.
 package groth16_bls24315
 
 import (
+	"fmt"
+
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/std/algebra/fields_bls24315"
 	"github.com/consensys/gnark/std/algebra/sw_bls24315"
@@ -47,8 +49,12 @@ type VerifyingKey struct {
 // Verify implements the verification function of groth16.
 // pubInputNames should what r1cs.PublicInputs() outputs for the inner r1cs.
 // It creates public circuits input, corresponding to the pubInputNames slice.
+// It returns an error if innerVk doesn't have one point per public input plus the one of the constant one wire.
 // Notations and naming are from https://eprint.iacr.org/2020/278.
-func Verify(api frontend.API, innerVk VerifyingKey, innerProof Proof, innerPubInputs []frontend.Variable) {
+func Verify(api frontend.API, innerVk VerifyingKey, innerProof Proof, innerPubInputs []frontend.Variable) error {
+	if len(innerVk.G1) != len(innerPubInputs)+1 {
+		return fmt.Errorf("groth16_bls24315: the verifying key has %d points for %d public inputs, expected %d", len(innerVk.G1), len(innerPubInputs), len(innerPubInputs)+1)
+	}
 
 	// compute psi0 using a sequence of multiexponentiations
 	// TODO maybe implement the bucket method with c=1 when there's a large input set
@@ -56,9 +62,6 @@ func Verify(api frontend.API, innerVk VerifyingKey, innerProof Proof, innerPubIn
 
 	// assign the initial psi0 to the part of the public key corresponding to one_wire
 	// note this assumes ONE_WIRE is at position 0
-	if len(innerVk.G1) == 0 {
-		panic("innver verifying key needs at least one point; VerifyingKey.G1 must be initialized before compiling circuit")
-	}
 	psi0.X = innerVk.G1[0].X
 	psi0.Y = innerVk.G1[0].Y
 
@@ -76,4 +79,5 @@ func Verify(api frontend.API, innerVk VerifyingKey, innerProof Proof, innerPubIn
 	// vk.E must be equal to resPairing
 	innerVk.E.MustBeEqual(api, resPairing)
 
+	return nil
 }
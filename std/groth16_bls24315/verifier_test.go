@@ -79,7 +79,7 @@ func generateBls24315InnerProof(t *testing.T, vk *groth16_bls24315.VerifyingKey,
 
 	// generate the data to return for the bls24315 proof
 	var pk groth16_bls24315.ProvingKey
-	groth16_bls24315.Setup(r1cs.(*backend_bls24315.R1CS), &pk, vk)
+	groth16_bls24315.Setup(r1cs.(*backend_bls24315.R1CS), &pk, vk, backend.SetupConfig{})
 	_proof, err := groth16_bls24315.Prove(r1cs.(*backend_bls24315.R1CS), &pk, correctAssignment, backend.ProverConfig{})
 	if err != nil {
 		t.Fatal(err)
@@ -109,9 +109,7 @@ type verifierCircuit struct {
 func (circuit *verifierCircuit) Define(api frontend.API) error {
 
 	// create the verifier cs
-	Verify(api, circuit.InnerVk, circuit.InnerProof, []frontend.Variable{circuit.Hash})
-
-	return nil
+	return Verify(api, circuit.InnerVk, circuit.InnerProof, []frontend.Variable{circuit.Hash})
 }
 
 func TestVerifier(t *testing.T) {
@@ -179,6 +177,14 @@ func TestVerifier(t *testing.T) {
 
 }
 
+func TestVerifyInvalidKey(t *testing.T) {
+	var circuit verifierCircuit
+	circuit.InnerVk.G1 = make([]sw_bls24315.G1Affine, 3)
+	if _, err := frontend.Compile(ecc.BW6_633, r1cs.NewBuilder, &circuit); err == nil {
+		t.Fatal("verifying key with the wrong number of points accepted")
+	}
+}
+
 func BenchmarkCompile(b *testing.B) {
 	// get the data
 	var innerVk groth16_bls24315.VerifyingKey
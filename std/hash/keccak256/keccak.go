@@ -27,10 +27,7 @@ func (h *Keccak256) Api() frontend.API {
 }
 
 func Keccak256Api(api frontend.API, data ...frontend.Variable) (res frontend.Variable) {
-	keccak256 := newKeccak256(api)
-	keccak256.Reset()
-	keccak256.Write(data[:]...)
-	keccakBytes := keccak256.Sum(nil)
+	keccakBytes := Keccak256Bytes(api, data...)
 	var keccakBits []frontend.Variable
 	for i := len(keccakBytes) - 1; i >= 0; i-- {
 		keccakBits = append(keccakBits, keccakBytes[i][:]...)
@@ -38,6 +35,15 @@ func Keccak256Api(api frontend.API, data ...frontend.Variable) (res frontend.Var
 	return api.FromBinary(keccakBits[:]...)
 }
 
+// Keccak256Bytes returns the 32 bytes of the digest of data, each variable of data being a byte.
+// Unlike Keccak256Api, the digest isn't packed in a single variable, which may not hold 256 bits.
+func Keccak256Bytes(api frontend.API, data ...frontend.Variable) []keccakf2.Xuint8 {
+	keccak256 := newKeccak256(api)
+	keccak256.Reset()
+	keccak256.Write(data[:]...)
+	return keccak256.Sum(nil)
+}
+
 func newKeccak256(api frontend.API) Keccak256 {
 	return Keccak256{
 		dsbyte: keccakf2.ConstUint8(0x01),
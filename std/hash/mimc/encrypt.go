@@ -26,19 +26,23 @@ import (
 	bw6761 "github.com/consensys/gnark-crypto/ecc/bw6-761/fr/mimc"
 
 	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/compiled"
 )
 
 var encryptFuncs map[ecc.ID]func(MiMC, frontend.Variable) frontend.Variable
 var newMimc map[ecc.ID]func(frontend.API) MiMC
 
 func init() {
+	// the round function of each curve is the one of the lazy expansion of the encryption
 	encryptFuncs = make(map[ecc.ID]func(MiMC, frontend.Variable) frontend.Variable)
-	encryptFuncs[ecc.BN254] = encryptPow5
-	encryptFuncs[ecc.BLS12_381] = encryptPow5
-	encryptFuncs[ecc.BLS12_377] = encryptInverse
-	encryptFuncs[ecc.BW6_761] = encryptPow5
-	encryptFuncs[ecc.BW6_633] = encryptPow5
-	encryptFuncs[ecc.BLS24_315] = encryptPow5
+	for id, round := range compiled.MimcRounds {
+		switch round {
+		case compiled.MimcPow5:
+			encryptFuncs[id] = encryptPow5
+		case compiled.MimcInverse:
+			encryptFuncs[id] = encryptInverse
+		}
+	}
 
 	newMimc = make(map[ecc.ID]func(frontend.API) MiMC)
 	newMimc[ecc.BN254] = newMimcBN254
@@ -115,6 +119,15 @@ func pow5(api frontend.API, x frontend.Variable) frontend.Variable {
 	return api.Mul(r, x)
 }
 
+// encrypt encrypts m, recording the constraints of the encryption as lazy constraints if h is lazy
+func (h MiMC) encrypt(m frontend.Variable) frontend.Variable {
+	if h.lazy {
+		v := h.api.AddInternalVariableWithLazy(compiled.MimcEncConstraintsNum(h.id))
+		h.api.AddLazyMimcEnc(m, h.h, v)
+	}
+	return encryptFuncs[h.id](h, m)
+}
+
 // encryptBn256 of a mimc run expressed as r1cs
 // m is the message, k the key
 func encryptPow5(h MiMC, m frontend.Variable) frontend.Variable {
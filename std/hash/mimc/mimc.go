@@ -32,6 +32,7 @@ type MiMC struct {
 	h      frontend.Variable   // current vector in the Miyaguchi–Preneel scheme
 	data   []frontend.Variable // state storage. data is updated when Write() is called. Sum sums the data.
 	api    frontend.API        // underlying constraint system
+	lazy   bool                // whether the encryptions are recorded as lazy constraints
 }
 
 // NewMiMC returns a MiMC instance, than can be used in a gnark circuit
@@ -42,6 +43,15 @@ func NewMiMC(api frontend.API) (MiMC, error) {
 	return MiMC{}, errors.New("unknown curve id")
 }
 
+// NewLazyMiMC returns a MiMC instance whose encryptions are recorded as lazy constraints
+// (see frontend.API.AddLazyMimcEnc), the constraint system must then be lazified to be
+// proven with Groth16, see groth16.SetupLazyWithDump.
+func NewLazyMiMC(api frontend.API) (MiMC, error) {
+	h, err := NewMiMC(api)
+	h.lazy = true
+	return h, err
+}
+
 // Write adds more data to the running hash.
 func (h *MiMC) Write(data ...frontend.Variable) {
 	h.data = append(h.data, data...)
@@ -61,7 +71,7 @@ func (h *MiMC) Sum() frontend.Variable {
 
 	//h.Write(data...)s
 	for _, stream := range h.data {
-		r := encryptFuncs[h.id](*h, stream)
+		r := h.encrypt(stream)
 		h.h = h.api.Add(h.h, r, stream)
 	}
 
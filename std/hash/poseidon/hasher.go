@@ -0,0 +1,123 @@
+package poseidon
+
+import (
+	"errors"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// Hasher is a Poseidon sponge, it implements hash.Hash.
+//
+// The first element of the state is the capacity and the T-1 others are the
+// rate. The message is padded with a 1 and as many 0 as needed to complete the
+// last block, so that it can be of any length, including 0. The output is the
+// second element of the state. NativeHasher computes the same hash out of
+// circuit.
+type Hasher struct {
+	api    frontend.API
+	params *Parameters
+	state  []frontend.Variable // state after the complete blocks written so far
+	data   []frontend.Variable // elements of the current block, fewer than the rate
+}
+
+var errFieldMismatch = errors.New("poseidon: the parameters are not defined over the scalar field of the curve")
+
+// NewHasher returns a Hasher with the given parameters, which must be defined
+// over the scalar field of the curve of api.
+func NewHasher(api frontend.API, params *Parameters) (Hasher, error) {
+	if params.Field.Cmp(api.Compiler().Curve().Info().Fr.Modulus()) != 0 {
+		return Hasher{}, errFieldMismatch
+	}
+	h := Hasher{api: api, params: params}
+	h.Reset()
+	return h, nil
+}
+
+// Write adds more data to the running hash.
+func (h *Hasher) Write(data ...frontend.Variable) {
+	for _, v := range data {
+		h.data = append(h.data, v)
+		if len(h.data) == h.params.T-1 {
+			h.state = h.absorb(h.state, h.data)
+			h.data = nil
+		}
+	}
+}
+
+// Reset resets the Hash to its initial state.
+func (h *Hasher) Reset() {
+	h.state = make([]frontend.Variable, h.params.T)
+	for i := range h.state {
+		h.state[i] = 0
+	}
+	h.data = nil
+}
+
+// Sum returns the hash of the data written since the last Reset. It does not
+// change the state of the hash, further writes extend the message.
+func (h *Hasher) Sum() frontend.Variable {
+	block := make([]frontend.Variable, h.params.T-1)
+	copy(block, h.data)
+	block[len(h.data)] = 1
+	for i := len(h.data) + 1; i < len(block); i++ {
+		block[i] = 0
+	}
+	state := make([]frontend.Variable, len(h.state))
+	copy(state, h.state)
+	return h.absorb(state, block)[1]
+}
+
+// absorb adds the block to the rate and applies the permutation
+func (h *Hasher) absorb(state, block []frontend.Variable) []frontend.Variable {
+	for i := range block {
+		state[i+1] = h.api.Add(state[i+1], block[i])
+	}
+	return h.permutation(state)
+}
+
+func (h *Hasher) permutation(state []frontend.Variable) []frontend.Variable {
+	api, p := h.api, h.params
+	for r := 0; r < p.RF+p.RP; r++ {
+		for j := range state {
+			state[j] = api.Add(state[j], p.RC[r*p.T+j])
+		}
+		if r < p.RF/2 || r >= p.RF/2+p.RP {
+			for j := range state {
+				state[j] = h.sbox(state[j])
+			}
+		} else {
+			state[0] = h.sbox(state[0])
+		}
+
+		// MDS matrix multiply
+		newState := make([]frontend.Variable, len(state))
+		for i := range newState {
+			newState[i] = 0
+			for j := range state {
+				newState[i] = api.Add(newState[i], api.Mul(p.MDS[i][j], state[j]))
+			}
+		}
+		state = newState
+	}
+	return state
+}
+
+// sbox returns x^α, by square and multiply
+func (h *Hasher) sbox(x frontend.Variable) frontend.Variable {
+	res := x
+	for i := bitLen(h.params.Alpha) - 2; i >= 0; i-- {
+		res = h.api.Mul(res, res)
+		if (h.params.Alpha>>i)&1 == 1 {
+			res = h.api.Mul(res, x)
+		}
+	}
+	return res
+}
+
+func bitLen(n int) int {
+	l := 0
+	for ; n != 0; n >>= 1 {
+		l++
+	}
+	return l
+}
@@ -0,0 +1,175 @@
+package poseidon
+
+import (
+	stdhash "hash"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/hash"
+	"github.com/consensys/gnark/std/hash/poseidon/constants"
+	"github.com/consensys/gnark/test"
+)
+
+var (
+	_ hash.Hash    = (*Hasher)(nil)
+	_ stdhash.Hash = (*NativeHasher)(nil)
+)
+
+func TestDefaultParametersBN254(t *testing.T) {
+	for w := 3; w <= 13; w++ {
+		p, err := DefaultParameters(ecc.BN254, w)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Alpha != 5 || len(p.RC) != len(constants.RC[w-3]) {
+			t.Fatalf("width %d: unexpected parameters", w)
+		}
+		for i := range p.RC {
+			if p.RC[i].Cmp(constants.RC[w-3][i]) != 0 {
+				t.Fatalf("width %d: round constant %d differs", w, i)
+			}
+		}
+		for i := range p.MDS {
+			for j := range p.MDS[i] {
+				if p.MDS[i][j].Cmp(constants.MDS[w-3][i][j]) != 0 {
+					t.Fatalf("width %d: MDS[%d][%d] differs", w, i, j)
+				}
+			}
+		}
+	}
+}
+
+func TestPermutationBN254(t *testing.T) {
+	// the permutation of Poseidon(1, 2), see TestPoseidon2
+	p, err := DefaultParameters(ecc.BN254, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := []*big.Int{big.NewInt(0), big.NewInt(1), big.NewInt(2)}
+	p.permutation(state)
+	expected, _ := new(big.Int).SetString("FCA49B798923AB0239DE1C9E7A4A9A2210312B6A2F616D18B5A87F9B628AE29", 16)
+	if state[1].Cmp(expected) != 0 {
+		t.Fatal("permutation differs from the Poseidon function")
+	}
+}
+
+type hasherCircuit struct {
+	Data [5]frontend.Variable
+	// Hashes[i] is the hash of Data[:i]
+	Hashes [6]frontend.Variable `gnark:",public"`
+
+	width int
+}
+
+func (circuit *hasherCircuit) Define(api frontend.API) error {
+	params, err := DefaultParameters(api.Compiler().Curve(), circuit.width)
+	if err != nil {
+		return err
+	}
+	h, err := NewHasher(api, params)
+	if err != nil {
+		return err
+	}
+
+	// streaming
+	api.AssertIsEqual(h.Sum(), circuit.Hashes[0])
+	for i := range circuit.Data {
+		h.Write(circuit.Data[i])
+		api.AssertIsEqual(h.Sum(), circuit.Hashes[i+1])
+	}
+
+	// all at once after a reset
+	h.Reset()
+	h.Write(circuit.Data[:3]...)
+	api.AssertIsEqual(h.Sum(), circuit.Hashes[3])
+	return nil
+}
+
+func TestHasher(t *testing.T) {
+	curves := []ecc.ID{ecc.BN254, ecc.BLS12_377, ecc.BLS12_381, ecc.BW6_761, ecc.BLS24_315, ecc.BW6_633}
+	for _, curve := range curves {
+		for _, width := range []int{3, 5} {
+			params, err := DefaultParameters(curve, width)
+			if err != nil {
+				t.Fatal(err)
+			}
+			native := NewNativeHasher(params)
+
+			witness := hasherCircuit{}
+			witness.Hashes[0] = native.SumElement()
+			for i := range witness.Data {
+				witness.Data[i] = i + 1
+				native.WriteElements(big.NewInt(int64(i + 1)))
+				witness.Hashes[i+1] = native.SumElement()
+			}
+
+			circuit := hasherCircuit{width: width}
+			if err := test.IsSolved(&circuit, &witness, curve, backend.UNKNOWN); err != nil {
+				t.Fatalf("%s, width %d: %v", curve, width, err)
+			}
+			witness.Hashes[0], witness.Hashes[1] = witness.Hashes[1], witness.Hashes[0]
+			if err := test.IsSolved(&circuit, &witness, curve, backend.UNKNOWN); err == nil {
+				t.Fatalf("%s, width %d: wrong hash accepted", curve, width)
+			}
+		}
+	}
+
+	// compiled on both backends
+	params, _ := DefaultParameters(ecc.BN254, 3)
+	native := NewNativeHasher(params)
+	witness := hasherCircuit{}
+	witness.Hashes[0] = native.SumElement()
+	for i := range witness.Data {
+		witness.Data[i] = i + 1
+		native.WriteElements(big.NewInt(int64(i + 1)))
+		witness.Hashes[i+1] = native.SumElement()
+	}
+	assert := test.NewAssert(t)
+	assert.SolvingSucceeded(&hasherCircuit{width: 3}, &witness, test.WithCurves(ecc.BN254))
+}
+
+func TestNativeHasher(t *testing.T) {
+	params, err := DefaultParameters(ecc.BLS12_377, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if params.Alpha != 11 {
+		t.Fatalf("unexpected S-box x^%d", params.Alpha)
+	}
+
+	h := NewNativeHasher(params)
+	elements := make([]byte, 3*h.Size())
+	for i := 0; i < 3; i++ {
+		elements[(i+1)*h.Size()-1] = byte(i + 1)
+	}
+
+	// bytes and elements give the same hash
+	if _, err := h.Write(elements); err != nil {
+		t.Fatal(err)
+	}
+	sum := h.Sum(nil)
+	h.Reset()
+	h.WriteElements(big.NewInt(1), big.NewInt(2), big.NewInt(3))
+	if new(big.Int).SetBytes(sum).Cmp(h.SumElement()) != 0 {
+		t.Fatal("bytes and elements hashes differ")
+	}
+
+	// the padding separates the messages of different lengths
+	h.WriteElements(big.NewInt(1))
+	if new(big.Int).SetBytes(sum).Cmp(h.SumElement()) == 0 {
+		t.Fatal("messages of different lengths have the same hash")
+	}
+
+	if _, err := h.Write(elements[1:]); err == nil {
+		t.Fatal("truncated element accepted")
+	}
+	for i := range elements[:h.Size()] {
+		elements[i] = 0xff
+	}
+	if _, err := h.Write(elements); err == nil {
+		t.Fatal("element larger than the modulus accepted")
+	}
+}
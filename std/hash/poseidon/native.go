@@ -0,0 +1,140 @@
+package poseidon
+
+import (
+	"errors"
+	"math/big"
+)
+
+// NativeHasher computes the hash of Hasher out of circuit, it implements the
+// hash.Hash of the standard library.
+//
+// Write takes the big-endian encodings of field elements on Size() bytes, and
+// Sum appends the encoding of the hash.
+type NativeHasher struct {
+	params *Parameters
+	state  []*big.Int // state after the complete blocks written so far
+	data   []*big.Int // elements of the current block, fewer than the rate
+}
+
+var errInvalidElement = errors.New("poseidon: data must be a sequence of encodings of field elements")
+
+// NewNativeHasher returns a NativeHasher with the given parameters.
+func NewNativeHasher(params *Parameters) *NativeHasher {
+	h := &NativeHasher{params: params}
+	h.Reset()
+	return h
+}
+
+// WriteElements adds field elements to the running hash, they are reduced
+// modulo the field.
+func (h *NativeHasher) WriteElements(elements ...*big.Int) {
+	for _, e := range elements {
+		h.data = append(h.data, new(big.Int).Mod(e, h.params.Field))
+		if len(h.data) == h.params.T-1 {
+			h.absorb(h.state, h.data)
+			h.data = nil
+		}
+	}
+}
+
+// SumElement returns the hash of the data written since the last Reset.
+func (h *NativeHasher) SumElement() *big.Int {
+	block := make([]*big.Int, h.params.T-1)
+	for i := range block {
+		block[i] = new(big.Int)
+	}
+	copy(block, h.data)
+	block[len(h.data)].SetUint64(1)
+	state := make([]*big.Int, len(h.state))
+	for i := range state {
+		state[i] = new(big.Int).Set(h.state[i])
+	}
+	h.absorb(state, block)
+	return state[1]
+}
+
+// Write adds the field elements encoded in p to the running hash.
+func (h *NativeHasher) Write(p []byte) (int, error) {
+	size := h.Size()
+	if len(p)%size != 0 {
+		return 0, errInvalidElement
+	}
+	elements := make([]*big.Int, len(p)/size)
+	for i := range elements {
+		elements[i] = new(big.Int).SetBytes(p[i*size : (i+1)*size])
+		if elements[i].Cmp(h.params.Field) >= 0 {
+			return 0, errInvalidElement
+		}
+	}
+	h.WriteElements(elements...)
+	return len(p), nil
+}
+
+// Sum appends the encoding of the hash of the data written since the last
+// Reset to b. It does not change the state of the hash.
+func (h *NativeHasher) Sum(b []byte) []byte {
+	res := make([]byte, h.Size())
+	h.SumElement().FillBytes(res)
+	return append(b, res...)
+}
+
+// Reset resets the Hash to its initial state.
+func (h *NativeHasher) Reset() {
+	h.state = make([]*big.Int, h.params.T)
+	for i := range h.state {
+		h.state[i] = new(big.Int)
+	}
+	h.data = nil
+}
+
+// Size returns the number of bytes of the encoding of a field element.
+func (h *NativeHasher) Size() int {
+	return (h.params.Field.BitLen() + 7) / 8
+}
+
+// BlockSize returns the number of bytes of the elements absorbed by a permutation.
+func (h *NativeHasher) BlockSize() int {
+	return h.Size() * (h.params.T - 1)
+}
+
+// absorb adds the block to the rate and applies the permutation to state in place
+func (h *NativeHasher) absorb(state, block []*big.Int) {
+	for i := range block {
+		state[i+1].Add(state[i+1], block[i]).Mod(state[i+1], h.params.Field)
+	}
+	h.params.permutation(state)
+}
+
+// permutation applies the Poseidon permutation to state in place
+func (p *Parameters) permutation(state []*big.Int) {
+	alpha := big.NewInt(int64(p.Alpha))
+	newState := make([]*big.Int, len(state))
+	for i := range newState {
+		newState[i] = new(big.Int)
+	}
+	var tmp big.Int
+	for r := 0; r < p.RF+p.RP; r++ {
+		for j := range state {
+			state[j].Add(state[j], p.RC[r*p.T+j])
+		}
+		if r < p.RF/2 || r >= p.RF/2+p.RP {
+			for j := range state {
+				state[j].Exp(state[j], alpha, p.Field)
+			}
+		} else {
+			state[0].Exp(state[0], alpha, p.Field)
+		}
+
+		// MDS matrix multiply
+		for i := range newState {
+			newState[i].SetUint64(0)
+			for j := range state {
+				newState[i].Add(newState[i], tmp.Mul(p.MDS[i][j], state[j]))
+			}
+			newState[i].Mod(newState[i], p.Field)
+		}
+		for i := range state {
+			state[i].Set(newState[i])
+		}
+	}
+}
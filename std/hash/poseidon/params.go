@@ -0,0 +1,205 @@
+package poseidon
+
+import (
+	"fmt"
+	"math/big"
+	"sync"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/std/hash/poseidon/constants"
+)
+
+// Parameters of a Poseidon permutation over a prime field
+type Parameters struct {
+	// Field is the modulus of the field
+	Field *big.Int
+
+	// T is the width of the state, RF and RP the numbers of full and partial rounds
+	T, RF, RP int
+
+	// Alpha is the exponent of the S-box
+	Alpha int
+
+	// RC are the round constants, T for each round
+	RC []*big.Int
+
+	// MDS is the T×T maximum distance separable matrix of the mix layer
+	MDS [][]*big.Int
+}
+
+// NewParameters returns the parameters of width t with rf full and rp partial
+// rounds over the given field, and x^alpha as S-box.
+//
+// The round constants and the MDS matrix are drawn from the Grain LFSR as in the
+// reference script https://extgit.iaik.tugraz.at/krypto/hadeshash/-/blob/master/code/generate_parameters_grain.sage
+// The script also rejects the MDS matrices with infinitely long invariant subspace
+// trails, which is not done here: the MDS matrix is the first Cauchy matrix drawn.
+func NewParameters(field *big.Int, t, rf, rp, alpha int) (*Parameters, error) {
+	if t < 2 || rf < 2 || rf%2 != 0 || rp < 0 {
+		return nil, fmt.Errorf("poseidon: invalid parameters t=%d, RF=%d, RP=%d", t, rf, rp)
+	}
+	var pm1, gcd big.Int
+	pm1.Sub(field, big.NewInt(1))
+	if alpha < 3 || gcd.GCD(nil, nil, big.NewInt(int64(alpha)), &pm1).Cmp(big.NewInt(1)) != 0 {
+		return nil, fmt.Errorf("poseidon: x^%d is not a permutation of the field", alpha)
+	}
+
+	p := &Parameters{
+		Field: new(big.Int).Set(field),
+		T:     t,
+		RF:    rf,
+		RP:    rp,
+		Alpha: alpha,
+		RC:    make([]*big.Int, (rf+rp)*t),
+		MDS:   make([][]*big.Int, t),
+	}
+	n := field.BitLen()
+	g := newGrain(n, t, rf, rp)
+
+	// round constants are rejection-sampled
+	for i := range p.RC {
+		p.RC[i] = g.nextInt(n)
+		for p.RC[i].Cmp(field) >= 0 {
+			p.RC[i] = g.nextInt(n)
+		}
+	}
+
+	// MDS[i][j] = 1 / (x[i] + y[j]) for 2t distinct elements x, y
+	xy := make([]*big.Int, 2*t)
+	for {
+		for i := range xy {
+			xy[i] = g.nextInt(n)
+			xy[i].Mod(xy[i], field)
+		}
+		if distinct(xy) && p.cauchy(xy[:t], xy[t:]) {
+			return p, nil
+		}
+	}
+}
+
+// cauchy sets MDS to the Cauchy matrix of x and y, it returns false if one of
+// the x[i] + y[j] is zero.
+func (p *Parameters) cauchy(x, y []*big.Int) bool {
+	for i := range x {
+		p.MDS[i] = make([]*big.Int, len(y))
+		for j := range y {
+			p.MDS[i][j] = new(big.Int).Add(x[i], y[j])
+			p.MDS[i][j].Mod(p.MDS[i][j], p.Field)
+			if p.MDS[i][j].Sign() == 0 {
+				return false
+			}
+			p.MDS[i][j].ModInverse(p.MDS[i][j], p.Field)
+		}
+	}
+	return true
+}
+
+func distinct(s []*big.Int) bool {
+	for i := range s {
+		for j := 0; j < i; j++ {
+			if s[i].Cmp(s[j]) == 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+var (
+	defaultParams  = make(map[ecc.ID]map[int]*Parameters)
+	defaultParamsM sync.Mutex
+)
+
+// DefaultParameters returns the parameters of width t, 3 ≤ t ≤ 13, over the
+// scalar field of curve.
+//
+// They have 8 full rounds and the numbers of partial rounds of the BN254
+// parameters, which are those of the reference for 128 bits of security with
+// x^5 as S-box. The S-box is x^α for the smallest α ≥ 5 which is a permutation
+// of the field, and the numbers of rounds are conservative for larger fields
+// and exponents. On BN254 the constants are the ones of package constants.
+func DefaultParameters(curve ecc.ID, t int) (*Parameters, error) {
+	if t < 3 || t-3 >= len(constants.RP) {
+		return nil, fmt.Errorf("poseidon: no default parameters of width %d", t)
+	}
+	defaultParamsM.Lock()
+	defer defaultParamsM.Unlock()
+	if p, ok := defaultParams[curve][t]; ok {
+		return p, nil
+	}
+
+	field := curve.Info().Fr.Modulus()
+	var pm1, gcd big.Int
+	pm1.Sub(field, big.NewInt(1))
+	alpha := 5
+	for gcd.GCD(nil, nil, big.NewInt(int64(alpha)), &pm1).Cmp(big.NewInt(1)) != 0 {
+		alpha += 2
+	}
+	p, err := NewParameters(field, t, constants.RF, constants.RP[t-3], alpha)
+	if err != nil {
+		return nil, err
+	}
+	if defaultParams[curve] == nil {
+		defaultParams[curve] = make(map[int]*Parameters)
+	}
+	defaultParams[curve][t] = p
+	return p, nil
+}
+
+// grain is the self-shrinking Grain LFSR of the reference script
+type grain struct {
+	state [80]uint8
+}
+
+// newGrain returns the generator initialized for a prime field of n bits and
+// x^α as S-box
+func newGrain(n, t, rf, rp int) *grain {
+	var g grain
+	i := 0
+	push := func(v, nbBits int) {
+		for j := nbBits - 1; j >= 0; j-- {
+			g.state[i] = uint8((v >> j) & 1)
+			i++
+		}
+	}
+	push(1, 2) // prime field
+	push(0, 4) // x^α
+	push(n, 12)
+	push(t, 12)
+	push(rf, 10)
+	push(rp, 10)
+	for ; i < len(g.state); i++ {
+		g.state[i] = 1
+	}
+	for j := 0; j < 160; j++ {
+		g.clock()
+	}
+	return &g
+}
+
+func (g *grain) clock() uint8 {
+	b := g.state[62] ^ g.state[51] ^ g.state[38] ^ g.state[23] ^ g.state[13] ^ g.state[0]
+	copy(g.state[:], g.state[1:])
+	g.state[len(g.state)-1] = b
+	return b
+}
+
+// nextBit outputs the second bit of the pairs whose first bit is 1
+func (g *grain) nextBit() uint8 {
+	for g.clock() == 0 {
+		g.clock()
+	}
+	return g.clock()
+}
+
+// nextInt returns the integer of the next n bits, most significant first
+func (g *grain) nextInt(n int) *big.Int {
+	res := new(big.Int)
+	for i := 0; i < n; i++ {
+		res.Lsh(res, 1)
+		if g.nextBit() == 1 {
+			res.SetBit(res, 0, 1)
+		}
+	}
+	return res
+}
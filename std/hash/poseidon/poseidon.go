@@ -1,6 +1,7 @@
 package poseidon
 
 import (
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/hint"
 	"github.com/consensys/gnark/frontend/compiled"
 	"github.com/consensys/gnark/std/hash/poseidon/constants"
@@ -75,6 +76,25 @@ func permutation(api frontend.API, state []frontend.Variable) []frontend.Variabl
 	return state
 }
 
+// permutationGadget is the lazy gadget used to record the permutation in sparse constraint systems
+const permutationGadget = "poseidon-permutation"
+
+func init() {
+	frontend.RegisterLazyGadget(permutationGadget, func(api frontend.API, state ...frontend.Variable) []frontend.Variable {
+		return permutation(api, state)
+	})
+}
+
+// lazyPermutation applies the permutation to state and records its constraints as lazy constraints
+func lazyPermutation(api frontend.API, state []frontend.Variable) []frontend.Variable {
+	if api.Compiler().Backend() == backend.PLONK {
+		return api.AddLazyGadget(permutationGadget, state...)
+	}
+	v := api.AddInternalVariableWithLazy(compiled.GetConstraintsNum(state, api))
+	api.AddLazyPoseidon(v, state...)
+	return permutation(api, state)
+}
+
 func preHandleData(api frontend.API, data ...frontend.Variable) []frontend.Variable {
 	// get the self variables by hint, and make sure it is equal to data[i]
 	for i := range data {
@@ -88,6 +108,9 @@ func preHandleData(api frontend.API, data ...frontend.Variable) []frontend.Varia
 	return data
 }
 
+// Poseidon returns the hash of at least 2 inputs with the BN254 constants of package
+// constants, chaining permutations of width 13 for longer inputs. See Hasher for a
+// hash.Hash over the scalar field of any curve.
 func Poseidon(api frontend.API, input ...frontend.Variable) frontend.Variable {
 	input = preHandleData(api, input...)
 	inputLength := len(input)
@@ -108,9 +131,7 @@ func Poseidon(api frontend.API, input ...frontend.Variable) frontend.Variable {
 		for i := 0; i < count; i++ {
 			lastIndex = (i + 1) * maxLength
 			copy(state[1:], input[startIndex:lastIndex])
-			v := api.AddInternalVariableWithLazy(compiled.GetConstraintsNum(state[:], api))
-			api.AddLazyPoseidon(v, state[:]...)
-			state = permutation(api, state)
+			state = lazyPermutation(api, state)
 			startIndex = lastIndex
 		}
 	}
@@ -120,9 +141,7 @@ func Poseidon(api frontend.API, input ...frontend.Variable) frontend.Variable {
 		lastIndex = inputLength
 		remainigLength := lastIndex - startIndex
 		copy(state[1:], input[startIndex:lastIndex])
-		v := api.AddInternalVariableWithLazy(compiled.GetConstraintsNum(state[:remainigLength+1], api))
-		api.AddLazyPoseidon(v, state[:remainigLength+1]...)
-		state = permutation(api, state[:remainigLength+1])
+		state = lazyPermutation(api, state[:remainigLength+1])
 	}
 	return state[1]
 }
@@ -5,6 +5,7 @@ import (
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/frontend"
 	"github.com/consensys/gnark/test"
 )
@@ -163,3 +164,21 @@ func TestPoseidon256(t *testing.T) {
 	wrongWitness.Hash = hash
 	assert.SolvingFailed(&circuit, &wrongWitness, test.WithCurves(ecc.BN254))
 }
+
+type poseidonChainCircuit struct {
+	Hash frontend.Variable `gnark:"data,public"`
+	Data [3]frontend.Variable
+}
+
+func (circuit *poseidonChainCircuit) Define(api frontend.API) error {
+	h := Poseidon(api, circuit.Data[0], circuit.Data[1])
+	h = Poseidon(api, h, circuit.Data[2])
+	api.AssertIsDifferent(h, circuit.Hash)
+	return nil
+}
+
+func TestPoseidonLazify(t *testing.T) {
+	assert := test.NewAssert(t)
+	witness := poseidonChainCircuit{Hash: 42, Data: [3]frontend.Variable{1, 2, 3}}
+	assert.SolvingSucceeded(&poseidonChainCircuit{}, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16), test.WithLazifyCheck())
+}
@@ -0,0 +1,202 @@
+// Package sha256 provides a ZKP-circuit function to compute SHA-256 digests of byte variables,
+// for fixed-length inputs or inputs whose length is only known when solving the circuit.
+package sha256
+
+import (
+	"github.com/consensys/gnark/frontend"
+)
+
+const Size = 256 / 8
+
+const BlockSize = 512 / 8
+
+var initialState = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+var roundConstants = [64]uint32{
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2,
+}
+
+// Sha256 computes the SHA-256 digest of the bytes written to it.
+// Each variable written is a single byte, it is constrained as such.
+type Sha256 struct {
+	api    frontend.API
+	uapi32 *Uint32api
+	data   []frontend.Variable
+}
+
+// New returns a SHA-256 hasher using api
+func New(api frontend.API) *Sha256 {
+	return &Sha256{
+		api:    api,
+		uapi32: NewUint32API(api),
+	}
+}
+
+func (h *Sha256) Api() frontend.API {
+	return h.api
+}
+
+func (h *Sha256) Size() int      { return Size }
+func (h *Sha256) BlockSize() int { return BlockSize }
+
+// Reset empties the data written so far
+func (h *Sha256) Reset() {
+	h.data = nil
+}
+
+// Write appends data to the message, each variable being a byte
+func (h *Sha256) Write(data ...frontend.Variable) {
+	h.data = append(h.data, data...)
+}
+
+// Sum returns the 32 bytes of the digest of all the data written
+func (h *Sha256) Sum() []frontend.Variable {
+	// 0x80, zeros and the length in bits as a 64-bit big-endian integer, up to a multiple of the block size
+	padded := append([]frontend.Variable{}, h.data...)
+	padded = append(padded, 0x80)
+	for (len(padded)+8)%BlockSize != 0 {
+		padded = append(padded, 0)
+	}
+	bitLen := uint64(len(h.data)) * 8
+	for i := 7; i >= 0; i-- {
+		padded = append(padded, (bitLen>>(8*i))&0xff)
+	}
+
+	state := h.initialState()
+	for i := 0; i < len(padded); i += BlockSize {
+		state = h.compress(state, padded[i:i+BlockSize])
+	}
+	return h.digest(state)
+}
+
+// SumVariableLength returns the 32 bytes of the digest of the first length bytes of the data written.
+// length is a variable which must be at most the number of bytes written, the circuit size only
+// depends on the latter.
+func (h *Sha256) SumVariableLength(length frontend.Variable) []frontend.Variable {
+	api := h.api
+	maxLen := len(h.data)
+	nbBlocks := (maxLen + 8 + BlockSize) / BlockSize
+
+	// isEnd[i] = 1 iff i = length, exactly one of them is set so that length <= maxLen
+	isEnd := make([]frontend.Variable, maxLen+1)
+	var sumEnd frontend.Variable = 0
+	for i := range isEnd {
+		isEnd[i] = api.IsZero(api.Sub(length, i))
+		sumEnd = api.Add(sumEnd, isEnd[i])
+	}
+	api.AssertIsEqual(sumEnd, 1)
+
+	// the message, then 0x80 at index length and zeros
+	padded := make([]frontend.Variable, nbBlocks*BlockSize)
+	var afterEnd frontend.Variable = 0
+	for i := range padded {
+		if i > maxLen {
+			padded[i] = 0
+			continue
+		}
+		if i < maxLen {
+			// h.data[i] if i < length
+			padded[i] = api.Mul(api.Sub(1, afterEnd, isEnd[i]), h.data[i])
+		} else {
+			padded[i] = 0
+		}
+		padded[i] = api.Add(padded[i], api.Mul(isEnd[i], 0x80))
+		afterEnd = api.Add(afterEnd, isEnd[i])
+	}
+
+	// the last block is the one whose last 8 bytes are after the 0x80 byte: isLast[k] = 1 iff
+	// k = ⌊(length + 8) / 64⌋, then the length in bits is added in its last 8 bytes
+	isLast := make([]frontend.Variable, nbBlocks)
+	for k := range isLast {
+		isLast[k] = 0
+	}
+	for i := range isEnd {
+		k := (i + 8) / BlockSize
+		isLast[k] = api.Add(isLast[k], isEnd[i])
+	}
+	bitLen := h.api.ToBinary(api.Mul(length, 8), 64)
+	for k := range isLast {
+		for j := 0; j < 8; j++ {
+			b := api.FromBinary(bitLen[8*(7-j) : 8*(8-j)]...)
+			idx := (k+1)*BlockSize - 8 + j
+			padded[idx] = api.Add(padded[idx], api.Mul(isLast[k], b))
+		}
+	}
+
+	// the digest is the state after the last block
+	var res [8]Xuint32
+	for i := range res {
+		for j := range res[i] {
+			res[i][j] = 0
+		}
+	}
+	state := h.initialState()
+	for k := 0; k < nbBlocks; k++ {
+		state = h.compress(state, padded[k*BlockSize:(k+1)*BlockSize])
+		for i := range res {
+			for j := range res[i] {
+				res[i][j] = api.Add(res[i][j], api.Mul(isLast[k], state[i][j]))
+			}
+		}
+	}
+	return h.digest(res)
+}
+
+func (h *Sha256) initialState() [8]Xuint32 {
+	var state [8]Xuint32
+	for i := range state {
+		state[i] = ConstUint32(initialState[i])
+	}
+	return state
+}
+
+func (h *Sha256) digest(state [8]Xuint32) []frontend.Variable {
+	res := make([]frontend.Variable, 0, Size)
+	for i := range state {
+		res = append(res, h.uapi32.ToBytes(state[i])...)
+	}
+	return res
+}
+
+// compress applies the compression function of SHA-256 to state and the 64 bytes of block
+func (h *Sha256) compress(state [8]Xuint32, block []frontend.Variable) [8]Xuint32 {
+	u := h.uapi32
+
+	// message schedule
+	var w [64]Xuint32
+	for i := 0; i < 16; i++ {
+		w[i] = u.AsUint32FromBytes(block[4*i : 4*i+4]...)
+	}
+	for i := 16; i < 64; i++ {
+		s0 := u.Xor(u.Rrot(w[i-15], 7), u.Rrot(w[i-15], 18), u.Rshift(w[i-15], 3))
+		s1 := u.Xor(u.Rrot(w[i-2], 17), u.Rrot(w[i-2], 19), u.Rshift(w[i-2], 10))
+		w[i] = u.Add(w[i-16], s0, w[i-7], s1)
+	}
+
+	a, b, c, d, e, f, g, hh := state[0], state[1], state[2], state[3], state[4], state[5], state[6], state[7]
+	for i := 0; i < 64; i++ {
+		s1 := u.Xor(u.Rrot(e, 6), u.Rrot(e, 11), u.Rrot(e, 25))
+		t1 := u.Add(hh, s1, u.Ch(e, f, g), ConstUint32(roundConstants[i]), w[i])
+		s0 := u.Xor(u.Rrot(a, 2), u.Rrot(a, 13), u.Rrot(a, 22))
+		t2 := u.Add(s0, u.Maj(a, b, c))
+
+		hh, g, f = g, f, e
+		e = u.Add(d, t1)
+		d, c, b = c, b, a
+		a = u.Add(t1, t2)
+	}
+
+	return [8]Xuint32{
+		u.Add(state[0], a), u.Add(state[1], b), u.Add(state[2], c), u.Add(state[3], d),
+		u.Add(state[4], e), u.Add(state[5], f), u.Add(state[6], g), u.Add(state[7], hh),
+	}
+}
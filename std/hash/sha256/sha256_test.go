@@ -0,0 +1,99 @@
+package sha256
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+type sha256Circuit struct {
+	Data     []frontend.Variable
+	Expected [Size]frontend.Variable `gnark:",public"`
+}
+
+func (c *sha256Circuit) Define(api frontend.API) error {
+	h := New(api)
+	h.Write(c.Data...)
+	res := h.Sum()
+	for i := range res {
+		api.AssertIsEqual(res[i], c.Expected[i])
+	}
+	return nil
+}
+
+func newSha256Circuit(data []byte, expected []byte) *sha256Circuit {
+	c := sha256Circuit{Data: make([]frontend.Variable, len(data))}
+	for i := range data {
+		c.Data[i] = data[i]
+	}
+	for i := range expected {
+		c.Expected[i] = expected[i]
+	}
+	return &c
+}
+
+func TestSha256(t *testing.T) {
+	assert := test.NewAssert(t)
+	msgs := []string{"abc"}
+	if !testing.Short() {
+		msgs = append(msgs, "", "gnark: a framework to execute (and verify) algorithms in zero-knowledge")
+	}
+	for _, msg := range msgs {
+		digest := sha256.Sum256([]byte(msg))
+		circuit := newSha256Circuit([]byte(msg), nil)
+		assert.ProverSucceeded(circuit, newSha256Circuit([]byte(msg), digest[:]),
+			test.WithBackends(backend.GROTH16, backend.PLONK), test.WithCurves(ecc.BN254))
+	}
+
+	digest := sha256.Sum256([]byte("abd"))
+	assert.ProverFailed(newSha256Circuit([]byte("abc"), nil), newSha256Circuit([]byte("abc"), digest[:]),
+		test.WithBackends(backend.GROTH16, backend.PLONK), test.WithCurves(ecc.BN254))
+}
+
+type sha256VarCircuit struct {
+	Data     []frontend.Variable
+	Length   frontend.Variable
+	Expected [Size]frontend.Variable `gnark:",public"`
+}
+
+func (c *sha256VarCircuit) Define(api frontend.API) error {
+	h := New(api)
+	h.Write(c.Data...)
+	res := h.SumVariableLength(c.Length)
+	for i := range res {
+		api.AssertIsEqual(res[i], c.Expected[i])
+	}
+	return nil
+}
+
+func TestSha256VariableLength(t *testing.T) {
+	assert := test.NewAssert(t)
+	data := []byte("the length of the message is only known when solving the circuit")
+	circuit := sha256VarCircuit{Data: make([]frontend.Variable, len(data))}
+
+	// the padding fits in the block of the message or requires an additional block
+	for _, length := range []int{0, 3, 55, 56, len(data)} {
+		digest := sha256.Sum256(data[:length])
+		witness := sha256VarCircuit{Data: make([]frontend.Variable, len(data)), Length: length}
+		for i := range data {
+			witness.Data[i] = data[i]
+		}
+		for i := range digest {
+			witness.Expected[i] = digest[i]
+		}
+		assert.NoError(test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN))
+
+		if length == 56 {
+			assert.SolvingSucceeded(&circuit, &witness, test.WithBackends(backend.GROTH16, backend.PLONK), test.WithCurves(ecc.BN254))
+		}
+		if length == len(data) {
+			// too long
+			witness.Length = length + 1
+			assert.Error(test.IsSolved(&circuit, &witness, ecc.BN254, backend.UNKNOWN))
+		}
+	}
+}
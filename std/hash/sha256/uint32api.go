@@ -0,0 +1,158 @@
+package sha256
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// Uint32api performs binary and modular operations on Xuint32 variables,
+// like keccakf.Uint64api does for 64-bit words.
+type Uint32api struct {
+	api frontend.API
+}
+
+func NewUint32API(api frontend.API) *Uint32api {
+	return &Uint32api{
+		api: api,
+	}
+}
+
+// Xuint32 represents 32-bit unsigned integer by its bits in little-endian order.
+// We use this type to ensure that we work over constrained bits.
+type Xuint32 [32]frontend.Variable
+
+func ConstUint32(a uint32) Xuint32 {
+	var res Xuint32
+	for i := 0; i < 32; i++ {
+		res[i] = (a >> i) & 1
+	}
+	return res
+}
+
+// AsUint32FromBytes returns the word whose big-endian bytes are in, as in SHA-256
+func (w *Uint32api) AsUint32FromBytes(in ...frontend.Variable) Xuint32 {
+	if len(in) != 4 {
+		panic("expecting 4 bytes")
+	}
+	var res Xuint32
+	for i := range in {
+		b := bits.ToBinary(w.api, in[i], bits.WithNbDigits(8))
+		copy(res[8*(3-i):], b)
+	}
+	return res
+}
+
+func (w *Uint32api) AsUint32(in frontend.Variable) Xuint32 {
+	bits := bits.ToBinary(w.api, in, bits.WithNbDigits(32))
+	var res Xuint32
+	copy(res[:], bits)
+	return res
+}
+
+func (w *Uint32api) FromUint32(in Xuint32) frontend.Variable {
+	return bits.FromBinary(w.api, in[:], bits.WithUnconstrainedInputs())
+}
+
+// ToBytes returns the big-endian bytes of in
+func (w *Uint32api) ToBytes(in Xuint32) []frontend.Variable {
+	res := make([]frontend.Variable, 4)
+	for i := range res {
+		res[i] = bits.FromBinary(w.api, in[8*(3-i):8*(4-i)], bits.WithUnconstrainedInputs())
+	}
+	return res
+}
+
+// Add returns the sum of the inputs modulo 2³²
+func (w *Uint32api) Add(in ...Xuint32) Xuint32 {
+	var sum frontend.Variable = 0
+	for _, v := range in {
+		sum = w.api.Add(sum, w.FromUint32(v))
+	}
+	// the carries take at most log2(len(in)) bits
+	nbCarryBits := 0
+	for n := len(in) - 1; n > 0; n >>= 1 {
+		nbCarryBits++
+	}
+	sumBits := bits.ToBinary(w.api, sum, bits.WithNbDigits(32+nbCarryBits))
+	var res Xuint32
+	copy(res[:], sumBits)
+	return res
+}
+
+func (w *Uint32api) And(in ...Xuint32) Xuint32 {
+	var res Xuint32
+	for i := range res {
+		res[i] = 1
+	}
+	for i := range res {
+		for _, v := range in {
+			res[i] = w.api.And(res[i], v[i])
+		}
+	}
+	return res
+}
+
+func (w *Uint32api) Xor(in ...Xuint32) Xuint32 {
+	var res Xuint32
+	for i := range res {
+		res[i] = 0
+	}
+	for i := range res {
+		for _, v := range in {
+			res[i] = w.api.Xor(res[i], v[i])
+		}
+	}
+	return res
+}
+
+func (w *Uint32api) Not(in Xuint32) Xuint32 {
+	var res Xuint32
+	for i := range res {
+		res[i] = w.api.Sub(1, in[i])
+	}
+	return res
+}
+
+// Rrot rotates in to the right by shift bits
+func (w *Uint32api) Rrot(in Xuint32, shift int) Xuint32 {
+	var res Xuint32
+	for i := range res {
+		res[i] = in[(i+shift)%32]
+	}
+	return res
+}
+
+// Rshift shifts in to the right by shift bits
+func (w *Uint32api) Rshift(in Xuint32, shift int) Xuint32 {
+	var res Xuint32
+	for i := range res {
+		if i+shift < 32 {
+			res[i] = in[i+shift]
+		} else {
+			res[i] = 0
+		}
+	}
+	return res
+}
+
+// Ch returns the bitwise choice e ? f : g, which costs a single constraint per bit
+func (w *Uint32api) Ch(e, f, g Xuint32) Xuint32 {
+	var res Xuint32
+	for i := range res {
+		// g + e·(f - g)
+		res[i] = w.api.Add(g[i], w.api.Mul(e[i], w.api.Sub(f[i], g[i])))
+	}
+	return res
+}
+
+// Maj returns the bitwise majority of a, b and c
+func (w *Uint32api) Maj(a, b, c Xuint32) Xuint32 {
+	var res Xuint32
+	for i := range res {
+		// a·b + c·(a ⊕ b), where a ⊕ b = a + b - 2·a·b
+		ab := w.api.Mul(a[i], b[i])
+		xor := w.api.Sub(w.api.Add(a[i], b[i]), w.api.Add(ab, ab))
+		res[i] = w.api.Add(ab, w.api.Mul(c[i], xor))
+	}
+	return res
+}
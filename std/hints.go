@@ -8,6 +8,8 @@ import (
 	"github.com/consensys/gnark/std/algebra/sw_bls12377"
 	"github.com/consensys/gnark/std/algebra/sw_bls24315"
 	"github.com/consensys/gnark/std/math/bits"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/std/signature/bls"
 )
 
 var registerOnce sync.Once
@@ -24,9 +26,14 @@ func registerHints() {
 	// note that importing these packages may already triggers a call to hint.Register(...)
 	hint.Register(sw_bls24315.DecomposeScalar)
 	hint.Register(sw_bls12377.DecomposeScalar)
+	hint.Register(sw_bls12377.DecomposeScalarSigned)
 	hint.Register(bits.NTrits)
 	hint.Register(bits.NNAF)
 	hint.Register(bits.IthBit)
 	hint.Register(bits.NBits)
 	hint.Register(mod.BigMulModP)
+	hint.Register(bls.SqrtRatio)
+	for _, h := range emulated.GetHints() {
+		hint.Register(h)
+	}
 }
@@ -0,0 +1,30 @@
+// Package emulated implements arithmetic over a field which isn't the native field of the circuit.
+//
+// An element of the emulated field is stored as NbLimbs limbs of BitsPerLimb bits each, in little-endian
+// order, every limb being a native variable. Additions and subtractions are done limb-wise without carries,
+// so the limbs of the result may exceed BitsPerLimb bits: each Element records this overflow, and the
+// Field reduces its operands only when the next operation could wrap around the native modulus.
+//
+// Multiplications and reductions compute the quotient and remainder of the division by the emulated
+// modulus p in a hint, and check that the integers a·b and q·p + r are equal by comparing their limbs
+// with carries. Unlike frontend.API.MulModP, the intermediate values don't have to fit in the native
+// field.
+//
+// The modulus is described by a FieldParams type parameter, predefined ones include Secp256k1, BN254Fp
+// and Ed25519. For example, with a circuit defined over BLS12-377:
+//
+//	type Circuit struct {
+//		A, B, C emulated.Element[emulated.BN254Fp]
+//	}
+//
+//	func (c *Circuit) Define(api frontend.API) error {
+//		f, err := emulated.NewField[emulated.BN254Fp](api)
+//		if err != nil {
+//			return err
+//		}
+//		f.AssertIsEqual(f.Mul(&c.A, &c.B), &c.C)
+//		return nil
+//	}
+//
+// The limbs of an Element of a circuit must be allocated before compiling it, see NewElement.
+package emulated
@@ -0,0 +1,45 @@
+package emulated
+
+import (
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/internal/utils"
+)
+
+// Element is an element of the emulated field described by T
+type Element[T FieldParams] struct {
+	// Limbs of the element in little-endian order
+	Limbs []frontend.Variable
+
+	// overflow is the number of bits by which the limbs may exceed BitsPerLimb
+	overflow uint
+
+	// internal is set if the width of the limbs is constrained, which isn't the case of the inputs
+	// of a circuit
+	internal bool
+}
+
+// NewElement returns the element of value v, reduced modulo the field modulus.
+//
+// If v is nil, the limbs are allocated but left unset, as needed to declare an Element in a circuit
+// before compiling it.
+func NewElement[T FieldParams](v interface{}) Element[T] {
+	var fParams T
+	limbs := make([]frontend.Variable, fParams.NbLimbs())
+	if v == nil {
+		return Element[T]{Limbs: limbs}
+	}
+
+	value := utils.FromInterface(v)
+	value.Mod(&value, fParams.Modulus())
+	bigLimbs := make([]*big.Int, len(limbs))
+	for i := range bigLimbs {
+		bigLimbs[i] = new(big.Int)
+	}
+	decompose(&value, fParams.BitsPerLimb(), bigLimbs)
+	for i := range limbs {
+		limbs[i] = bigLimbs[i]
+	}
+	return Element[T]{Limbs: limbs, internal: true}
+}
@@ -0,0 +1,444 @@
+package emulated
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	mathbits "math/bits"
+
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+)
+
+// Field performs the arithmetic of the emulated field T in a circuit.
+//
+// The elements returned by its methods have constrained limbs. The elements given as inputs of the
+// circuit have their limbs constrained the first time they are used by the Field.
+type Field[T FieldParams] struct {
+	api     frontend.API
+	fParams T
+
+	// inputs whose limbs are constrained, the elements themselves aren't marked so that the
+	// circuit can be compiled again
+	checked map[*Element[T]]struct{}
+
+	// limbs of the modulus
+	pLimbs []*big.Int
+
+	// maxCoeffBits is the largest width of the coefficients compared by assertLimbsEquality,
+	// so that no intermediate value wraps around the native modulus
+	maxCoeffBits uint
+
+	// maxOverflow is the largest overflow of the limbs of an element
+	maxOverflow uint
+}
+
+// NewField returns a Field performing the arithmetic of T with the native api
+func NewField[T FieldParams](native frontend.API) (*Field[T], error) {
+	var fParams T
+	w, n := fParams.BitsPerLimb(), fParams.NbLimbs()
+	if w == 0 || n == 0 {
+		return nil, errors.New("emulated: elements must have limbs")
+	}
+	p := fParams.Modulus()
+	if p.Sign() <= 0 || p.BitLen() > int(w*n) {
+		return nil, fmt.Errorf("emulated: modulus of %d bits doesn't fit in %d limbs of %d bits", p.BitLen(), n, w)
+	}
+
+	f := &Field[T]{
+		api:          native,
+		fParams:      fParams,
+		pLimbs:       make([]*big.Int, n),
+		checked:      make(map[*Element[T]]struct{}),
+		maxCoeffBits: uint(native.Compiler().Curve().Info().Fr.Bits) - 3,
+	}
+	for i := range f.pLimbs {
+		f.pLimbs[i] = new(big.Int)
+	}
+	decompose(p, w, f.pLimbs)
+
+	// the product of two reduced elements and q·p must be comparable
+	if f.mulCoeffBits(0, 0)+1 > f.maxCoeffBits {
+		return nil, fmt.Errorf("emulated: limbs of %d bits are too large for the native field", w)
+	}
+	f.maxOverflow = f.maxCoeffBits - w
+
+	return f, nil
+}
+
+// Zero returns the element 0
+func (f *Field[T]) Zero() *Element[T] {
+	e := NewElement[T](0)
+	return &e
+}
+
+// One returns the element 1
+func (f *Field[T]) One() *Element[T] {
+	e := NewElement[T](1)
+	return &e
+}
+
+// Add returns a + b, its limbs overflow by one more bit than the ones of a and b
+func (f *Field[T]) Add(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	for maxUint(a.overflow, b.overflow)+1 > f.maxOverflow {
+		a, b = f.reduceLargest(a, b)
+	}
+
+	limbs := make([]frontend.Variable, len(a.Limbs))
+	for i := range limbs {
+		limbs[i] = f.api.Add(a.Limbs[i], b.Limbs[i])
+	}
+	return &Element[T]{Limbs: limbs, overflow: maxUint(a.overflow, b.overflow) + 1, internal: true}
+}
+
+// Sub returns a - b, computed as a + k·p - b for a multiple k·p whose limbs are larger than the ones of b
+func (f *Field[T]) Sub(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	for maxUint(a.overflow, b.overflow+1)+1 > f.maxOverflow {
+		a, b = f.reduceLargest(a, b)
+	}
+
+	padding := f.subPadding(b.overflow)
+	limbs := make([]frontend.Variable, len(a.Limbs))
+	for i := range limbs {
+		limbs[i] = f.api.Sub(f.api.Add(a.Limbs[i], padding[i]), b.Limbs[i])
+	}
+	return &Element[T]{Limbs: limbs, overflow: maxUint(a.overflow, b.overflow+1) + 1, internal: true}
+}
+
+// Neg returns -a
+func (f *Field[T]) Neg(a *Element[T]) *Element[T] {
+	return f.Sub(f.Zero(), a)
+}
+
+// Mul returns a * b, reduced
+func (f *Field[T]) Mul(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	for f.mulCoeffBits(a.overflow, b.overflow) > f.maxCoeffBits {
+		a, b = f.reduceLargest(a, b)
+	}
+
+	// coefficients of the product of the polynomials of limbs
+	coeffs := make([]frontend.Variable, 2*len(a.Limbs)-1)
+	for i := range coeffs {
+		coeffs[i] = 0
+	}
+	for i := range a.Limbs {
+		for j := range b.Limbs {
+			coeffs[i+j] = f.api.Add(coeffs[i+j], f.api.Mul(a.Limbs[i], b.Limbs[j]))
+		}
+	}
+
+	valueBits := 2*f.nbBits() + a.overflow + b.overflow
+	return f.reduceCoeffs(coeffs, f.mulCoeffBits(a.overflow, b.overflow), valueBits, false)
+}
+
+// Reduce returns an element equal to a modulo p whose limbs don't overflow.
+// Its value is less than 2^(NbLimbs·BitsPerLimb) but not necessarily less than p.
+func (f *Field[T]) Reduce(a *Element[T]) *Element[T] {
+	f.enforceWidth(a)
+	if a.overflow == 0 {
+		return a
+	}
+	w := f.fParams.BitsPerLimb()
+	return f.reduceCoeffs(a.Limbs, w+a.overflow, f.nbBits()+a.overflow, false)
+}
+
+// Inverse returns 1 / a, the proof fails if a = 0 mod p
+func (f *Field[T]) Inverse(a *Element[T]) *Element[T] {
+	f.enforceWidth(a)
+	res := f.newHintElement(InverseHint, append(f.hintModulus(), a.Limbs...)...)
+	f.AssertIsEqual(f.Mul(a, res), f.One())
+	return res
+}
+
+// Div returns a / b, the proof fails if b = 0 mod p unless a = 0 mod p too, in which case the result
+// is unconstrained. Use Inverse if b must be checked.
+func (f *Field[T]) Div(a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	inputs := append(f.hintModulus(), a.Limbs...)
+	res := f.newHintElement(DivHint, append(inputs, b.Limbs...)...)
+	f.AssertIsEqual(f.Mul(res, b), a)
+	return res
+}
+
+// Sqrt returns a square root of a, the proof fails if a isn't a square modulo p.
+// Which of the two roots is returned is up to the prover.
+func (f *Field[T]) Sqrt(a *Element[T]) *Element[T] {
+	f.enforceWidth(a)
+	res := f.newHintElement(SqrtHint, append(f.hintModulus(), a.Limbs...)...)
+	f.AssertIsEqual(f.Mul(res, res), a)
+	return res
+}
+
+// Select returns a if sel is true and b otherwise, sel must be boolean
+func (f *Field[T]) Select(sel frontend.Variable, a, b *Element[T]) *Element[T] {
+	f.enforceWidth(a, b)
+	limbs := make([]frontend.Variable, len(a.Limbs))
+	for i := range limbs {
+		limbs[i] = f.api.Select(sel, a.Limbs[i], b.Limbs[i])
+	}
+	return &Element[T]{Limbs: limbs, overflow: maxUint(a.overflow, b.overflow), internal: true}
+}
+
+// AssertIsEqual fails if a != b mod p
+func (f *Field[T]) AssertIsEqual(a, b *Element[T]) {
+	diff := f.Sub(a, b)
+	w := f.fParams.BitsPerLimb()
+	f.reduceCoeffs(diff.Limbs, w+diff.overflow, f.nbBits()+diff.overflow, true)
+}
+
+// ToBits returns the NbLimbs·BitsPerLimb bits of the reduced a in little-endian order,
+// as a isn't fully reduced they may encode a + k·p
+func (f *Field[T]) ToBits(a *Element[T]) []frontend.Variable {
+	a = f.Reduce(a)
+	w := int(f.fParams.BitsPerLimb())
+	res := make([]frontend.Variable, 0, len(a.Limbs)*w)
+	for _, l := range a.Limbs {
+		res = append(res, f.api.ToBinary(l, w)...)
+	}
+	return res
+}
+
+// ToCanonicalBits returns the NbLimbs·BitsPerLimb bits in little-endian order of the unique representative
+// of a in [0, p)
+func (f *Field[T]) ToCanonicalBits(a *Element[T]) []frontend.Variable {
+	// a is reduced even if its limbs don't overflow, as its value may still be larger than p, e.g. when
+	// it's built from bits
+	f.enforceWidth(a)
+	w := f.fParams.BitsPerLimb()
+	a = f.reduceCoeffs(a.Limbs, w+a.overflow, f.nbBits()+a.overflow, false)
+	res := f.ToBits(a)
+	f.assertBitsLessOrEqual(res, new(big.Int).Sub(f.fParams.Modulus(), big.NewInt(1)))
+	return res
+}
+
+// FromBits returns the element whose bits in little-endian order are bs, there can't be more than
+// NbLimbs·BitsPerLimb of them
+func (f *Field[T]) FromBits(bs ...frontend.Variable) *Element[T] {
+	w, n := int(f.fParams.BitsPerLimb()), int(f.fParams.NbLimbs())
+	if len(bs) > n*w {
+		panic(fmt.Sprintf("emulated: %d bits don't fit in %d limbs of %d bits", len(bs), n, w))
+	}
+	limbs := make([]frontend.Variable, n)
+	for i := range limbs {
+		start, end := i*w, (i+1)*w
+		if end > len(bs) {
+			end = len(bs)
+		}
+		if start >= end {
+			limbs[i] = 0
+			continue
+		}
+		limbs[i] = f.api.FromBinary(bs[start:end]...)
+	}
+	return &Element[T]{Limbs: limbs, internal: true}
+}
+
+// assertBitsLessOrEqual checks that the integer whose boolean bits in little-endian order are bs is at most bound
+func (f *Field[T]) assertBitsLessOrEqual(bs []frontend.Variable, bound *big.Int) {
+	// eq is 1 as long as the bits processed from the top are the ones of the bound; wherever the
+	// bound has a 0 bit, the bit of bs must be 0 too if all the bits above are equal
+	var eq frontend.Variable = 1
+	for i := len(bs) - 1; i >= 0; i-- {
+		if bound.Bit(i) == 1 {
+			eq = f.api.Mul(eq, bs[i])
+		} else {
+			f.api.AssertIsEqual(f.api.Mul(eq, bs[i]), 0)
+		}
+	}
+}
+
+// enforceWidth constrains the limbs of the elements which weren't created by the Field to BitsPerLimb bits
+func (f *Field[T]) enforceWidth(elements ...*Element[T]) {
+	w := f.fParams.BitsPerLimb()
+	for _, e := range elements {
+		if len(e.Limbs) != int(f.fParams.NbLimbs()) {
+			panic(fmt.Sprintf("emulated: element has %d limbs, expected %d", len(e.Limbs), f.fParams.NbLimbs()))
+		}
+		if _, ok := f.checked[e]; ok || e.internal {
+			continue
+		}
+		for _, l := range e.Limbs {
+			f.rangeCheck(l, w)
+		}
+		f.checked[e] = struct{}{}
+	}
+}
+
+// reduceLargest reduces the operand whose limbs overflow the most
+func (f *Field[T]) reduceLargest(a, b *Element[T]) (*Element[T], *Element[T]) {
+	if a.overflow >= b.overflow {
+		return f.Reduce(a), b
+	}
+	return a, f.Reduce(b)
+}
+
+// newHintElement returns the element whose limbs are computed by the hint h, and constrains them to BitsPerLimb bits
+func (f *Field[T]) newHintElement(h hint.Function, inputs ...frontend.Variable) *Element[T] {
+	limbs, err := f.api.Compiler().NewHint(h, int(f.fParams.NbLimbs()), inputs...)
+	if err != nil {
+		panic(err)
+	}
+	for _, l := range limbs {
+		f.rangeCheck(l, f.fParams.BitsPerLimb())
+	}
+	return &Element[T]{Limbs: limbs, internal: true}
+}
+
+// reduceCoeffs returns the remainder r of the division by p of v = Σ coeffs[i]·2^(w·i), where v is less than
+// 2^valueBits and the coefficients are less than 2^coeffBits, and constrains v = q·p + r.
+// If zero is set, r is constrained to 0 and nil is returned.
+func (f *Field[T]) reduceCoeffs(coeffs []frontend.Variable, coeffBits, valueBits uint, zero bool) *Element[T] {
+	w, n := f.fParams.BitsPerLimb(), int(f.fParams.NbLimbs())
+
+	// q < 2^valueBits / 2^(|p|-1)
+	nbQuoBits := int(valueBits) - f.fParams.Modulus().BitLen() + 1
+	nbQuoLimbs := 1
+	if nbQuoBits > int(w) {
+		nbQuoLimbs = (nbQuoBits + int(w) - 1) / int(w)
+	}
+
+	inputs := append(append(f.hintModulus(), nbQuoLimbs), coeffs...)
+	nbOutputs := nbQuoLimbs + n
+	if zero {
+		nbOutputs = nbQuoLimbs
+	}
+	res, err := f.api.Compiler().NewHint(RemHint, nbOutputs, inputs...)
+	if err != nil {
+		panic(err)
+	}
+	q, r := res[:nbQuoLimbs], res[nbQuoLimbs:]
+	for _, l := range q {
+		f.rangeCheck(l, w)
+	}
+	for _, l := range r {
+		f.rangeCheck(l, w)
+	}
+
+	// q·p + r
+	rhs := make([]frontend.Variable, nbQuoLimbs+n-1)
+	for i := range rhs {
+		rhs[i] = 0
+	}
+	for i := range q {
+		for j := range f.pLimbs {
+			rhs[i+j] = f.api.Add(rhs[i+j], f.api.Mul(q[i], f.pLimbs[j]))
+		}
+	}
+	for i := range r {
+		rhs[i] = f.api.Add(rhs[i], r[i])
+	}
+	rhsBits := 2*w + uint(mathbits.Len(uint(minInt(nbQuoLimbs, n)))) + 1
+
+	f.assertLimbsEquality(coeffs, rhs, maxUint(coeffBits, rhsBits))
+
+	if zero {
+		return nil
+	}
+	return &Element[T]{Limbs: r, internal: true}
+}
+
+// assertLimbsEquality checks that Σ l[i]·2^(w·i) = Σ r[i]·2^(w·i), where all the coefficients are less than
+// 2^coeffBits, by propagating the carries of l[i] - r[i] from the lowest limb
+func (f *Field[T]) assertLimbsEquality(l, r []frontend.Variable, coeffBits uint) {
+	w := f.fParams.BitsPerLimb()
+
+	// the carries may be negative, they are shifted by 2^nbCarryBits so that each step decomposes
+	// a non negative integer less than 2^(w+nbCarryBits+1), whose lower w bits must be zero
+	nbCarryBits := coeffBits - w + 1
+	maxValue := new(big.Int).Lsh(big.NewInt(1), w+nbCarryBits)
+	shift := new(big.Int).Lsh(big.NewInt(1), nbCarryBits)
+
+	var carry frontend.Variable = 0
+	for i := 0; i < len(l) || i < len(r); i++ {
+		diff := f.api.Add(maxValue, carry)
+		if i < len(l) {
+			diff = f.api.Add(diff, l[i])
+		}
+		if i < len(r) {
+			diff = f.api.Sub(diff, r[i])
+		}
+		if i > 0 {
+			diff = f.api.Sub(diff, shift)
+		}
+		carry = f.rsh(diff, w, nbCarryBits+1)
+	}
+	f.api.AssertIsEqual(carry, shift)
+}
+
+// rsh returns v >> shift, it constrains the lower shift bits of v to be zero and the result to nbBits bits
+func (f *Field[T]) rsh(v frontend.Variable, shift, nbBits uint) frontend.Variable {
+	res, err := f.api.Compiler().NewHint(RightShiftHint, 1, v, shift)
+	if err != nil {
+		panic(err)
+	}
+	f.rangeCheck(res[0], nbBits)
+	f.api.AssertIsEqual(v, f.api.Mul(res[0], new(big.Int).Lsh(big.NewInt(1), shift)))
+	return res[0]
+}
+
+// subPadding returns the limbs of a multiple of p which are all at least 2^(w+overflow), so that
+// subtracting an element of this overflow from it leaves non negative limbs
+func (f *Field[T]) subPadding(overflow uint) []*big.Int {
+	w := f.fParams.BitsPerLimb()
+	padding := make([]*big.Int, f.fParams.NbLimbs())
+	for i := range padding {
+		padding[i] = new(big.Int).Lsh(big.NewInt(1), w+overflow)
+	}
+
+	// add p - (Σ padding[i]·2^(w·i) mod p), which fits in the limbs, to get a multiple of p
+	p := f.fParams.Modulus()
+	m := recompose(padding, w)
+	m.Sub(p, m.Mod(m, p))
+	extra := make([]*big.Int, len(padding))
+	for i := range extra {
+		extra[i] = new(big.Int)
+	}
+	decompose(m, w, extra)
+	for i := range padding {
+		padding[i].Add(padding[i], extra[i])
+	}
+	return padding
+}
+
+// hintModulus returns the first inputs of the hints, describing the modulus as limbs since it may not
+// fit in a native variable, see parseModulus
+func (f *Field[T]) hintModulus() []frontend.Variable {
+	res := make([]frontend.Variable, 0, len(f.pLimbs)+2)
+	res = append(res, f.fParams.BitsPerLimb(), len(f.pLimbs))
+	for _, l := range f.pLimbs {
+		res = append(res, l)
+	}
+	return res
+}
+
+// mulCoeffBits returns the width of the coefficients of the product of elements of overflows oa and ob
+func (f *Field[T]) mulCoeffBits(oa, ob uint) uint {
+	w, n := f.fParams.BitsPerLimb(), f.fParams.NbLimbs()
+	return 2*w + oa + ob + uint(mathbits.Len(n))
+}
+
+// nbBits returns the number of bits of a reduced element
+func (f *Field[T]) nbBits() uint {
+	return f.fParams.BitsPerLimb() * f.fParams.NbLimbs()
+}
+
+func (f *Field[T]) rangeCheck(v frontend.Variable, nbBits uint) {
+	f.api.ToBinary(v, int(nbBits))
+}
+
+func maxUint(a, b uint) uint {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
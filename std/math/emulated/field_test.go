@@ -0,0 +1,222 @@
+package emulated
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+func randomElement[T FieldParams](t *testing.T) *big.Int {
+	var fParams T
+	v, err := rand.Int(rand.Reader, fParams.Modulus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+type mulCircuit[T FieldParams] struct {
+	A, B, C Element[T]
+}
+
+func (c *mulCircuit[T]) Define(api frontend.API) error {
+	f, err := NewField[T](api)
+	if err != nil {
+		return err
+	}
+	f.AssertIsEqual(f.Mul(&c.A, &c.B), &c.C)
+	return nil
+}
+
+func testMul[T FieldParams](t *testing.T, curves ...ecc.ID) {
+	assert := test.NewAssert(t)
+	var fParams T
+	a, b := randomElement[T](t), randomElement[T](t)
+	c := new(big.Int).Mul(a, b)
+	c.Mod(c, fParams.Modulus())
+
+	circuit := mulCircuit[T]{A: NewElement[T](nil), B: NewElement[T](nil), C: NewElement[T](nil)}
+	witness := mulCircuit[T]{A: NewElement[T](a), B: NewElement[T](b), C: NewElement[T](c)}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(curves[0], curves[1:]...))
+
+	wrong := mulCircuit[T]{A: NewElement[T](a), B: NewElement[T](b), C: NewElement[T](new(big.Int).Add(c, big.NewInt(1)))}
+	assert.ProverFailed(&circuit, &wrong, test.WithCurves(curves[0], curves[1:]...))
+}
+
+func TestMul(t *testing.T) {
+	testMul[Secp256k1](t, ecc.BN254)
+	testMul[BN254Fp](t, ecc.BLS12_377)
+	testMul[Ed25519](t, ecc.BN254)
+}
+
+// arithCircuit checks (A + B - C) · A / B + 1/C - (-A) = Res
+type arithCircuit[T FieldParams] struct {
+	A, B, C, Res Element[T]
+}
+
+func (c *arithCircuit[T]) Define(api frontend.API) error {
+	f, err := NewField[T](api)
+	if err != nil {
+		return err
+	}
+	res := f.Sub(f.Add(&c.A, &c.B), &c.C)
+	res = f.Div(f.Mul(res, &c.A), &c.B)
+	res = f.Add(res, f.Inverse(&c.C))
+	res = f.Sub(res, f.Neg(&c.A))
+	f.AssertIsEqual(res, &c.Res)
+	f.AssertIsEqual(f.Reduce(res), &c.Res)
+	return nil
+}
+
+func TestArithmetic(t *testing.T) {
+	assert := test.NewAssert(t)
+	p := Secp256k1{}.Modulus()
+	a, b, c := randomElement[Secp256k1](t), randomElement[Secp256k1](t), randomElement[Secp256k1](t)
+
+	res := new(big.Int).Add(a, b)
+	res.Sub(res, c).Mul(res, a).Mul(res, new(big.Int).ModInverse(b, p))
+	res.Add(res, new(big.Int).ModInverse(c, p)).Add(res, a).Mod(res, p)
+
+	circuit := arithCircuit[Secp256k1]{A: NewElement[Secp256k1](nil), B: NewElement[Secp256k1](nil), C: NewElement[Secp256k1](nil), Res: NewElement[Secp256k1](nil)}
+	witness := arithCircuit[Secp256k1]{A: NewElement[Secp256k1](a), B: NewElement[Secp256k1](b), C: NewElement[Secp256k1](c), Res: NewElement[Secp256k1](res)}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254))
+
+	// division by zero
+	witness.B = NewElement[Secp256k1](0)
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
+
+// lazyCircuit adds A to itself more times than a limb can hold without reduction, then multiplies by B
+type lazyCircuit struct {
+	A, B, Res Element[Secp256k1]
+}
+
+const nbLazyAdditions = 300
+
+func (c *lazyCircuit) Define(api frontend.API) error {
+	f, err := NewField[Secp256k1](api)
+	if err != nil {
+		return err
+	}
+	res := &c.A
+	for i := 1; i < nbLazyAdditions; i++ {
+		res = f.Add(res, &c.A)
+	}
+	res = f.Mul(res, &c.B)
+	f.AssertIsEqual(res, &c.Res)
+
+	bits := f.ToBits(res)
+	f.AssertIsEqual(f.FromBits(bits...), &c.Res)
+	return nil
+}
+
+func TestLazyReduction(t *testing.T) {
+	assert := test.NewAssert(t)
+	p := Secp256k1{}.Modulus()
+	a, b := randomElement[Secp256k1](t), randomElement[Secp256k1](t)
+	res := new(big.Int).Mul(a, big.NewInt(nbLazyAdditions))
+	res.Mul(res, b).Mod(res, p)
+
+	circuit := lazyCircuit{A: NewElement[Secp256k1](nil), B: NewElement[Secp256k1](nil), Res: NewElement[Secp256k1](nil)}
+	witness := lazyCircuit{A: NewElement[Secp256k1](a), B: NewElement[Secp256k1](b), Res: NewElement[Secp256k1](res)}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16))
+}
+
+// limbCircuit checks that the limbs of the inputs are constrained
+type limbCircuit struct {
+	A, B Element[Secp256k1]
+}
+
+func (c *limbCircuit) Define(api frontend.API) error {
+	f, err := NewField[Secp256k1](api)
+	if err != nil {
+		return err
+	}
+	f.AssertIsEqual(&c.A, &c.B)
+	return nil
+}
+
+func TestLimbWidth(t *testing.T) {
+	assert := test.NewAssert(t)
+	circuit := limbCircuit{A: NewElement[Secp256k1](nil), B: NewElement[Secp256k1](nil)}
+
+	// 2^64 in the first limb equals 1 in the second limb
+	witness := limbCircuit{A: NewElement[Secp256k1](nil), B: NewElement[Secp256k1](new(big.Int).Lsh(big.NewInt(1), 64))}
+	witness.A.Limbs = []frontend.Variable{new(big.Int).Lsh(big.NewInt(1), 64), 0, 0, 0}
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254))
+}
+
+// sqrtCircuit checks that the square root of A is the one of parity Parity, given by the canonical bits
+type sqrtCircuit struct {
+	A, Root Element[Secp256k1]
+	Parity  frontend.Variable
+}
+
+func (c *sqrtCircuit) Define(api frontend.API) error {
+	f, err := NewField[Secp256k1](api)
+	if err != nil {
+		return err
+	}
+	root := f.Sqrt(&c.A)
+	bits := f.ToCanonicalBits(root)
+	root = f.Select(api.Xor(bits[0], c.Parity), f.Neg(root), root)
+	f.AssertIsEqual(root, &c.Root)
+	return nil
+}
+
+func TestSqrt(t *testing.T) {
+	assert := test.NewAssert(t)
+	p := Secp256k1{}.Modulus()
+	root := randomElement[Secp256k1](t)
+	a := new(big.Int).Mul(root, root)
+	a.Mod(a, p)
+
+	circuit := sqrtCircuit{A: NewElement[Secp256k1](nil), Root: NewElement[Secp256k1](nil)}
+	witness := sqrtCircuit{A: NewElement[Secp256k1](a), Root: NewElement[Secp256k1](root), Parity: root.Bit(0)}
+	assert.ProverSucceeded(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16))
+
+	// the other root
+	witness.Parity = 1 - root.Bit(0)
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16))
+
+	// p - 1 is not a square modulo p ≡ 3 mod 4
+	witness = sqrtCircuit{A: NewElement[Secp256k1](new(big.Int).Sub(p, big.NewInt(1))), Root: NewElement[Secp256k1](0), Parity: 0}
+	assert.ProverFailed(&circuit, &witness, test.WithCurves(ecc.BN254), test.WithBackends(backend.GROTH16))
+}
+
+// canonicalCircuit checks that the canonical bits of the element of bits Bits, whose value may exceed the
+// modulus, are the ones of Res
+type canonicalCircuit struct {
+	Bits [256]frontend.Variable
+	Res  frontend.Variable
+}
+
+func (c *canonicalCircuit) Define(api frontend.API) error {
+	f, err := NewField[BN254Fp](api)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(api.FromBinary(f.ToCanonicalBits(f.FromBits(c.Bits[:]...))...), c.Res)
+	return nil
+}
+
+func TestToCanonicalBits(t *testing.T) {
+	assert := test.NewAssert(t)
+	q := BN254Fp{}.Modulus()
+	v := new(big.Int).Add(q, randomElement[BN254Fp](t))
+
+	var witness canonicalCircuit
+	for i := range witness.Bits {
+		witness.Bits[i] = v.Bit(i)
+	}
+	witness.Res = new(big.Int).Sub(v, q)
+	assert.SolvingSucceeded(&canonicalCircuit{}, &witness, test.WithCurves(ecc.BN254))
+
+	witness.Res = v
+	assert.SolvingFailed(&canonicalCircuit{}, &witness, test.WithCurves(ecc.BN254))
+}
@@ -0,0 +1,141 @@
+package emulated
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/hint"
+)
+
+func init() {
+	for _, h := range GetHints() {
+		hint.Register(h)
+	}
+}
+
+// GetHints returns the hints used by the emulated arithmetic
+func GetHints() []hint.Function {
+	return []hint.Function{
+		RemHint,
+		InverseHint,
+		DivHint,
+		RightShiftHint,
+		SqrtHint,
+	}
+}
+
+// recompose returns Σ limbs[i]·2^(w·i), the limbs may be larger than w bits
+func recompose(limbs []*big.Int, w uint) *big.Int {
+	res := new(big.Int)
+	for i := len(limbs) - 1; i >= 0; i-- {
+		res.Lsh(res, w)
+		res.Add(res, limbs[i])
+	}
+	return res
+}
+
+// decompose sets limbs to the w-bit limbs of v, truncated if v doesn't fit: the hints don't fail on invalid
+// inputs, the constraints using their outputs do
+func decompose(v *big.Int, w uint, limbs []*big.Int) {
+	mask := new(big.Int).Lsh(big.NewInt(1), w)
+	mask.Sub(mask, big.NewInt(1))
+	tmp := new(big.Int).Abs(v)
+	for i := range limbs {
+		limbs[i].And(tmp, mask)
+		tmp.Rsh(tmp, w)
+	}
+}
+
+// parseModulus parses the inputs of the hints, which start with the width w of the limbs, the number
+// of limbs n of the modulus p and its limbs. p doesn't fit in a native variable in general.
+func parseModulus(inputs []*big.Int) (w uint, p *big.Int, rest []*big.Int, err error) {
+	if len(inputs) < 2 || !inputs[1].IsUint64() || uint64(len(inputs)-2) < inputs[1].Uint64() {
+		return 0, nil, nil, errors.New("missing limbs of the modulus")
+	}
+	n := int(inputs[1].Uint64())
+	w = uint(inputs[0].Uint64())
+	return w, recompose(inputs[2:2+n], w), inputs[2+n:], nil
+}
+
+// RemHint computes the quotient and remainder of the division by p of the integer whose limbs in base 2^w are
+// given after the modulus and the number of limbs of the quotient, see parseModulus.
+// The outputs are the limbs of the quotient followed by the limbs of the remainder, if any.
+func RemHint(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	w, p, inputs, err := parseModulus(inputs)
+	if err != nil {
+		return err
+	}
+	if len(inputs) < 1 || !inputs[0].IsUint64() || inputs[0].Uint64() > uint64(len(outputs)) {
+		return errors.New("not enough outputs for the quotient")
+	}
+	nbQuoLimbs := int(inputs[0].Uint64())
+	q, r := new(big.Int).QuoRem(recompose(inputs[1:], w), p, new(big.Int))
+	decompose(q, w, outputs[:nbQuoLimbs])
+	decompose(r, w, outputs[nbQuoLimbs:])
+	return nil
+}
+
+// InverseHint computes the limbs of the inverse modulo p of the integer whose limbs in base 2^w are given
+// after the modulus, see parseModulus
+func InverseHint(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	w, p, inputs, err := parseModulus(inputs)
+	if err != nil {
+		return err
+	}
+	res := new(big.Int).ModInverse(recompose(inputs, w), p)
+	if res == nil {
+		// not invertible
+		res = new(big.Int)
+	}
+	decompose(res, w, outputs)
+	return nil
+}
+
+// DivHint computes the limbs of a/b modulo p, where the limbs of a and b in base 2^w are given after the
+// modulus, see parseModulus, and are as many as the outputs
+func DivHint(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	w, p, inputs, err := parseModulus(inputs)
+	if err != nil {
+		return err
+	}
+	if len(inputs) != 2*len(outputs) {
+		return errors.New("expecting as many limbs for a and b as outputs")
+	}
+	a := recompose(inputs[:len(outputs)], w)
+	b := recompose(inputs[len(outputs):], w)
+	res := new(big.Int).ModInverse(b, p)
+	if res == nil {
+		// not invertible
+		res = new(big.Int)
+	}
+	res.Mul(res, a).Mod(res, p)
+	decompose(res, w, outputs)
+	return nil
+}
+
+// SqrtHint computes the limbs of a square root modulo p of the integer whose limbs in base 2^w are given
+// after the modulus, see parseModulus. It outputs 0 if there is none.
+func SqrtHint(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	w, p, inputs, err := parseModulus(inputs)
+	if err != nil {
+		return err
+	}
+	a := recompose(inputs, w)
+	res := new(big.Int).ModSqrt(a.Mod(a, p), p)
+	if res == nil {
+		// not a square
+		res = new(big.Int)
+	}
+	decompose(res, w, outputs)
+	return nil
+}
+
+// RightShiftHint computes inputs[0] >> inputs[1]
+func RightShiftHint(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	if len(inputs) != 2 || len(outputs) != 1 {
+		return errors.New("expecting 2 inputs and 1 output")
+	}
+	outputs[0].Rsh(inputs[0], uint(inputs[1].Uint64()))
+	return nil
+}
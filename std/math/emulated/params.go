@@ -0,0 +1,84 @@
+package emulated
+
+import "math/big"
+
+// FieldParams describes an emulated field and how its elements are split in limbs
+type FieldParams interface {
+	// NbLimbs is the number of limbs of an element
+	NbLimbs() uint
+
+	// BitsPerLimb is the width of the limbs of a reduced element
+	BitsPerLimb() uint
+
+	// Modulus returns the modulus of the field, it must not be modified
+	Modulus() *big.Int
+}
+
+var (
+	qSecp256k1, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", 16)
+	qBN254Fp, _   = new(big.Int).SetString("30644e72e131a029b85045b68181585d97816a916871ca8d3c208c16d87cfd47", 16)
+	qEd25519, _   = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", 16)
+
+	rBLS12377, _ = new(big.Int).SetString("12ab655e9a2ca55660b44d1e5c37b00159aa76fed00000010a11800000000001", 16)
+	rBLS24315, _ = new(big.Int).SetString("196deac24a9da12b25fc7ec9cf927a98c8c480ece644e36419d0c5fd00c00001", 16)
+
+	rSecp256k1, _ = new(big.Int).SetString("fffffffffffffffffffffffffffffffebaaedce6af48a03bbfd25e8cd0364141", 16)
+	qP256, _      = new(big.Int).SetString("ffffffff00000001000000000000000000000000ffffffffffffffffffffffff", 16)
+	rP256, _      = new(big.Int).SetString("ffffffff00000000ffffffffffffffffbce6faada7179e84f3b9cac2fc632551", 16)
+)
+
+// Secp256k1 is the base field of the secp256k1 curve
+type Secp256k1 struct{}
+
+func (Secp256k1) NbLimbs() uint     { return 4 }
+func (Secp256k1) BitsPerLimb() uint { return 64 }
+func (Secp256k1) Modulus() *big.Int { return qSecp256k1 }
+
+// Secp256k1Fr is the scalar field of the secp256k1 curve
+type Secp256k1Fr struct{}
+
+func (Secp256k1Fr) NbLimbs() uint     { return 4 }
+func (Secp256k1Fr) BitsPerLimb() uint { return 64 }
+func (Secp256k1Fr) Modulus() *big.Int { return rSecp256k1 }
+
+// P256Fp is the base field of the NIST P-256 curve
+type P256Fp struct{}
+
+func (P256Fp) NbLimbs() uint     { return 4 }
+func (P256Fp) BitsPerLimb() uint { return 64 }
+func (P256Fp) Modulus() *big.Int { return qP256 }
+
+// P256Fr is the scalar field of the NIST P-256 curve
+type P256Fr struct{}
+
+func (P256Fr) NbLimbs() uint     { return 4 }
+func (P256Fr) BitsPerLimb() uint { return 64 }
+func (P256Fr) Modulus() *big.Int { return rP256 }
+
+// BN254Fp is the base field of the BN254 curve
+type BN254Fp struct{}
+
+func (BN254Fp) NbLimbs() uint     { return 4 }
+func (BN254Fp) BitsPerLimb() uint { return 64 }
+func (BN254Fp) Modulus() *big.Int { return qBN254Fp }
+
+// Ed25519 is the base field of the Ed25519 curve, of modulus 2²⁵⁵-19
+type Ed25519 struct{}
+
+func (Ed25519) NbLimbs() uint     { return 4 }
+func (Ed25519) BitsPerLimb() uint { return 64 }
+func (Ed25519) Modulus() *big.Int { return qEd25519 }
+
+// BLS12377Fr is the scalar field of the BLS12-377 curve
+type BLS12377Fr struct{}
+
+func (BLS12377Fr) NbLimbs() uint     { return 4 }
+func (BLS12377Fr) BitsPerLimb() uint { return 64 }
+func (BLS12377Fr) Modulus() *big.Int { return rBLS12377 }
+
+// BLS24315Fr is the scalar field of the BLS24-315 curve
+type BLS24315Fr struct{}
+
+func (BLS24315Fr) NbLimbs() uint     { return 4 }
+func (BLS24315Fr) BitsPerLimb() uint { return 64 }
+func (BLS24315Fr) Modulus() *big.Int { return rBLS24315 }
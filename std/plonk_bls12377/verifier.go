@@ -0,0 +1,454 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package plonk_bls12377 provides a ZKP-circuit function to verify BLS12_377 PLONK inside a BW6_761 circuit.
+//
+// The verifier replays the sha256 Fiat-Shamir transcript of the native prover, so that it accepts the proofs
+// returned by plonk.Prove, and the arithmetic of the scalar field of BLS12_377 is emulated, see std/math/emulated.
+// The verifying key is compiled in the circuit as constants. Lookup arguments aren't supported.
+package plonk_bls12377
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fp"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	plonk_bls12377 "github.com/consensys/gnark/internal/backend/bls12-377/plonk"
+	"github.com/consensys/gnark/std/algebra/fields_bls12377"
+	"github.com/consensys/gnark/std/algebra/sw_bls12377"
+	"github.com/consensys/gnark/std/hash/sha256"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// Scalar is an element of the scalar field of BLS12_377, emulated in the circuit
+type Scalar = emulated.Element[emulated.BLS12377Fr]
+
+// nbClaimedValues is the number of polynomials opened at ζ: the folded quotient, the linearized
+// polynomial, l, r, o, s1 and s2
+const nbClaimedValues = 7
+
+var (
+	errNoVerifyingKey = errors.New("plonk_bls12377: the verifying key is not set")
+	errLookup         = errors.New("plonk_bls12377: lookup arguments are not supported")
+)
+
+// VerifyingKey is a BLS12_377 PLONK verifying key, compiled in the circuit as constants
+type VerifyingKey struct {
+	vk *plonk_bls12377.VerifyingKey
+}
+
+// NewVerifyingKey returns the verifying key to compile in the circuit from vk, a BLS12_377 verifying key
+// returned by plonk.Setup
+func NewVerifyingKey(vk plonk.VerifyingKey) (VerifyingKey, error) {
+	_vk, ok := vk.(*plonk_bls12377.VerifyingKey)
+	if !ok {
+		return VerifyingKey{}, fmt.Errorf("plonk_bls12377: expected a BLS12_377 verifying key, got %T", vk)
+	}
+	if _vk.Lookup != nil {
+		return VerifyingKey{}, errLookup
+	}
+	if _vk.KZGSRS == nil {
+		return VerifyingKey{}, errors.New("plonk_bls12377: the KZG SRS of the verifying key is not initialized")
+	}
+	return VerifyingKey{vk: _vk}, nil
+}
+
+// Proof represents a BLS12_377 PLONK proof in a BW6_761 circuit
+type Proof struct {
+	// Commitments to the solution vectors l, r, o
+	LRO [3]sw_bls12377.G1Affine
+
+	// Commitment to Z, the permutation polynomial
+	Z sw_bls12377.G1Affine
+
+	// Commitments to h1, h2, h3 such that h = h1 + Xh2 + X**2h3 is the quotient polynomial
+	H [3]sw_bls12377.G1Affine
+
+	// Batch opening proof at ζ of h1 + zeta*h2 + zeta**2h3, linearizedPolynomial, l, r, o, s1, s2 and the
+	// values they open to
+	BatchedProof  sw_bls12377.G1Affine
+	ClaimedValues [nbClaimedValues]Scalar
+
+	// Opening proof of Z at ζω and the value it opens to
+	ZShiftedProof sw_bls12377.G1Affine
+	ZShiftedValue Scalar
+}
+
+// NewPlaceholderProof returns a Proof whose scalars have their limbs allocated, as needed to declare it in a
+// circuit before compiling it
+func NewPlaceholderProof() Proof {
+	var res Proof
+	for i := range res.ClaimedValues {
+		res.ClaimedValues[i] = emulated.NewElement[emulated.BLS12377Fr](nil)
+	}
+	res.ZShiftedValue = emulated.NewElement[emulated.BLS12377Fr](nil)
+	return res
+}
+
+// NewProof returns the witness assignment of proof, a BLS12_377 proof returned by plonk.Prove
+func NewProof(proof plonk.Proof) (Proof, error) {
+	_proof, ok := proof.(*plonk_bls12377.Proof)
+	if !ok {
+		return Proof{}, fmt.Errorf("plonk_bls12377: expected a BLS12_377 proof, got %T", proof)
+	}
+	if _proof.Lookup != nil {
+		return Proof{}, errLookup
+	}
+	if len(_proof.BatchedProof.ClaimedValues) != nbClaimedValues {
+		return Proof{}, fmt.Errorf("plonk_bls12377: expected %d claimed values, got %d", nbClaimedValues, len(_proof.BatchedProof.ClaimedValues))
+	}
+
+	var res Proof
+	for i := range res.LRO {
+		res.LRO[i].Assign(&_proof.LRO[i])
+	}
+	res.Z.Assign(&_proof.Z)
+	for i := range res.H {
+		res.H[i].Assign(&_proof.H[i])
+	}
+	res.BatchedProof.Assign(&_proof.BatchedProof.H)
+	for i := range res.ClaimedValues {
+		res.ClaimedValues[i] = emulated.NewElement[emulated.BLS12377Fr](&_proof.BatchedProof.ClaimedValues[i])
+	}
+	res.ZShiftedProof.Assign(&_proof.ZShiftedOpening.H)
+	res.ZShiftedValue = emulated.NewElement[emulated.BLS12377Fr](&_proof.ZShiftedOpening.ClaimedValue)
+	return res, nil
+}
+
+// Verify checks that proof is a valid PLONK proof for vk and the public inputs publicInputs, which must be
+// given in the order of the public witness of the inner circuit.
+//
+// The commitments of the proof must not be the point at infinity, which happens with a negligible probability
+// for the proofs returned by plonk.Prove.
+func Verify(api frontend.API, vk VerifyingKey, proof *Proof, publicInputs []frontend.Variable) error {
+	if vk.vk == nil {
+		return errNoVerifyingKey
+	}
+	if uint64(len(publicInputs)) != vk.vk.NbPublicVariables {
+		return fmt.Errorf("plonk_bls12377: expected %d public inputs, got %d", vk.vk.NbPublicVariables, len(publicInputs))
+	}
+	f, err := emulated.NewField[emulated.BLS12377Fr](api)
+	if err != nil {
+		return err
+	}
+	v := verifier{api: api, f: f, vk: vk.vk}
+
+	// the public inputs are bound to gamma by their canonical bytes
+	publicElements := make([]*Scalar, len(publicInputs))
+	var publicBytes []frontend.Variable
+	for i := range publicInputs {
+		bits := v.canonicalBits(publicInputs[i], fr.Modulus())
+		publicElements[i] = f.FromBits(bits...)
+		publicBytes = append(publicBytes, v.bytes(bits, fr.Bytes)...)
+	}
+
+	// derive the challenges as the native verifier does
+	fs := transcript{api: api}
+	vkBytes := make([]frontend.Variable, 0, 8*bls12377.SizeOfG1AffineUncompressed)
+	for _, d := range []bls12377.G1Affine{v.vk.S[0], v.vk.S[1], v.vk.S[2], v.vk.Ql, v.vk.Qr, v.vk.Qm, v.vk.Qo, v.vk.Qk} {
+		vkBytes = append(vkBytes, constBytes(d.Marshal())...)
+	}
+	gamma := v.challenge(fs.challenge("gamma", vkBytes, publicBytes))
+	beta := v.challenge(fs.challenge("beta"))
+	alpha := v.challenge(fs.challenge("alpha", v.rawBytes(proof.Z)))
+	zetaHash := fs.challenge("zeta", v.rawBytes(proof.H[0]), v.rawBytes(proof.H[1]), v.rawBytes(proof.H[2]))
+	zeta := v.challenge(zetaHash)
+
+	// ζⁿ, the size of the domain being a power of two
+	zetaPowerN := zeta
+	for i := uint64(1); i < v.vk.Size; i <<= 1 {
+		zetaPowerN = f.Mul(zetaPowerN, zetaPowerN)
+	}
+	zzeta := f.Sub(zetaPowerN, f.One())
+
+	// PI = ∑_{i<n} Lᵢ*wᵢ, with Lᵢ(ζ) = ωⁱ/n * (ζⁿ-1)/(ζ-ωⁱ)
+	var omegaI fr.Element
+	omegaI.SetOne()
+	lagrange := func() *Scalar {
+		var c fr.Element
+		c.Mul(&omegaI, &v.vk.SizeInv)
+		return f.Div(f.Mul(zzeta, v.constant(&c)), f.Sub(zeta, v.constant(&omegaI)))
+	}
+	lagrangeOne := lagrange()
+	pi := f.Zero()
+	for i := range publicElements {
+		li := lagrangeOne
+		if i > 0 {
+			li = lagrange()
+		}
+		pi = f.Add(pi, f.Mul(li, publicElements[i]))
+		omegaI.Mul(&omegaI, &v.vk.Generator)
+	}
+
+	zu := &proof.ZShiftedValue
+	claimedQuotient := &proof.ClaimedValues[0]
+	linearizedPolynomialZeta := &proof.ClaimedValues[1]
+	l, r, o := &proof.ClaimedValues[2], &proof.ClaimedValues[3], &proof.ClaimedValues[4]
+	s1, s2 := &proof.ClaimedValues[5], &proof.ClaimedValues[6]
+
+	// linearizedpolynomial + pi(ζ) + α*(Z(μζ))*(l(ζ)+β*s1(ζ)+γ)*(r(ζ)+β*s2(ζ)+γ)*(o(ζ)+γ) - α²*L₁(ζ)
+	_s1 := f.Add(f.Add(f.Mul(s1, beta), l), gamma) // (l(ζ)+β*s1(ζ)+γ)
+	_s2 := f.Add(f.Add(f.Mul(s2, beta), r), gamma) // (r(ζ)+β*s2(ζ)+γ)
+	_o := f.Add(o, gamma)                          // (o(ζ)+γ)
+	_s1 = f.Mul(f.Mul(f.Mul(f.Mul(_s1, _s2), _o), alpha), zu)
+	alphaSquareLagrange := f.Mul(f.Mul(lagrangeOne, alpha), alpha) // α²*L₁(ζ)
+	numerator := f.Sub(f.Add(f.Add(linearizedPolynomialZeta, pi), _s1), alphaSquareLagrange)
+
+	// check that H(ζ) is as claimed, H(ζ) = numerator/(ζⁿ-1)
+	f.AssertIsEqual(f.Mul(claimedQuotient, zzeta), numerator)
+
+	// compute the folded commitment to H: Comm(h₁) + ζᵐ⁺²*Comm(h₂) + ζ²⁽ᵐ⁺²⁾*Comm(h₃)
+	zetaMPlusTwo := v.toNative(f.Mul(zetaPowerN, f.Mul(zeta, zeta)))
+	var foldedH sw_bls12377.G1Affine
+	foldedH.ScalarMul(api, proof.H[2], zetaMPlusTwo)
+	foldedH.AddAssign(api, proof.H[1])
+	foldedH.ScalarMul(api, foldedH, zetaMPlusTwo)
+	foldedH.AddAssign(api, proof.H[0])
+
+	// Compute the commitment to the linearized polynomial
+	// linearizedPolynomialDigest =
+	// 		l(ζ)*ql+r(ζ)*qr+r(ζ)l(ζ)*qm+o(ζ)*qo+qk +
+	// 		α*( Z(μζ)(l(ζ)+β*s₁(ζ)+γ)*(r(ζ)+β*s₂(ζ)+γ)*s₃(X)-Z(X)(l(ζ)+β*id_1(ζ)+γ)*(r(ζ)+β*id_2(ζ)+γ)*(o(ζ)+β*id_3(ζ)+γ) ) +
+	// 		α²*L₁(ζ)*Z
+	u := f.Mul(zu, beta)
+	w1 := f.Add(f.Add(f.Mul(beta, s1), l), gamma)
+	w2 := f.Add(f.Add(f.Mul(beta, s2), r), gamma)
+	_s1 = f.Mul(f.Mul(f.Mul(u, w1), w2), alpha) // α*Z(μζ)(l(ζ)+β*s₁(ζ)+γ)*(r(ζ)+β*s₂(ζ)+γ)*β
+
+	var cosetSquare fr.Element
+	cosetSquare.Square(&v.vk.CosetShift)
+	betaZeta := f.Mul(beta, zeta)
+	w0 := f.Add(f.Add(betaZeta, l), gamma)                                     // (l(ζ)+β*ζ+γ)
+	w1 = f.Add(f.Add(f.Mul(betaZeta, v.constant(&v.vk.CosetShift)), r), gamma) // (r(ζ)+β*μ*ζ+γ)
+	w2 = f.Add(f.Add(f.Mul(betaZeta, v.constant(&cosetSquare)), o), gamma)     // (o(ζ)+β*μ²*ζ+γ)
+	_s2 = f.Sub(alphaSquareLagrange, f.Mul(f.Mul(f.Mul(w0, w1), w2), alpha))   // -α*(l(ζ)+β*ζ+γ)*(r(ζ)+β*u*ζ+γ)*(o(ζ)+β*u²*ζ+γ) + α²*L₁(ζ)
+
+	var zTerm sw_bls12377.G1Affine
+	zTerm.ScalarMul(api, proof.Z, v.toNative(_s2))
+	linearizedPolynomialDigest := v.addConstantMultiExp(zTerm,
+		[]*bls12377.G1Affine{&v.vk.Ql, &v.vk.Qr, &v.vk.Qm, &v.vk.Qo, &v.vk.Qk, &v.vk.S[2]},
+		[]*Scalar{l, r, f.Mul(l, r), o, nil, _s1},
+	)
+
+	// fold the openings at ζ with the challenge γ derived from ζ and the digests as kzg.FoldProof does
+	var s0, s1Digest sw_bls12377.G1Affine
+	s0.Assign(&v.vk.S[0])
+	s1Digest.Assign(&v.vk.S[1])
+	digests := []sw_bls12377.G1Affine{foldedH, linearizedPolynomialDigest, proof.LRO[0], proof.LRO[1], proof.LRO[2], s0, s1Digest}
+	foldingBindings := [][]frontend.Variable{v.bytes(f.ToCanonicalBits(zeta), fr.Bytes)}
+	for i := 0; i < 5; i++ {
+		foldingBindings = append(foldingBindings, v.rawBytes(digests[i]))
+	}
+	foldingBindings = append(foldingBindings, constBytes(v.vk.S[0].Marshal()), constBytes(v.vk.S[1].Marshal()))
+	foldingFs := transcript{api: api}
+	foldingGamma := v.challenge(foldingFs.challenge("gamma", foldingBindings...))
+
+	foldedDigest := digests[0]
+	foldedValue := &proof.ClaimedValues[0]
+	gammaI := foldingGamma
+	for i := 1; i < len(digests); i++ {
+		var tmp sw_bls12377.G1Affine
+		tmp.ScalarMul(api, digests[i], v.toNative(gammaI))
+		foldedDigest.AddAssign(api, tmp)
+		foldedValue = f.Add(foldedValue, f.Mul(gammaI, &proof.ClaimedValues[i]))
+		if i+1 < len(digests) {
+			gammaI = f.Mul(gammaI, foldingGamma)
+		}
+	}
+
+	// check the opening of the folded digest at ζ and the one of Z at ζω with a single pairing, the random
+	// challenge λ of their folding being bound to both openings
+	lambda := v.challenge(foldingFs.challenge("lambda",
+		v.rawBytes(foldedDigest), v.bytes(f.ToCanonicalBits(foldedValue), fr.Bytes), v.rawBytes(proof.BatchedProof),
+		v.rawBytes(proof.Z), v.bytes(f.ToCanonicalBits(zu), fr.Bytes), v.rawBytes(proof.ZShiftedProof)))
+	shiftedZeta := f.Mul(zeta, v.constant(&v.vk.Generator))
+	v.verifyOpenings(lambda,
+		opening{digest: foldedDigest, value: foldedValue, point: zeta, h: proof.BatchedProof},
+		opening{digest: proof.Z, value: zu, point: shiftedZeta, h: proof.ZShiftedProof},
+	)
+
+	return nil
+}
+
+// verifier holds what the steps of Verify share
+type verifier struct {
+	api frontend.API
+	f   *emulated.Field[emulated.BLS12377Fr]
+	vk  *plonk_bls12377.VerifyingKey
+}
+
+// opening is a KZG opening proof h that the polynomial committed to by digest is worth value at point
+type opening struct {
+	digest, h    sw_bls12377.G1Affine
+	value, point *Scalar
+}
+
+// verifyOpenings checks the KZG openings with a single pairing, folding them with the powers of the random
+// challenge λ: e(∑ᵢλⁱ([fᵢ(α)] - [fᵢ(aᵢ)]G₁ + [aᵢ]Hᵢ), G₂) * e(-∑ᵢλⁱHᵢ, [α]G₂) = 1
+func (v *verifier) verifyOpenings(lambda *Scalar, openings ...opening) {
+	folded, foldedH, foldedValue := openings[0].digest, openings[0].h, openings[0].value
+	var aH sw_bls12377.G1Affine
+	aH.ScalarMul(v.api, openings[0].h, v.toNative(openings[0].point))
+	folded.AddAssign(v.api, aH)
+	lambdaI := lambda
+	for i := 1; i < len(openings); i++ {
+		var tmp sw_bls12377.G1Affine
+		tmp.ScalarMul(v.api, openings[i].digest, v.toNative(lambdaI))
+		folded.AddAssign(v.api, tmp)
+		tmp.ScalarMul(v.api, openings[i].h, v.toNative(v.f.Mul(lambdaI, openings[i].point)))
+		folded.AddAssign(v.api, tmp)
+		tmp.ScalarMul(v.api, openings[i].h, v.toNative(lambdaI))
+		foldedH.AddAssign(v.api, tmp)
+		foldedValue = v.f.Add(foldedValue, v.f.Mul(lambdaI, openings[i].value))
+		if i+1 < len(openings) {
+			lambdaI = v.f.Mul(lambdaI, lambda)
+		}
+	}
+	var g, claimed sw_bls12377.G1Affine
+	g.Assign(&v.vk.KZGSRS.G1[0])
+	claimed.ScalarMul(v.api, g, v.toNative(foldedValue))
+	claimed.Neg(v.api, claimed)
+	folded.AddAssign(v.api, claimed)
+
+	var g2, alphaG2 sw_bls12377.G2Affine
+	g2.Assign(&v.vk.KZGSRS.G2[0])
+	alphaG2.Assign(&v.vk.KZGSRS.G2[1])
+	var negH sw_bls12377.G1Affine
+	negH.Neg(v.api, foldedH)
+
+	res := sw_bls12377.MillerLoop(v.api, folded, g2)
+	ml := sw_bls12377.MillerLoop(v.api, negH, alphaG2)
+	res.Mul(v.api, res, ml)
+	res = sw_bls12377.FinalExponentiation(v.api, res)
+	var one fields_bls12377.E12
+	one.SetOne(v.api)
+	res.MustBeEqual(v.api, one)
+}
+
+// addConstantMultiExp returns acc + ∑ᵢ[scalarsᵢ]pointsᵢ, the points being constants of the verifying key,
+// and a nil scalar standing for 1. The point at infinity, which is the commitment to an unused selector,
+// is skipped.
+func (v *verifier) addConstantMultiExp(acc sw_bls12377.G1Affine, points []*bls12377.G1Affine, scalars []*Scalar) sw_bls12377.G1Affine {
+	for i, p := range points {
+		if p.IsInfinity() {
+			continue
+		}
+		var q sw_bls12377.G1Affine
+		q.Assign(p)
+		if scalars[i] != nil {
+			q.ScalarMul(v.api, q, v.toNative(scalars[i]))
+		}
+		acc.AddAssign(v.api, q)
+	}
+	return acc
+}
+
+// challenge returns the scalar derived from the hash h as fr.Element.SetBytes does
+func (v *verifier) challenge(h []frontend.Variable) *Scalar {
+	bits := make([]frontend.Variable, 0, 8*len(h))
+	for i := len(h) - 1; i >= 0; i-- {
+		bits = append(bits, v.api.ToBinary(h[i], 8)...)
+	}
+	return v.f.FromBits(bits...)
+}
+
+// toNative returns the canonical value of the scalar s as a native variable
+func (v *verifier) toNative(s *Scalar) frontend.Variable {
+	return v.api.FromBinary(v.f.ToCanonicalBits(s)...)
+}
+
+// constant returns the scalar of constant value c
+func (v *verifier) constant(c *fr.Element) *Scalar {
+	res := emulated.NewElement[emulated.BLS12377Fr](c)
+	return &res
+}
+
+// canonicalBits returns the bits in little-endian order of the unique representative in [0, modulus) of x
+func (v *verifier) canonicalBits(x frontend.Variable, modulus *big.Int) []frontend.Variable {
+	bits := v.api.ToBinary(x, modulus.BitLen())
+	assertBitsLessOrEqual(v.api, bits, new(big.Int).Sub(modulus, big.NewInt(1)))
+	return bits
+}
+
+// bytes returns the nbBytes big-endian bytes of the integer whose bits in little-endian order are bits
+func (v *verifier) bytes(bits []frontend.Variable, nbBytes int) []frontend.Variable {
+	res := make([]frontend.Variable, nbBytes)
+	for i := range res {
+		start, end := 8*i, 8*(i+1)
+		if end > len(bits) {
+			end = len(bits)
+		}
+		if start >= end {
+			res[nbBytes-1-i] = 0
+			continue
+		}
+		res[nbBytes-1-i] = v.api.FromBinary(bits[start:end]...)
+	}
+	return res
+}
+
+// rawBytes returns the uncompressed encoding of p as bls12377.G1Affine.Marshal does
+func (v *verifier) rawBytes(p sw_bls12377.G1Affine) []frontend.Variable {
+	res := v.bytes(v.canonicalBits(p.X, fp.Modulus()), fp.Bytes)
+	return append(res, v.bytes(v.canonicalBits(p.Y, fp.Modulus()), fp.Bytes)...)
+}
+
+// transcript replays in the circuit the fiat-shamir transcript of gnark-crypto over sha256: a challenge is
+// the hash of its name, of the previous challenge and of the values bound to it
+type transcript struct {
+	api      frontend.API
+	previous []frontend.Variable
+}
+
+// challenge returns the hash of the challenge name bound to the bytes of bindings
+func (t *transcript) challenge(name string, bindings ...[]frontend.Variable) []frontend.Variable {
+	h := sha256.New(t.api)
+	h.Write(constBytes([]byte(name))...)
+	h.Write(t.previous...)
+	for _, b := range bindings {
+		h.Write(b...)
+	}
+	t.previous = h.Sum()
+	return t.previous
+}
+
+// constBytes returns the constant variables of the bytes b
+func constBytes(b []byte) []frontend.Variable {
+	res := make([]frontend.Variable, len(b))
+	for i := range b {
+		res[i] = b[i]
+	}
+	return res
+}
+
+// assertBitsLessOrEqual checks that the integer whose boolean bits in little-endian order are bs is at most bound
+func assertBitsLessOrEqual(api frontend.API, bs []frontend.Variable, bound *big.Int) {
+	// eq is 1 as long as the bits processed from the top are the ones of the bound; wherever the
+	// bound has a 0 bit, the bit of bs must be 0 too if all the bits above are equal
+	var eq frontend.Variable = 1
+	for i := len(bs) - 1; i >= 0; i-- {
+		if bound.Bit(i) == 1 {
+			eq = api.Mul(eq, bs[i])
+		} else {
+			api.AssertIsEqual(api.Mul(eq, bs[i]), 0)
+		}
+	}
+}
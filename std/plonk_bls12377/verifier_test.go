@@ -0,0 +1,116 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package plonk_bls12377
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/backend/plonk"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/scs"
+	"github.com/consensys/gnark/test"
+)
+
+// cubicCircuit checks that Y = X³ + X + 5
+type cubicCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *cubicCircuit) Define(api frontend.API) error {
+	x3 := api.Mul(circuit.X, circuit.X, circuit.X)
+	api.AssertIsEqual(circuit.Y, api.Add(x3, circuit.X, 5))
+	return nil
+}
+
+// generateInnerProof returns a BLS12_377 PLONK proof that 35 = 3³ + 3 + 5 and its verifying key
+func generateInnerProof(t *testing.T) (plonk.VerifyingKey, plonk.Proof) {
+	ccs, err := frontend.Compile(ecc.BLS12_377, scs.NewBuilder, &cubicCircuit{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	srs, err := test.NewKZGSRS(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := plonk.Setup(ccs, srs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := frontend.NewWitness(&cubicCircuit{X: 3, Y: 35}, ecc.BLS12_377)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := plonk.Prove(ccs, pk, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// before returning verifies that the proof passes on bls12377
+	publicWitness, err := w.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := plonk.Verify(proof, vk, publicWitness); err != nil {
+		t.Fatal(err)
+	}
+	return vk, proof
+}
+
+type verifierCircuit struct {
+	InnerProof Proof
+	Y          frontend.Variable `gnark:",public"`
+
+	innerVk VerifyingKey
+}
+
+func (circuit *verifierCircuit) Define(api frontend.API) error {
+	return Verify(api, circuit.innerVk, &circuit.InnerProof, []frontend.Variable{circuit.Y})
+}
+
+func TestVerifier(t *testing.T) {
+	innerVk, innerProof := generateInnerProof(t)
+	vk, err := NewVerifyingKey(innerVk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := NewProof(innerProof)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	circuit := verifierCircuit{InnerProof: NewPlaceholderProof(), innerVk: vk}
+	witness := verifierCircuit{InnerProof: proof, Y: 35}
+	if err := test.IsSolved(&circuit, &witness, ecc.BW6_761, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := verifierCircuit{InnerProof: proof, Y: 36}
+	if err := test.IsSolved(&circuit, &wrong, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("proof accepted for other public inputs")
+	}
+
+	// the opening of Z at ζω is folded with the one at ζ
+	tampered := proof
+	tampered.ZShiftedProof = proof.BatchedProof
+	wrong = verifierCircuit{InnerProof: tampered, Y: 35}
+	if err := test.IsSolved(&circuit, &wrong, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("proof accepted with a wrong opening of Z at ζω")
+	}
+}
@@ -0,0 +1,151 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package bls provides ZKP-circuit functions to verify BLS signatures over
+// BLS12-377, single or aggregated, in a circuit over BW6-761.
+//
+// Public keys are points of G1 and signatures points of G2: the signature of a
+// message m by the secret key sk is [sk]H(m), H being the hash to G2 of
+// HashToG2, and it is checked with e(pk, H(m)) = e(g1, sig). The public keys
+// are trusted to be points of G1 different from the infinity, for instance
+// because they are known to the verifier or checked natively.
+//
+// The signatures and the public keys are assigned from their gnark-crypto
+// points with the Assign methods, and the messages with NewMessage. A native
+// signature is computed with bls12377.HashToCurveG2SSWU, using the same
+// domain separation tag as in the circuit, and ScalarMultiplication.
+package bls
+
+import (
+	"errors"
+	"fmt"
+
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/fields_bls12377"
+	"github.com/consensys/gnark/std/algebra/sw_bls12377"
+)
+
+// PublicKey stores a BLS public key (to be used in gnark circuit), a point of G1
+type PublicKey sw_bls12377.G1Affine
+
+// Signature stores a BLS signature (to be used in gnark circuit), a point of G2
+type Signature sw_bls12377.G2Affine
+
+// Verify checks that sig is the signature of msg, whose variables are bytes,
+// by pk with the domain separation tag dst.
+func Verify(api frontend.API, pk PublicKey, sig Signature, msg []frontend.Variable, dst []byte) error {
+	return AggregateVerify(api, []PublicKey{pk}, sig, [][]frontend.Variable{msg}, dst)
+}
+
+// FastAggregateVerify checks that sig is the aggregation, the sum, of the
+// signatures of msg by each of pks.
+//
+// To prevent rogue key attacks the public keys must come with proofs of
+// possession of their secret key, verified beforehand. They must be distinct.
+func FastAggregateVerify(api frontend.API, pks []PublicKey, sig Signature, msg []frontend.Variable, dst []byte) error {
+	if len(pks) == 0 {
+		return errors.New("bls: no public key to aggregate")
+	}
+	pk := sw_bls12377.G1Affine(pks[0])
+	for i := 1; i < len(pks); i++ {
+		pk.AddAssign(api, sw_bls12377.G1Affine(pks[i]))
+	}
+	return Verify(api, PublicKey(pk), sig, msg, dst)
+}
+
+// AggregateVerify checks that sig is the aggregation, the sum, of the
+// signatures of msgs[i] by pks[i].
+//
+// Unless the public keys come with proofs of possession of their secret key
+// the messages must be distinct.
+func AggregateVerify(api frontend.API, pks []PublicKey, sig Signature, msgs [][]frontend.Variable, dst []byte) error {
+	if len(pks) == 0 || len(pks) != len(msgs) {
+		return fmt.Errorf("bls: %d public keys for %d messages", len(pks), len(msgs))
+	}
+	sigPoint := sw_bls12377.G2Affine(sig)
+	assertIsInG2(api, sigPoint)
+
+	// e(-g1, sig)·∏ e(pks[i], H(msgs[i])) = 1
+	_, _, g1, _ := bls12377.Generators()
+	g1.Neg(&g1)
+	P := make([]sw_bls12377.G1Affine, 1, len(pks)+1)
+	P[0].Assign(&g1)
+	Q := []sw_bls12377.G2Affine{sigPoint}
+	for i := range msgs {
+		h, err := HashToG2(api, msgs[i], dst)
+		if err != nil {
+			return err
+		}
+		P = append(P, sw_bls12377.G1Affine(pks[i]))
+		Q = append(Q, h)
+	}
+	assertPairingIsOne(api, P, Q)
+	return nil
+}
+
+// assertPairingIsOne checks that ∏ e(P[i], Q[i]) = 1
+func assertPairingIsOne(api frontend.API, P []sw_bls12377.G1Affine, Q []sw_bls12377.G2Affine) {
+	// the Miller loops are computed by three where possible, to share the squares
+	var ml []fields_bls12377.E12
+	i := 0
+	for ; i+3 <= len(P); i += 3 {
+		ml = append(ml, sw_bls12377.TripleMillerLoop(api,
+			[3]sw_bls12377.G1Affine{P[i], P[i+1], P[i+2]},
+			[3]sw_bls12377.G2Affine{Q[i], Q[i+1], Q[i+2]}))
+	}
+	for ; i < len(P); i++ {
+		ml = append(ml, sw_bls12377.MillerLoop(api, P[i], Q[i]))
+	}
+	for i := 1; i < len(ml); i++ {
+		ml[0].Mul(api, ml[0], ml[i])
+	}
+
+	var one fields_bls12377.E12
+	one.SetOne(api)
+	res := sw_bls12377.FinalExponentiation(api, ml[0])
+	res.MustBeEqual(api, one)
+}
+
+// assertIsInG2 checks that p is on the twist, and in G2 with ψ(p) = [xGen]p
+func assertIsInG2(api frontend.API, p sw_bls12377.G2Affine) {
+	var lhs, rhs fields_bls12377.E2
+	lhs.Square(api, p.Y)
+	rhs.Square(api, p.X).Mul(api, rhs, p.X).Add(api, rhs, bTwist)
+	lhs.MustBeEqual(api, rhs)
+
+	xp := mulByX(api, p)
+	xp.MustBeEqual(api, psi(api, p))
+}
+
+// Assign sets the public key to pk (witness assignment)
+func (pk *PublicKey) Assign(p *bls12377.G1Affine) {
+	(*sw_bls12377.G1Affine)(pk).Assign(p)
+}
+
+// Assign sets the signature to sig (witness assignment)
+func (sig *Signature) Assign(s *bls12377.G2Affine) {
+	(*sw_bls12377.G2Affine)(sig).Assign(s)
+}
+
+// NewMessage returns the bytes of msg as variables (witness assignment)
+func NewMessage(msg []byte) []frontend.Variable {
+	res := make([]frontend.Variable, len(msg))
+	for i := range msg {
+		res[i] = msg[i]
+	}
+	return res
+}
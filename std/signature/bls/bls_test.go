@@ -0,0 +1,244 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bls
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark-crypto/ecc/bls12-377/fr"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/sw_bls12377"
+	"github.com/consensys/gnark/test"
+)
+
+var testDST = []byte("BLS_SIG_BLS12377G2_XMD:SHA-256_SSWU_RO_POP_")
+
+type hashToG2Circuit struct {
+	Msg  [3]frontend.Variable
+	Hash sw_bls12377.G2Affine `gnark:",public"`
+}
+
+func (circuit *hashToG2Circuit) Define(api frontend.API) error {
+	h, err := HashToG2(api, circuit.Msg[:], testDST)
+	if err != nil {
+		return err
+	}
+	h.MustBeEqual(api, circuit.Hash)
+	return nil
+}
+
+func TestHashToG2(t *testing.T) {
+	msg := []byte("abc")
+	h, err := bls12377.HashToCurveG2SSWU(msg, testDST)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var witness hashToG2Circuit
+	copy(witness.Msg[:], NewMessage(msg))
+	witness.Hash.Assign(&h)
+	if err := test.IsSolved(&hashToG2Circuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	witness.Msg[2] = 'd'
+	if err := test.IsSolved(&hashToG2Circuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("hash of another message accepted")
+	}
+}
+
+// sign returns the signature of msg by sk
+func sign(t *testing.T, sk *big.Int, msg []byte) bls12377.G2Affine {
+	h, err := bls12377.HashToCurveG2SSWU(msg, testDST)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sig bls12377.G2Affine
+	sig.ScalarMultiplication(&h, sk)
+	return sig
+}
+
+// newKey returns a random secret key and its public key
+func newKey(t *testing.T) (*big.Int, bls12377.G1Affine) {
+	sk, err := rand.Int(rand.Reader, fr.Modulus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, g1, _ := bls12377.Generators()
+	var pk bls12377.G1Affine
+	pk.ScalarMultiplication(&g1, sk)
+	return sk, pk
+}
+
+type verifyCircuit struct {
+	PublicKey PublicKey
+	Signature Signature
+	Msg       [4]frontend.Variable
+}
+
+func (circuit *verifyCircuit) Define(api frontend.API) error {
+	return Verify(api, circuit.PublicKey, circuit.Signature, circuit.Msg[:], testDST)
+}
+
+func TestVerify(t *testing.T) {
+	msg := []byte("ping")
+	sk, pk := newKey(t)
+	sig := sign(t, sk, msg)
+
+	var witness verifyCircuit
+	witness.PublicKey.Assign(&pk)
+	witness.Signature.Assign(&sig)
+	copy(witness.Msg[:], NewMessage(msg))
+	if err := test.IsSolved(&verifyCircuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := witness
+	wrong.Msg[0] = 'P'
+	if err := test.IsSolved(&verifyCircuit{}, &wrong, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("signature accepted for another message")
+	}
+
+	_, other := newKey(t)
+	wrong = witness
+	wrong.PublicKey.Assign(&other)
+	if err := test.IsSolved(&verifyCircuit{}, &wrong, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("signature accepted for another key")
+	}
+}
+
+type fastAggregateVerifyCircuit struct {
+	PublicKeys [3]PublicKey
+	Signature  Signature
+	Msg        [4]frontend.Variable
+}
+
+func (circuit *fastAggregateVerifyCircuit) Define(api frontend.API) error {
+	return FastAggregateVerify(api, circuit.PublicKeys[:], circuit.Signature, circuit.Msg[:], testDST)
+}
+
+func TestFastAggregateVerify(t *testing.T) {
+	msg := []byte("ping")
+	var witness fastAggregateVerifyCircuit
+	var sigs [3]bls12377.G2Affine
+	for i := range witness.PublicKeys {
+		sk, pk := newKey(t)
+		sigs[i] = sign(t, sk, msg)
+		witness.PublicKeys[i].Assign(&pk)
+	}
+	var aggregate bls12377.G2Jac
+	aggregate.FromAffine(&sigs[0])
+	aggregate.AddMixed(&sigs[1])
+	var partial bls12377.G2Affine
+	partial.FromJacobian(&aggregate)
+	aggregate.AddMixed(&sigs[2])
+	var sig bls12377.G2Affine
+	sig.FromJacobian(&aggregate)
+
+	witness.Signature.Assign(&sig)
+	copy(witness.Msg[:], NewMessage(msg))
+	if err := test.IsSolved(&fastAggregateVerifyCircuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	// one of the signatures is missing
+	witness.Signature.Assign(&partial)
+	if err := test.IsSolved(&fastAggregateVerifyCircuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("partial aggregate signature accepted")
+	}
+}
+
+type aggregateVerifyCircuit struct {
+	PublicKeys [2]PublicKey
+	Signature  Signature
+	Msgs       [2][4]frontend.Variable
+}
+
+func (circuit *aggregateVerifyCircuit) Define(api frontend.API) error {
+	return AggregateVerify(api, circuit.PublicKeys[:], circuit.Signature, [][]frontend.Variable{circuit.Msgs[0][:], circuit.Msgs[1][:]}, testDST)
+}
+
+func TestAggregateVerify(t *testing.T) {
+	msgs := [][]byte{[]byte("ping"), []byte("pong")}
+	var witness aggregateVerifyCircuit
+	var aggregate bls12377.G2Jac
+	for i := range witness.PublicKeys {
+		sk, pk := newKey(t)
+		sig := sign(t, sk, msgs[i])
+		aggregate.AddMixed(&sig)
+		witness.PublicKeys[i].Assign(&pk)
+		copy(witness.Msgs[i][:], NewMessage(msgs[i]))
+	}
+	var sig bls12377.G2Affine
+	sig.FromJacobian(&aggregate)
+	witness.Signature.Assign(&sig)
+	if err := test.IsSolved(&aggregateVerifyCircuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	// the messages are swapped
+	witness.Msgs[0], witness.Msgs[1] = witness.Msgs[1], witness.Msgs[0]
+	if err := test.IsSolved(&aggregateVerifyCircuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("aggregate signature accepted for swapped messages")
+	}
+}
+
+type g2MembershipCircuit struct {
+	P sw_bls12377.G2Affine
+}
+
+func (circuit *g2MembershipCircuit) Define(api frontend.API) error {
+	assertIsInG2(api, circuit.P)
+	return nil
+}
+
+func TestAssertIsInG2(t *testing.T) {
+	_, _, _, g2 := bls12377.Generators()
+	var witness g2MembershipCircuit
+	witness.P.Assign(&g2)
+	if err := test.IsSolved(&g2MembershipCircuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+
+	// a point of the twist outside of G2
+	var p bls12377.G2Affine
+	var b bls12377.E2
+	b.A1.SetOne()
+	b.Inverse(&b)
+	for {
+		if _, err := p.X.SetRandom(); err != nil {
+			t.Fatal(err)
+		}
+		p.Y.Square(&p.X).Mul(&p.Y, &p.X).Add(&p.Y, &b)
+		if p.Y.Legendre() == 1 {
+			p.Y.Sqrt(&p.Y)
+			break
+		}
+	}
+	if !p.IsOnCurve() || p.IsInSubGroup() {
+		t.Fatal("invalid test point")
+	}
+	witness.P.Assign(&p)
+	if err := test.IsSolved(&g2MembershipCircuit{}, &witness, ecc.BW6_761, backend.UNKNOWN); err == nil {
+		t.Fatal("point outside of G2 accepted")
+	}
+}
@@ -0,0 +1,411 @@
+/*
+Copyright © 2020 ConsenSys
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package bls
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377"
+	"github.com/consensys/gnark/backend/hint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/fields_bls12377"
+	"github.com/consensys/gnark/std/algebra/sw_bls12377"
+	"github.com/consensys/gnark/std/hash/sha256"
+	"github.com/consensys/gnark/std/math/bits"
+)
+
+// the hash to field parameters: 64 bytes per element of Fp for 128 bits of
+// security, and 2 elements of Fp2
+const (
+	fieldElementSize = 64
+	nbFieldElements  = 4
+)
+
+var (
+	// z is the non-square of Fp2 of the SSWU map, a and b the coefficients of
+	// E': y² = x³ + a·x + b, which is isogenous to the twist
+	sswuZ, sswuA, sswuB fields_bls12377.E2
+
+	// bTwist is the coefficient b of the twist y² = x³ + b
+	bTwist fields_bls12377.E2
+
+	// the coefficients of the isogeny
+	isoXNumC, isoXDenC, isoYNumC, isoYDenC []fields_bls12377.E2
+
+	// ψ(x, y) = (endoU·x̄, endoV·ȳ), and thirdRootOne is the cube root of unity
+	// of the endomorphism (x, y) ↦ (thirdRootOne·x, y)
+	endoU, endoV, thirdRootOne *big.Int
+
+	// xGen is the seed of the curve
+	xGen uint64 = 9586122913090633729
+)
+
+func init() {
+	hint.Register(SqrtRatio)
+
+	sswuZ = newE2("12", "1")
+	sswuA = newE2(
+		"203567575243095400658685394654545117908398249146024925306257919445062693445414588103741379252427065422417496933054",
+		"69357795553467368835766998649443114298653120475771922004522583893765862042427351483161253261358624703462995261783")
+	sswuB = newE2(
+		"249039961697346248294162904170316935273494032138504221215795383014884687447192317932476994472315647695087734549420",
+		"806998283981877041862626354975415285020485827233942100233224759047656510577433749137260740227904569833498998565")
+
+	var b bls12377.E2
+	b.A1.SetOne()
+	b.Inverse(&b)
+	bTwist = fromE2(&b)
+
+	isoXNumC = newE2s(isoXNum)
+	isoXDenC = newE2s(isoXDen)
+	isoYNumC = newE2s(isoYNum)
+	isoYDenC = newE2s(isoYDen)
+
+	endoU, _ = new(big.Int).SetString("80949648264912719408558363140637477264845294720710499478137287262712535938301461879813459410946", 10)
+	endoV, _ = new(big.Int).SetString("216465761340224619389371505802605247630151569547285782856803747159100223055385581585702401816380679166954762214499", 10)
+	thirdRootOne, _ = new(big.Int).SetString("80949648264912719408558363140637477264845294720710499478137287262712535938301461879813459410945", 10)
+}
+
+func newE2(a0, a1 string) fields_bls12377.E2 {
+	var e bls12377.E2
+	e.A0.SetString(a0)
+	e.A1.SetString(a1)
+	return fromE2(&e)
+}
+
+func newE2s(c [][2]string) []fields_bls12377.E2 {
+	res := make([]fields_bls12377.E2, len(c))
+	for i := range c {
+		res[i] = newE2(c[i][0], c[i][1])
+	}
+	return res
+}
+
+// fromE2 returns the constant e
+func fromE2(e *bls12377.E2) fields_bls12377.E2 {
+	var a0, a1 big.Int
+	e.A0.ToBigIntRegular(&a0)
+	e.A1.ToBigIntRegular(&a1)
+	return fields_bls12377.E2{A0: &a0, A1: &a1}
+}
+
+// HashToG2 returns the hash of msg, whose variables are bytes, to G2 with the
+// domain separation tag dst.
+//
+// It follows the random oracle hash_to_curve of RFC 9380 with
+// expand_message_xmd over SHA-256 and the simplified SWU map to a curve
+// isogenous to the twist, as bls12377.HashToCurveG2SSWU which is its native
+// counterpart. The circuit must be defined over BW6-761, whose scalar field is
+// the base field of BLS12-377.
+//
+// Most of its 700k R1CS constraints are those of the 19 SHA-256 compressions
+// of expand_message_xmd, for short messages.
+func HashToG2(api frontend.API, msg []frontend.Variable, dst []byte) (sw_bls12377.G2Affine, error) {
+	if api.Compiler().Curve() != ecc.BW6_761 {
+		return sw_bls12377.G2Affine{}, errors.New("bls: hashing to BLS12-377 requires a circuit over BW6-761")
+	}
+	if len(dst) > 255 {
+		return sw_bls12377.G2Affine{}, errors.New("bls: the domain separation tag is longer than 255 bytes")
+	}
+	u := hashToField(api, msg, dst)
+
+	// the isogeny being a morphism, the points are added on E' before being mapped
+	q0 := mapToCurve(api, fields_bls12377.E2{A0: u[0], A1: u[1]})
+	q1 := mapToCurve(api, fields_bls12377.E2{A0: u[2], A1: u[3]})
+	q0.AddAssign(api, &q1)
+	q := isogeny(api, q0)
+	return clearCofactor(api, q), nil
+}
+
+// hashToField returns the elements of Fp, in the native field, of the
+// pseudo-random bytes of msg
+func hashToField(api frontend.API, msg []frontend.Variable, dst []byte) []frontend.Variable {
+	uniformBytes := expandMsgXmd(api, msg, dst, nbFieldElements*fieldElementSize)
+	res := make([]frontend.Variable, nbFieldElements)
+	for i := range res {
+		// the big-endian integer of the bytes, reduced by the field arithmetic
+		var e frontend.Variable = 0
+		for _, b := range uniformBytes[i*fieldElementSize : (i+1)*fieldElementSize] {
+			e = api.Add(api.Mul(e, 256), b)
+		}
+		res[i] = e
+	}
+	return res
+}
+
+// expandMsgXmd returns lenInBytes pseudo-random bytes of msg, as
+// ecc.ExpandMsgXmd. lenInBytes must be at most 255·32 and dst at most 255 bytes.
+func expandMsgXmd(api frontend.API, msg []frontend.Variable, dst []byte, lenInBytes int) []frontend.Variable {
+	h := sha256.New(api)
+	ell := (lenInBytes + h.Size() - 1) / h.Size()
+
+	// DST_prime = DST || I2OSP(len(DST), 1)
+	dstPrime := make([]frontend.Variable, 0, len(dst)+1)
+	for _, b := range dst {
+		dstPrime = append(dstPrime, b)
+	}
+	dstPrime = append(dstPrime, len(dst))
+
+	// b_0 = H(Z_pad || msg || I2OSP(len_in_bytes, 2) || I2OSP(0, 1) || DST_prime)
+	for i := 0; i < h.BlockSize(); i++ {
+		h.Write(0)
+	}
+	h.Write(msg...)
+	h.Write(lenInBytes>>8, lenInBytes&0xff, 0)
+	h.Write(dstPrime...)
+	b0 := h.Sum()
+	b0Bits := make([][]frontend.Variable, len(b0))
+	for i := range b0 {
+		b0Bits[i] = bits.ToBinary(api, b0[i], bits.WithNbDigits(8))
+	}
+
+	// b_1 = H(b_0 || I2OSP(1, 1) || DST_prime)
+	h.Reset()
+	h.Write(b0...)
+	h.Write(1)
+	h.Write(dstPrime...)
+	bi := h.Sum()
+	res := append([]frontend.Variable{}, bi...)
+
+	// b_i = H(strxor(b_0, b_(i - 1)) || I2OSP(i, 1) || DST_prime)
+	for i := 2; i <= ell; i++ {
+		h.Reset()
+		for j := range bi {
+			bBits := bits.ToBinary(api, bi[j], bits.WithNbDigits(8))
+			for k := range bBits {
+				bBits[k] = api.Xor(bBits[k], b0Bits[j][k])
+			}
+			h.Write(bits.FromBinary(api, bBits, bits.WithUnconstrainedInputs()))
+		}
+		h.Write(i)
+		h.Write(dstPrime...)
+		bi = h.Sum()
+		res = append(res, bi...)
+	}
+	return res[:lenInBytes]
+}
+
+// mapToCurve returns the image of u on E' by the simplified SWU map, following
+// the straight-line implementation of RFC 9380, appendix F.2.
+func mapToCurve(api frontend.API, u fields_bls12377.E2) sw_bls12377.G2Affine {
+	var tv1, tv2, tv3, tv4, tv5, tv6, x, y, one fields_bls12377.E2
+	one.SetOne(api)
+
+	tv1.Square(api, u).Mul(api, tv1, sswuZ)
+	tv2.Square(api, tv1).Add(api, tv2, tv1)
+	tv3.Add(api, tv2, one).Mul(api, tv3, sswuB)
+
+	// tv4 = A·(tv2 ≠ 0 ? -tv2 : Z)
+	tv4.Neg(api, tv2)
+	tv4 = selectE2(api, isZeroE2(api, tv2), sswuZ, tv4)
+	tv4.Mul(api, tv4, sswuA)
+
+	tv2.Square(api, tv3)
+	tv6.Square(api, tv4)
+	tv5.Mul(api, tv6, sswuA)
+	tv2.Add(api, tv2, tv5).Mul(api, tv2, tv3)
+	tv6.Mul(api, tv6, tv4)
+	tv5.Mul(api, tv6, sswuB)
+	tv2.Add(api, tv2, tv5)
+
+	x.Mul(api, tv1, tv3)
+	isQR, y1 := sqrtRatio(api, tv2, tv6)
+	y.Mul(api, tv1, u).Mul(api, y, y1)
+	x = selectE2(api, isQR, tv3, x)
+	y = selectE2(api, isQR, y1, y)
+
+	// y has the sign of u
+	var yNeg fields_bls12377.E2
+	yNeg.Neg(api, y)
+	y = selectE2(api, api.Xor(sgn0(api, u), sgn0(api, y)), yNeg, y)
+
+	tv4.Inverse(api, tv4)
+	x.Mul(api, x, tv4)
+	return sw_bls12377.G2Affine{X: x, Y: y}
+}
+
+// sqrtRatio returns (1, √(u/v)) if u/v is a square and (0, √(Z·u/v)) otherwise,
+// v being non-zero. The square root comes from a hint.
+func sqrtRatio(api frontend.API, u, v fields_bls12377.E2) (frontend.Variable, fields_bls12377.E2) {
+	res, err := api.Compiler().NewHint(SqrtRatio, 3, u.A0, u.A1, v.A0, v.A1)
+	if err != nil {
+		panic(err)
+	}
+	isQR := res[0]
+	y := fields_bls12377.E2{A0: res[1], A1: res[2]}
+	api.AssertIsBoolean(isQR)
+
+	// y²·v = u if u/v is a square, and Z·u otherwise, which is then non-zero
+	var lhs, zu fields_bls12377.E2
+	lhs.Square(api, y).Mul(api, lhs, v)
+	zu.Mul(api, u, sswuZ)
+	lhs.MustBeEqual(api, selectE2(api, isQR, u, zu))
+	api.AssertIsEqual(api.Mul(api.Sub(1, isQR), isZeroE2(api, y)), 0)
+
+	return isQR, y
+}
+
+// SqrtRatio is the hint of the simplified SWU map. Its inputs are the elements
+// u and v ≠ 0 of Fp2 and it returns 1 and a square root of u/v if u/v is a
+// square, and 0 and a square root of Z·u/v otherwise.
+func SqrtRatio(_ ecc.ID, inputs []*big.Int, outputs []*big.Int) error {
+	var u, v, r bls12377.E2
+	u.A0.SetBigInt(inputs[0])
+	u.A1.SetBigInt(inputs[1])
+	v.A0.SetBigInt(inputs[2])
+	v.A1.SetBigInt(inputs[3])
+	if v.IsZero() {
+		return errors.New("bls: division by zero")
+	}
+	r.Inverse(&v).Mul(&r, &u)
+
+	outputs[0].SetUint64(1)
+	if r.Legendre() == -1 {
+		var z bls12377.E2
+		z.A0.SetUint64(12)
+		z.A1.SetUint64(1)
+		r.Mul(&r, &z)
+		outputs[0].SetUint64(0)
+	}
+	r.Sqrt(&r)
+	r.A0.ToBigIntRegular(outputs[1])
+	r.A1.ToBigIntRegular(outputs[2])
+	return nil
+}
+
+// isogeny returns the image of p on the twist of BLS12-377
+func isogeny(api frontend.API, p sw_bls12377.G2Affine) sw_bls12377.G2Affine {
+	// the powers of x up to the largest degree, the polynomials being linear
+	// combinations of them
+	powers := make([]fields_bls12377.E2, len(isoYDenC)+1)
+	powers[0].SetOne(api)
+	powers[1] = p.X
+	for i := 2; i < len(powers); i++ {
+		powers[i].Mul(api, powers[i-1], p.X)
+	}
+	eval := func(c []fields_bls12377.E2, monic bool) fields_bls12377.E2 {
+		res := fields_bls12377.E2{A0: 0, A1: 0}
+		if monic {
+			res = powers[len(c)]
+		}
+		for i := range c {
+			var t fields_bls12377.E2
+			t.Mul(api, powers[i], c[i])
+			res.Add(api, res, t)
+		}
+		return res
+	}
+
+	var res sw_bls12377.G2Affine
+	var den fields_bls12377.E2
+	den = eval(isoXDenC, true)
+	den.Inverse(api, den)
+	res.X = eval(isoXNumC, false)
+	res.X.Mul(api, res.X, den)
+
+	den = eval(isoYDenC, true)
+	den.Inverse(api, den)
+	res.Y = eval(isoYNumC, false)
+	res.Y.Mul(api, res.Y, p.Y).Mul(api, res.Y, den)
+	return res
+}
+
+// clearCofactor returns [h_eff]p, computed as in gnark-crypto as
+// [x²-x-1]p + ψ([x-1]p) - ψ²([2]p), see https://eprint.iacr.org/2017/419.pdf, 4.1
+func clearCofactor(api frontend.API, p sw_bls12377.G2Affine) sw_bls12377.G2Affine {
+	xp := mulByX(api, p)
+	x2p := mulByX(api, xp)
+
+	var pNeg, sum, diff sw_bls12377.G2Affine
+	pNeg.Neg(api, &p)
+	sum = xp
+	sum.AddAssign(api, &p)
+	diff = xp
+	diff.AddAssign(api, &pNeg)
+
+	// -ψ²([2]p) = (thirdRootOne·x, -y) of [2]p
+	var p2 sw_bls12377.G2Affine
+	p2.Double(api, &p)
+	p2.X.MulByFp(api, p2.X, thirdRootOne)
+	p2.Y.Neg(api, p2.Y)
+
+	res := psi(api, diff)
+	sum.Neg(api, &sum)
+	res.AddAssign(api, &sum)
+	res.AddAssign(api, &x2p)
+	res.AddAssign(api, &p2)
+	return res
+}
+
+// mulByX returns [xGen]p
+func mulByX(api frontend.API, p sw_bls12377.G2Affine) sw_bls12377.G2Affine {
+	res := p
+	for i := 62; i >= 0; i-- {
+		if (xGen>>i)&1 == 1 {
+			res.DoubleAndAdd(api, &res, &p)
+		} else {
+			res.Double(api, &res)
+		}
+	}
+	return res
+}
+
+// psi returns the endomorphism ψ(p) = (endoU·x̄, endoV·ȳ)
+func psi(api frontend.API, p sw_bls12377.G2Affine) sw_bls12377.G2Affine {
+	var res sw_bls12377.G2Affine
+	res.X.Conjugate(api, p.X).MulByFp(api, res.X, endoU)
+	res.Y.Conjugate(api, p.Y).MulByFp(api, res.Y, endoV)
+	return res
+}
+
+// sgn0 returns the sign of e as defined in RFC 9380, section 4.1
+func sgn0(api frontend.API, e fields_bls12377.E2) frontend.Variable {
+	sign0 := parity(api, e.A0)
+	sign1 := parity(api, e.A1)
+	return api.Or(sign0, api.And(api.IsZero(e.A0), sign1))
+}
+
+// parity returns the least significant bit of the canonical representative of a
+func parity(api frontend.API, a frontend.Variable) frontend.Variable {
+	aBits := bits.ToBinary(api, a)
+
+	// the bits must be those of an integer at most p-1, eq is 1 while they
+	// are the most significant bits of p-1
+	bound := new(big.Int).Sub(api.Compiler().Curve().Info().Fr.Modulus(), big.NewInt(1))
+	var eq frontend.Variable = 1
+	for i := len(aBits) - 1; i >= 0; i-- {
+		if bound.Bit(i) == 1 {
+			eq = api.Mul(eq, aBits[i])
+		} else {
+			api.AssertIsEqual(api.Mul(eq, aBits[i]), 0)
+		}
+	}
+	return aBits[0]
+}
+
+func selectE2(api frontend.API, b frontend.Variable, e1, e2 fields_bls12377.E2) fields_bls12377.E2 {
+	return fields_bls12377.E2{
+		A0: api.Select(b, e1.A0, e2.A0),
+		A1: api.Select(b, e1.A1, e2.A1),
+	}
+}
+
+func isZeroE2(api frontend.API, e fields_bls12377.E2) frontend.Variable {
+	return api.And(api.IsZero(e.A0), api.IsZero(e.A1))
+}
@@ -0,0 +1,84 @@
+// Package ecdsa provides ZKP-circuit functions to verify ECDSA signatures over short Weierstrass curves
+// like secp256k1 or P-256, and to recover the public key of a signature as done by Ethereum.
+//
+// The arithmetic of the curve is emulated, see std/algebra/weierstrass, so that any curve can be used
+// whatever the native field.
+package ecdsa
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/weierstrass"
+	keccak "github.com/consensys/gnark/std/hash/keccak256"
+	"github.com/consensys/gnark/std/math/emulated"
+)
+
+// PublicKey stores an ECDSA public key (to be used in gnark circuit), of base field Base and scalar field Scalar
+type PublicKey[Base, Scalar emulated.FieldParams] weierstrass.AffinePoint[Base]
+
+// Signature stores an ECDSA signature (to be used in gnark circuit), the tuple (R, S) of scalars
+type Signature[Scalar emulated.FieldParams] struct {
+	R, S emulated.Element[Scalar]
+}
+
+// Verify checks that sig is a signature of the message hash msg by pk, on the curve described by params.
+// The hash must already be truncated to the size of the scalar field as specified by ECDSA, which is
+// a no-op for secp256k1 and P-256 with a 256-bit hash.
+func (pk PublicKey[Base, Scalar]) Verify(api frontend.API, params weierstrass.CurveParams, msg *emulated.Element[Scalar], sig *Signature[Scalar]) error {
+	curve, err := weierstrass.New[Base, Scalar](api, params)
+	if err != nil {
+		return err
+	}
+	fr := curve.ScalarField()
+	q := (*weierstrass.AffinePoint[Base])(&pk)
+	curve.AssertIsOnCurve(q)
+
+	// r and s must be non zero, the inverse of r is only computed to check it
+	fr.Inverse(&sig.R)
+	sInv := fr.Inverse(&sig.S)
+
+	// [msg/s]G + [r/s]Q must have r as abscissa modulo the order
+	res := curve.JointScalarMul(curve.Generator(), q, fr.Mul(msg, sInv), fr.Mul(&sig.R, sInv))
+	fr.AssertIsEqual(fr.FromBits(curve.BaseField().ToCanonicalBits(&res.X)...), &sig.R)
+	return nil
+}
+
+// Recover returns the public key of the signature sig of the message hash msg on the curve described by
+// params, v being the parity of the ordinate of the point of abscissa R (the last byte of the signatures
+// returned by go-ethereum's crypto.Sign). The case where the abscissa of this point is larger than the
+// order of the curve, which happens with a negligible probability, isn't supported.
+func Recover[Base, Scalar emulated.FieldParams](api frontend.API, params weierstrass.CurveParams, msg *emulated.Element[Scalar], v frontend.Variable, sig *Signature[Scalar]) (*PublicKey[Base, Scalar], error) {
+	curve, err := weierstrass.New[Base, Scalar](api, params)
+	if err != nil {
+		return nil, err
+	}
+	fr := curve.ScalarField()
+	r := curve.Lift(curve.BaseField().FromBits(fr.ToCanonicalBits(&sig.R)...), v)
+
+	// Q = [-msg/r]G + [s/r]R
+	rInv := fr.Inverse(&sig.R)
+	res := curve.JointScalarMul(curve.Generator(), r, fr.Neg(fr.Mul(msg, rInv)), fr.Mul(&sig.S, rInv))
+	return (*PublicKey[Base, Scalar])(res), nil
+}
+
+// Address returns the Ethereum address of pk, the last 20 bytes of the Keccak-256 digest of the
+// big-endian bytes of its coordinates
+func (pk PublicKey[Base, Scalar]) Address(api frontend.API) (frontend.Variable, error) {
+	fp, err := emulated.NewField[Base](api)
+	if err != nil {
+		return nil, err
+	}
+	var data []frontend.Variable
+	for _, c := range []*emulated.Element[Base]{&pk.X, &pk.Y} {
+		bits := fp.ToCanonicalBits(c)
+		for i := len(bits) - 8; i >= 0; i -= 8 {
+			data = append(data, api.FromBinary(bits[i:i+8]...))
+		}
+	}
+
+	digest := keccak.Keccak256Bytes(api, data...)
+	var bits []frontend.Variable
+	for i := len(digest) - 1; i >= len(digest)-20; i-- {
+		bits = append(bits, digest[i][:]...)
+	}
+	return api.FromBinary(bits...), nil
+}
@@ -0,0 +1,130 @@
+package ecdsa
+
+import (
+	stdecdsa "crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/weierstrass"
+	"github.com/consensys/gnark/std/math/emulated"
+	"github.com/consensys/gnark/test"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+type verifyCircuit[Base, Scalar emulated.FieldParams] struct {
+	params weierstrass.CurveParams
+
+	PublicKey PublicKey[Base, Scalar]
+	Msg       emulated.Element[Scalar]
+	Sig       Signature[Scalar]
+}
+
+func (c *verifyCircuit[Base, Scalar]) Define(api frontend.API) error {
+	return c.PublicKey.Verify(api, c.params, &c.Msg, &c.Sig)
+}
+
+func newVerifyCircuit[Base, Scalar emulated.FieldParams](params weierstrass.CurveParams, x, y, msg, r, s interface{}) *verifyCircuit[Base, Scalar] {
+	return &verifyCircuit[Base, Scalar]{
+		params:    params,
+		PublicKey: PublicKey[Base, Scalar]{X: emulated.NewElement[Base](x), Y: emulated.NewElement[Base](y)},
+		Msg:       emulated.NewElement[Scalar](msg),
+		Sig:       Signature[Scalar]{R: emulated.NewElement[Scalar](r), S: emulated.NewElement[Scalar](s)},
+	}
+}
+
+type recoverCircuit struct {
+	Msg     emulated.Element[emulated.Secp256k1Fr]
+	V       frontend.Variable
+	Sig     Signature[emulated.Secp256k1Fr]
+	Address frontend.Variable `gnark:",public"`
+}
+
+func (c *recoverCircuit) Define(api frontend.API) error {
+	pk, err := Recover[emulated.Secp256k1](api, weierstrass.GetSecp256k1Params(), &c.Msg, c.V, &c.Sig)
+	if err != nil {
+		return err
+	}
+	address, err := pk.Address(api)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(address, c.Address)
+	return nil
+}
+
+func TestSecp256k1(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := crypto.Keccak256([]byte("gnark ecdsa"))
+	sig, err := crypto.Sign(msg, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, s, v := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:64]), sig[64]
+	z := new(big.Int).SetBytes(msg)
+	params := weierstrass.GetSecp256k1Params()
+
+	circuit := newVerifyCircuit[emulated.Secp256k1, emulated.Secp256k1Fr](params, nil, nil, nil, nil, nil)
+	witness := newVerifyCircuit[emulated.Secp256k1, emulated.Secp256k1Fr](params, key.PublicKey.X, key.PublicKey.Y, z, r, s)
+	if err := test.IsSolved(circuit, witness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+	wrong := newVerifyCircuit[emulated.Secp256k1, emulated.Secp256k1Fr](params, key.PublicKey.X, key.PublicKey.Y, new(big.Int).Add(z, big.NewInt(1)), r, s)
+	if err := test.IsSolved(circuit, wrong, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("signature of another message accepted")
+	}
+
+	address := new(big.Int).SetBytes(crypto.PubkeyToAddress(key.PublicKey).Bytes())
+	rc := recoverCircuit{
+		Msg: emulated.NewElement[emulated.Secp256k1Fr](nil),
+		Sig: Signature[emulated.Secp256k1Fr]{R: emulated.NewElement[emulated.Secp256k1Fr](nil), S: emulated.NewElement[emulated.Secp256k1Fr](nil)},
+	}
+	rw := recoverCircuit{
+		Msg:     emulated.NewElement[emulated.Secp256k1Fr](z),
+		V:       v,
+		Sig:     Signature[emulated.Secp256k1Fr]{R: emulated.NewElement[emulated.Secp256k1Fr](r), S: emulated.NewElement[emulated.Secp256k1Fr](s)},
+		Address: address,
+	}
+	if err := test.IsSolved(&rc, &rw, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+	rw.V = 1 - v
+	if err := test.IsSolved(&rc, &rw, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("wrong recovery id accepted")
+	}
+}
+
+func TestP256(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping P-256 in short mode")
+	}
+	key, err := stdecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("gnark ecdsa"))
+	r, s, err := stdecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	z := new(big.Int).SetBytes(digest[:])
+	params := weierstrass.GetP256Params()
+
+	circuit := newVerifyCircuit[emulated.P256Fp, emulated.P256Fr](params, nil, nil, nil, nil, nil)
+	witness := newVerifyCircuit[emulated.P256Fp, emulated.P256Fr](params, key.X, key.Y, z, r, s)
+	if err := test.IsSolved(circuit, witness, ecc.BN254, backend.UNKNOWN); err != nil {
+		t.Fatal(err)
+	}
+	wrong := newVerifyCircuit[emulated.P256Fp, emulated.P256Fr](params, key.X, key.Y, z, s, r)
+	if err := test.IsSolved(circuit, wrong, ecc.BN254, backend.UNKNOWN); err == nil {
+		t.Fatal("wrong signature accepted")
+	}
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/consensys/gnark"
 	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/kzg"
 	"github.com/consensys/gnark/backend"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/backend/plonk"
@@ -81,8 +82,10 @@ func (assert *Assert) Log(v ...interface{}) {
 //
 // 1. compiles the circuit (or fetch it from the cache)
 // 2. using the test execution engine, executes the circuit with provided witness
-// 3. run Setup / Prove / Verify with the backend
-// 4. if set, (de)serializes the witness and call ReadAndProve and ReadAndVerify on the backend
+// 3. if set, checks that lazifying the constraint system preserves its semantic (Groth16 only)
+// 4. run Setup / Prove / Verify with the backend
+// 5. if set, (de)serializes the witness and call ReadAndProve and ReadAndVerify on the backend
+// 6. if set, compares the keys and the proof with their snapshots (see WithSnapshots)
 //
 // By default, this tests on all curves and proving schemes supported by gnark. See available TestingOption.
 func (assert *Assert) ProverSucceeded(circuit frontend.Circuit, validAssignment frontend.Circuit, opts ...TestingOption) {
@@ -136,32 +139,59 @@ func (assert *Assert) ProverSucceeded(circuit frontend.Circuit, validAssignment
 
 				assert.t.Parallel()
 
+				// with snapshots, the randomness of the setup and of the prover is deterministic
+				var setupOpts []backend.SetupOption
+				proverOpts := opt.proverOpts
+				if opt.snapshots != "" {
+					setupOpts = append(setupOpts, backend.WithSetupRandomSource(newSnapshotSource("setup")))
+					proverOpts = append(proverOpts[:len(proverOpts):len(proverOpts)], backend.WithRandomSource(newSnapshotSource("prove")))
+				}
+
 				switch b {
 				case backend.GROTH16:
-					pk, vk, err := groth16.Setup(ccs)
+					if opt.lazifyCheck {
+						checkError(groth16.CheckLazify(ccs, validWitness, opt.proverOpts...))
+					}
+
+					pk, vk, err := groth16.Setup(ccs, setupOpts...)
 					checkError(err)
 
 					// ensure prove / verify works well with valid witnesses
 
-					proof, err := groth16.Prove(ccs, pk, validWitness, opt.proverOpts...)
+					proof, err := groth16.Prove(ccs, pk, validWitness, proverOpts...)
 					checkError(err)
 
 					err = groth16.Verify(proof, vk, validPublicWitness)
 					checkError(err)
 
+					if opt.snapshots != "" {
+						checkError(checkSnapshot(snapshotPath(opt.snapshots, assert.t.Name()),
+							snapshotObject{"pk", pk}, snapshotObject{"vk", vk}, snapshotObject{"proof", proof}))
+					}
+
 				case backend.PLONK:
-					srs, err := NewKZGSRS(ccs)
+					var srs kzg.SRS
+					if opt.snapshots != "" {
+						srs, err = newKZGSRSFromSource(ccs, newSnapshotSource("srs"))
+					} else {
+						srs, err = NewKZGSRS(ccs)
+					}
 					checkError(err)
 
 					pk, vk, err := plonk.Setup(ccs, srs)
 					checkError(err)
 
-					correctProof, err := plonk.Prove(ccs, pk, validWitness, opt.proverOpts...)
+					correctProof, err := plonk.Prove(ccs, pk, validWitness, proverOpts...)
 					checkError(err)
 
 					err = plonk.Verify(correctProof, vk, validPublicWitness)
 					checkError(err)
 
+					if opt.snapshots != "" {
+						checkError(checkSnapshot(snapshotPath(opt.snapshots, assert.t.Name()),
+							snapshotObject{"pk", pk}, snapshotObject{"vk", vk}, snapshotObject{"proof", correctProof}))
+					}
+
 				default:
 					panic("backend not implemented")
 				}
@@ -279,6 +309,9 @@ func (assert *Assert) solvingSucceeded(circuit frontend.Circuit, validAssignment
 	err = ccs.IsSolved(validWitness, opt.proverOpts...)
 	checkError(err)
 
+	if opt.lazifyCheck && b == backend.GROTH16 {
+		checkError(groth16.CheckLazify(ccs, validWitness, opt.proverOpts...))
+	}
 }
 
 func (assert *Assert) SolvingFailed(circuit frontend.Circuit, invalidWitness frontend.Circuit, opts ...TestingOption) {
@@ -47,6 +47,9 @@ type engine struct {
 	curveID   ecc.ID
 	opt       backend.ProverConfig
 	// mHintsFunctions map[hint.ID]hintFunction
+
+	// lookup tables defined by the circuit (see frontend.Lookuper)
+	tables [][][3]big.Int
 }
 
 // IsSolved returns an error if the test execution engine failed to execute the given circuit
@@ -569,6 +572,44 @@ func (e *engine) AddLazyPoseidon(v frontend.Variable, s ...frontend.Variable) {
 	// Not implemented
 }
 
+func (e *engine) AddLazyGadget(name string, inputs ...frontend.Variable) []frontend.Variable {
+	return frontend.GetLazyGadget(name)(e, inputs...)
+}
+
+func (e *engine) DefineTable(rows ...[3]frontend.Variable) int {
+	if len(rows) == 0 {
+		panic("a lookup table must have at least one row")
+	}
+	table := make([][3]big.Int, len(rows))
+	for i, row := range rows {
+		for j, v := range row {
+			table[i][j] = e.toBigInt(v)
+		}
+	}
+	e.tables = append(e.tables, table)
+	return len(e.tables) - 1
+}
+
+func (e *engine) Lookup(table int, a, b frontend.Variable) frontend.Variable {
+	ba, bb := e.toBigInt(a), e.toBigInt(b)
+	for _, row := range e.tables[table] {
+		if row[0].Cmp(&ba) == 0 && row[1].Cmp(&bb) == 0 {
+			return row[2]
+		}
+	}
+	panic(fmt.Sprintf("[lookup] (%s, %s, _) ∉ table %d", ba.String(), bb.String(), table))
+}
+
+func (e *engine) AssertInTable(table int, a, b, c frontend.Variable) {
+	ba, bb, bc := e.toBigInt(a), e.toBigInt(b), e.toBigInt(c)
+	for _, row := range e.tables[table] {
+		if row[0].Cmp(&ba) == 0 && row[1].Cmp(&bb) == 0 && row[2].Cmp(&bc) == 0 {
+			return
+		}
+	}
+	panic(fmt.Sprintf("[assertInTable] (%s, %s, %s) ∉ table %d", ba.String(), bb.String(), bc.String(), table))
+}
+
 func (e *engine) AddInternalVariableWithLazy(lazyCnt int) frontend.Variable {
 	// Not implemented
 	return compiled.LinearExpression{
@@ -18,10 +18,12 @@ package test
 
 import (
 	"crypto/rand"
+	"io"
 	"sync"
 
 	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/kzg"
+	"github.com/consensys/gnark/backend/srs"
 	"github.com/consensys/gnark/frontend"
 
 	kzg_bls12377 "github.com/consensys/gnark-crypto/ecc/bls12-377/fr/kzg"
@@ -37,22 +39,25 @@ const srsCachedSize = (1 << 14) + 3
 // NewKZGSRS uses ccs nb variables and nb constraints to initialize a kzg srs
 // for sizes < 2¹⁵, returns a pre-computed cached SRS
 //
-// /!\ warning /!\: this method is here for convenience only: in production, a SRS generated through MPC should be used.
+// /!\ warning /!\: this method is here for convenience only: in production, a SRS generated through MPC should be used,
+// see gnark/backend/srs to import the output of a public ceremony.
 func NewKZGSRS(ccs frontend.CompiledConstraintSystem) (kzg.SRS, error) {
 
-	nbConstraints := ccs.GetNbConstraints()
-	_, _, public := ccs.GetNbVariables()
-	sizeSystem := nbConstraints + public
-	kzgSize := ecc.NextPowerOfTwo(uint64(sizeSystem)) + 3
+	kzgSize := srs.Size(ccs)
 
 	if kzgSize <= srsCachedSize {
 		return getCachedSRS(ccs)
 	}
 
-	return newKZGSRS(ccs.CurveID(), kzgSize)
+	return newKZGSRS(ccs.CurveID(), kzgSize, rand.Reader)
 
 }
 
+// newKZGSRSFromSource is NewKZGSRS with a toxic waste read from rnd, the SRS isn't cached
+func newKZGSRSFromSource(ccs frontend.CompiledConstraintSystem, rnd io.Reader) (kzg.SRS, error) {
+	return newKZGSRS(ccs.CurveID(), srs.Size(ccs), rnd)
+}
+
 var srsCache map[ecc.ID]kzg.SRS
 var lock sync.Mutex
 
@@ -67,7 +72,7 @@ func getCachedSRS(ccs frontend.CompiledConstraintSystem) (kzg.SRS, error) {
 		return srs, nil
 	}
 
-	srs, err := newKZGSRS(ccs.CurveID(), srsCachedSize)
+	srs, err := newKZGSRS(ccs.CurveID(), srsCachedSize, rand.Reader)
 	if err != nil {
 		return nil, err
 	}
@@ -75,9 +80,10 @@ func getCachedSRS(ccs frontend.CompiledConstraintSystem) (kzg.SRS, error) {
 	return srs, nil
 }
 
-func newKZGSRS(curve ecc.ID, kzgSize uint64) (kzg.SRS, error) {
+// newKZGSRS returns a KZG SRS of kzgSize points with a toxic waste read from rnd
+func newKZGSRS(curve ecc.ID, kzgSize uint64, rnd io.Reader) (kzg.SRS, error) {
 
-	alpha, err := rand.Int(rand.Reader, curve.Info().Fr.Modulus())
+	alpha, err := rand.Int(rnd, curve.Info().Fr.Modulus())
 	if err != nil {
 		return nil, err
 	}
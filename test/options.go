@@ -33,6 +33,8 @@ type testingConfig struct {
 	witnessSerialization bool
 	proverOpts           []backend.ProverOption
 	compileOpts          []frontend.CompileOption
+	lazifyCheck          bool
+	snapshots            string
 }
 
 // WithBackends is testing option which restricts the backends the assertions are
@@ -82,3 +84,30 @@ func WithCompileOpts(compileOpts ...frontend.CompileOption) TestingOption {
 		return nil
 	}
 }
+
+// WithLazifyCheck is a testing option which checks, in assertions with a valid
+// witness and the Groth16 backend, that lazifying the compiled circuit preserves
+// its semantic (see groth16.CheckLazify).
+func WithLazifyCheck() TestingOption {
+	return func(opt *testingConfig) error {
+		opt.lazifyCheck = true
+		return nil
+	}
+}
+
+// WithSnapshots is a testing option which, in ProverSucceeded, runs the setup and the prover with
+// deterministic random sources and compares the serialized proving key, verifying key and proof
+// with the snapshots stored under dir, one file per test, curve and backend. A change to the
+// serialization or to the prover shows up as a snapshot mismatch.
+//
+// A missing snapshot is a failure. Set the environment variable GNARK_UPDATE_SNAPSHOTS=1 to
+// write the snapshots, or to rewrite them after an intended change.
+//
+// /!\ warning /!\: the keys and proofs of the snapshots are built from public randomness, they
+// must not be used outside of tests.
+func WithSnapshots(dir string) TestingOption {
+	return func(opt *testingConfig) error {
+		opt.snapshots = dir
+		return nil
+	}
+}
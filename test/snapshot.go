@@ -0,0 +1,113 @@
+/*
+Copyright © 2021 ConsenSys Software Inc.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// updateSnapshots is the environment variable which rewrites the existing snapshots
+const updateSnapshots = "GNARK_UPDATE_SNAPSHOTS"
+
+// snapshotSource is a deterministic stream of bytes, the SHA-256 digests of a seed and a counter.
+// It replaces crypto/rand in the setups and provers of the assertions with snapshots.
+type snapshotSource struct {
+	seed    string
+	counter uint64
+	buf     []byte
+}
+
+func newSnapshotSource(seed string) *snapshotSource {
+	return &snapshotSource{seed: seed}
+}
+
+func (s *snapshotSource) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.buf) == 0 {
+			var counter [8]byte
+			binary.BigEndian.PutUint64(counter[:], s.counter)
+			s.counter++
+			h := sha256.New()
+			h.Write([]byte(s.seed))
+			h.Write(counter[:])
+			s.buf = h.Sum(nil)
+		}
+		k := copy(p[n:], s.buf)
+		s.buf = s.buf[k:]
+		n += k
+	}
+	return n, nil
+}
+
+// snapshotPath returns the path of the snapshot of the test name under dir
+func snapshotPath(dir, name string) string {
+	return filepath.Join(dir, strings.NewReplacer("/", "_", "#", "_").Replace(name)+".snapshot")
+}
+
+// snapshotObject is an object of a snapshot, recorded as the SHA-256 digest of its serialization
+type snapshotObject struct {
+	name string
+	o    io.WriterTo
+}
+
+// checkSnapshot compares the digests of the objects with the snapshot at path. The snapshot is
+// written instead if the environment variable GNARK_UPDATE_SNAPSHOTS is set.
+func checkSnapshot(path string, objects ...snapshotObject) error {
+	var snapshot bytes.Buffer
+	for _, obj := range objects {
+		h := sha256.New()
+		if _, err := obj.o.WriteTo(h); err != nil {
+			return fmt.Errorf("%s: %w", obj.name, err)
+		}
+		fmt.Fprintf(&snapshot, "%s %s\n", obj.name, hex.EncodeToString(h.Sum(nil)))
+	}
+
+	if os.Getenv(updateSnapshots) != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		return os.WriteFile(path, snapshot.Bytes(), 0o644)
+	}
+	expected, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s: no snapshot, set %s=1 to write it", path, updateSnapshots)
+	}
+	if err != nil {
+		return err
+	}
+
+	if bytes.Equal(snapshot.Bytes(), expected) {
+		return nil
+	}
+	want := strings.Split(string(expected), "\n")
+	for i, line := range strings.Split(snapshot.String(), "\n") {
+		if i >= len(want) || line != want[i] {
+			return fmt.Errorf("%s: %q doesn't match the snapshot, set %s=1 to update it", path, line, updateSnapshots)
+		}
+	}
+	return fmt.Errorf("%s: the snapshot records more objects, set %s=1 to update it", path, updateSnapshots)
+}
@@ -0,0 +1,106 @@
+package test
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/stretchr/testify/require"
+)
+
+type snapshotCircuit struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+}
+
+func (circuit *snapshotCircuit) Define(api frontend.API) error {
+	api.AssertIsEqual(api.Mul(circuit.X, circuit.X, circuit.X), circuit.Y)
+	return nil
+}
+
+// snapshotsDir is the environment variable which replaces the snapshots of TestSnapshots,
+// set by TestSnapshotMismatch when it runs TestSnapshots in a subprocess
+const snapshotsDir = "GNARK_TEST_SNAPSHOTS_DIR"
+
+func TestSnapshots(t *testing.T) {
+	dir := filepath.Join("testdata", "snapshots")
+	if d := os.Getenv(snapshotsDir); d != "" {
+		dir = d
+	}
+	assert := NewAssert(t)
+	assert.ProverSucceeded(&snapshotCircuit{}, &snapshotCircuit{X: 3, Y: 27}, WithCurves(ecc.BN254), WithSnapshots(dir))
+}
+
+// runSnapshots runs TestSnapshots against the snapshots of dir in a subprocess, and returns its output
+func runSnapshots(dir string) (string, error) {
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSnapshots$")
+	cmd.Env = append(os.Environ(), snapshotsDir+"="+dir, updateSnapshots+"=")
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+func TestSnapshotMismatch(t *testing.T) {
+	if os.Getenv(snapshotsDir) != "" {
+		t.Skip("running in a subprocess")
+	}
+	goldens, err := filepath.Glob(filepath.Join("testdata", "snapshots", "TestSnapshots_*.snapshot"))
+	require.NoError(t, err)
+	require.Len(t, goldens, 2)
+
+	for _, golden := range goldens {
+		golden := golden
+		t.Run(filepath.Base(golden), func(t *testing.T) {
+			// the proof of the snapshot is changed, the others are copied as is
+			dir := t.TempDir()
+			for _, file := range goldens {
+				data, err := os.ReadFile(file)
+				require.NoError(t, err)
+				if file == golden {
+					data = bytes.Replace(data, []byte("proof "), []byte("proof 00"), 1)
+				}
+				require.NoError(t, os.WriteFile(filepath.Join(dir, filepath.Base(file)), data, 0o644))
+			}
+			out, err := runSnapshots(dir)
+			require.Error(t, err, out)
+			require.Contains(t, out, "doesn't match the snapshot")
+		})
+	}
+
+	t.Run("missing", func(t *testing.T) {
+		dir := t.TempDir()
+		out, err := runSnapshots(dir)
+		require.Error(t, err, out)
+		require.Contains(t, out, "no snapshot")
+		files, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		require.Empty(t, files, "missing snapshots written")
+	})
+}
+
+func TestSnapshotSource(t *testing.T) {
+	assert := require.New(t)
+
+	// the stream doesn't depend on the size of the reads
+	a, b := make([]byte, 100), make([]byte, 100)
+	_, err := io.ReadFull(newSnapshotSource("seed"), a)
+	assert.NoError(err)
+	src := newSnapshotSource("seed")
+	for i := 0; i < len(b); i += 7 {
+		end := i + 7
+		if end > len(b) {
+			end = len(b)
+		}
+		_, err := src.Read(b[i:end])
+		assert.NoError(err)
+	}
+	assert.Equal(a, b)
+
+	_, err = io.ReadFull(newSnapshotSource("other"), b)
+	assert.NoError(err)
+	assert.NotEqual(a, b)
+}